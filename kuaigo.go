@@ -64,6 +64,8 @@ func (app *App) Run(servers ...server.Server) error {
 		app.cycle.Run(app.startBackgroundTasks)
 	}
 	app.runHooks(StageAfterStart)
+	// 热重启启动的子进程通知父进程退出
+	app.notifyReady()
 	//blocking and wait quit
 	if err := <-app.cycle.Wait(); err != nil {
 		app.logger.Error("tabby shutdown with error", klog.FieldMod(ecode.ModApp), klog.FieldErr(err))
@@ -77,6 +77,15 @@ func (app *App) watchSignals() {
 			_ = app.Stop()
 		}
 	})
+	if config := app.hotRestartConfig(); config.Enable {
+		app.logger.Info("init listen hot restart signal", klog.FieldMod(ecode.ModApp), klog.FieldEvent("init"))
+		signals.Restart(func() {
+			app.logger.Info("hot restart begin", klog.FieldMod(ecode.ModApp), klog.FieldEvent("restart"))
+			if err := app.hotRestart(config); err != nil {
+				app.logger.Error("hot restart failed", klog.FieldMod(ecode.ModApp), klog.FieldEvent("restart"), klog.FieldErr(err))
+			}
+		})
+	}
 }
 
 // runHooks
@@ -279,6 +288,7 @@ func (app *App) loadConfig() error {
 	file.RegisterConfigHandler()
 	http.RegisterConfigHandler()
 	apollo.RegisterConfigHandler()
+	conf.OnApply(app.auditConfig)
 	var configAddr = flag.String("config")
 	if configAddr == "" {
 		configAddr = os.Getenv("APOLLO_SERVER_ADDR")
@@ -298,6 +308,25 @@ func (app *App) loadConfig() error {
 	return nil
 }
 
+// auditConfig
+//  @Description 记录每次生效的配置变更，只记录 key 与变更类型，不记录值，避免密码、DSN 等写入日志
+//  @Receiver app App类型
+//  @Param snapshot 生效后的配置快照
+func (app *App) auditConfig(snapshot *conf.Snapshot) {
+	changes := make([]string, 0, len(snapshot.Changes))
+	for _, change := range snapshot.Changes {
+		changes = append(changes, change.Op+" "+change.Key)
+	}
+	app.logger.Info("config applied",
+		klog.FieldMod(ecode.ModConfig),
+		klog.FieldEvent("audit"),
+		klog.Int64("revision", snapshot.Revision),
+		klog.String("source", snapshot.Source),
+		klog.Int("changeCount", len(snapshot.Changes)),
+		klog.Any("changes", changes),
+	)
+}
+
 // initConfigVersion
 //  @Description 初始化版本信息
 //  @Receiver app App类型
@@ -50,6 +50,8 @@ type App struct {
 	ctx context.Context
 	// 版本号
 	ConfigVersion string
+	// restarting 是否正在热重启
+	restarting int32
 }
 
 var appInstance *App
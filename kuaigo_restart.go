@@ -0,0 +1,170 @@
+package kuaigo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/conf"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/ecode"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/knet"
+)
+
+const (
+	// EnvRestartNotifyFD 子进程就绪后写入的管道文件描述符
+	EnvRestartNotifyFD = "KUAIGO_RESTART_NOTIFY_FD"
+	// hotRestartKey 热重启配置
+	hotRestartKey = "hotRestart"
+)
+
+// HotRestartConfig 热重启配置
+type HotRestartConfig struct {
+	// Enable 开启后收到 SIGUSR2 时热重启
+	Enable bool
+	// ReadyTimeout 等待子进程就绪的超时时间，超时后终止子进程，父进程继续提供服务
+	ReadyTimeout time.Duration
+	// StopTimeout 子进程就绪后父进程优雅停止的超时时间
+	StopTimeout time.Duration
+}
+
+// hotRestartConfig
+//  @Description 读取热重启配置
+//  @Receiver app App类型
+//  @Return HotRestartConfig
+func (app *App) hotRestartConfig() HotRestartConfig {
+	config := HotRestartConfig{
+		ReadyTimeout: 30 * time.Second,
+		StopTimeout:  30 * time.Second,
+	}
+	if conf.Get(hotRestartKey) != nil {
+		if err := conf.UnmarshalKey(hotRestartKey, &config); err != nil {
+			app.logger.Error("hot restart config invalid", klog.FieldMod(ecode.ModApp), klog.FieldErrKind(ecode.ErrKindUnmarshalConfigErr), klog.FieldErr(err))
+		}
+	}
+	return config
+}
+
+// hotRestart
+//  @Description 启动继承监听的子进程，子进程就绪后父进程优雅停止；子进程启动失败时父进程继续提供服务
+//  @Receiver app App类型
+//  @Param config 热重启配置
+//  @Return error 启动子进程过程中的报错
+func (app *App) hotRestart(config HotRestartConfig) error {
+	if !atomic.CompareAndSwapInt32(&app.restarting, 0, 1) {
+		return errors.New("hot restart in progress")
+	}
+	defer atomic.StoreInt32(&app.restarting, 0)
+
+	child, err := app.startChild(config.ReadyTimeout)
+	if err != nil {
+		return err
+	}
+	app.logger.Info("hot restart child ready, graceful stop", klog.FieldMod(ecode.ModApp), klog.FieldEvent("restart"), klog.Int("pid", child.Pid))
+	_ = child.Release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.StopTimeout)
+	defer cancel()
+	return app.GracefulStop(ctx)
+}
+
+// startChild
+//  @Description 以相同的参数启动子进程，传递所有监听，等待子进程通过管道通知就绪
+//  @Receiver app App类型
+//  @Param readyTimeout 等待就绪的超时时间
+//  @Return *os.Process 就绪的子进程
+//  @Return error
+func (app *App) startChild(readyTimeout time.Duration) (*os.Process, error) {
+	files, listeners, err := knet.ListenerFiles()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}()
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	path, err := os.Executable()
+	if err != nil {
+		_ = w.Close()
+		return nil, err
+	}
+	env := make([]string, 0, len(os.Environ())+2)
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, knet.EnvInheritListeners+"=") || strings.HasPrefix(kv, EnvRestartNotifyFD+"=") {
+			continue
+		}
+		env = append(env, kv)
+	}
+	env = append(env,
+		knet.EnvInheritListeners+"="+listeners,
+		// 监听之后的第一个文件描述符
+		EnvRestartNotifyFD+"="+strconv.Itoa(3+len(files)),
+	)
+	cmd := exec.Command(path, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.Env = env
+	cmd.ExtraFiles = append(files, w)
+	err = cmd.Start()
+	_ = w.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	ready := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 1)
+		// 子进程退出时写端关闭，读到 EOF
+		_, err := r.Read(buf)
+		ready <- err
+	}()
+	select {
+	case err = <-ready:
+	case <-time.After(readyTimeout):
+		err = fmt.Errorf("child not ready in %s", readyTimeout)
+	}
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return nil, fmt.Errorf("hot restart child failed: %w", err)
+	}
+	return cmd.Process, nil
+}
+
+// notifyReady
+//  @Description 热重启的子进程启动完成后通知父进程，并关闭未使用的继承监听
+//  @Receiver app App类型
+func (app *App) notifyReady() {
+	value := os.Getenv(EnvRestartNotifyFD)
+	if value == "" {
+		return
+	}
+	_ = os.Unsetenv(EnvRestartNotifyFD)
+	knet.CloseInherited()
+	fd, err := strconv.Atoi(value)
+	if err != nil {
+		return
+	}
+	f := os.NewFile(uintptr(fd), "notify")
+	if f == nil {
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write([]byte{1}); err != nil {
+		app.logger.Error("hot restart notify parent failed", klog.FieldMod(ecode.ModApp), klog.FieldErr(err))
+		return
+	}
+	app.logger.Info("hot restart ready, notify parent", klog.FieldMod(ecode.ModApp), klog.FieldEvent("restart"), klog.Int("ppid", os.Getppid()))
+}
@@ -0,0 +1,130 @@
+// @Description API key 鉴权，用于服务间调用
+
+package kauth
+
+import (
+	"crypto/sha256"
+	"sync"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/biz/kentity"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/conf"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/server/ginserver"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/ecode"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/errs"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
+
+	"github.com/pkg/errors"
+)
+
+// APIKey 调用方的 key
+type APIKey struct {
+	// Key 密钥
+	Key string
+	// Name 调用方名称，作为主体 id
+	Name string
+	// AppID 应用 id
+	AppID int
+	// Scopes 授权范围
+	Scopes []string
+}
+
+// APIKeyConfig 配置
+type APIKeyConfig struct {
+	// Header 请求头，默认 X-Api-Key
+	Header string
+	// Keys 调用方的 key，轮换时新旧 key 同时配置
+	Keys []APIKey
+
+	key    string
+	logger *klog.Logger
+}
+
+// DefaultAPIKeyConfig ...
+func DefaultAPIKeyConfig() *APIKeyConfig {
+	return &APIKeyConfig{
+		Header: "X-Api-Key",
+		logger: klog.KuaigoLogger.With(klog.FieldMod(ModName)),
+	}
+}
+
+// RawAPIKeyConfig ...
+func RawAPIKeyConfig(key string) *APIKeyConfig {
+	var config = DefaultAPIKeyConfig()
+	if err := conf.UnmarshalKey(key, &config); err != nil &&
+		errors.Cause(err) != conf.ErrInvalidKey {
+		config.logger.Panic("apikey parse config panic", klog.FieldErrKind(ecode.ErrKindUnmarshalConfigErr), klog.FieldErr(err), klog.FieldKey(key))
+	}
+	config.key = key
+	return config
+}
+
+// Build
+//  @Description  创建 API key 鉴权，由 RawAPIKeyConfig 创建时配置变更后重新加载 Keys
+//  @Receiver config
+//  @Return *APIKeyAuth
+func (config *APIKeyConfig) Build() *APIKeyAuth {
+	a := &APIKeyAuth{header: config.Header}
+	a.SetKeys(config.Keys)
+	if config.key != "" {
+		conf.OnChange(func(c *conf.Configuration) {
+			var keys []APIKey
+			if err := c.UnmarshalKey(config.key+".keys", &keys); err != nil {
+				config.logger.Warn("apikey reload keys failed", klog.FieldErr(err), klog.FieldKey(config.key))
+				return
+			}
+			a.SetKeys(keys)
+		})
+	}
+	return a
+}
+
+// APIKeyAuth API key 鉴权
+type APIKeyAuth struct {
+	header string
+
+	mu   sync.RWMutex
+	keys map[[sha256.Size]byte]APIKey
+}
+
+// SetKeys
+//  @Description  替换调用方的 key
+//  @Receiver a
+//  @Param keys
+func (a *APIKeyAuth) SetKeys(keys []APIKey) {
+	m := make(map[[sha256.Size]byte]APIKey, len(keys))
+	for _, key := range keys {
+		if key.Key != "" {
+			// 按摘要查找，比较耗时与 key 内容无关
+			m[sha256.Sum256([]byte(key.Key))] = key
+		}
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.keys = m
+}
+
+// Name ...
+func (a *APIKeyAuth) Name() string {
+	return ProviderAPIKey
+}
+
+// Authenticate ...
+func (a *APIKeyAuth) Authenticate(c *ginserver.TContext) (*kentity.Principal, error) {
+	raw := c.GetHeader(a.header)
+	if raw == "" {
+		return nil, ErrNoCredentials
+	}
+	a.mu.RLock()
+	key, ok := a.keys[sha256.Sum256([]byte(raw))]
+	a.mu.RUnlock()
+	if !ok {
+		return nil, errs.New(ecode.CodeUnauthorized, "无效的 API key").WithKey("kauth.apikey_invalid")
+	}
+	return &kentity.Principal{
+		Type:     kentity.PrincipalService,
+		ID:       key.Name,
+		AppID:    key.AppID,
+		Scopes:   key.Scopes,
+		Provider: ProviderAPIKey,
+	}, nil
+}
@@ -0,0 +1,72 @@
+// @Description 可插拔的鉴权方式，鉴权通过后得到统一的 kentity.Principal
+
+package kauth
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/biz/kentity"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/server/ginserver"
+)
+
+//ModName ..
+const ModName = "biz.kauth"
+
+// 鉴权方式
+const (
+	ProviderJWT           = "jwt"
+	ProviderAPIKey        = "apikey"
+	ProviderGuest         = "guest"
+	ProviderIntrospection = "introspection"
+)
+
+// CodeLoginFailed 游客登录失败错误码
+const CodeLoginFailed = 102002
+
+// ErrNoCredentials 请求中没有该鉴权方式的凭证，鉴权链继续尝试下一个
+var ErrNoCredentials = errors.New("kauth: no credentials")
+
+// Authenticator 鉴权方式
+type Authenticator interface {
+	// Name 鉴权方式名称，见 ProviderJWT 等
+	Name() string
+	// Authenticate 没有凭证时返回 ErrNoCredentials，凭证无效时返回 errs.Error
+	Authenticate(c *ginserver.TContext) (*kentity.Principal, error)
+}
+
+// Authenticate
+//  @Description  依次尝试鉴权方式，返回第一个通过的主体
+//  @Param c
+//  @Param auths
+//  @Return *kentity.Principal
+//  @Return error 全部未通过时返回第一个凭证无效的错误，都没有凭证时返回 ErrNoCredentials
+func Authenticate(c *ginserver.TContext, auths ...Authenticator) (*kentity.Principal, error) {
+	var first error
+	for _, auth := range auths {
+		p, err := auth.Authenticate(c)
+		if err == nil {
+			return p, nil
+		}
+		if err != ErrNoCredentials && first == nil {
+			first = err
+		}
+	}
+	if first == nil {
+		first = ErrNoCredentials
+	}
+	return nil, first
+}
+
+// BearerToken
+//  @Description  获取 Authorization 请求头中的 Bearer token
+//  @Param c
+//  @Return string
+func BearerToken(c *ginserver.TContext) string {
+	auth := c.GetHeader("Authorization")
+	const prefix = "Bearer "
+	if len(auth) > len(prefix) && strings.EqualFold(auth[:len(prefix)], prefix) {
+		return strings.TrimSpace(auth[len(prefix):])
+	}
+	return ""
+}
@@ -0,0 +1,155 @@
+package kauth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/biz/kentity"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/ecode"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/errs"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/kjwt"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newContext(header map[string]string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	for k, v := range header {
+		c.Request.Header.Set(k, v)
+	}
+	return c
+}
+
+type memoryCache map[string]string
+
+func (m memoryCache) Get(key string) string {
+	return m[key]
+}
+
+func (m memoryCache) Set(key string, value interface{}, expire time.Duration) bool {
+	m[key] = string(value.([]byte))
+	return true
+}
+
+func TestAuthenticate(t *testing.T) {
+	jwtConfig := kjwt.DefaultConfig()
+	jwtConfig.Signing = "k1"
+	jwtConfig.Keys = []kjwt.KeyConfig{{ID: "k1", Secret: "secret"}}
+	manager := jwtConfig.BuildWithStore(kjwt.NewMemoryStore())
+
+	apiKeyConfig := DefaultAPIKeyConfig()
+	apiKeyConfig.Keys = []APIKey{{Key: "key-1", Name: "order", Scopes: []string{"user.read"}}}
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		id, secret, _ := r.BasicAuth()
+		assert.Equal(t, "gateway", id)
+		assert.Equal(t, "s", secret)
+		rsp := introspectionResponse{Active: r.FormValue("token") == "opaque", ClientID: "billing", Scope: "a b"}
+		_ = json.NewEncoder(w).Encode(rsp)
+	}))
+	defer server.Close()
+	introspectionConfig := DefaultIntrospectionConfig()
+	introspectionConfig.Endpoint = server.URL
+	introspectionConfig.ClientID = "gateway"
+	introspectionConfig.ClientSecret = "s"
+
+	auths := []Authenticator{NewJWT(manager), apiKeyConfig.Build(), introspectionConfig.Build()}
+
+	_, err := Authenticate(newContext(nil), auths...)
+	assert.Equal(t, ErrNoCredentials, err)
+
+	token, _ := manager.Issue(&kjwt.MyClaims{UserID: "1", AppID: 101, LoginRole: kjwt.LoginRoleGuest})
+	p, err := Authenticate(newContext(map[string]string{"Authorization": "Bearer " + token}), auths...)
+	assert.Nil(t, err)
+	assert.Equal(t, kentity.PrincipalGuest, p.Type)
+	assert.Equal(t, "1", p.ID)
+	assert.Equal(t, ProviderJWT, p.Provider)
+
+	p, err = Authenticate(newContext(map[string]string{"X-Api-Key": "key-1"}), auths...)
+	assert.Nil(t, err)
+	assert.Equal(t, kentity.PrincipalService, p.Type)
+	assert.True(t, p.HasScope("user.read"))
+	_, err = Authenticate(newContext(map[string]string{"X-Api-Key": "key-2"}), auths...)
+	assert.Equal(t, ecode.CodeUnauthorized, err.(errs.Error).Code)
+
+	// JWT 解析失败后由 introspection 校验，结果被缓存
+	for i := 0; i < 2; i++ {
+		p, err = Authenticate(newContext(map[string]string{"Authorization": "Bearer opaque"}), auths...)
+		assert.Nil(t, err)
+		assert.Equal(t, "billing", p.ID)
+		assert.Equal(t, []string{"a", "b"}, p.Scopes)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// 都未通过时返回第一个错误
+	_, err = Authenticate(newContext(map[string]string{"Authorization": "Bearer other"}), auths...)
+	assert.Equal(t, ecode.CodeInvalidAuthToken, err.(errs.Error).Code)
+	assert.Equal(t, "kauth.token_invalid", err.(errs.Error).Key)
+}
+
+func TestGuest(t *testing.T) {
+	var status int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/login.json", r.URL.Path)
+		var req kentity.ReqPassportOpenLogin
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		assert.Equal(t, 7, req.Type)
+		assert.Equal(t, 7, req.DeviceType)
+		rsp := kentity.RspPassportLogin{Data: &kentity.RspPassportLoginData{
+			PassportInfo: kentity.PassportInfo{UID: 42, Token: "t", Status: int(atomic.LoadInt32(&status))},
+		}}
+		_ = json.NewEncoder(w).Encode(rsp)
+	}))
+	defer server.Close()
+
+	config := DefaultGuestConfig()
+	config.Addr = server.URL
+	cache := memoryCache{}
+	guest := config.BuildWithCache(cache)
+
+	c := newContext(nil)
+	kentity.SetPParam(c, &kentity.PParams{DID: "device"})
+	kentity.SetHeader(c, &kentity.KuaigoHeader{AppId: 101})
+	p, err := guest.Authenticate(c)
+	assert.Nil(t, err)
+	assert.Equal(t, kentity.PrincipalGuest, p.Type)
+	assert.Equal(t, "42", p.ID)
+	assert.Equal(t, "device", p.DID)
+	assert.NotEmpty(t, cache["guest:user:101:device"])
+
+	// 被封禁的设备
+	atomic.StoreInt32(&status, 2)
+	kentity.SetPParam(c, &kentity.PParams{DID: "banned"})
+	_, err = guest.Authenticate(c)
+	assert.Equal(t, "kauth.guest_banned", err.(errs.Error).Key)
+
+	_, err = guest.Authenticate(newContext(nil))
+	assert.Equal(t, ErrNoCredentials, err)
+}
+
+func TestGuest_RawRequest(t *testing.T) {
+	var got kentity.ReqPassportOpenLogin
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_ = json.NewEncoder(w).Encode(kentity.RspPassportLogin{Data: &kentity.RspPassportLoginData{}})
+	}))
+	defer server.Close()
+
+	config := DefaultGuestConfig()
+	config.Addr = server.URL
+	config.Source = "src"
+	config.RawRequest = true
+	_, err := config.BuildWithCache(memoryCache{}).Login(context.Background(), kentity.ReqPassportOpenLogin{OpenId: "device", AppId: 101})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, got.Type)
+	assert.Equal(t, 0, got.ExpireTime)
+	assert.Equal(t, "", got.ReqSrc)
+}
@@ -0,0 +1,194 @@
+// @Description 游客/设备登录，向上游 passport 服务换取用户信息
+
+package kauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/biz/kentity"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/biz/tcontext"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/conf"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/constant"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/cache"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/net/khttp"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/server/ginserver"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/ecode"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/errs"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/kjwt"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
+
+	"github.com/pkg/errors"
+)
+
+// ClaimsCache 缓存登录结果，redis.Redis 与缓存管理器的 ICache 均已实现
+type ClaimsCache interface {
+	Get(key string) string
+	Set(key string, value interface{}, expire time.Duration) bool
+}
+
+// GuestConfig 配置
+type GuestConfig struct {
+	// Addr passport 服务地址
+	Addr string
+	// Path 登录接口，默认 /v1/login.json
+	Path string
+	// LoginType 登录类型，默认 7
+	LoginType int
+	// Source 请求来源
+	Source string
+	// Cache 缓存配置 key
+	Cache string
+	// KeyPrefix 缓存 key 前缀，默认 guest:user:
+	KeyPrefix string
+	// TTL 登录有效期，默认 30 天
+	TTL time.Duration
+	// ExpiredCode passport 返回的凭证过期错误码，默认 102
+	ExpiredCode int
+	// BannedStatus passport 返回的封禁状态，默认 2
+	BannedStatus int
+	// DeviceTypes 应用 id 到登录设备类型
+	DeviceTypes map[int]int
+	// Platforms 应用 id 是否分平台
+	Platforms map[int]bool
+	// RawRequest 原样发送登录请求，不以 LoginType、TTL、Source 填充请求中为空的 Type、ExpireTime、ReqSrc
+	RawRequest bool
+
+	logger *klog.Logger
+}
+
+// DefaultGuestConfig ...
+func DefaultGuestConfig() *GuestConfig {
+	return &GuestConfig{
+		Path:         "/v1/login.json",
+		LoginType:    7,
+		KeyPrefix:    constant.GuestUserKeyPre,
+		TTL:          constant.GuestLoginExpireTime * time.Minute,
+		ExpiredCode:  102,
+		BannedStatus: 2,
+		DeviceTypes:  map[int]int{101: 7, 104: 4, 110: 1},
+		Platforms:    map[int]bool{110: true},
+		logger:       klog.KuaigoLogger.With(klog.FieldMod(ModName)),
+	}
+}
+
+// RawGuestConfig ...
+func RawGuestConfig(key string) *GuestConfig {
+	var config = DefaultGuestConfig()
+	if err := conf.UnmarshalKey(key, &config); err != nil &&
+		errors.Cause(err) != conf.ErrInvalidKey {
+		config.logger.Panic("guest parse config panic", klog.FieldErrKind(ecode.ErrKindUnmarshalConfigErr), klog.FieldErr(err), klog.FieldKey(key))
+	}
+	return config
+}
+
+// Build
+//  @Description  创建游客登录，登录结果缓存在 Cache 中
+//  @Receiver config
+//  @Return *Guest
+func (config *GuestConfig) Build() *Guest {
+	return config.BuildWithCache(cache.GetCacheManagerInstance().GetCache(context.Background(), config.Cache))
+}
+
+// BuildWithCache
+//  @Description  使用指定的缓存创建游客登录
+//  @Receiver config
+//  @Param cache
+//  @Return *Guest
+func (config *GuestConfig) BuildWithCache(cache ClaimsCache) *Guest {
+	return &Guest{config: config, cache: cache}
+}
+
+// Guest 游客登录，以 p 参数中的设备 id 登录
+type Guest struct {
+	config *GuestConfig
+	cache  ClaimsCache
+}
+
+// Name ...
+func (a *Guest) Name() string {
+	return ProviderGuest
+}
+
+// Authenticate ...
+func (a *Guest) Authenticate(c *ginserver.TContext) (*kentity.Principal, error) {
+	pp := tcontext.GetPParam(c)
+	if pp == nil || pp.DID == "" {
+		return nil, ErrNoCredentials
+	}
+	ctx := tcontext.WithContext(c)
+	appID := tcontext.GetAppID(ctx)
+	claims, err := a.Login(ctx, kentity.ReqPassportOpenLogin{
+		OpenId:     pp.DID,
+		AppId:      appID,
+		DeviceType: a.config.DeviceTypes[appID],
+		RegIp:      c.ClientIP(),
+		Platform:   a.config.Platforms[appID],
+	})
+	if err != nil {
+		return nil, err
+	}
+	return kentity.PrincipalFromClaims(claims, ProviderGuest), nil
+}
+
+// Login
+//  @Description  游客登录，优先使用缓存的登录结果
+//  @Receiver a
+//  @Param ctx
+//  @Param req OpenId、AppId 必填，Type、ExpireTime、ReqSrc 为空时取自配置，开启 RawRequest 时原样发送
+//  @Return *kjwt.MyClaims
+//  @Return error
+func (a *Guest) Login(ctx context.Context, req kentity.ReqPassportOpenLogin) (*kjwt.MyClaims, error) {
+	key := fmt.Sprintf("%s%v:%s", a.config.KeyPrefix, req.AppId, req.OpenId)
+	claims := &kjwt.MyClaims{}
+	if s := a.cache.Get(key); s != "" {
+		if err := json.Unmarshal([]byte(s), claims); err == nil {
+			return claims, nil
+		}
+	}
+	if !a.config.RawRequest {
+		if req.Type == 0 {
+			req.Type = a.config.LoginType
+		}
+		if req.ExpireTime == 0 {
+			req.ExpireTime = int(a.config.TTL / time.Second)
+		}
+		if req.ReqSrc == "" {
+			req.ReqSrc = a.config.Source
+		}
+	}
+
+	url := strings.TrimSuffix(a.config.Addr, "/") + a.config.Path
+	resp, err := khttp.PostJson(ctx, url, req)
+	if err != nil {
+		a.config.logger.Error("guest login failed", klog.FieldErr(err), klog.String("url", url), klog.FieldValueAny(req))
+		return nil, errs.Wrap(err, CodeLoginFailed, "登录失败").WithKey("kauth.guest_failed")
+	}
+	rsp := &kentity.RspPassportLogin{}
+	if err := resp.Json(rsp); err != nil {
+		return nil, errs.Wrap(err, CodeLoginFailed, "登录失败").WithKey("kauth.guest_failed")
+	}
+	switch {
+	case rsp.Code == a.config.ExpiredCode:
+		return nil, errs.New(CodeLoginFailed, "凭证过期").WithKey("kauth.guest_expired")
+	case rsp.Code != 0 || rsp.Data == nil:
+		a.config.logger.Error("guest login failed", klog.Int("code", rsp.Code), klog.String("msg", rsp.Msg), klog.String("url", url))
+		return nil, errs.New(CodeLoginFailed, rsp.Msg).WithKey("kauth.guest_failed")
+	case rsp.Data.PassportInfo.Status == a.config.BannedStatus:
+		return nil, errs.New(CodeLoginFailed, "用户已被封禁").WithKey("kauth.guest_banned")
+	}
+
+	claims.UserID = fmt.Sprintf("%v", rsp.Data.PassportInfo.UID)
+	claims.AppID = req.AppId
+	claims.DID = req.OpenId
+	claims.PlatToken = rsp.Data.PassportInfo.Token
+	claims.LoginRole = kjwt.LoginRoleGuest
+	data, _ := json.Marshal(claims)
+	if !a.cache.Set(key, data, a.config.TTL) {
+		a.config.logger.Warn("guest login set cache failed", klog.FieldKey(key))
+	}
+	return claims, nil
+}
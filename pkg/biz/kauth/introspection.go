@@ -0,0 +1,146 @@
+// @Description OAuth2 token introspection 鉴权，见 RFC 7662
+
+package kauth
+
+import (
+	"crypto/sha256"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/biz/kentity"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/conf"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/net/khttp"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/server/ginserver"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/ecode"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/errs"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
+
+	"github.com/pkg/errors"
+)
+
+// maxIntrospectionCache 缓存条数上限，超过时清空
+const maxIntrospectionCache = 10000
+
+// IntrospectionConfig 配置
+type IntrospectionConfig struct {
+	// Endpoint introspection 地址
+	Endpoint string
+	// ClientID 调用 introspection 使用的 client id
+	ClientID string
+	// ClientSecret 调用 introspection 使用的 client secret
+	ClientSecret string
+	// CacheTTL 结果缓存时间，不超过 token 的过期时间，默认 1m，小于 0 时不缓存
+	CacheTTL time.Duration
+
+	logger *klog.Logger
+}
+
+// DefaultIntrospectionConfig ...
+func DefaultIntrospectionConfig() *IntrospectionConfig {
+	return &IntrospectionConfig{
+		CacheTTL: time.Minute,
+		logger:   klog.KuaigoLogger.With(klog.FieldMod(ModName)),
+	}
+}
+
+// RawIntrospectionConfig ...
+func RawIntrospectionConfig(key string) *IntrospectionConfig {
+	var config = DefaultIntrospectionConfig()
+	if err := conf.UnmarshalKey(key, &config); err != nil &&
+		errors.Cause(err) != conf.ErrInvalidKey {
+		config.logger.Panic("introspection parse config panic", klog.FieldErrKind(ecode.ErrKindUnmarshalConfigErr), klog.FieldErr(err), klog.FieldKey(key))
+	}
+	return config
+}
+
+// Build ...
+func (config *IntrospectionConfig) Build() *Introspection {
+	return &Introspection{config: config, cache: map[[sha256.Size]byte]introspected{}, now: time.Now}
+}
+
+// introspectionResponse 见 RFC 7662 2.2
+type introspectionResponse struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope"`
+	ClientID  string `json:"client_id"`
+	Username  string `json:"username"`
+	Subject   string `json:"sub"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+type introspected struct {
+	principal *kentity.Principal
+	expire    time.Time
+}
+
+// Introspection OAuth2 token introspection 鉴权，token 取自 Authorization 请求头
+type Introspection struct {
+	config *IntrospectionConfig
+	now    func() time.Time
+
+	mu    sync.Mutex
+	cache map[[sha256.Size]byte]introspected
+}
+
+// Name ...
+func (a *Introspection) Name() string {
+	return ProviderIntrospection
+}
+
+// Authenticate ...
+func (a *Introspection) Authenticate(c *ginserver.TContext) (*kentity.Principal, error) {
+	token := BearerToken(c)
+	if token == "" {
+		return nil, ErrNoCredentials
+	}
+	sum := sha256.Sum256([]byte(token))
+	now := a.now()
+	a.mu.Lock()
+	cached, ok := a.cache[sum]
+	a.mu.Unlock()
+	if ok && now.Before(cached.expire) {
+		return cached.principal, nil
+	}
+
+	resp, err := khttp.Post(c.Request.Context(), a.config.Endpoint,
+		khttp.Datas{"token": token, "token_type_hint": "access_token"},
+		khttp.Auth{a.config.ClientID, a.config.ClientSecret})
+	if err != nil {
+		a.config.logger.Error("introspection request failed", klog.FieldErr(err), klog.String("endpoint", a.config.Endpoint))
+		return nil, errs.Wrap(err, ecode.CodeUnauthorized, "token校验失败").WithKey("kauth.introspection_failed")
+	}
+	rsp := &introspectionResponse{}
+	if err := resp.Json(rsp); err != nil {
+		return nil, errs.Wrap(err, ecode.CodeUnauthorized, "token校验失败").WithKey("kauth.introspection_failed")
+	}
+	if !rsp.Active {
+		return nil, errs.New(ecode.CodeInvalidAuthToken, "无效的token").WithKey("kauth.token_invalid")
+	}
+
+	p := &kentity.Principal{
+		Type:     kentity.PrincipalService,
+		ID:       rsp.ClientID,
+		Scopes:   strings.Fields(rsp.Scope),
+		Provider: ProviderIntrospection,
+	}
+	// client credentials 的 sub 为空或等于 client id，其他授权方式代表用户
+	if rsp.Subject != "" && rsp.Subject != rsp.ClientID {
+		p.Type = kentity.PrincipalUser
+		p.ID = rsp.Subject
+	}
+
+	if a.config.CacheTTL > 0 {
+		expire := now.Add(a.config.CacheTTL)
+		if rsp.ExpiresAt > 0 && time.Unix(rsp.ExpiresAt, 0).Before(expire) {
+			expire = time.Unix(rsp.ExpiresAt, 0)
+		}
+		a.mu.Lock()
+		if len(a.cache) >= maxIntrospectionCache {
+			a.cache = map[[sha256.Size]byte]introspected{}
+		}
+		a.cache[sum] = introspected{principal: p, expire: expire}
+		a.mu.Unlock()
+	}
+	return p, nil
+}
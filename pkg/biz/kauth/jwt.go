@@ -0,0 +1,77 @@
+// @Description JWT bearer 鉴权
+
+package kauth
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/biz/kentity"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/biz/tcontext"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/server/ginserver"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/ecode"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/errs"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/kjwt"
+)
+
+// JWT JWT bearer 鉴权，token 取自 Authorization 请求头，没有时取 p 参数中的 ak
+type JWT struct {
+	manager *kjwt.Manager
+	client  *kjwt.Client
+}
+
+// NewJWT
+//  @Description  使用 kjwt.Manager 验签，支持密钥轮换与吊销
+//  @Param manager
+//  @Return *JWT
+func NewJWT(manager *kjwt.Manager) *JWT {
+	return &JWT{manager: manager}
+}
+
+// NewJWTWithClient
+//  @Description  使用单密钥的 kjwt.Client 验签，兼容旧配置
+//  @Param client
+//  @Return *JWT
+func NewJWTWithClient(client *kjwt.Client) *JWT {
+	return &JWT{client: client}
+}
+
+// Name ...
+func (a *JWT) Name() string {
+	return ProviderJWT
+}
+
+// Authenticate ...
+func (a *JWT) Authenticate(c *ginserver.TContext) (*kentity.Principal, error) {
+	token := BearerToken(c)
+	if token == "" {
+		if pp := tcontext.GetPParam(c); pp != nil {
+			token = pp.AK
+		}
+	}
+	if token == "" {
+		return nil, ErrNoCredentials
+	}
+	claims, err := a.parse(c, token)
+	if err != nil {
+		switch {
+		case errors.Is(err, kjwt.ErrRevoked):
+			return nil, errs.Wrap(err, ecode.CodeInvalidAuthToken, "token已吊销").WithKey("kauth.token_revoked")
+		case strings.Contains(err.Error(), "expired"):
+			return nil, errs.Wrap(err, ecode.CodeAuthTokenTimeout, "token已过期").WithKey("kauth.token_expired")
+		}
+		return nil, errs.Wrap(err, ecode.CodeInvalidAuthToken, "解密token失败").WithKey("kauth.token_invalid")
+	}
+	return kentity.PrincipalFromClaims(claims, ProviderJWT), nil
+}
+
+func (a *JWT) parse(c *ginserver.TContext, token string) (*kjwt.MyClaims, error) {
+	if a.manager != nil {
+		claims := &kjwt.MyClaims{}
+		if err := a.manager.Parse(c.Request.Context(), token, claims); err != nil {
+			return nil, err
+		}
+		return claims, nil
+	}
+	return a.client.ParseToken(token)
+}
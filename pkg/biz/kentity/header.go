@@ -342,4 +342,5 @@ func Clear(ctx *kgin.TContext) {
 	ctx.Set(constant.KeyPParam, nil)
 	ctx.Set(constant.KeyHeader, nil)
 	ctx.Set(constant.KeyCurrUser, nil)
+	ctx.Set(constant.KeyPrincipal, nil)
 }
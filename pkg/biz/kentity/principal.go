@@ -0,0 +1,95 @@
+package kentity
+
+import (
+	"github.com/LuoHongLiang0921/kuaigo/pkg/constant"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/server/kgin"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/kjwt"
+)
+
+// 主体类型
+const (
+	// PrincipalUser 登录用户
+	PrincipalUser = "user"
+	// PrincipalGuest 游客
+	PrincipalGuest = "guest"
+	// PrincipalService 服务调用方，如 API key、OAuth2 client credentials
+	PrincipalService = "service"
+)
+
+// Principal 鉴权通过的主体
+type Principal struct {
+	// Type 主体类型，见 PrincipalUser 等
+	Type string `json:"type"`
+	// ID 用户 id 或服务调用方名称
+	ID string `json:"id"`
+	// AppID 应用 id
+	AppID int `json:"appId"`
+	// DID 设备 id
+	DID string `json:"did,omitempty"`
+	// Scopes 授权范围
+	Scopes []string `json:"scopes,omitempty"`
+	// Provider 鉴权方式，如 jwt、apikey、guest、introspection
+	Provider string `json:"provider"`
+	// Claims 用户 token 的 claims，服务调用方为空
+	Claims *kjwt.MyClaims `json:"-"`
+}
+
+// IsUser
+//  @Description  是否是用户，包括游客
+//  @Receiver p
+//  @Return bool
+func (p *Principal) IsUser() bool {
+	return p.Type == PrincipalUser || p.Type == PrincipalGuest
+}
+
+// HasScope
+//  @Description  是否拥有全部授权范围
+//  @Receiver p
+//  @Param scopes
+//  @Return bool
+func (p *Principal) HasScope(scopes ...string) bool {
+	for _, scope := range scopes {
+		found := false
+		for _, s := range p.Scopes {
+			if s == scope {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// PrincipalFromClaims
+//  @Description  由用户 token 的 claims 创建主体
+//  @Param claims
+//  @Param provider
+//  @Return *Principal
+func PrincipalFromClaims(claims *kjwt.MyClaims, provider string) *Principal {
+	p := &Principal{
+		Type:     PrincipalUser,
+		ID:       claims.UserID,
+		AppID:    claims.AppID,
+		DID:      claims.DID,
+		Provider: provider,
+		Claims:   claims,
+	}
+	if claims.LoginRole == kjwt.LoginRoleGuest {
+		p.Type = PrincipalGuest
+	}
+	return p
+}
+
+// SetPrincipal
+//  @Description  设置鉴权通过的主体，用户主体同时设置当前用户
+//  @Param ctx
+//  @Param p
+func SetPrincipal(ctx *kgin.TContext, p *Principal) {
+	ctx.Set(constant.KeyPrincipal, p)
+	if p.Claims != nil {
+		SetCurrUser(ctx, p.Claims)
+	}
+}
@@ -3,7 +3,10 @@ package kentity
 import (
 	"github.com/LuoHongLiang0921/kuaigo/pkg/constant"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/core/server/kgin"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/ecode"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/errs"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/kvalidator"
 	"net/http"
 
 )
@@ -33,6 +36,10 @@ type Response struct {
 	Expire int `json:"expire,omitempty"`
 	//服务端data部分数据签名
 	SK string `json:"sk,omitempty"`
+	//Errors 参数校验失败的字段列表
+	Errors []kvalidator.FieldError `json:"errors,omitempty"`
+	//Details 错误附加信息，见 errs.Error
+	Details []interface{} `json:"details,omitempty"`
 }
 
 //ListData 分页数据
@@ -82,6 +89,16 @@ func WithExpire(expire int) ResponseOption {
 	}
 }
 
+// WithErrors
+//  @Description 设置参数校验失败的字段列表
+//  @Param errors
+//  @Return ResponseOption
+func WithErrors(errors []kvalidator.FieldError) ResponseOption {
+	return func(response *Response) {
+		response.Errors = errors
+	}
+}
+
 //NewResponse ...
 func NewResponse(data interface{}, opts ...ResponseOption) *Response {
 	r := new(Response)
@@ -119,50 +136,63 @@ func JSON(c *kgin.TContext, data interface{}) {
 	c.JSON(http.StatusOK, &resp)
 }
 
+// RequestLangs
+//  @Description  请求语言的候选，依次为 Accept-Language 与 p 参数中的客户端系统语言
+//  @Param c
+//  @Return []string
+func RequestLangs(c *kgin.TContext) []string {
+	langs := []string{c.GetHeader("Accept-Language")}
+	if vo, ok := c.Get(constant.KeyPParam); ok {
+		if p, ok := vo.(*PParams); ok && p.SL != "" {
+			langs = append(langs, p.SL)
+		}
+	}
+	return langs
+}
+
 // ErrJSON
-//  @Description 根据错误码返回错误
+//  @Description 根据错误码返回错误，消息取自 ecode.DefaultCatalog
 //  @Param c
 //  @Param code
 func ErrJSON(c *kgin.TContext, code int) {
-	var resp Response
-	resp.Code = code
-	//msg := xconfig.GetMsg(code)
-	resp.Msg = ""
-	c.Set(constant.RespCode, code)
-	c.JSON(http.StatusOK, &resp)
+	ErrorJSON(c, errs.New(code, ""))
 }
 
 // ErrorJSON TODO 重命名
-//  @Description 自定义err返回错误
+//  @Description 自定义err返回错误，消息按请求语言取自 ecode.DefaultCatalog，非 errs.Error 的错误返回 CodeInternalServerError，err 为 nil 时返回空响应
 //  @Param c
 //  @Param err
 func ErrorJSON(c *kgin.TContext, err error) {
-	var resp Response
-	if v, ok := err.(errs.Error); ok {
-		resp.Code = v.Code
-		c.Set(constant.RespCode, v.Code)
-		resp.Msg = v.Msg
-	}
-	//todo: 从配置服务获取
-	//msg := xconfig.GetMsg(code)
-	//resp.Msg = ""
-	c.JSON(http.StatusOK, &resp)
+	status, resp := errorResponse(c, err)
+	c.Set(constant.RespCode, resp.Code)
+	c.JSON(status, resp)
 }
 
 // AbortWithErrorJSON
-// @Description:
+// @Description: 中断请求并返回错误，见 ErrorJSON
 // @Param c gin context
 // @Param err err
 func AbortWithErrorJSON(c *kgin.TContext, err error) {
-	var resp Response
-	if v, ok := err.(errs.Error); ok {
-		resp.Code = v.Code
-		c.Set(constant.RespCode, v.Code)
-		resp.Msg = v.Msg
-	}
-	//todo: 从配置服务获取
-	//msg := xconfig.GetMsg(code)
-	//resp.Msg = ""
+	status, resp := errorResponse(c, err)
+	c.Set(constant.RespCode, resp.Code)
 	c.Abort()
-	c.JSON(http.StatusOK, &resp)
+	c.JSON(status, resp)
+}
+
+// errorResponse 生成错误响应与 HTTP 状态码，非 errs.Error 的错误原因只记录日志，不返回给客户端
+func errorResponse(c *kgin.TContext, err error) (int, *Response) {
+	if err == nil {
+		return http.StatusOK, &Response{}
+	}
+	e, ok := errs.FromError(err)
+	if !ok {
+		klog.WithContext(c.Request.Context()).Errorf("internal error, path:%v, err:%v", c.Request.URL.Path, err)
+		e = errs.New(ecode.CodeInternalServerError, "服务器错误")
+	}
+	resp := &Response{
+		Code:    e.Code,
+		Msg:     ecode.DefaultCatalog.Localize(c.Request.Context(), e, RequestLangs(c)...),
+		Details: e.Details(),
+	}
+	return e.HTTPStatus(), resp
 }
@@ -0,0 +1,78 @@
+// @Description 可插拔鉴权中间件，依次尝试鉴权方式，通过后在上下文中设置 kentity.Principal
+
+package kmiddleware
+
+import (
+	"github.com/LuoHongLiang0921/kuaigo/pkg/biz/kauth"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/biz/kentity"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/biz/tcontext"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/server/ginserver"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/ecode"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/errs"
+)
+
+// WithAuthenticators
+//  @Description 设置鉴权方式，按顺序尝试，如 JWT、API key、OAuth2 introspection、游客登录
+//  @Param auths
+//  @Return Option
+func WithAuthenticators(auths ...kauth.Authenticator) Option {
+	return func(m *Middleware) {
+		m.authenticators = append(m.authenticators, auths...)
+	}
+}
+
+// Authenticate
+//  @Description  要求请求通过任一鉴权方式，用户主体同时设置为当前用户
+//  @Receiver m
+//  @Return ginserver.HandlerFunc
+func (m *Middleware) Authenticate() ginserver.HandlerFunc {
+	return m.authenticate(false)
+}
+
+// OptionalAuthenticate
+//  @Description  请求没有凭证时放行，凭证无效时拒绝
+//  @Receiver m
+//  @Return ginserver.HandlerFunc
+func (m *Middleware) OptionalAuthenticate() ginserver.HandlerFunc {
+	return m.authenticate(true)
+}
+
+func (m *Middleware) authenticate(optional bool) ginserver.HandlerFunc {
+	return func(c *ginserver.TContext) {
+		p, err := kauth.Authenticate(c, m.authenticators...)
+		if err == kauth.ErrNoCredentials {
+			if optional {
+				c.Next()
+				return
+			}
+			m.abortWithErrorJSON(c, errs.New(ecode.CodeUnauthorized, "未登录").WithKey("kauth.no_credentials"))
+			return
+		}
+		if err != nil {
+			m.abortWithErrorJSON(c, err)
+			return
+		}
+		kentity.SetPrincipal(c, p)
+		c.Next()
+	}
+}
+
+// RequireScopes
+//  @Description  要求主体拥有全部授权范围，需在 Authenticate 之后使用
+//  @Receiver m
+//  @Param scopes
+//  @Return ginserver.HandlerFunc
+func (m *Middleware) RequireScopes(scopes ...string) ginserver.HandlerFunc {
+	return func(c *ginserver.TContext) {
+		p := tcontext.GetPrincipal(c)
+		if p == nil {
+			m.abortWithErrorJSON(c, errs.New(ecode.CodeUnauthorized, "未登录").WithKey("kauth.no_credentials"))
+			return
+		}
+		if !p.HasScope(scopes...) {
+			m.abortWithErrorJSON(c, errs.New(ecode.CodePermissionDenied, "权限不足").WithKey("kauth.scope_denied"))
+			return
+		}
+		c.Next()
+	}
+}
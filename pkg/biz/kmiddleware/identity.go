@@ -0,0 +1,34 @@
+// @Description mTLS 客户端身份鉴权中间件
+
+package kmiddleware
+
+import (
+	"strings"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/biz/tcontext"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/server/ginserver"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/ecode"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/errs"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
+)
+
+// RequireClientIdentity
+//  @Description: 要求请求方持有校验通过的客户端证书，且身份匹配任一规则，用于内部服务间调用鉴权
+//  @Receiver m
+//  @Param allowed 允许的身份，支持 *.example.com 子域通配与以 * 结尾的前缀通配，不传时只要求证书校验通过
+//  @Return ginserver.HandlerFunc
+func (m *Middleware) RequireClientIdentity(allowed ...string) ginserver.HandlerFunc {
+	return func(c *ginserver.TContext) {
+		id := tcontext.GetClientIdentity(c)
+		if id == nil {
+			m.abortWithErrorJSON(c, errs.NewCustomError(ecode.CodeUnauthorized, "client certificate required"))
+			return
+		}
+		if len(allowed) > 0 && !id.Match(allowed...) {
+			klog.Warn("client identity not allowed", klog.String("identity", strings.Join(id.Names(), ",")), klog.String("path", c.Request.URL.Path))
+			m.abortWithErrorJSON(c, errs.NewCustomError(ecode.CodeForbidden, "client identity not allowed"))
+			return
+		}
+		c.Next()
+	}
+}
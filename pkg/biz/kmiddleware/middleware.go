@@ -1,11 +1,15 @@
 package kmiddleware
 
 import (
+	"github.com/LuoHongLiang0921/kuaigo/pkg/biz/kauth"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/core/ratelimiter"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/rbac"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/core/server/ginserver"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/core/storage/redis"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/tenant"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/util/kjwt"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/ksign"
 	"time"
 
 )
@@ -20,6 +24,16 @@ type Middleware struct {
 	// 解析用户id
 	jwt      *kjwt.Client
 	adminJwt *kjwt.Client
+	// 支持密钥轮换与吊销，设置后替代 jwt
+	jwtManager *kjwt.Manager
+	// 鉴权方式，按顺序尝试
+	authenticators []kauth.Authenticator
+	// 后台权限控制
+	rbac *rbac.Enforcer
+	// 租户解析
+	tenant *tenant.Config
+	// HMAC 签名校验
+	signer *ksign.Verifier
 	//ABTest 来源
 	ABSource   string
 	GuestModel GuestUserModel
@@ -89,6 +103,16 @@ func WithAdminJwt(jwtKey string, expireTime int64) Option {
 	}
 }
 
+// WithJwtManager
+//  @Description 使用 kjwt.Manager 解析用户 token，支持 kid 密钥轮换与吊销，设置后 WithJwt 不再生效
+//  @Param key 配置 key，见 kjwt.Config
+//  @Return Option
+func WithJwtManager(key string) Option {
+	return func(m *Middleware) {
+		m.jwtManager = kjwt.RawConfig(key).Build()
+	}
+}
+
 // WithRateLimiter
 //  @Description: 开启限流中间件
 //  @Param redisKey 配置文件中用于限流的rediskey
@@ -36,14 +36,17 @@ func (m *Middleware) isDebug(c *ginserver.TContext) bool {
 func (m *Middleware) mustParseDIDAndAK(c *ginserver.TContext, pp *kentity.PParams) error {
 	if pp.DID != "" {
 		if pp.AK != "" {
-			if m.jwt.JwtKey == "" {
+			if m.jwtManager == nil && (m.jwt == nil || m.jwt.JwtKey == "") {
 				return errs.NewCustomError(ecode.CodeAuthTokenTimeout, "Jwt中间件未开启")
 			}
-			myClaims, err := kjwt.NewClient(m.jwt.JwtKey, m.jwt.Expire).ParseToken(pp.AK)
+			myClaims, err := m.parseToken(c, pp.AK)
 			if err != nil {
 				if strings.Contains(err.Error(), "expired") {
 					return errs.NewCustomError(ecode.CodeAuthTokenTimeout, "token已过期")
 				}
+				if err == kjwt.ErrRevoked {
+					return errs.NewCustomError(ecode.CodeInvalidAuthToken, "token已吊销")
+				}
 				return errs.NewCustomError(ecode.CodeInvalidAuthToken, "解密token失败")
 			}
 			// TODO：单设备登录需要使用redis
@@ -189,6 +192,24 @@ func absInt(n int64) int64 {
 	return n
 }
 
+// parseToken
+//  @Description  解析用户 token，设置了 jwtManager 时按 kid 验签并检查吊销列表
+//  @Receiver m
+//  @Param c
+//  @Param ak
+//  @Return *kjwt.MyClaims
+//  @Return error
+func (m *Middleware) parseToken(c *ginserver.TContext, ak string) (*kjwt.MyClaims, error) {
+	if m.jwtManager != nil {
+		myClaims := &kjwt.MyClaims{}
+		if err := m.jwtManager.Parse(c.Request.Context(), ak, myClaims); err != nil {
+			return nil, err
+		}
+		return myClaims, nil
+	}
+	return kjwt.NewClient(m.jwt.JwtKey, m.jwt.Expire).ParseToken(ak)
+}
+
 // parseDIDAndAK
 //  @Description  解析did、ak
 //  @Receiver m
@@ -196,8 +217,8 @@ func absInt(n int64) int64 {
 //  @Param pp
 func (m *Middleware) parseDIDAndAK(c *ginserver.TContext, pp *kentity.PParams) {
 	if pp.DID != "" {
-		if pp.AK != "" && m.jwt != nil {
-			myClaims, err := kjwt.NewClient(m.jwt.JwtKey, m.jwt.Expire).ParseToken(pp.AK)
+		if pp.AK != "" && (m.jwt != nil || m.jwtManager != nil) {
+			myClaims, err := m.parseToken(c, pp.AK)
 			if err != nil {
 				myClaims = new(kjwt.MyClaims)
 			}
@@ -292,19 +313,12 @@ func getRespCodeFromContext(c *ginserver.TContext) int {
 }
 
 // abortWithErrorJSON
-//  @Description
+//  @Description  非 errs.Error 的错误返回 CodeInternalServerError，见 kentity.AbortWithErrorJSON
 //  @Receiver m
 //  @Param c
 //  @Param err
 func (m *Middleware) abortWithErrorJSON(c *ginserver.TContext, err error) {
-	if _, ok := err.(errs.Error); ok {
-		kentity.AbortWithErrorJSON(c, err)
-		return
-	}
-	kentity.AbortWithJSONResponse(c, &kentity.Response{
-		Code: ecode.CodeInternalServerError,
-		Msg:  err.Error(),
-	})
+	kentity.AbortWithErrorJSON(c, err)
 }
 
 // getDeviceType 获取 deviceType
@@ -3,39 +3,159 @@
 package kmiddleware
 
 import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/LuoHongLiang0921/kuaigo/pkg/biz/tcontext"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/ratelimiter"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/core/server/ginserver"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/ecode"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/errs"
 )
 
+const (
+	HeaderRateLimitLimit     = "X-RateLimit-Limit"
+	HeaderRateLimitRemaining = "X-RateLimit-Remaining"
+	HeaderRateLimitReset     = "X-RateLimit-Reset"
+	HeaderRetryAfter         = "Retry-After"
+)
+
+// 限流 key 来源前缀，对应 ratelimiter.RLConfig.Keys
+const (
+	keySourceHeader = "header:"
+	keySourceClaim  = "claim:"
+	keySourceParam  = "param:"
+	keySourceIP     = "ip"
+	keySourceDID    = "did"
+)
+
 // RateLimiter
-//  @Description: RateLimiter 接口限流
+//  @Description: RateLimiter 接口限流，响应中带 X-RateLimit-* 头，被限流时带 Retry-After 头
 //  @Receiver m
-//  @Param rater
-//  @Param udid 请求方唯一值 如udid等
+//  @Param keys 未配置 Keys 的规则使用的请求方唯一值，如udid等，不传时依次取 p 参数中的 did 和客户端 ip
 //  @Return xgin.HandlerFunc
 func (m *Middleware) RateLimiter(keys ...string) ginserver.HandlerFunc {
 	return func(c *ginserver.TContext) {
+		if m.rater == nil {
+			c.Next()
+			return
+		}
 		path := c.Request.URL.Path
 		var udid string
-		// todo 待优化
-		if len(keys) == 0 && tcontext.GetPParam(c) != nil {
-			udid = tcontext.GetPParam(c).DID
-		} else if len(keys) > 0 {
+		if len(keys) > 0 {
 			udid = keys[0]
+		} else if p := tcontext.GetPParam(c); p != nil {
+			udid = p.DID
 		} else {
 			udid = c.ClientIP()
 		}
+		appID := rateLimitAppID(c)
 
-		ans := m.rater.Find(path)
-		for _, an := range ans {
-			if m.rater != nil {
-				if m.rater.Execute(c, an, udid, path, c.ClientIP()).RateLimiter() {
-					m.abortWithErrorJSON(c, errs.NewCustomError(ecode.CodeRateLimitError, "RateLimiter limit"))
+		var (
+			result ratelimiter.Result
+			found  bool
+		)
+		for _, an := range m.rater.Find(path) {
+			req := ratelimiter.Request{UDID: udid, Path: path, IP: c.ClientIP(), AppID: appID}
+			if rule, ok := m.rater.GetRule(an); ok && len(rule.Keys) > 0 {
+				// 限流对象取不到值时跳过该规则，避免所有请求共用同一个空 key
+				if req.UniqueID = rateLimitKey(c, rule.Keys); req.UniqueID == "" {
+					continue
 				}
 			}
+			res := m.rater.ExecuteRequest(an, req).Take()
+			if res.Limit == 0 {
+				continue
+			}
+			if !res.Allowed {
+				setRateLimitHeaders(c, res)
+				m.abortWithErrorJSON(c, errs.NewCustomError(ecode.CodeRateLimitError, "RateLimiter limit"))
+				return
+			}
+			if !found || res.Remaining < result.Remaining {
+				result, found = res, true
+			}
+		}
+		if found {
+			setRateLimitHeaders(c, result)
 		}
 		c.Next()
 	}
 }
+
+// rateLimitAppID 匹配分级配额的 app ID，优先取 jwt 中的 appID，其次取请求头
+func rateLimitAppID(c *ginserver.TContext) int {
+	if claims := tcontext.GetMyClaims(c); claims != nil && claims.AppID != 0 {
+		return claims.AppID
+	}
+	if header := tcontext.GetHeader(c); header != nil {
+		return header.AppId
+	}
+	return 0
+}
+
+// rateLimitKey
+//  @Description: 按来源拼接限流对象唯一值，任一来源取不到值时返回空，不做限流
+//  @Param c
+//  @Param sources 来源列表
+//  @Return string
+func rateLimitKey(c *ginserver.TContext, sources []string) string {
+	parts := make([]string, 0, len(sources))
+	for _, source := range sources {
+		var v string
+		switch {
+		case strings.HasPrefix(source, keySourceHeader):
+			v = c.GetHeader(strings.TrimPrefix(source, keySourceHeader))
+		case strings.HasPrefix(source, keySourceClaim):
+			v = claimValue(c, strings.TrimPrefix(source, keySourceClaim))
+		case strings.HasPrefix(source, keySourceParam):
+			v = c.Param(strings.TrimPrefix(source, keySourceParam))
+		case source == keySourceIP:
+			v = c.ClientIP()
+		case source == keySourceDID:
+			if p := tcontext.GetPParam(c); p != nil {
+				v = p.DID
+			}
+		}
+		if v == "" {
+			return ""
+		}
+		parts = append(parts, v)
+	}
+	return strings.Join(parts, ":")
+}
+
+func claimValue(c *ginserver.TContext, name string) string {
+	claims := tcontext.GetMyClaims(c)
+	if claims == nil {
+		return ""
+	}
+	switch strings.ToLower(name) {
+	case "userid":
+		return claims.UserID
+	case "appid":
+		if claims.AppID == 0 {
+			return ""
+		}
+		return strconv.Itoa(claims.AppID)
+	case "did":
+		return claims.DID
+	}
+	return ""
+}
+
+// setRateLimitHeaders 设置限流响应头，时间单位为秒，向上取整
+func setRateLimitHeaders(c *ginserver.TContext, res ratelimiter.Result) {
+	c.Header(HeaderRateLimitLimit, strconv.Itoa(res.Limit))
+	c.Header(HeaderRateLimitRemaining, strconv.Itoa(res.Remaining))
+	c.Header(HeaderRateLimitReset, strconv.FormatInt(ceilSeconds(res.ResetAfter), 10))
+	if !res.Allowed {
+		c.Header(HeaderRetryAfter, strconv.FormatInt(ceilSeconds(res.RetryAfter), 10))
+	}
+}
+
+func ceilSeconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
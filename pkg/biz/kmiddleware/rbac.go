@@ -0,0 +1,134 @@
+// @Description 后台接口权限控制
+
+package kmiddleware
+
+import (
+	"crypto/hmac"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/biz/tcontext"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/constant"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/rbac"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/server/ginserver"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/ecode"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/errs"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/ksign"
+)
+
+// RBACConfigKey 权限控制配置 key，见 rbac.Config
+const RBACConfigKey = "middleware.rbac"
+
+// keyAdminIdentity 身份签名校验通过的后台用户，AdminPermission 只使用该身份作为权限主体
+const keyAdminIdentity = "adminIdentity"
+
+// adminIdentity 身份签名校验通过的后台用户
+type adminIdentity struct {
+	id   string
+	name string
+}
+
+// WithRBAC
+//  @Description 开启后台接口权限控制，配置见 rbac.Config，需同时开启 WithAdminJwt 用于校验后台用户身份签名
+//  @Param key 配置 key，为空时使用 RBACConfigKey
+//  @Return Option
+func WithRBAC(key string) Option {
+	if key == "" {
+		key = RBACConfigKey
+	}
+	return func(m *Middleware) {
+		m.rbac = rbac.RawConfig(key).Build()
+	}
+}
+
+// AdminIdentityValidate
+//  @Description 校验后台用户身份签名，请求头 asg 为 hex(HMAC-SHA256(后台 jwt 密钥, aid + "\n" + aln + "\n" + t))，
+//  t 为毫秒时间戳，偏差不超过 3 分钟；校验通过后 AdminPermission 才以 aid、aln 作为权限主体
+//  @Receiver m
+//  @Param c
+func (m *Middleware) AdminIdentityValidate(c *ginserver.TContext) {
+	if m.adminJwt == nil || m.adminJwt.JwtKey == "" {
+		m.abortWithErrorJSON(c, errs.NewCustomError(ecode.CodeInValidParameterSignature, "未开启后台身份签名"))
+		return
+	}
+	aid := c.GetHeader(constant.AdminHeaderFieldAid)
+	aln := c.GetHeader(constant.AdminHeaderFieldAln)
+	t := c.GetHeader(constant.HeaderFieldT)
+	sig := c.GetHeader(constant.AdminHeaderFieldAsg)
+	if aid == "" || sig == "" {
+		m.abortWithErrorJSON(c, errs.NewCustomError(ecode.CodeInValidParameterSignature, "签名验证失败"))
+		return
+	}
+	ts, err := strconv.ParseInt(t, 10, 64)
+	if err != nil || absInt(time.Now().UnixNano()/1e6-ts) > constant.SignExpireTime {
+		m.abortWithErrorJSON(c, errs.NewCustomError(ecode.CodeInvalidParameterTimestamp, "签名验证失败,时间参数错误"))
+		return
+	}
+	expected := ksign.Signature(m.adminJwt.JwtKey, AdminIdentityCanonical(aid, aln, t))
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(sig))) {
+		klog.Warn("后台身份签名验证失败", klog.String("aid", aid), klog.String("aln", aln), klog.String("path", c.Request.URL.Path))
+		m.abortWithErrorJSON(c, errs.NewCustomError(ecode.CodeInValidParameterSignature, "签名验证失败"))
+		return
+	}
+	c.Set(keyAdminIdentity, &adminIdentity{id: aid, name: aln})
+}
+
+// AdminIdentityCanonical
+//  @Description 后台用户身份签名的待签名字符串，调用方以 ksign.Signature 计算 asg
+//  @Param aid 后台用户 id
+//  @Param aln 后台用户登录名
+//  @Param t 毫秒时间戳
+//  @Return string
+func AdminIdentityCanonical(aid, aln, t string) string {
+	return aid + "\n" + aln + "\n" + t
+}
+
+// AdminPermission
+//  @Description 校验后台用户权限，需在 AdminIdentityValidate 之后使用，只以身份签名校验通过的 aid、aln 作为权限主体；
+//  未开启 WithRBAC 或身份未校验时拒绝访问，拒绝时写入审计日志
+//  @Receiver m
+//  @Param actions 需要的动作权限，如 "user:export"，需全部满足；为空时校验当前路由，资源为 "METHOD 路由"
+//  @Return ginserver.HandlerFunc
+func (m *Middleware) AdminPermission(actions ...string) ginserver.HandlerFunc {
+	return func(c *ginserver.TContext) {
+		if m.rbac == nil {
+			klog.Warn("rbac not enabled, deny", klog.String("path", c.Request.URL.Path))
+			m.abortWithErrorJSON(c, errs.NewCustomError(ecode.CodePermissionDenied, "权限不足"))
+			return
+		}
+		ctx := tcontext.WithContext(c)
+		admin := tcontext.GetAdminHeader(ctx)
+		identity := &adminIdentity{}
+		if v, ok := c.Get(keyAdminIdentity); ok {
+			identity = v.(*adminIdentity)
+		}
+		resources := actions
+		if len(resources) == 0 {
+			route := c.FullPath()
+			if route == "" {
+				route = c.Request.URL.Path
+			}
+			resources = []string{rbac.Resource(c.Request.Method, route)}
+		}
+		for _, resource := range resources {
+			if identity.id != "" && m.rbac.Allow(resource, identity.id, identity.name) {
+				continue
+			}
+			klog.AccessLogger.WithContext(ctx).Warn("rbac denied",
+				klog.FieldEvent("rbac.deny"),
+				klog.String("resource", resource),
+				klog.String("aid", admin.AdminId),
+				klog.String("aln", admin.AdminLoginName),
+				klog.String("aac", admin.AdminAppCode),
+				klog.Any("verified", identity.id != ""),
+				klog.Any("roles", m.rbac.Roles(identity.id, identity.name)),
+				klog.String("ip", c.ClientIP()),
+			)
+			m.abortWithErrorJSON(c, errs.NewCustomError(ecode.CodePermissionDenied, "权限不足"))
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,89 @@
+package kmiddleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/constant"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/rbac"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/ecode"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/kjwt"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/ksign"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+const testAdminKey = "admin-secret"
+
+func newRBACEngine(m *Middleware) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	handlers := append(m.RequiredAdminValidLegal(), func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	engine.GET("/admin/user/:id", handlers...)
+	return engine
+}
+
+func newAdminRequest(aid, aln, signedAid string) *http.Request {
+	t := strconv.FormatInt(time.Now().UnixNano()/1e6, 10)
+	req := httptest.NewRequest(http.MethodGet, "/admin/user/1", nil)
+	req.Header.Set(constant.AdminHeaderFieldAid, aid)
+	req.Header.Set(constant.AdminHeaderFieldAln, aln)
+	req.Header.Set(constant.HeaderFieldT, t)
+	req.Header.Set(constant.AdminHeaderFieldAsg, ksign.Signature(testAdminKey, AdminIdentityCanonical(signedAid, aln, t)))
+	return req
+}
+
+func respCode(t *testing.T, w *httptest.ResponseRecorder) int {
+	var resp struct {
+		Code int `json:"code"`
+	}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp.Code
+}
+
+func TestAdminPermission(t *testing.T) {
+	config := rbac.DefaultConfig()
+	config.Policy = rbac.Policy{
+		Admins: map[string][]string{"id:1": {"admin"}},
+		Supers: []string{"admin"},
+	}
+	enforcer := config.BuildWithLoader(nil)
+	defer enforcer.Close()
+	m := &Middleware{rbac: enforcer, adminJwt: kjwt.NewClient(testAdminKey, time.Hour)}
+	engine := newRBACEngine(m)
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, newAdminRequest("1", "root", "1"))
+	assert.Equal(t, "ok", w.Body.String())
+
+	// 伪造超级管理员 id，签名仍是自己的 id
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, newAdminRequest("1", "bob", "2"))
+	assert.Equal(t, ecode.CodeInValidParameterSignature, respCode(t, w))
+
+	// 未签名
+	req := newAdminRequest("1", "root", "1")
+	req.Header.Del(constant.AdminHeaderFieldAsg)
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, ecode.CodeInValidParameterSignature, respCode(t, w))
+}
+
+func TestAdminPermission_NoRBAC(t *testing.T) {
+	m := &Middleware{adminJwt: kjwt.NewClient(testAdminKey, time.Hour)}
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/admin/user/:id", m.AdminIdentityValidate, m.AdminPermission(), func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, newAdminRequest("1", "root", "1"))
+	assert.Equal(t, ecode.CodePermissionDenied, respCode(t, w))
+}
@@ -0,0 +1,76 @@
+// @Description HMAC-SHA256 请求签名校验，请求头 X-Sign-Version 为 2 时使用，否则走旧版 MD5 签名
+
+package kmiddleware
+
+import (
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/server/ginserver"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/ecode"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/errs"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/ksign"
+)
+
+// SignatureConfigKey 签名配置 key，见 ksign.Config
+const SignatureConfigKey = "middleware.signature"
+
+// keySignApp 签名校验通过后保存应用 id，同一请求不重复校验
+const keySignApp = "signApp"
+
+// WithSignature
+//  @Description 开启 HMAC-SHA256 签名校验，配置见 ksign.Config
+//  @Param key 配置 key，为空时使用 SignatureConfigKey
+//  @Return Option
+func WithSignature(key string) Option {
+	if key == "" {
+		key = SignatureConfigKey
+	}
+	return func(m *Middleware) {
+		m.signer = ksign.RawConfig(key).Build()
+	}
+}
+
+// SignValidate
+//  @Description  HMAC-SHA256 验签，校验时间戳偏差并防重放，不接受旧版 MD5 签名，可用于服务间调用
+//  @Receiver m
+//  @Param c
+func (m *Middleware) SignValidate(c *ginserver.TContext) {
+	if _, ok := c.Get(keySignApp); ok {
+		return
+	}
+	if m.signer == nil {
+		m.abortWithErrorJSON(c, errs.NewCustomError(ecode.CodeInValidParameterSignature, "未开启签名校验"))
+		return
+	}
+	app, err := m.signer.Verify(c.Request.Context(), c.Request)
+	if err != nil {
+		klog.Warn("签名验证失败", klog.FieldErr(err), klog.String("app", app), klog.String("path", c.Request.URL.Path))
+		m.abortWithErrorJSON(c, signError(err))
+		return
+	}
+	c.Set(keySignApp, app)
+}
+
+// GetSignApp
+//  @Description  获取签名校验通过的应用 id
+//  @Param c
+//  @Return string
+func GetSignApp(c *ginserver.TContext) string {
+	return c.GetString(keySignApp)
+}
+
+// useHMACSign 客户端是否协商使用 HMAC 签名
+func useHMACSign(c *ginserver.TContext) bool {
+	return c.GetHeader(ksign.HeaderVersion) == ksign.Version
+}
+
+func signError(err error) error {
+	switch err {
+	case ksign.ErrTimestamp:
+		return errs.NewCustomError(ecode.CodeInvalidParameterTimestamp, "签名验证失败,时间戳超出范围")
+	case ksign.ErrReplay:
+		return errs.NewCustomError(ecode.CodeInValidParameterSignature, "签名验证失败,重复的请求")
+	case ksign.ErrMissingHeader, ksign.ErrNonce, ksign.ErrUnknownApp, ksign.ErrSignature:
+		return errs.NewCustomError(ecode.CodeInValidParameterSignature, "签名验证失败")
+	}
+	return errs.Wrap(err, ecode.CodeCacheError, "签名验证失败")
+}
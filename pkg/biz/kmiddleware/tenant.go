@@ -0,0 +1,138 @@
+// @Description 多租户，解析请求的租户并按租户限流与统计
+
+package kmiddleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/biz/tcontext"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/constant"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/ratelimiter"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/server/ginserver"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/tenant"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/ecode"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/errs"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/metric"
+)
+
+// TenantConfigKey 租户解析配置 key，见 tenant.Config
+const TenantConfigKey = "middleware.tenant"
+
+// tenantUnknown 未配置 Tenants 时租户指标使用的固定标签，避免客户端传入的租户使指标基数无限增长
+const tenantUnknown = "unknown"
+
+// WithTenant
+//  @Description 开启多租户，配置见 tenant.Config，配置了 Quota 时需同时开启 WithRateLimiter
+//  @Param key 配置 key，为空时使用 TenantConfigKey
+//  @Return Option
+func WithTenant(key string) Option {
+	if key == "" {
+		key = TenantConfigKey
+	}
+	return func(m *Middleware) {
+		m.tenant = tenant.RawConfig(key)
+	}
+}
+
+// ResolveTenant
+//  @Description 解析请求的租户，写入 gin 上下文与 c.Request 的上下文，按租户限流并统计请求数与耗时；
+//  jwt 来源需在 Authenticate 或 UserValidate 之后使用，请求头、子域名中的租户与已鉴权的 app id 不一致时拒绝请求
+//  @Receiver m
+//  @Return ginserver.HandlerFunc
+func (m *Middleware) ResolveTenant() ginserver.HandlerFunc {
+	return func(c *ginserver.TContext) {
+		if m.tenant == nil {
+			c.Next()
+			return
+		}
+		id, ok := m.resolveTenant(c)
+		if !ok {
+			m.abortWithErrorJSON(c, errs.NewCustomError(ecode.CodeInvalidParameterAppId, "租户与登录信息不一致"))
+			return
+		}
+		if id == "" {
+			if m.tenant.Required {
+				m.abortWithErrorJSON(c, errs.NewCustomError(ecode.CodeMissingParameterAppId, "缺少租户"))
+				return
+			}
+			c.Next()
+			return
+		}
+		if !m.tenant.Allowed(id) {
+			m.abortWithErrorJSON(c, errs.NewCustomError(ecode.CodeInvalidParameterAppId, "无效的租户"))
+			return
+		}
+		label, route := m.tenantLabel(id), c.Request.Method+"."+c.FullPath()
+		c.Set(constant.KeyTenant, id)
+		c.Request = c.Request.WithContext(tenant.NewContext(c.Request.Context(), id))
+
+		if m.tenant.Quota != "" && m.rater != nil {
+			res := m.rater.ExecuteRequest(m.tenant.Quota, ratelimiter.Request{
+				UniqueID: "tenant:" + id,
+				Path:     c.Request.URL.Path,
+				AppID:    rateLimitAppID(c),
+			}).Take()
+			if res.Limit > 0 && !res.Allowed {
+				setRateLimitHeaders(c, res)
+				m.abortWithErrorJSON(c, errs.NewCustomError(ecode.CodeRateLimitError, "RateLimiter limit"))
+				metric.TenantHandleCounter.Inc(metric.TypeHTTP, label, route, http.StatusText(c.Writer.Status()))
+				return
+			}
+		}
+
+		beg := time.Now()
+		c.Next()
+		metric.TenantHandleHistogram.Observe(time.Since(beg).Seconds(), metric.TypeHTTP, label, route)
+		metric.TenantHandleCounter.Inc(metric.TypeHTTP, label, route, http.StatusText(c.Writer.Status()))
+	}
+}
+
+// resolveTenant 按配置的来源顺序解析租户，请求头、子域名等来源的租户与已鉴权的 app id 不一致时返回 false
+func (m *Middleware) resolveTenant(c *ginserver.TContext) (string, bool) {
+	authed := authedTenant(c)
+	for _, source := range m.tenant.Sources {
+		var id string
+		switch source {
+		case tenant.SourceHeader:
+			id = c.GetHeader(m.tenant.Header)
+			if id == "" {
+				if header := tcontext.GetHeader(c); header != nil {
+					id = tenant.FromAppID(header.AppId)
+				}
+			}
+		case tenant.SourceJWT:
+			id = authed
+		case tenant.SourceSubdomain:
+			id = m.tenant.Subdomain(c.Request.Host)
+		}
+		if id == "" {
+			continue
+		}
+		// 非 jwt 来源由客户端控制，已鉴权时必须与鉴权的 app id 一致
+		if authed != "" && id != authed {
+			return "", false
+		}
+		return id, true
+	}
+	return "", true
+}
+
+// authedTenant 鉴权主体或 jwt 中的 app id
+func authedTenant(c *ginserver.TContext) string {
+	if p := tcontext.GetPrincipal(c); p != nil {
+		return tenant.FromAppID(p.AppID)
+	}
+	if claims := tcontext.GetMyClaims(c); claims != nil {
+		return tenant.FromAppID(claims.AppID)
+	}
+	return ""
+}
+
+// tenantLabel 租户指标标签，只有配置了 Tenants 时使用租户 id，此时租户已校验在 Tenants 中
+func (m *Middleware) tenantLabel(id string) string {
+	if len(m.tenant.Tenants) == 0 {
+		return tenantUnknown
+	}
+	return id
+}
@@ -0,0 +1,45 @@
+package kmiddleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/biz/kentity"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/biz/tcontext"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/tenant"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/ecode"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveTenant_CrossTenant(t *testing.T) {
+	config := tenant.DefaultConfig()
+	config.Sources = []string{tenant.SourceSubdomain, tenant.SourceHeader}
+	config.Domain = "example.com"
+	m := &Middleware{tenant: config}
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/orders", func(c *gin.Context) {
+		kentity.SetPrincipal(c, &kentity.Principal{AppID: 1})
+	}, m.ResolveTenant(), func(c *gin.Context) {
+		c.String(http.StatusOK, tcontext.GetTenant(c))
+	})
+
+	serve := func(host, header string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+		req.Host = host
+		if header != "" {
+			req.Header.Set(config.Header, header)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, "1", serve("1.example.com", "").Body.String())
+	assert.Equal(t, ecode.CodeInvalidParameterAppId, respCode(t, serve("2.example.com", "")))
+	assert.Equal(t, ecode.CodeInvalidParameterAppId, respCode(t, serve("localhost", "2")))
+	assert.Equal(t, "1", serve("localhost", "1").Body.String())
+}
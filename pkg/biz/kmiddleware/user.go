@@ -2,12 +2,10 @@ package kmiddleware
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/biz/kauth"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/biz/kentity"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/biz/tcontext"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/constant"
-	"github.com/LuoHongLiang0921/kuaigo/pkg/core/net/khttp"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/core/server/ginserver"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/core/storage/redis"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/ecode"
@@ -15,13 +13,10 @@ import (
 	"github.com/LuoHongLiang0921/kuaigo/pkg/util/kjwt"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
 	"strings"
-	"time"
-
-	"github.com/dgrijalva/jwt-go"
 )
 
 // 登录失败错误码
-var LoginFailed = 102002
+var LoginFailed = kauth.CodeLoginFailed
 
 type GuestUserModel interface {
 	// UserOpenLogin 三方登录
@@ -29,17 +24,19 @@ type GuestUserModel interface {
 }
 
 type guestUserModel struct {
-	cache *redis.Redis
-	addr  string
+	guest *kauth.Guest
 }
 
 // NewGuestUserModel init实例
-// 	@Description:  init实例
+// 	@Description:  init实例，登录接口等配置见 kauth.GuestConfig，登录请求原样发送，不填充默认值
 //	@Param cache redis.Redis
-//	@Param addr
+//	@Param addr passport 服务地址
 // 	@return XGuestUserModel
 func NewGuestUserModel(cache *redis.Redis, addr string) GuestUserModel {
-	return &guestUserModel{cache: cache, addr: addr}
+	config := kauth.DefaultGuestConfig()
+	config.Addr = addr
+	config.RawRequest = true
+	return &guestUserModel{guest: config.BuildWithCache(cache)}
 }
 
 // CheckRealLogin 用户登录校验
@@ -48,14 +45,14 @@ func NewGuestUserModel(cache *redis.Redis, addr string) GuestUserModel {
 //	@Param c ginserver.TContext
 func (m *Middleware) CheckRealLogin(c *ginserver.TContext) {
 	myClaims := tcontext.GetMyClaims(c)
-	if myClaims == nil || myClaims.LoginRole == 2 {
+	if myClaims == nil || myClaims.LoginRole == kjwt.LoginRoleGuest {
 		m.abortWithErrorJSON(c, errs.NewCustomError(ecode.CodeUserNotLogin, "用户未登录"))
 		return
 	}
 }
 
 // UserGuestLogin 游客登录
-// 	@Description  游客登录
+// 	@Description  游客登录，token 中已有用户信息时直接返回
 // 	@receiver um xguestUserModel
 //	@Param c ginserver.TContext
 //	@Param ctx context.Context
@@ -63,58 +60,10 @@ func (m *Middleware) CheckRealLogin(c *ginserver.TContext) {
 // 	@return *xjwt.MyClaims
 // 	@return error
 func (um *guestUserModel) UserGuestLogin(c *ginserver.TContext, ctx context.Context, req kentity.ReqPassportOpenLogin) (*kjwt.MyClaims, error) {
-	guestUserKey := fmt.Sprintf("%s%v:%s", constant.GuestUserKeyPre, req.AppId, req.OpenId)
-	claimsStr := um.cache.Get(guestUserKey)
-	// 1.判断 token 中解析出来的 用户信息，如果有可以直接返回，没有的话校验redis中是否存在
-	myClaims := tcontext.GetMyClaims(c)
-	if myClaims != nil {
+	if myClaims := tcontext.GetMyClaims(c); myClaims != nil {
 		return myClaims, nil
 	}
-	// 2.判断缓存中是否存在
-	claims := &kjwt.MyClaims{}
-	if claimsStr != "" {
-		err := json.Unmarshal([]byte(claimsStr), claims)
-		if err == nil {
-			return claims, nil
-		}
-	}
-	// 3. 调用游客登录接口生成
-	//reqHeader := http.Header{}
-	//reqHeader.Set("Content-Type", "application/json")
-	URL := um.addr + "/v1/login.json"
-	pRsp := &kentity.RspPassportLogin{}
-	resp, err := khttp.PostJson(ctx, URL, req)
-	logFmt := fmt.Sprintf("url:%s, req:%+v, recResp:%+v", URL, req, pRsp)
-	if err != nil {
-		logFmt = logFmt + fmt.Sprintf(", err:%s", err.Error())
-		msg := pRsp.Msg
-		if pRsp.Code == 102 {
-			msg = "凭证过期"
-		}
-		return claims, errs.NewCustomError(LoginFailed, msg)
-	}
-	resp.Json(pRsp)
-	if pRsp.Code != 0 {
-		klog.WithContext(ctx).Error(logFmt)
-		return nil, errs.NewCustomError(LoginFailed, pRsp.Msg)
-	}
-	if pRsp.Data.PassportInfo.Status == 2 {
-		return nil, errs.NewCustomError(LoginFailed, "用户已被封禁")
-	}
-	claims.UserID = fmt.Sprintf("%v", pRsp.Data.PassportInfo.UID)
-	claims.AppID = req.AppId
-	claims.DID = req.OpenId
-	claims.PlatToken = pRsp.Data.PassportInfo.Token
-	// 0,1： 真实登录， 2：游客登录（0 为了兼容老版本用户）
-	claims.LoginRole = 2
-	claims.StandardClaims = jwt.StandardClaims{}
-	// 登录成功之后从新设置 缓存信息
-	claimsByte, _ := json.Marshal(claims)
-	flag := um.cache.Set(guestUserKey, claimsByte, time.Duration(constant.GuestLoginExpireTime)*time.Minute)
-	if !flag {
-		klog.WithContext(ctx).Error("游客登录设置redis缓存失败")
-	}
-	return claims, nil
+	return um.guest.Login(ctx, req)
 }
 
 // CheckGuestLogin 校验游客登录
@@ -166,6 +115,10 @@ func (m *Middleware) UserValidate(c *ginserver.TContext) {
 			m.abortWithErrorJSON(c, errs.NewCustomError(ecode.CodeAuthTokenTimeout, "token已过期"))
 			return
 		}
+		if err == kjwt.ErrRevoked {
+			m.abortWithErrorJSON(c, errs.NewCustomError(ecode.CodeInvalidAuthToken, "token已吊销"))
+			return
+		}
 		m.abortWithErrorJSON(c, errs.NewCustomError(ecode.CodeInvalidAuthToken, "解密token失败"))
 		return
 	}
@@ -52,12 +52,16 @@ func (m *Middleware) RequiredInnerValidLegal() []ginserver.HandlerFunc {
 }
 
 // RequiredAdminValidLegal 内部服务 header公参统一校验
-//  @Description:
+//  @Description: 开启 WithRBAC 时先校验后台用户身份签名，再校验后台用户是否有权访问当前路由
 //  @Receiver m
 func (m *Middleware) RequiredAdminValidLegal() []ginserver.HandlerFunc {
-	return []ginserver.HandlerFunc{
+	handlers := []ginserver.HandlerFunc{
 		m.ExtractAdminHeader,
 	}
+	if m.rbac != nil {
+		handlers = append(handlers, m.AdminIdentityValidate, m.AdminPermission())
+	}
+	return handlers
 }
 
 // RequiredOpenValidLegal 管理系统 header公参统一校验
@@ -208,7 +212,7 @@ func (m *Middleware) AppHeaderValidate(c *ginserver.TContext) {
 }
 
 // AppSignValidate
-//  @Description  app 验签
+//  @Description  app 验签，请求头 X-Sign-Version 为 2 时使用 HMAC 签名，见 SignValidate，否则使用旧版 MD5 签名
 //  @Receiver m
 //  @Param c
 func (m *Middleware) AppSignValidate(c *ginserver.TContext) {
@@ -221,6 +225,10 @@ func (m *Middleware) AppSignValidate(c *ginserver.TContext) {
 			return
 		}
 	}
+	if useHMACSign(c) {
+		m.SignValidate(c)
+		return
+	}
 	// 从上下文获取信息
 	snsNdkHeader := tcontext.GetHeader(c)
 	snsNdkPublic := tcontext.GetPParam(c)
@@ -260,10 +268,14 @@ func (m *Middleware) AppSignValidate(c *ginserver.TContext) {
 }
 
 // AdminSignValidate
-//  @Description  后台验证
+//  @Description  后台验证，请求头 X-Sign-Version 为 2 时使用 HMAC 签名，见 SignValidate
 //  @Receiver m
 //  @Param c
 func (m *Middleware) AdminSignValidate(c *ginserver.TContext) {
+	if useHMACSign(c) {
+		m.SignValidate(c)
+		return
+	}
 	adminId := c.GetHeader(constant.HeaderFieldAdminId)
 	adminAppId := c.GetHeader(constant.HeaderFieldAdminId)
 	t := c.GetHeader(constant.HeaderFieldT)
@@ -278,7 +290,10 @@ func (m *Middleware) AdminSignValidate(c *ginserver.TContext) {
 		return
 	}
 	//判断时间是否超过3分钟
-	timeIfExpire(t, adminId, adminAppId, sk)
+	if timeIfExpire(t, adminId, adminAppId, sk) {
+		m.abortWithErrorJSON(c, errs.NewCustomError(ecode.CodeInvalidParameterTimestamp, "签名验证失败,签名超过3分钟"))
+		return
+	}
 	// 加密header
 	headerMd5 := getMd5AdminHeader(adminId, adminAppId, t)
 	// 加密body
@@ -308,14 +323,16 @@ func (m *Middleware) AdminSignValidate(c *ginserver.TContext) {
 //  @Param adminId
 //  @Param adminAppId
 //  @Param sk
-func timeIfExpire(t string, adminId string, adminAppId string, sk string) {
+//  @Return bool 超过 3 分钟时为 true
+func timeIfExpire(t string, adminId string, adminAppId string, sk string) bool {
 	l, _ := strconv.ParseInt(t, 10, 64)
 	currentTimeMillis := time.Now().UnixNano() / 1e6
 	//时间超过3分钟
 	if absInt(currentTimeMillis-l) > constant.SignExpireTime {
-		// 打印日志,不抛出异常
 		klog.Debug("签名验证失败,签名超过3分钟,adminId:" + adminId + ",adminAppId:" + adminAppId + ",t:" + t + ",sk:" + sk + ",current:" + strconv.FormatInt(currentTimeMillis, 10))
+		return true
 	}
+	return false
 }
 
 // getMd5AppHeader
@@ -0,0 +1,260 @@
+// @Description 生成 OpenAPI 文档
+
+package kopenapi
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/biz/kentity"
+)
+
+var (
+	responseType     = reflect.TypeOf(kentity.Response{})
+	listDataType     = reflect.TypeOf(kentity.ListData{})
+	kuaigoHeaderType = reflect.TypeOf(kentity.KuaigoHeader{})
+)
+
+// build 由路由生成文档
+func build(routes []route) *Document {
+	version := pkg.GetAppVersion()
+	if version == "" {
+		version = "dev"
+	}
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    Info{Title: pkg.GetAppName(), Version: version},
+		Paths:   make(map[string]*PathItem),
+	}
+	g := newGenerator()
+	parameters := make(map[string]*Parameter)
+	tags := make(map[string]struct{})
+	for _, rt := range routes {
+		p := openAPIPath(rt.path)
+		item, ok := doc.Paths[p]
+		if !ok {
+			item = &PathItem{}
+			doc.Paths[p] = item
+		}
+		item.set(rt.method, g.operation(rt, p, parameters))
+		for _, tag := range rt.Tags {
+			tags[tag] = struct{}{}
+		}
+	}
+	for tag := range tags {
+		doc.Tags = append(doc.Tags, Tag{Name: tag})
+	}
+	sort.Slice(doc.Tags, func(i, j int) bool { return doc.Tags[i].Name < doc.Tags[j].Name })
+	doc.Components = Components{Schemas: g.schemas, Parameters: parameters}
+	return doc
+}
+
+func (item *PathItem) set(method string, op *Operation) {
+	switch method {
+	case http.MethodGet:
+		item.Get = op
+	case http.MethodPut:
+		item.Put = op
+	case http.MethodPost:
+		item.Post = op
+	case http.MethodDelete:
+		item.Delete = op
+	case http.MethodOptions:
+		item.Options = op
+	case http.MethodHead:
+		item.Head = op
+	case http.MethodPatch:
+		item.Patch = op
+	}
+}
+
+// openAPIPath 将 gin 的 :id 与 *path 转为 {id} 与 {path}
+func openAPIPath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, s := range segments {
+		if strings.HasPrefix(s, ":") || strings.HasPrefix(s, "*") {
+			segments[i] = "{" + s[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func (g *generator) operation(rt route, p string, parameters map[string]*Parameter) *Operation {
+	op := &Operation{
+		Tags:        rt.Tags,
+		Summary:     rt.Summary,
+		Description: rt.Description,
+		OperationID: rt.OperationID,
+		Deprecated:  rt.Deprecated,
+		Responses:   map[string]*Response{"200": g.response(rt.Response)},
+	}
+	if op.OperationID == "" {
+		op.OperationID = operationID(rt.method, p)
+	}
+	if !rt.NoHeader {
+		op.Parameters = append(op.Parameters, g.headerParameters(rt.Header, parameters)...)
+	}
+	if rt.Request != nil {
+		g.request(op, rt.method, reflect.TypeOf(rt.Request))
+	}
+	// 请求结构体中未声明的路径参数
+	for _, s := range strings.Split(p, "/") {
+		if !strings.HasPrefix(s, "{") {
+			continue
+		}
+		name := strings.Trim(s, "{}")
+		if !hasParameter(op.Parameters, InPath, name) {
+			op.Parameters = append(op.Parameters, &Parameter{Name: name, In: InPath, Required: true, Schema: &Schema{Type: "string"}})
+		}
+	}
+	return op
+}
+
+func hasParameter(params []*Parameter, in, name string) bool {
+	for _, p := range params {
+		if p.In == in && p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// operationID 如 GET /v1/user/{id} 生成 getV1UserId
+func operationID(method, p string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, s := range strings.FieldsFunc(p, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	}) {
+		b.WriteString(strings.ToUpper(s[:1]) + s[1:])
+	}
+	return b.String()
+}
+
+// headerParameters 公共请求头放入 components.parameters，接口中以 $ref 引用
+func (g *generator) headerParameters(header interface{}, parameters map[string]*Parameter) []*Parameter {
+	t := kuaigoHeaderType
+	if header != nil {
+		t = reflect.TypeOf(header)
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	var refs []*Parameter
+	walkFields(t, func(f reflect.StructField) {
+		name := f.Tag.Get("header")
+		if name == "" || name == "-" {
+			return
+		}
+		key := t.Name() + "." + name
+		if _, ok := parameters[key]; !ok {
+			parameters[key] = g.parameter(f, name, InHeader)
+		}
+		refs = append(refs, &Parameter{Ref: "#/components/parameters/" + key})
+	})
+	return refs
+}
+
+func (g *generator) parameter(f reflect.StructField, name, in string) *Parameter {
+	s := g.fieldSchema(f)
+	description := s.Description
+	s.Description = ""
+	return &Parameter{
+		Name:        name,
+		In:          in,
+		Description: description,
+		Required:    in == InPath || required(f),
+		Schema:      s,
+	}
+}
+
+// request 按字段标签拆分为路径参数、请求头、query 参数与请求体
+func (g *generator) request(op *Operation, method string, t reflect.Type) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+	hasBody := method != http.MethodGet && method != http.MethodDelete && method != http.MethodHead
+	jsonBody := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	formBody := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	onlyJSON := true
+	walkFields(t, func(f reflect.StructField) {
+		if name := f.Tag.Get("uri"); name != "" && name != "-" {
+			op.Parameters = append(op.Parameters, g.parameter(f, name, InPath))
+			onlyJSON = false
+			return
+		}
+		if name := f.Tag.Get("header"); name != "" && name != "-" {
+			op.Parameters = append(op.Parameters, g.parameter(f, name, InHeader))
+			onlyJSON = false
+			return
+		}
+		if !hasBody {
+			if name := tagName(f, "form"); name != "" {
+				op.Parameters = append(op.Parameters, g.parameter(f, name, InQuery))
+			}
+			return
+		}
+		body, key := jsonBody, "json"
+		if f.Tag.Get("form") != "" && f.Tag.Get("json") == "" {
+			body, key = formBody, "form"
+			onlyJSON = false
+		}
+		name := tagName(f, key)
+		if name == "" {
+			return
+		}
+		body.Properties[name] = g.fieldSchema(f)
+		if required(f) {
+			body.Required = append(body.Required, name)
+		}
+	})
+	if !hasBody {
+		return
+	}
+	content := make(map[string]*MediaType)
+	if len(jsonBody.Properties) > 0 {
+		if onlyJSON && t.Name() != "" {
+			// 整个结构体均为请求体时以组件引用
+			jsonBody = g.schema(t)
+		}
+		content[MIMEJSON] = &MediaType{Schema: jsonBody}
+	}
+	if len(formBody.Properties) > 0 {
+		content[MIMEForm] = &MediaType{Schema: formBody}
+	}
+	if len(content) > 0 {
+		op.RequestBody = &RequestBody{Required: true, Content: content}
+	}
+}
+
+// response 响应外层为 kentity.Response，data 为接口声明的类型
+func (g *generator) response(data interface{}) *Response {
+	envelope := g.schema(responseType)
+	if data != nil {
+		var dataSchema *Schema
+		if list, ok := data.(listOf); ok {
+			dataSchema = &Schema{AllOf: []*Schema{
+				g.schema(listDataType),
+				{Type: "object", Properties: map[string]*Schema{
+					"list": {Type: "array", Items: g.schema(list.item)},
+				}},
+			}}
+		} else {
+			dataSchema = g.schema(reflect.TypeOf(data))
+		}
+		envelope = &Schema{AllOf: []*Schema{
+			envelope,
+			{Type: "object", Properties: map[string]*Schema{"data": dataSchema}},
+		}}
+	}
+	return &Response{
+		Description: "OK",
+		Content:     map[string]*MediaType{MIMEJSON: {Schema: envelope}},
+	}
+}
@@ -0,0 +1,31 @@
+// @Description 在治理服务上提供 OpenAPI 文档与文档页面
+
+package kopenapi
+
+import (
+	"embed"
+	"encoding/json"
+	"net/http"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/server/governor"
+)
+
+//go:embed ui/index.html
+var ui embed.FS
+
+func init() {
+	governor.HandleFunc("/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		encoder := json.NewEncoder(w)
+		if r.URL.Query().Get("pretty") == "true" {
+			encoder.SetIndent("", "    ")
+		}
+		_ = encoder.Encode(Spec())
+	})
+
+	governor.HandleFunc("/docs", func(w http.ResponseWriter, r *http.Request) {
+		page, _ := ui.ReadFile("ui/index.html")
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(page)
+	})
+}
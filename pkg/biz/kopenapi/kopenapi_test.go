@@ -0,0 +1,109 @@
+package kopenapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/biz/kentity"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/server/governor"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type testUser struct {
+	ID      int64       `json:"id"`
+	Name    string      `json:"name" binding:"required,max=20" description:"昵称"`
+	Mobile  string      `json:"mobile" binding:"mobile"`
+	Friends []*testUser `json:"friends"`
+}
+
+type getUserReq struct {
+	ID     int64  `uri:"id" binding:"required"`
+	Fields string `form:"fields" binding:"omitempty,oneof=all brief"`
+}
+
+type createUserReq struct {
+	Name   string `json:"name" binding:"required"`
+	Mobile string `json:"mobile" binding:"required,mobile"`
+}
+
+type updateUserReq struct {
+	ID   int64  `uri:"id"`
+	Name string `json:"name"`
+}
+
+func TestSpec(t *testing.T) {
+	defaultRegistry = &registry{}
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	api := Wrap(engine.Group("/v1")).Group("/user")
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	api.GET("/:id", Route{Summary: "用户详情", Tags: []string{"user"}, Request: getUserReq{}, Response: testUser{}}, ok)
+	api.POST("", Route{Tags: []string{"user"}, Request: &createUserReq{}, Response: testUser{}}, ok)
+	api.PUT("/:id", Route{Request: updateUserReq{}, Header: kentity.AdminHeader{}}, ok)
+	api.GET("/list/*tag", Route{Response: ListOf(testUser{}), NoHeader: true}, ok)
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/user/1", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	doc := Spec()
+	assert.Equal(t, Version, doc.OpenAPI)
+	assert.Equal(t, []Tag{{Name: "user"}}, doc.Tags)
+
+	get := doc.Paths["/v1/user/{id}"].Get
+	assert.Equal(t, "getV1UserId", get.OperationID)
+	assert.Equal(t, "#/components/parameters/KuaigoHeader.User-Agent", get.Parameters[0].Ref)
+	n := len(get.Parameters)
+	assert.Equal(t, &Parameter{Name: "id", In: InPath, Required: true, Schema: &Schema{Type: "integer", Format: "int64"}}, get.Parameters[n-2])
+	assert.Equal(t, []interface{}{"all", "brief"}, get.Parameters[n-1].Schema.Enum)
+	assert.Nil(t, get.RequestBody)
+	data := get.Responses["200"].Content[MIMEJSON].Schema
+	assert.Equal(t, "#/components/schemas/Response", data.AllOf[0].Ref)
+	assert.Equal(t, "#/components/schemas/testUser", data.AllOf[1].Properties["data"].Ref)
+
+	user := doc.Components.Schemas["testUser"]
+	assert.Equal(t, []string{"name"}, user.Required)
+	assert.Equal(t, "昵称", user.Properties["name"].Description)
+	assert.Equal(t, 20, *user.Properties["name"].MaxLength)
+	assert.Equal(t, "#/components/schemas/testUser", user.Properties["friends"].Items.Ref)
+	assert.NotEmpty(t, user.Properties["mobile"].Pattern)
+	assert.Contains(t, doc.Components.Schemas, "Response")
+	assert.True(t, doc.Components.Parameters["KuaigoHeader.rv"].Required)
+
+	post := doc.Paths["/v1/user"].Post
+	assert.Equal(t, "#/components/schemas/createUserReq", post.RequestBody.Content[MIMEJSON].Schema.Ref)
+
+	put := doc.Paths["/v1/user/{id}"].Put
+	assert.Equal(t, "#/components/parameters/AdminHeader.aid", put.Parameters[0].Ref)
+	body := put.RequestBody.Content[MIMEJSON].Schema
+	assert.Contains(t, body.Properties, "name")
+	assert.NotContains(t, body.Properties, "id")
+
+	list := doc.Paths["/v1/user/list/{tag}"].Get
+	assert.Equal(t, []*Parameter{{Name: "tag", In: InPath, Required: true, Schema: &Schema{Type: "string"}}}, list.Parameters)
+	listData := list.Responses["200"].Content[MIMEJSON].Schema.AllOf[1].Properties["data"]
+	assert.Equal(t, "#/components/schemas/ListData", listData.AllOf[0].Ref)
+	assert.Equal(t, "#/components/schemas/testUser", listData.AllOf[1].Properties["list"].Items.Ref)
+
+	_, err := json.Marshal(doc)
+	assert.Nil(t, err)
+}
+
+func TestGovernor(t *testing.T) {
+	defaultRegistry = &registry{}
+	Wrap(gin.New()).GET("/ping", Route{Summary: "ping"}, func(c *gin.Context) {})
+
+	w := httptest.NewRecorder()
+	governor.DefaultServeMux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	var doc Document
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	assert.Equal(t, "ping", doc.Paths["/ping"].Get.Summary)
+
+	w = httptest.NewRecorder()
+	governor.DefaultServeMux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "openapi.json")
+}
@@ -0,0 +1,166 @@
+// @Description 带请求与响应类型的路由注册，注册的路由用于生成 OpenAPI 文档
+
+package kopenapi
+
+import (
+	"net/http"
+	"path"
+	"reflect"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Route 接口文档
+type Route struct {
+	// Summary 接口名称
+	Summary string
+	// Description 接口说明
+	Description string
+	// Tags 接口分组
+	Tags []string
+	// OperationID 接口唯一标识，为空时由请求方法与路径生成
+	OperationID string
+	// Request 请求参数结构体，uri 标签为路径参数，header 标签为请求头，
+	// GET、DELETE 请求的其余字段为 query 参数，其他请求的其余字段为请求体
+	Request interface{}
+	// Response 响应中 data 的类型，列表使用 ListOf，响应外层为 kentity.Response
+	Response interface{}
+	// Header 公共请求头结构体，默认 kentity.KuaigoHeader，后台接口可使用 kentity.AdminHeader
+	Header interface{}
+	// NoHeader 不添加公共请求头
+	NoHeader bool
+	// Deprecated 接口已废弃
+	Deprecated bool
+}
+
+// listOf 分页列表，data 为 kentity.ListData
+type listOf struct {
+	item reflect.Type
+}
+
+// ListOf
+//  @Description  响应 data 为 kentity.ListData，list 的元素类型为 item
+//  @Param item 列表元素
+//  @Return interface{} 用于 Route.Response
+func ListOf(item interface{}) interface{} {
+	return listOf{item: reflect.TypeOf(item)}
+}
+
+type route struct {
+	Route
+	method string
+	path   string
+}
+
+// registry 已注册的路由，文档在路由变更后重新生成
+type registry struct {
+	mu     sync.Mutex
+	routes []route
+	doc    *Document
+}
+
+var defaultRegistry = &registry{}
+
+func (r *registry) add(rt route) {
+	r.mu.Lock()
+	r.routes = append(r.routes, rt)
+	r.doc = nil
+	r.mu.Unlock()
+}
+
+func (r *registry) document() *Document {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.doc == nil {
+		r.doc = build(r.routes)
+	}
+	return r.doc
+}
+
+// Spec
+//  @Description  由已注册的路由生成的 OpenAPI 文档
+//  @Return *Document
+func Spec() *Document {
+	return defaultRegistry.document()
+}
+
+// Router 注册路由的同时记录接口文档，未记录文档的路由可通过 IRouter 注册
+type Router struct {
+	gin.IRouter
+	base string
+}
+
+// Wrap
+//  @Description  包装路由，通常在 IController.RegisterController 中使用
+//  @Param r
+//  @Return *Router
+func Wrap(r gin.IRouter) *Router {
+	base := "/"
+	if g, ok := r.(interface{ BasePath() string }); ok {
+		base = g.BasePath()
+	}
+	return &Router{IRouter: r, base: base}
+}
+
+// Group
+//  @Description  创建路由分组
+//  @Receiver r
+//  @Param relativePath
+//  @Param handlers
+//  @Return *Router
+func (r *Router) Group(relativePath string, handlers ...gin.HandlerFunc) *Router {
+	return &Router{
+		IRouter: r.IRouter.Group(relativePath, handlers...),
+		base:    joinPath(r.base, relativePath),
+	}
+}
+
+// Handle
+//  @Description  注册路由并记录接口文档
+//  @Receiver r
+//  @Param method 请求方法
+//  @Param relativePath
+//  @Param doc 接口文档
+//  @Param handlers
+//  @Return gin.IRoutes
+func (r *Router) Handle(method, relativePath string, doc Route, handlers ...gin.HandlerFunc) gin.IRoutes {
+	defaultRegistry.add(route{Route: doc, method: method, path: joinPath(r.base, relativePath)})
+	return r.IRouter.Handle(method, relativePath, handlers...)
+}
+
+// GET ...
+func (r *Router) GET(relativePath string, doc Route, handlers ...gin.HandlerFunc) gin.IRoutes {
+	return r.Handle(http.MethodGet, relativePath, doc, handlers...)
+}
+
+// POST ...
+func (r *Router) POST(relativePath string, doc Route, handlers ...gin.HandlerFunc) gin.IRoutes {
+	return r.Handle(http.MethodPost, relativePath, doc, handlers...)
+}
+
+// PUT ...
+func (r *Router) PUT(relativePath string, doc Route, handlers ...gin.HandlerFunc) gin.IRoutes {
+	return r.Handle(http.MethodPut, relativePath, doc, handlers...)
+}
+
+// PATCH ...
+func (r *Router) PATCH(relativePath string, doc Route, handlers ...gin.HandlerFunc) gin.IRoutes {
+	return r.Handle(http.MethodPatch, relativePath, doc, handlers...)
+}
+
+// DELETE ...
+func (r *Router) DELETE(relativePath string, doc Route, handlers ...gin.HandlerFunc) gin.IRoutes {
+	return r.Handle(http.MethodDelete, relativePath, doc, handlers...)
+}
+
+func joinPath(base, relativePath string) string {
+	if relativePath == "" {
+		return base
+	}
+	p := path.Join(base, relativePath)
+	if relativePath[len(relativePath)-1] == '/' && p[len(p)-1] != '/' {
+		return p + "/"
+	}
+	return p
+}
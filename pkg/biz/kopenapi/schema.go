@@ -0,0 +1,251 @@
+// @Description 由 Go 类型生成 Schema
+
+package kopenapi
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/kvalidator"
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	bytesType      = reflect.TypeOf([]byte{})
+)
+
+// generator 生成 Schema，具名结构体放入 components 并以 $ref 引用
+type generator struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func newGenerator() *generator {
+	return &generator{
+		schemas: make(map[string]*Schema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+func refSchema(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// schema 生成类型的 Schema
+func (g *generator) schema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case rawMessageType:
+		return &Schema{}
+	case bytesType:
+		return &Schema{Type: "string", Format: "byte"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		if name, ok := g.names[t]; ok {
+			return refSchema(name)
+		}
+		name := g.name(t)
+		// 先占位，递归引用自身时直接返回 $ref
+		g.names[t] = name
+		g.schemas[name] = &Schema{}
+		*g.schemas[name] = *g.structSchema(t)
+		return refSchema(name)
+	}
+	// interface{} 等任意类型
+	return &Schema{}
+}
+
+// name 组件名默认为类型名，重名时加包名前缀
+func (g *generator) name(t reflect.Type) string {
+	name := t.Name()
+	if _, ok := g.schemas[name]; ok {
+		name = path.Base(t.PkgPath()) + "." + name
+	}
+	for i := 2; ; i++ {
+		if _, ok := g.schemas[name]; !ok {
+			return name
+		}
+		name = t.Name() + strconv.Itoa(i)
+	}
+}
+
+func (g *generator) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	walkFields(t, func(f reflect.StructField) {
+		name := tagName(f, "json")
+		if name == "" {
+			return
+		}
+		s.Properties[name] = g.fieldSchema(f)
+		if required(f) {
+			s.Required = append(s.Required, name)
+		}
+	})
+	return s
+}
+
+// fieldSchema 生成字段的 Schema，并附加描述与 binding 标签中的校验规则
+func (g *generator) fieldSchema(f reflect.StructField) *Schema {
+	s := g.schema(f.Type)
+	description := f.Tag.Get("description")
+	if s.Ref != "" {
+		if description != "" {
+			return &Schema{AllOf: []*Schema{s}, Description: description}
+		}
+		return s
+	}
+	s.Description = description
+	if example := f.Tag.Get("example"); example != "" {
+		s.Example = example
+	}
+	applyBinding(s, f.Tag.Get("binding"))
+	return s
+}
+
+// applyBinding 将常用的校验标签转为 Schema 约束
+func applyBinding(s *Schema, binding string) {
+	if binding == "" {
+		return
+	}
+	for _, rule := range strings.Split(binding, ",") {
+		kv := strings.SplitN(rule, "=", 2)
+		var param string
+		if len(kv) == 2 {
+			param = kv[1]
+		}
+		switch kv[0] {
+		case "min", "gte":
+			setMin(s, param)
+		case "max", "lte":
+			setMax(s, param)
+		case "len":
+			setMin(s, param)
+			setMax(s, param)
+		case "oneof":
+			for _, v := range strings.Fields(param) {
+				s.Enum = append(s.Enum, enumValue(s.Type, v))
+			}
+		case "email":
+			s.Format = "email"
+		case "url", "uri", kvalidator.TagFullURL:
+			s.Format = "uri"
+		case kvalidator.TagMobile:
+			s.Pattern = kvalidator.Mobile
+		}
+	}
+}
+
+func setMin(s *Schema, param string) {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	switch s.Type {
+	case "string":
+		v := int(n)
+		s.MinLength = &v
+	case "integer", "number":
+		s.Minimum = &n
+	}
+}
+
+func setMax(s *Schema, param string) {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	switch s.Type {
+	case "string":
+		v := int(n)
+		s.MaxLength = &v
+	case "integer", "number":
+		s.Maximum = &n
+	}
+}
+
+func enumValue(typ, v string) interface{} {
+	switch typ {
+	case "integer":
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return n
+		}
+	}
+	return v
+}
+
+// walkFields 遍历导出字段，未打标签的匿名结构体字段展开
+func walkFields(t reflect.Type, fn func(f reflect.StructField)) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct && f.Tag.Get("json") == "" {
+				walkFields(ft, fn)
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		fn(f)
+	}
+}
+
+// tagName 取标签中的名称，"-" 时返回空，未设置时为字段名
+func tagName(f reflect.StructField, key string) string {
+	name := strings.SplitN(f.Tag.Get(key), ",", 2)[0]
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return f.Name
+	}
+	return name
+}
+
+// required binding 标签包含 required
+func required(f reflect.StructField) bool {
+	for _, rule := range strings.Split(f.Tag.Get("binding"), ",") {
+		if rule == "required" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+// @Description 请求参数绑定与校验
+
+package tcontext
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/biz/kentity"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/server/kgin"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/ecode"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/kvalidator"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+// 参数错误的默认描述
+var invalidParameterMsg = map[string]string{
+	kvalidator.LangZh: "参数错误",
+	kvalidator.LangEn: "invalid parameter",
+}
+
+// GetLang
+//  @Description  获取请求语言，依次取 Accept-Language 与 p 参数中的客户端系统语言
+//  @Param c
+//  @Return string 见 kvalidator.MatchLang
+func GetLang(c *kgin.TContext) string {
+	return kvalidator.MatchLang(kentity.RequestLangs(c)...)
+}
+
+// Bind
+//  @Description  按 Content-Type 绑定并校验请求参数，失败时中断请求并返回字段错误列表
+//  @Param c
+//  @Param obj 参数结构体指针，校验规则使用 binding 标签，调用 khttp.SetupValidator 或开启 Config.Validator 后支持 mobile、citizen_no 等 kvalidator 标签
+//  @Return error 绑定或校验失败时不为 nil，调用方直接 return 即可
+func Bind(c *kgin.TContext, obj interface{}) error {
+	return BindWith(c, obj, binding.Default(c.Request.Method, c.ContentType()))
+}
+
+// BindQuery
+//  @Description  绑定并校验 query 参数，见 Bind
+//  @Param c
+//  @Param obj
+//  @Return error
+func BindQuery(c *kgin.TContext, obj interface{}) error {
+	return BindWith(c, obj, binding.Query)
+}
+
+// BindJSON
+//  @Description  绑定并校验 json 请求体，见 Bind
+//  @Param c
+//  @Param obj
+//  @Return error
+func BindJSON(c *kgin.TContext, obj interface{}) error {
+	return BindWith(c, obj, binding.JSON)
+}
+
+// BindUri
+//  @Description  绑定并校验路径参数，见 Bind
+//  @Param c
+//  @Param obj
+//  @Return error
+func BindUri(c *kgin.TContext, obj interface{}) error {
+	if err := c.ShouldBindUri(obj); err != nil {
+		abortWithBindError(c, err)
+		return err
+	}
+	return nil
+}
+
+// BindWith
+//  @Description  使用指定的 binding 绑定并校验，见 Bind
+//  @Param c
+//  @Param obj
+//  @Param b binding.JSON、binding.Form、binding.Header 等
+//  @Return error
+func BindWith(c *kgin.TContext, obj interface{}, b binding.Binding) error {
+	if err := c.ShouldBindWith(obj, b); err != nil {
+		abortWithBindError(c, err)
+		return err
+	}
+	return nil
+}
+
+// abortWithBindError 校验错误返回 CodeInvalidParameter 与字段错误列表，类型错误返回 CodeInvalidParameterType
+func abortWithBindError(c *kgin.TContext, err error) {
+	lang := GetLang(c)
+	resp := &kentity.Response{Code: ecode.CodeInvalidParameter, Msg: invalidParameterMsg[lang]}
+	var typeErr *json.UnmarshalTypeError
+	if fields, ok := kvalidator.Translate(err, lang); ok {
+		resp.Errors = fields
+		if len(fields) > 0 {
+			resp.Msg = fields[0].Message
+		}
+	} else if errors.As(err, &typeErr) {
+		resp.Code = ecode.CodeInvalidParameterType
+		resp.Errors = []kvalidator.FieldError{{Field: typeErr.Field, Tag: "type", Param: typeErr.Type.String(), Message: invalidParameterMsg[lang]}}
+	}
+	kentity.AbortWithJSONResponse(c, resp)
+}
@@ -5,8 +5,11 @@ import (
 	"github.com/LuoHongLiang0921/kuaigo/pkg/biz/kentity"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/constant"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/core/server/kgin"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/server/khttp"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/tenant"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/util/kjwt"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/ktls"
 )
 
 type headerName string
@@ -21,6 +24,8 @@ type adminHeaderContextKey struct{}
 
 type userContextKey struct{}
 
+type principalContextKey struct{}
+
 type KuaigoContext struct{}
 
 // GetTabbyContext
@@ -83,10 +88,20 @@ func WithContext(c *kgin.TContext) context.Context {
 		ctx = context.WithValue(ctx, userContextKey{}, v)
 	}
 
+	principal, ok := c.Get(constant.KeyPrincipal)
+	if ok {
+		ctx = context.WithValue(ctx, principalContextKey{}, principal)
+	}
+
 	adminHeader, ok := c.Get(constant.KeyAdminHeader)
 	if ok {
 		ctx = context.WithValue(ctx, adminHeaderContextKey{}, adminHeader)
 	}
+
+	if id := GetClientIdentity(c); id != nil {
+		ctx = ktls.NewContext(ctx, id)
+	}
+	ctx = tenant.NewContext(ctx, GetTenant(c))
 	ctx = klog.RunningLoggerContext(ctx)
 	return ctx
 }
@@ -215,7 +230,7 @@ func GetFUDid(ctx *kgin.TContext) string {
 // 	@Return bool
 func CheckGuest(ctx *kgin.TContext) bool {
 	myClaims := GetMyClaims(ctx)
-	if myClaims == nil || myClaims.LoginRole == 2 {
+	if myClaims == nil || myClaims.LoginRole == kjwt.LoginRoleGuest {
 		return true
 	}
 	return false
@@ -227,8 +242,46 @@ func CheckGuest(ctx *kgin.TContext) bool {
 // 	@return bool
 func CheckRealUser(ctx *kgin.TContext) bool {
 	myClaims := GetMyClaims(ctx)
-	if myClaims != nil && (myClaims.LoginRole == 0 || myClaims.LoginRole == 1) {
+	if myClaims != nil && (myClaims.LoginRole == 0 || myClaims.LoginRole == kjwt.LoginRoleUser) {
 		return true
 	}
 	return false
 }
+
+// GetClientIdentity 获取 mTLS 客户端身份
+// 	@Description: 同 khttp.GetClientIdentity
+//	@Param ctx xgin.TContext
+// 	@return *ktls.Identity 未校验客户端证书时为 nil
+func GetClientIdentity(ctx *kgin.TContext) *ktls.Identity {
+	return khttp.GetClientIdentity(ctx)
+}
+
+// GetPrincipal 获取鉴权通过的主体
+// 	@Description: 获取 kmiddleware.Authenticate 设置的主体，包括用户、游客与服务调用方
+//	@Param ctx xgin.TContext
+// 	@return *kentity.Principal 未鉴权时为 nil
+func GetPrincipal(ctx *kgin.TContext) *kentity.Principal {
+	if v, ok := ctx.Get(constant.KeyPrincipal); ok {
+		if p, flag := v.(*kentity.Principal); flag {
+			return p
+		}
+	}
+	return nil
+}
+
+// GetTenant 获取当前请求的租户
+// 	@Description: 获取 kmiddleware.ResolveTenant 设置的租户
+//	@Param ctx xgin.TContext
+// 	@return string 未设置时为空
+func GetTenant(ctx *kgin.TContext) string {
+	return ctx.GetString(constant.KeyTenant)
+}
+
+// PrincipalFromContext
+//  @Description  从 WithContext 返回的上下文中获取主体
+//  @Param ctx
+//  @Return *kentity.Principal 未鉴权时为 nil
+func PrincipalFromContext(ctx context.Context) *kentity.Principal {
+	p, _ := ctx.Value(principalContextKey{}).(*kentity.Principal)
+	return p
+}
@@ -25,6 +25,9 @@ type Configuration struct {
 	onChanges []func(*Configuration)
 
 	watchers map[string][]func(*Configuration)
+
+	history   *history
+	onApplies []func(*Snapshot)
 }
 
 const (
@@ -41,6 +44,7 @@ func New() *Configuration {
 		keyMap:    &sync.Map{},
 		onChanges: make([]func(*Configuration), 0),
 		watchers:  make(map[string][]func(*Configuration)),
+		history:   newHistory(defaultHistorySize),
 	}
 }
 
@@ -89,14 +93,15 @@ func (c *Configuration) LoadFromConfigSource(ds ConfigSource, unmarshaller Unmar
 		return err
 	}
 
-	if err := c.Load(content, unmarshaller); err != nil {
+	source := sourceName(ds)
+	if err := c.load(content, unmarshaller, source); err != nil {
 		return err
 	}
 
 	go func() {
 		for range ds.IsConfigChanged() {
 			if content, err := ds.ReadConfig(); err == nil {
-				_ = c.Load(content, unmarshaller)
+				_ = c.load(content, unmarshaller, source)
 				for _, change := range c.onChanges {
 					change(c)
 				}
@@ -114,11 +119,15 @@ func (c *Configuration) LoadFromConfigSource(ds ConfigSource, unmarshaller Unmar
 //  @Param unmarshal
 //  @Return error
 func (c *Configuration) Load(content []byte, unmarshal Unmarshaller) error {
+	return c.load(content, unmarshal, SourceReader)
+}
+
+func (c *Configuration) load(content []byte, unmarshal Unmarshaller, source string) error {
 	configuration := make(map[string]interface{})
 	if err := unmarshal(content, &configuration); err != nil {
 		return err
 	}
-	return c.apply(configuration)
+	return c.applyFrom(configuration, source)
 }
 
 // LoadFromReader
@@ -136,13 +145,23 @@ func (c *Configuration) LoadFromReader(reader io.Reader, unmarshaller Unmarshall
 }
 
 func (c *Configuration) apply(conf map[string]interface{}) error {
+	return c.applyFrom(conf, SourceApply)
+}
+
+// applyFrom
+//  @Description 合并配置，并记录来源为 source 的快照
+//  @Receiver c
+//  @Param conf
+//  @Param source 配置来源
+//  @Return error
+func (c *Configuration) applyFrom(conf map[string]interface{}, source string) error {
 	c.mu.Lock()
-	defer c.mu.Unlock()
 
 	var changes = make(map[string]interface{})
 
 	kmap.MergeStringMap(c.override, conf)
-	for k, v := range c.traverse(c.keyDelim) {
+	flat := c.traverse(c.keyDelim)
+	for k, v := range flat {
 		orig, ok := c.keyMap.Load(k)
 		if ok && !reflect.DeepEqual(orig, v) {
 			changes[k] = v
@@ -153,7 +172,10 @@ func (c *Configuration) apply(conf map[string]interface{}) error {
 	if len(changes) > 0 {
 		c.notifyChanges(changes)
 	}
+	applied := c.recordSnapshot(source, flat)
+	c.mu.Unlock()
 
+	c.notifyApply(applied)
 	return nil
 }
 
@@ -192,7 +214,7 @@ func (c *Configuration) Set(key string, val interface{}) error {
 	lastKey := paths[len(paths)-1]
 	m := deepSearch(c.override, paths[:len(paths)-1])
 	m[lastKey] = val
-	return c.apply(m)
+	return c.applyFrom(m, SourceSet)
 	// c.keyMap.Store(key, val)
 }
 
@@ -0,0 +1,356 @@
+package conf
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// defaultHistorySize 默认保留的快照数量
+	defaultHistorySize = 20
+
+	// SourceApply 通过 Apply 直接合入的配置
+	SourceApply = "apply"
+	// SourceSet 通过 Set 设置的配置
+	SourceSet = "set"
+	// SourceReader 通过 io.Reader 加载的配置
+	SourceReader = "reader"
+	// SourceRollback 通过回滚恢复的配置
+	SourceRollback = "rollback"
+)
+
+const (
+	// DiffAdded 新增的 key
+	DiffAdded = "added"
+	// DiffRemoved 删除的 key
+	DiffRemoved = "removed"
+	// DiffModified 修改的 key
+	DiffModified = "modified"
+)
+
+// ErrRevisionNotFound ...
+var ErrRevisionNotFound = errors.New("config revision not found in history")
+
+// Snapshot 一次配置变更生效后的快照
+type Snapshot struct {
+	// Revision 版本号，单调递增
+	Revision int64 `json:"revision"`
+	// Source 配置来源
+	Source string `json:"source"`
+	// Timestamp 生效时间
+	Timestamp time.Time `json:"timestamp"`
+	// Changes 相对上一个版本的变更
+	Changes []KeyDiff `json:"changes"`
+
+	data map[string]interface{}
+}
+
+// KeyDiff 单个 key 的变更
+type KeyDiff struct {
+	Key string      `json:"key"`
+	Op  string      `json:"op"`
+	Old interface{} `json:"old,omitempty"`
+	New interface{} `json:"new,omitempty"`
+}
+
+// history 有界的快照历史
+type history struct {
+	mu        sync.RWMutex
+	size      int
+	revision  int64
+	snapshots []*Snapshot
+}
+
+func newHistory(size int) *history {
+	return &history{
+		size:      size,
+		snapshots: make([]*Snapshot, 0, size),
+	}
+}
+
+// record
+//  @Description 记录一个快照，超出容量时丢弃最旧的快照
+//  @Receiver h
+//  @Param source 配置来源
+//  @Param data 当前完整配置，调用方需保证不会再被修改
+//  @Param flat 当前配置的平铺结果
+//  @Return *Snapshot 配置没有变化时返回nil
+func (h *history) record(source string, data map[string]interface{}, flat map[string]interface{}) *Snapshot {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var prev map[string]interface{}
+	n := len(h.snapshots)
+	if n > 0 {
+		prev = h.snapshots[n-1].flat()
+	}
+	changes := diffFlat(prev, flat)
+	// 配置没有实际变化时不产生新版本
+	if n > 0 && len(changes) == 0 {
+		return nil
+	}
+	h.revision++
+	snapshot := &Snapshot{
+		Revision:  h.revision,
+		Source:    source,
+		Timestamp: time.Now(),
+		Changes:   changes,
+		data:      data,
+	}
+	h.snapshots = append(h.snapshots, snapshot)
+	if h.size > 0 && len(h.snapshots) > h.size {
+		h.snapshots = h.snapshots[len(h.snapshots)-h.size:]
+	}
+	return snapshot
+}
+
+func (h *history) get(revision int64) (*Snapshot, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, s := range h.snapshots {
+		if s.Revision == revision {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+func (h *history) list() []Snapshot {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	list := make([]Snapshot, 0, len(h.snapshots))
+	for _, s := range h.snapshots {
+		list = append(list, *s)
+	}
+	return list
+}
+
+func (h *history) resize(size int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.size = size
+	if size > 0 && len(h.snapshots) > size {
+		h.snapshots = h.snapshots[len(h.snapshots)-size:]
+	}
+}
+
+// flat 返回快照的平铺结果
+func (s *Snapshot) flat() map[string]interface{} {
+	data := make(map[string]interface{})
+	lookup("", s.data, data, defaultKeyDelim)
+	return data
+}
+
+// diffFlat 比较两个平铺后的配置，结果按 key 排序
+func diffFlat(from, to map[string]interface{}) []KeyDiff {
+	diffs := make([]KeyDiff, 0)
+	for k, nv := range to {
+		ov, ok := from[k]
+		if !ok {
+			diffs = append(diffs, KeyDiff{Key: k, Op: DiffAdded, New: nv})
+			continue
+		}
+		if !reflect.DeepEqual(ov, nv) {
+			diffs = append(diffs, KeyDiff{Key: k, Op: DiffModified, Old: ov, New: nv})
+		}
+	}
+	for k, ov := range from {
+		if _, ok := to[k]; !ok {
+			diffs = append(diffs, KeyDiff{Key: k, Op: DiffRemoved, Old: ov})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Key < diffs[j].Key
+	})
+	return diffs
+}
+
+// deepCopy 深拷贝配置，避免快照被后续的合并修改
+func deepCopy(v interface{}) interface{} {
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(vv))
+		for k, val := range vv {
+			m[k] = deepCopy(val)
+		}
+		return m
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(vv))
+		for k, val := range vv {
+			m[fmt.Sprintf("%v", k)] = deepCopy(val)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(vv))
+		for i, val := range vv {
+			s[i] = deepCopy(val)
+		}
+		return s
+	default:
+		return v
+	}
+}
+
+func deepCopyMap(m map[string]interface{}) map[string]interface{} {
+	return deepCopy(m).(map[string]interface{})
+}
+
+// SetHistorySize
+//  @Description 设置保留的快照数量，小于等于0表示不限制
+//  @Receiver c
+//  @Param size
+func (c *Configuration) SetHistorySize(size int) {
+	if c.history == nil {
+		c.history = newHistory(size)
+		return
+	}
+	c.history.resize(size)
+}
+
+// OnApply
+//  @Description 注册配置生效回调，每次配置生效后以快照为参数调用，可用于审计
+//  @Receiver c
+//  @Param fn
+func (c *Configuration) OnApply(fn func(*Snapshot)) {
+	c.onApplies = append(c.onApplies, fn)
+}
+
+// History
+//  @Description 返回历史快照，按版本号升序
+//  @Receiver c
+//  @Return []Snapshot
+func (c *Configuration) History() []Snapshot {
+	if c.history == nil {
+		return nil
+	}
+	return c.history.list()
+}
+
+// Diff
+//  @Description 比较两个版本之间每个 key 的变更
+//  @Receiver c
+//  @Param from 起始版本
+//  @Param to 目标版本
+//  @Return []KeyDiff
+//  @Return error
+func (c *Configuration) Diff(from, to int64) ([]KeyDiff, error) {
+	if c.history == nil {
+		return nil, errors.Wrapf(ErrRevisionNotFound, "revision %d", from)
+	}
+	fromSnapshot, ok := c.history.get(from)
+	if !ok {
+		return nil, errors.Wrapf(ErrRevisionNotFound, "revision %d", from)
+	}
+	toSnapshot, ok := c.history.get(to)
+	if !ok {
+		return nil, errors.Wrapf(ErrRevisionNotFound, "revision %d", to)
+	}
+	return diffFlat(fromSnapshot.flat(), toSnapshot.flat()), nil
+}
+
+// Rollback
+//  @Description 回滚到历史快照，回滚本身会作为一个新版本记录，并触发变更通知
+//  @Receiver c
+//  @Param revision 目标版本
+//  @Return error
+func (c *Configuration) Rollback(revision int64) error {
+	if c.history == nil {
+		return errors.Wrapf(ErrRevisionNotFound, "revision %d", revision)
+	}
+	snapshot, ok := c.history.get(revision)
+	if !ok {
+		return errors.Wrapf(ErrRevisionNotFound, "revision %d", revision)
+	}
+
+	c.mu.Lock()
+	prev := c.traverse(c.keyDelim)
+	c.override = deepCopyMap(snapshot.data)
+	flat := c.traverse(c.keyDelim)
+	// 清空缓存的查询结果，避免读到回滚前的值
+	c.keyMap.Range(func(key, value interface{}) bool {
+		c.keyMap.Delete(key)
+		return true
+	})
+	for k, v := range flat {
+		c.keyMap.Store(k, v)
+	}
+	var changes = make(map[string]interface{})
+	for _, diff := range diffFlat(prev, flat) {
+		changes[diff.Key] = diff.New
+	}
+	if len(changes) > 0 {
+		c.notifyChanges(changes)
+	}
+	applied := c.recordSnapshot(SourceRollback, flat)
+	c.mu.Unlock()
+
+	c.notifyApply(applied)
+	for _, change := range c.onChanges {
+		change(c)
+	}
+	return nil
+}
+
+// recordSnapshot 记录快照，调用方需持有写锁
+func (c *Configuration) recordSnapshot(source string, flat map[string]interface{}) *Snapshot {
+	if c.history == nil {
+		return nil
+	}
+	return c.history.record(source, deepCopyMap(c.override), flat)
+}
+
+// notifyApply 触发 OnApply 回调，需在释放锁之后调用
+func (c *Configuration) notifyApply(snapshot *Snapshot) {
+	if snapshot == nil {
+		return
+	}
+	for _, fn := range c.onApplies {
+		fn(snapshot)
+	}
+}
+
+// sourceName 返回配置数据源的名称
+func sourceName(ds ConfigSource) string {
+	if named, ok := ds.(interface{ Name() string }); ok {
+		return named.Name()
+	}
+	return strings.TrimPrefix(fmt.Sprintf("%T", ds), "*")
+}
+
+// OnApply
+//  @Description 注册默认配置的生效回调
+//  @Param fn
+func OnApply(fn func(*Snapshot)) {
+	defaultConfiguration.OnApply(fn)
+}
+
+// History
+//  @Description 默认配置的历史快照
+//  @Return []Snapshot
+func History() []Snapshot {
+	return defaultConfiguration.History()
+}
+
+// Diff
+//  @Description 比较默认配置两个版本之间的变更
+//  @Param from
+//  @Param to
+//  @Return []KeyDiff
+//  @Return error
+func Diff(from, to int64) ([]KeyDiff, error) {
+	return defaultConfiguration.Diff(from, to)
+}
+
+// Rollback
+//  @Description 将默认配置回滚到历史快照
+//  @Param revision
+//  @Return error
+func Rollback(revision int64) error {
+	return defaultConfiguration.Rollback(revision)
+}
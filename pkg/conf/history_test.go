@@ -0,0 +1,63 @@
+package conf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfiguration_History(t *testing.T) {
+	c := New()
+	c.SetHistorySize(2)
+	var applied []int64
+	c.OnApply(func(s *Snapshot) {
+		applied = append(applied, s.Revision)
+	})
+
+	assert.Nil(t, c.apply(map[string]interface{}{"app": map[string]interface{}{"name": "a", "port": 80}}))
+	assert.Nil(t, c.apply(map[string]interface{}{"app": map[string]interface{}{"port": 81}}))
+	// 没有变化时不会产生新版本
+	assert.Nil(t, c.apply(map[string]interface{}{"app": map[string]interface{}{"port": 81}}))
+	assert.Nil(t, c.apply(map[string]interface{}{"app": map[string]interface{}{"debug": true}}))
+
+	assert.Equal(t, []int64{1, 2, 3}, applied)
+	history := c.History()
+	assert.Len(t, history, 2)
+	assert.Equal(t, int64(2), history[0].Revision)
+	assert.Equal(t, []KeyDiff{{Key: "app.debug", Op: DiffAdded, New: true}}, history[1].Changes)
+
+	_, err := c.Diff(1, 3)
+	assert.ErrorIs(t, err, ErrRevisionNotFound)
+
+	diffs, err := c.Diff(2, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, []KeyDiff{{Key: "app.debug", Op: DiffAdded, New: true}}, diffs)
+}
+
+func TestConfiguration_Rollback(t *testing.T) {
+	c := New()
+	assert.Nil(t, c.apply(map[string]interface{}{"app": map[string]interface{}{"port": 80}}))
+	assert.Nil(t, c.apply(map[string]interface{}{"app": map[string]interface{}{"port": 81, "debug": true}}))
+	assert.Equal(t, 81, c.GetInt("app.port"))
+
+	changed := make(chan struct{}, 1)
+	c.OnChange(func(*Configuration) {
+		changed <- struct{}{}
+	})
+	assert.Nil(t, c.Rollback(1))
+	<-changed
+
+	assert.Equal(t, 80, c.GetInt("app.port"))
+	assert.Nil(t, c.Get("app.debug"))
+
+	history := c.History()
+	last := history[len(history)-1]
+	assert.Equal(t, int64(3), last.Revision)
+	assert.Equal(t, SourceRollback, last.Source)
+	assert.Equal(t, []KeyDiff{
+		{Key: "app.debug", Op: DiffRemoved, Old: true},
+		{Key: "app.port", Op: DiffModified, Old: 81, New: 80},
+	}, last.Changes)
+
+	assert.ErrorIs(t, c.Rollback(10), ErrRevisionNotFound)
+}
@@ -40,6 +40,7 @@ const (
 	HeaderFieldAdminId          = "adminId"
 	HeaderFieldAdminAppId       = "adminAppId"
 	HeaderFieldT                = "t"
+	HeaderRequestID             = "X-Request-Id"
 
 	//上下文传递的实体key
 	KeyHeader      = "tabbyHeader"
@@ -47,6 +48,13 @@ const (
 	KeyPParam      = "pparam"
 	KeySecret      = "secret"
 	KeyAppId       = "appId"
+	KeyRequestID   = "requestId"
+	// KeyClientIdentity mTLS 校验通过的客户端身份
+	KeyClientIdentity = "clientIdentity"
+	// KeyPrincipal 鉴权通过的主体，见 kentity.Principal
+	KeyPrincipal = "principal"
+	// KeyTenant 当前请求的租户，见 tenant 包
+	KeyTenant = "tenant"
 
 	KeyP = "p"
 	KeyS = "s"
@@ -60,6 +68,8 @@ const (
 	AdminHeaderFieldAid = "aid"
 	AdminHeaderFieldAln = "aln"
 	AdminHeaderFieldAac = "aac"
+	// AdminHeaderFieldAsg 后台用户身份签名，覆盖 aid、aln 与 t
+	AdminHeaderFieldAsg = "asg"
 )
 
 const (
@@ -13,6 +13,8 @@ const (
 	ModeRabbitmq = "rabbitmq"
 	// ModeRocketmq rocketmq 模式
 	ModeRocketmq = "rocketmq"
+	// ModeRedisStream redis stream 模式
+	ModeRedisStream = "redisstream"
 )
 
 const (
@@ -6,6 +6,7 @@ import (
 	"github.com/LuoHongLiang0921/kuaigo/pkg/core/cache/config"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/util/kgo"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
+	"strings"
 	"sync"
 	"time"
 
@@ -440,6 +441,58 @@ func (r *redisAdapter) TTL(key string) (int64, error) {
 	}
 }
 
+// GetObject
+// 	@Description 通过 `GET key` 获取值并按配置的编码方式解析到 v
+// 	@Receiver r redisAdapter
+//	@Param key 键名字
+//	@Param v 指针
+// 	@Return error 键不存在时为 config.Nil
+func (r *redisAdapter) GetObject(key string, v interface{}) error {
+	if !r.checkOpen() {
+		return r.openError
+	}
+	data, err := r.getRedisClient().Get(key).Bytes()
+	if err != nil {
+		return err
+	}
+	return r.config.Serializer().Unmarshal(data, v)
+}
+
+// SetObject
+// 	@Description 按配置的编码与压缩方式序列化 v 后通过 `SET key value [EX seconds]` 设置
+// 	@Receiver r redisAdapter
+//	@Param key 键名字
+//	@Param v 值
+//	@Param expire 过期时间
+// 	@Return error 错误
+func (r *redisAdapter) SetObject(key string, v interface{}, expire time.Duration) error {
+	if !r.checkOpen() {
+		return r.openError
+	}
+	data, err := r.config.Serializer().Marshal(v)
+	if err != nil {
+		return err
+	}
+	return r.getRedisClient().Set(key, data, expire).Err()
+}
+
+// MGetObjects
+// 	@Description 通过 `MGET key [key ...]` 获取多个值并解析到 dst
+// 	@Receiver r redisAdapter
+//	@Param keys 键名字数组
+//	@Param dst 切片指针，长度与 keys 相同，不存在的键为元素的零值
+// 	@Return error 错误
+func (r *redisAdapter) MGetObjects(keys []string, dst interface{}) error {
+	if !r.checkOpen() {
+		return r.openError
+	}
+	values, err := r.getRedisClient().MGet(keys...).Result()
+	if err != nil && err != redis.Nil {
+		return err
+	}
+	return r.config.Serializer().UnmarshalSlice(values, dst)
+}
+
 // HGetAll
 // 	@Description 通过 `HGETALL key` 命令获取 键下所有字段值
 // 	@Receiver r redisAdapter
@@ -548,14 +601,7 @@ func (r *redisAdapter) HMSet(key string, hash map[string]interface{}, expire tim
 		return false
 	}
 	if len(hash) > 0 {
-		err := r.getRedisClient().HMSet(key, hash).Err()
-		if err != nil {
-			return false
-		}
-		if expire > 0 {
-			r.getRedisClient().Expire(key, expire)
-		}
-		return true
+		return r.HMSetWithTTL(key, hash, expire) == nil
 	}
 	return false
 }
@@ -1191,6 +1237,301 @@ func (r *redisAdapter) GeoRadius(key string, longitude, latitude float64, query
 	return res, nil
 }
 
+// Eval
+// 	@Description 通过 `EVAL script numkeys key [key ...] arg [arg ...]` 执行 lua 脚本，集群模式下 keys 需在同一 slot
+// 	@Receiver r redisAdapter
+//	@Param script 脚本内容
+//	@Param keys 脚本使用的键
+//	@Param args 脚本参数
+// 	@Return interface{} 脚本返回值
+// 	@Return error 错误
+func (r *redisAdapter) Eval(script string, keys []string, args ...interface{}) (interface{}, error) {
+	if !r.checkOpen() {
+		return nil, r.openError
+	}
+	return r.getRedisClient().Eval(script, keys, args...).Result()
+}
+
+// EvalSha
+// 	@Description 通过 `EVALSHA sha1 numkeys key [key ...] arg [arg ...]` 执行已缓存的 lua 脚本，脚本不存在时返回 NOSCRIPT 错误
+// 	@Receiver r redisAdapter
+//	@Param sha1 脚本 sha1
+//	@Param keys 脚本使用的键
+//	@Param args 脚本参数
+// 	@Return interface{} 脚本返回值
+// 	@Return error 错误
+func (r *redisAdapter) EvalSha(sha1 string, keys []string, args ...interface{}) (interface{}, error) {
+	if !r.checkOpen() {
+		return nil, r.openError
+	}
+	return r.getRedisClient().EvalSha(sha1, keys, args...).Result()
+}
+
+// Pipeline
+// 	@Description 通过管道一次发送多条命令，作为一次操作上报指标与慢日志
+// 	@Receiver r redisAdapter
+//	@Param fn 在 p 中添加命令，返回错误时不发送
+// 	@Return error 回调返回的错误或第一个失败命令的错误，不含 Nil；各命令的结果与错误在其返回值中
+func (r *redisAdapter) Pipeline(fn func(p config.PipelineCmds) error) error {
+	if !r.checkOpen() {
+		return r.openError
+	}
+	return r.config.Pipelined(r.ctx, "pipeline", func() ([]config.Cmder, error) {
+		return r.getRedisClient().Pipelined(func(p redis.Pipeliner) error {
+			return fn(p)
+		})
+	})
+}
+
+// TxPipeline
+// 	@Description 通过 `MULTI` / `EXEC` 事务管道执行多条命令
+// 	@Receiver r redisAdapter
+//	@Param fn 在 p 中添加命令，返回错误时不发送
+// 	@Return error 回调返回的错误或第一个失败命令的错误，不含 Nil；各命令的结果与错误在其返回值中
+func (r *redisAdapter) TxPipeline(fn func(p config.PipelineCmds) error) error {
+	if !r.checkOpen() {
+		return r.openError
+	}
+	return r.config.Pipelined(r.ctx, "txPipeline", func() ([]config.Cmder, error) {
+		return r.getRedisClient().TxPipelined(func(p redis.Pipeliner) error {
+			return fn(p)
+		})
+	})
+}
+
+// HMSetWithTTL
+// 	@Description 在事务中通过 `HMSET` 与 `EXPIRE` 设置哈希表及过期时间
+// 	@Receiver r redisAdapter
+//	@Param key 键名字
+//	@Param hash 字段与值
+//	@Param expire 过期时间，不大于 0 时不过期
+// 	@Return error 错误
+func (r *redisAdapter) HMSetWithTTL(key string, hash map[string]interface{}, expire time.Duration) error {
+	return r.TxPipeline(func(p config.PipelineCmds) error {
+		p.HMSet(key, hash)
+		if expire > 0 {
+			p.Expire(key, expire)
+		}
+		return nil
+	})
+}
+
+// SAddWithTTL
+// 	@Description 在事务中通过 `SADD` 与 `EXPIRE` 添加集合成员并设置过期时间
+// 	@Receiver r redisAdapter
+//	@Param key 键名字
+//	@Param expire 过期时间，不大于 0 时不过期
+//	@Param members 成员
+// 	@Return int64 新增的成员数
+// 	@Return error 错误
+func (r *redisAdapter) SAddWithTTL(key string, expire time.Duration, members ...interface{}) (int64, error) {
+	var cmd *config.IntCmd
+	err := r.TxPipeline(func(p config.PipelineCmds) error {
+		cmd = p.SAdd(key, members...)
+		if expire > 0 {
+			p.Expire(key, expire)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return cmd.Val(), nil
+}
+
+// ZAddWithTTL
+// 	@Description 在事务中通过 `ZADD` 与 `EXPIRE` 添加有序集合成员并设置过期时间
+// 	@Receiver r redisAdapter
+//	@Param key 键名字
+//	@Param expire 过期时间，不大于 0 时不过期
+//	@Param members 元素和分数结构数组
+// 	@Return int64 新增的成员数
+// 	@Return error 错误
+func (r *redisAdapter) ZAddWithTTL(key string, expire time.Duration, members ...config.Z) (int64, error) {
+	var cmd *config.IntCmd
+	err := r.TxPipeline(func(p config.PipelineCmds) error {
+		cmd = p.ZAdd(key, members...)
+		if expire > 0 {
+			p.Expire(key, expire)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return cmd.Val(), nil
+}
+
+// RPushWithTTL
+// 	@Description 在事务中通过 `RPUSH` 与 `EXPIRE` 在列表尾部添加元素并设置过期时间
+// 	@Receiver r redisAdapter
+//	@Param key 键名字
+//	@Param expire 过期时间，不大于 0 时不过期
+//	@Param values 元素
+// 	@Return int64 列表长度
+// 	@Return error 错误
+func (r *redisAdapter) RPushWithTTL(key string, expire time.Duration, values ...interface{}) (int64, error) {
+	var cmd *config.IntCmd
+	err := r.TxPipeline(func(p config.PipelineCmds) error {
+		cmd = p.RPush(key, values...)
+		if expire > 0 {
+			p.Expire(key, expire)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return cmd.Val(), nil
+}
+
+// ScriptLoad
+// 	@Description 通过 `SCRIPT LOAD script` 缓存 lua 脚本
+// 	@Receiver r redisAdapter
+//	@Param script 脚本内容
+// 	@Return string 脚本 sha1
+// 	@Return error 错误
+func (r *redisAdapter) ScriptLoad(script string) (string, error) {
+	if !r.checkOpen() {
+		return "", r.openError
+	}
+	return r.getRedisClient().ScriptLoad(script).Result()
+}
+
+// Publish
+// 	@Description 通过 `PUBLISH channel message` 发布消息
+// 	@Receiver r redisAdapter
+//	@Param channel 频道
+//	@Param message 消息
+// 	@Return int64 收到消息的订阅者数量
+// 	@Return error 错误
+func (r *redisAdapter) Publish(channel string, message interface{}) (int64, error) {
+	if !r.checkOpen() {
+		return 0, r.openError
+	}
+	return r.getRedisClient().Publish(channel, message).Result()
+}
+
+// Subscribe
+// 	@Description 通过 `SUBSCRIBE channel [channel ...]` 订阅频道，连接断开后自动重新订阅
+// 	@Receiver r redisAdapter
+//	@Param ctx 结束时取消订阅
+//	@Param handler 消息处理，在同一个 goroutine 中依次调用
+//	@Param channels 频道
+// 	@Return *config.Subscription 订阅，不再使用时需 Close
+// 	@Return error 首次订阅失败时返回
+func (r *redisAdapter) Subscribe(ctx context.Context, handler func(msg *config.Message), channels ...string) (*config.Subscription, error) {
+	if !r.checkOpen() {
+		return nil, r.openError
+	}
+	return r.config.Subscribe(ctx, func() config.Subscriber {
+		return r.getRedisClient().(config.Subscriber)
+	}, handler, channels...)
+}
+
+// XAdd
+// 	@Description 通过 `XADD key [MAXLEN [~] count] ID field value [field value ...]` 追加消息
+// 	@Receiver r redisAdapter
+//	@Param a 参数，ID 为空时由 redis 生成
+// 	@Return string 消息 id
+// 	@Return error 错误
+func (r *redisAdapter) XAdd(a *config.XAddArgs) (string, error) {
+	if !r.checkOpen() {
+		return "", r.openError
+	}
+	return r.getRedisClient().XAdd(a).Result()
+}
+
+// XGroupCreate
+// 	@Description 通过 `XGROUP CREATE key group id MKSTREAM` 创建消费组，消费组已存在时不返回错误
+// 	@Receiver r redisAdapter
+//	@Param stream 流
+//	@Param group 消费组
+//	@Param start 开始消费的消息 id，0 为从头消费，$ 为只消费新消息
+// 	@Return error 错误
+func (r *redisAdapter) XGroupCreate(stream, group, start string) error {
+	if !r.checkOpen() {
+		return r.openError
+	}
+	err := r.getRedisClient().XGroupCreateMkStream(stream, group, start).Err()
+	if err != nil && strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil
+	}
+	return err
+}
+
+// XReadGroup
+// 	@Description 通过 `XREADGROUP GROUP group consumer [COUNT count] [BLOCK milliseconds] STREAMS key [key ...] ID [ID ...]` 消费消息
+// 	@Receiver r redisAdapter
+//	@Param a 参数，Streams 为流名与 id，id 为 > 时读取新消息
+// 	@Return []config.XStream 消息，超时没有消息时为空
+// 	@Return error 错误
+func (r *redisAdapter) XReadGroup(a *config.XReadGroupArgs) ([]config.XStream, error) {
+	if !r.checkOpen() {
+		return nil, r.openError
+	}
+	streams, err := r.getRedisClient().XReadGroup(a).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	return streams, err
+}
+
+// XAck
+// 	@Description 通过 `XACK key group ID [ID ...]` 确认消息
+// 	@Receiver r redisAdapter
+//	@Param stream 流
+//	@Param group 消费组
+//	@Param ids 消息 id
+// 	@Return int64 确认成功的消息数
+// 	@Return error 错误
+func (r *redisAdapter) XAck(stream, group string, ids ...string) (int64, error) {
+	if !r.checkOpen() {
+		return 0, r.openError
+	}
+	return r.getRedisClient().XAck(stream, group, ids...).Result()
+}
+
+// XPending
+// 	@Description 通过 `XPENDING key group` 获取消费组未确认消息的概况
+// 	@Receiver r redisAdapter
+//	@Param stream 流
+//	@Param group 消费组
+// 	@Return *config.XPending 未确认消息数、id 范围与各消费者的未确认数
+// 	@Return error 错误
+func (r *redisAdapter) XPending(stream, group string) (*config.XPending, error) {
+	if !r.checkOpen() {
+		return nil, r.openError
+	}
+	return r.getRedisClient().XPending(stream, group).Result()
+}
+
+// XPendingExt
+// 	@Description 通过 `XPENDING key group start end count [consumer]` 获取未确认消息的详情
+// 	@Receiver r redisAdapter
+//	@Param a 参数
+// 	@Return []config.XPendingExt 消息 id、消费者、空闲时间与投递次数
+// 	@Return error 错误
+func (r *redisAdapter) XPendingExt(a *config.XPendingExtArgs) ([]config.XPendingExt, error) {
+	if !r.checkOpen() {
+		return nil, r.openError
+	}
+	return r.getRedisClient().XPendingExt(a).Result()
+}
+
+// XClaim
+// 	@Description 通过 `XCLAIM key group consumer min-idle-time ID [ID ...]` 将空闲超时的消息转给其他消费者
+// 	@Receiver r redisAdapter
+//	@Param a 参数
+// 	@Return []config.XMessage 转移成功的消息
+// 	@Return error 错误
+func (r *redisAdapter) XClaim(a *config.XClaimArgs) ([]config.XMessage, error) {
+	if !r.checkOpen() {
+		return nil, r.openError
+	}
+	return r.getRedisClient().XClaim(a).Result()
+}
+
 // Open
 // 	@Description 初始化redis 客户端
 // 	@Receiver r
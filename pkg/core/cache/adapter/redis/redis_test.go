@@ -141,3 +141,50 @@ func TestRedisAdapter_SetNx(t *testing.T) {
 	time.Sleep(4 * time.Second)
 	assert.Equal(t, true, is, "set nx 3")
 }
+
+func TestRedisAdapter_Pipeline(t *testing.T) {
+	cfgAdapter := newRedis()
+	defer cfgAdapter.Close()
+	var (
+		get  *config.StringCmd
+		hmap *config.StringStringMapCmd
+	)
+	err := cfgAdapter.Pipeline(func(p config.PipelineCmds) error {
+		p.Set("test.pipeline", "v", time.Minute)
+		get = p.Get("test.pipeline")
+		hmap = p.HGetAll("test.pipeline.none")
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "v", get.Val())
+	assert.Empty(t, hmap.Val())
+}
+
+func TestRedisAdapter_HMSetWithTTL(t *testing.T) {
+	cfgAdapter := newRedis()
+	defer cfgAdapter.Close()
+	err := cfgAdapter.HMSetWithTTL("test.hmset.ttl", map[string]interface{}{"a": 1}, time.Minute)
+	assert.Nil(t, err)
+	ttl, err := cfgAdapter.TTL("test.hmset.ttl")
+	assert.Nil(t, err)
+	assert.True(t, ttl > 0)
+}
+
+func TestRedisAdapter_Object(t *testing.T) {
+	cfgAdapter := newRedis()
+	defer cfgAdapter.Close()
+	type user struct {
+		ID   int64
+		Name string
+	}
+	err := cfgAdapter.SetObject("test.object.1", &user{ID: 1, Name: "a"}, time.Minute)
+	assert.Nil(t, err)
+	var u user
+	assert.Nil(t, cfgAdapter.GetObject("test.object.1", &u))
+	assert.Equal(t, user{ID: 1, Name: "a"}, u)
+	assert.Equal(t, config.Nil, cfgAdapter.GetObject("test.object.none", &u))
+
+	var users []*user
+	assert.Nil(t, cfgAdapter.MGetObjects([]string{"test.object.1", "test.object.none"}, &users))
+	assert.Equal(t, []*user{{ID: 1, Name: "a"}, nil}, users)
+}
@@ -61,7 +61,13 @@ func (df *cacheManager) GetAdvanceCache(ctx context.Context, conf string) config
 func (df *cacheManager) buildCache(ctx context.Context, conf string) config.ICache {
 	sum.Lock()
 	if _, ok := df.caches[conf]; !ok {
-		df.caches[conf] = df.buildCacheAdapter(ctx, conf)
+		dbConfig := config.GetConfig(ctx, conf)
+		adapter := df.buildCacheAdapter(ctx, dbConfig)
+		if dbConfig.Tenant {
+			df.caches[conf] = newTenantCache(adapter.(config.IAdvanceCache))
+		} else {
+			df.caches[conf] = adapter
+		}
 	}
 	sum.Unlock()
 	return df.caches[conf]
@@ -71,10 +77,9 @@ func (df *cacheManager) buildCache(ctx context.Context, conf string) config.ICac
 // 	@Description 构造缓存适配器内部方法
 // 	@Receiver databaseFactory
 //  @Param ctx 上下文Context
-//	@Param config 数据库配置key
+//	@Param dbConfig 缓存配置
 // 	@Return IDataBaseAdapter
-func (df *cacheManager) buildCacheAdapter(ctx context.Context, conf string) config.ICacheAdapter {
-	dbConfig := config.GetConfig(ctx, conf)
+func (df *cacheManager) buildCacheAdapter(ctx context.Context, dbConfig *config.CacheConfig) config.ICacheAdapter {
 	if dbConfig.Type == "redis" {
 		return redis.NewRedisAdapter(ctx, dbConfig)
 	} else if dbConfig.Type == "redisCluster" {
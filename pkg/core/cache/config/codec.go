@@ -0,0 +1,259 @@
+package config
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/kjson"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/ugorji/go/codec"
+)
+
+const (
+	CodecJSON     = "json"
+	CodecMsgpack  = "msgpack"
+	CodecProtobuf = "protobuf"
+
+	CompressGzip   = "gzip"
+	CompressSnappy = "snappy"
+
+	// envelopeMagic 信封首字节，不以该字节开头的值按未加信封的 json 解析
+	envelopeMagic byte = 0xC5
+	// envelopeVersion 信封格式版本，格式变化时递增，旧版本仍可读取
+	envelopeVersion  byte = 1
+	envelopeHeadSize      = 4
+)
+
+var (
+	// ErrUnknownEnvelope 信封版本、编码或压缩方式未知
+	ErrUnknownEnvelope = errors.New("cache value: unknown envelope")
+	// ErrNotProtoMessage protobuf 编码的值需实现 proto.Message
+	ErrNotProtoMessage = errors.New("cache value: not proto.Message")
+)
+
+// Codec 缓存值的编码方式
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// Compressor 缓存值的压缩方式
+type Compressor interface {
+	Compress(data []byte) ([]byte, error)
+	Decompress(data []byte) ([]byte, error)
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return kjson.Encode(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return kjson.Decode(data, v)
+}
+
+type msgpackCodec struct {
+	handle *codec.MsgpackHandle
+}
+
+func (c msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	var data []byte
+	err := codec.NewEncoderBytes(&data, c.handle).Encode(v)
+	return data, err
+}
+
+func (c msgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	return codec.NewDecoderBytes(data, c.handle).Decode(v)
+}
+
+type protobufCodec struct{}
+
+func (protobufCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, ErrNotProtoMessage
+	}
+	return proto.Marshal(m)
+}
+
+func (protobufCodec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return ErrNotProtoMessage
+	}
+	return proto.Unmarshal(data, m)
+}
+
+type gzipCompressor struct{}
+
+func (gzipCompressor) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gzipCompressor) Decompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+type snappyCompressor struct{}
+
+func (snappyCompressor) Compress(data []byte) ([]byte, error) {
+	return snappy.Encode(nil, data), nil
+}
+
+func (snappyCompressor) Decompress(data []byte) ([]byte, error) {
+	return snappy.Decode(nil, data)
+}
+
+// codecs 与 compressors 的下标写入信封，只能追加
+var (
+	codecNames      = []string{"", CodecJSON, CodecMsgpack, CodecProtobuf}
+	codecs          = []Codec{nil, jsonCodec{}, msgpackCodec{handle: &codec.MsgpackHandle{}}, protobufCodec{}}
+	compressorNames = []string{"", CompressGzip, CompressSnappy}
+	compressors     = []Compressor{nil, gzipCompressor{}, snappyCompressor{}}
+)
+
+func indexOf(names []string, name string) int {
+	for i, n := range names {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Serializer 缓存对象的序列化，值带有信封：magic、版本、编码、压缩方式各一个字节，之后为数据。
+// 读取时按信封中的编码与压缩方式解析，修改配置后无需清空缓存
+type Serializer struct {
+	codec     byte
+	compress  byte
+	threshold int
+}
+
+// NewSerializer
+//  @Description  创建序列化
+//  @Param codec 编码方式 json|msgpack|protobuf，默认 json
+//  @Param compress 压缩方式 gzip|snappy，为空不压缩
+//  @Param threshold 编码后超过该字节数才压缩
+//  @Return *Serializer
+//  @Return error 编码或压缩方式不支持
+func NewSerializer(codec, compress string, threshold int) (*Serializer, error) {
+	if codec == "" {
+		codec = CodecJSON
+	}
+	ci := indexOf(codecNames, codec)
+	if ci <= 0 {
+		return nil, fmt.Errorf("cache codec %s not support", codec)
+	}
+	zi := indexOf(compressorNames, compress)
+	if zi < 0 {
+		return nil, fmt.Errorf("cache compress %s not support", compress)
+	}
+	return &Serializer{codec: byte(ci), compress: byte(zi), threshold: threshold}, nil
+}
+
+// Marshal
+//  @Description  编码并按需压缩，加上信封
+//  @Receiver s
+//  @Param v
+//  @Return []byte
+//  @Return error
+func (s *Serializer) Marshal(v interface{}) ([]byte, error) {
+	data, err := codecs[s.codec].Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	compress := byte(0)
+	if s.compress > 0 && len(data) > s.threshold {
+		if data, err = compressors[s.compress].Compress(data); err != nil {
+			return nil, err
+		}
+		compress = s.compress
+	}
+	out := make([]byte, envelopeHeadSize, envelopeHeadSize+len(data))
+	out[0], out[1], out[2], out[3] = envelopeMagic, envelopeVersion, s.codec, compress
+	return append(out, data...), nil
+}
+
+// Unmarshal
+//  @Description  按信封解压并解码，没有信封的值按 json 解析
+//  @Receiver s
+//  @Param data
+//  @Param v 指针
+//  @Return error
+func (s *Serializer) Unmarshal(data []byte, v interface{}) error {
+	if len(data) == 0 || data[0] != envelopeMagic {
+		return jsonCodec{}.Unmarshal(data, v)
+	}
+	if len(data) < envelopeHeadSize || data[1] != envelopeVersion ||
+		int(data[2]) >= len(codecs) || data[2] == 0 || int(data[3]) >= len(compressors) {
+		return ErrUnknownEnvelope
+	}
+	payload := data[envelopeHeadSize:]
+	if data[3] > 0 {
+		var err error
+		if payload, err = compressors[data[3]].Decompress(payload); err != nil {
+			return err
+		}
+	}
+	return codecs[data[2]].Unmarshal(payload, v)
+}
+
+// UnmarshalSlice
+//  @Description  解析 MGET 的结果
+//  @Receiver s
+//  @Param values MGET 返回的值，不存在的键为 nil
+//  @Param dst 切片指针，长度与 values 相同，不存在的键为元素的零值
+//  @Return error
+func (s *Serializer) UnmarshalSlice(values []interface{}, dst interface{}) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("cache value: dst must be pointer to slice, got %T", dst)
+	}
+	slice := reflect.MakeSlice(rv.Elem().Type(), len(values), len(values))
+	elemType := slice.Type().Elem()
+	for i, value := range values {
+		var data []byte
+		switch v := value.(type) {
+		case nil:
+			continue
+		case string:
+			data = []byte(v)
+		case []byte:
+			data = v
+		default:
+			return fmt.Errorf("cache value: unexpected type %T", value)
+		}
+		elem := slice.Index(i)
+		target := elem.Addr()
+		// 指针元素需分配，protobuf 消息通常为指针
+		if elemType.Kind() == reflect.Ptr {
+			elem.Set(reflect.New(elemType.Elem()))
+			target = elem
+		}
+		if err := s.Unmarshal(data, target.Interface()); err != nil {
+			return err
+		}
+	}
+	rv.Elem().Set(slice)
+	return nil
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/conf"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/stretchr/testify/assert"
+)
+
+type user struct {
+	ID   int64  `json:"id" codec:"id"`
+	Name string `json:"name" codec:"name"`
+}
+
+func TestSerializer(t *testing.T) {
+	for _, tc := range []struct{ codec, compress string }{
+		{CodecJSON, ""},
+		{CodecMsgpack, ""},
+		{CodecJSON, CompressGzip},
+		{CodecMsgpack, CompressSnappy},
+	} {
+		s, err := NewSerializer(tc.codec, tc.compress, 16)
+		assert.Nil(t, err)
+		in := user{ID: 1, Name: string(bytes.Repeat([]byte("a"), 64))}
+		data, err := s.Marshal(in)
+		assert.Nil(t, err)
+		assert.Equal(t, envelopeMagic, data[0])
+		var out user
+		assert.Nil(t, s.Unmarshal(data, &out), tc.codec+tc.compress)
+		assert.Equal(t, in, out)
+
+		// 修改配置后仍可读取旧格式
+		json, _ := NewSerializer(CodecJSON, "", 0)
+		out = user{}
+		assert.Nil(t, json.Unmarshal(data, &out))
+		assert.Equal(t, in, out)
+	}
+
+	// 未达到阈值不压缩
+	s, _ := NewSerializer(CodecJSON, CompressGzip, 1024)
+	data, _ := s.Marshal(user{ID: 2})
+	assert.Equal(t, byte(0), data[3])
+
+	// 没有信封的 json
+	var out user
+	assert.Nil(t, s.Unmarshal([]byte(`{"id":3,"name":"legacy"}`), &out))
+	assert.Equal(t, user{ID: 3, Name: "legacy"}, out)
+
+	assert.Equal(t, ErrUnknownEnvelope, s.Unmarshal([]byte{envelopeMagic, 9, 1, 0}, &out))
+	_, err := NewSerializer("xml", "", 0)
+	assert.NotNil(t, err)
+}
+
+func TestSerializer_Protobuf(t *testing.T) {
+	s, _ := NewSerializer(CodecProtobuf, CompressSnappy, 0)
+	data, err := s.Marshal(&wrappers.StringValue{Value: "hello"})
+	assert.Nil(t, err)
+	var out wrappers.StringValue
+	assert.Nil(t, s.Unmarshal(data, &out))
+	assert.Equal(t, "hello", out.Value)
+
+	_, err = s.Marshal(user{})
+	assert.Equal(t, ErrNotProtoMessage, err)
+}
+
+func TestSerializer_UnmarshalSlice(t *testing.T) {
+	s, _ := NewSerializer(CodecMsgpack, "", 0)
+	a, _ := s.Marshal(user{ID: 1})
+	b, _ := s.Marshal(user{ID: 2})
+	values := []interface{}{string(a), nil, string(b)}
+
+	var users []user
+	assert.Nil(t, s.UnmarshalSlice(values, &users))
+	assert.Equal(t, []user{{ID: 1}, {}, {ID: 2}}, users)
+
+	var ptrs []*user
+	assert.Nil(t, s.UnmarshalSlice(values, &ptrs))
+	assert.Equal(t, []*user{{ID: 1}, nil, {ID: 2}}, ptrs)
+
+	assert.NotNil(t, s.UnmarshalSlice(values, users))
+}
+
+func TestCacheConfig_ReloadSerializer(t *testing.T) {
+	c := &CacheConfig{Name: "test", CompressThreshold: 1024}
+	old := c.Serializer()
+	assert.Equal(t, byte(1), old.codec)
+
+	cfg := conf.New()
+	assert.Nil(t, cfg.Load([]byte(`{"caches":{"test":{"codec":"msgpack","compress":"gzip","compressThreshold":16}}}`), json.Unmarshal))
+	c.reloadSerializer(cfg, c.getConfigKey("test"))
+	s := c.Serializer()
+	assert.NotEqual(t, old, s)
+	assert.Equal(t, &Serializer{codec: 2, compress: 1, threshold: 16}, s)
+
+	// 不支持的配置保留原序列化
+	cfg = conf.New()
+	assert.Nil(t, cfg.Load([]byte(`{"caches":{"test":{"codec":"xml"}}}`), json.Unmarshal))
+	c.reloadSerializer(cfg, c.getConfigKey("test"))
+	assert.Equal(t, s, c.Serializer())
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/LuoHongLiang0921/kuaigo/pkg/conf"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/util/ktime"
+	"sync"
 	"time"
 )
 
@@ -59,10 +60,21 @@ type CacheConfig struct {
 	SlowThreshold time.Duration `json:"slowThreshold" yaml:"slowThreshold"`
 	// OnDialError panic|error
 	OnDialError string `json:"level" yaml:"level"`
-	Logger      *klog.Logger
+	// Tenant 开启后 key 自动加上上下文中租户的前缀，需通过 WithContext 传入上下文
+	Tenant bool `json:"tenant" yaml:"tenant"`
+	// Codec GetObject、SetObject 使用的编码方式 json|msgpack|protobuf，默认 json
+	Codec string `json:"codec" yaml:"codec"`
+	// Compress 对象的压缩方式 gzip|snappy，默认不压缩
+	Compress string `json:"compress" yaml:"compress"`
+	// CompressThreshold 编码后超过该字节数才压缩，默认 1024
+	CompressThreshold int `json:"compressThreshold" yaml:"compressThreshold"`
+	Logger            *klog.Logger
 
 	latestDsn string
 	change    chan struct{}
+
+	serializerMu sync.RWMutex
+	serializer   *Serializer
 }
 
 // IsConfigChange
@@ -83,13 +95,62 @@ func (c *CacheConfig) setOnChange(key string) {
 			c.change <- struct{}{}
 		}
 		c.latestDsn = dsnStr
+		c.reloadSerializer(cfg, configKey)
 	})
 }
 
+// reloadSerializer 编码或压缩配置变化时重建序列化，新配置不支持时保留原序列化。
+// 值带有信封，切换后已缓存的旧值仍可读取
+func (c *CacheConfig) reloadSerializer(cfg *conf.Configuration, configKey string) {
+	codec := cfg.GetString(configKey + ".codec")
+	compress := cfg.GetString(configKey + ".compress")
+	c.serializerMu.Lock()
+	defer c.serializerMu.Unlock()
+	threshold := c.CompressThreshold
+	if cfg.Get(configKey+".compressThreshold") != nil {
+		threshold = cfg.GetInt(configKey + ".compressThreshold")
+	}
+	if codec == c.Codec && compress == c.Compress && threshold == c.CompressThreshold {
+		return
+	}
+	s, err := NewSerializer(codec, compress, threshold)
+	if err != nil {
+		c.logger().Error("cache serializer reload", klog.FieldName(c.Name), klog.FieldErr(err))
+		return
+	}
+	c.Codec, c.Compress, c.CompressThreshold = codec, compress, threshold
+	c.serializer = s
+	c.logger().Info("cache serializer reload", klog.FieldName(c.Name), klog.String("codec", codec),
+		klog.String("compress", compress), klog.Int("compressThreshold", threshold))
+}
+
 func (c *CacheConfig) getConfigKey(key string) string {
 	return cacheConfigPrefix + key
 }
 
+// Serializer
+// 	@Description 获取对象的序列化，编码或压缩方式不支持时 panic，配置热更新后返回新的序列化
+// 	@Receiver c
+// 	@Return *Serializer
+func (c *CacheConfig) Serializer() *Serializer {
+	c.serializerMu.RLock()
+	s := c.serializer
+	c.serializerMu.RUnlock()
+	if s != nil {
+		return s
+	}
+	c.serializerMu.Lock()
+	defer c.serializerMu.Unlock()
+	if c.serializer == nil {
+		s, err := NewSerializer(c.Codec, c.Compress, c.CompressThreshold)
+		if err != nil {
+			c.logger().Panic("cache serializer", klog.FieldName(c.Name), klog.FieldErr(err))
+		}
+		c.serializer = s
+	}
+	return c.serializer
+}
+
 // GetConfig
 // 	@Description 获取默认配置
 //  @Param ctx 上下文Context
@@ -114,6 +175,8 @@ func GetConfig(ctx context.Context, key string) *CacheConfig {
 		OnDialError:   "panic",
 		Logger:        klog.KuaigoLogger,
 		change:        make(chan struct{}, 1),
+
+		CompressThreshold: 1024,
 	}
 	config.latestDsn = config.Addr
 	configKey := config.getConfigKey(key)
@@ -124,6 +187,7 @@ func GetConfig(ctx context.Context, key string) *CacheConfig {
 			klog.String("error", err.Error()))
 	}
 	config.latestDsn = config.Addr
+	config.Serializer()
 	config.setOnChange(key)
 	return config
 }
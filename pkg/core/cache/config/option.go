@@ -24,6 +24,14 @@ type (
 	GeoRadiusQuery     = redis.GeoRadiusQuery
 	GeoLocation        = redis.GeoLocation
 	ZRangeBy           = redis.ZRangeBy
+	XAddArgs           = redis.XAddArgs
+	XReadGroupArgs     = redis.XReadGroupArgs
+	XStream            = redis.XStream
+	XMessage           = redis.XMessage
+	XPending           = redis.XPending
+	XPendingExt        = redis.XPendingExt
+	XPendingExtArgs    = redis.XPendingExtArgs
+	XClaimArgs         = redis.XClaimArgs
 )
 
 var (
@@ -51,6 +59,9 @@ type ICache interface {
 	ExistsWithErr(key string) (bool, error)
 	Expire(key string, expiration time.Duration) (bool, error)
 	TTL(key string) (int64, error)
+	GetObject(key string, v interface{}) error
+	SetObject(key string, v interface{}, expire time.Duration) error
+	MGetObjects(keys []string, dst interface{}) error
 }
 
 // IAdvanceCache 高级操作实现接口
@@ -97,6 +108,24 @@ type IAdvanceCache interface {
 	HLen(key string) int64
 	GeoAdd(key string, location *GeoLocation) (int64, error)
 	GeoRadius(key string, longitude, latitude float64, query *GeoRadiusQuery) ([]GeoLocation, error)
+	Eval(script string, keys []string, args ...interface{}) (interface{}, error)
+	EvalSha(sha1 string, keys []string, args ...interface{}) (interface{}, error)
+	Pipeline(fn func(p PipelineCmds) error) error
+	TxPipeline(fn func(p PipelineCmds) error) error
+	HMSetWithTTL(key string, hash map[string]interface{}, expire time.Duration) error
+	SAddWithTTL(key string, expire time.Duration, members ...interface{}) (int64, error)
+	ZAddWithTTL(key string, expire time.Duration, members ...Z) (int64, error)
+	RPushWithTTL(key string, expire time.Duration, values ...interface{}) (int64, error)
+	ScriptLoad(script string) (string, error)
+	Publish(channel string, message interface{}) (int64, error)
+	Subscribe(ctx context.Context, handler func(msg *Message), channels ...string) (*Subscription, error)
+	XAdd(a *XAddArgs) (string, error)
+	XGroupCreate(stream, group, start string) error
+	XReadGroup(a *XReadGroupArgs) ([]XStream, error)
+	XAck(stream, group string, ids ...string) (int64, error)
+	XPending(stream, group string) (*XPending, error)
+	XPendingExt(a *XPendingExtArgs) ([]XPendingExt, error)
+	XClaim(a *XClaimArgs) ([]XMessage, error)
 }
 
 // ICacheAdapter 缓存适配器接口
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/metric"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
+
+	"github.com/go-redis/redis"
+)
+
+type (
+	Cmd       = redis.Cmd
+	Cmder     = redis.Cmder
+	StatusCmd = redis.StatusCmd
+	SliceCmd  = redis.SliceCmd
+	ZSliceCmd = redis.ZSliceCmd
+)
+
+var errSlowPipeline = errors.New("redis slow pipeline")
+
+// PipelineCmds 管道中可用的命令，命令在回调返回后一次发送，返回的结果在 Pipeline 返回后可用
+type PipelineCmds interface {
+	Get(key string) *StringCmd
+	MGet(keys ...string) *SliceCmd
+	Set(key string, value interface{}, expiration time.Duration) *StatusCmd
+	SetNX(key string, value interface{}, expiration time.Duration) *BoolCmd
+	Incr(key string) *IntCmd
+	IncrBy(key string, value int64) *IntCmd
+	Decr(key string) *IntCmd
+	Del(keys ...string) *IntCmd
+	Exists(keys ...string) *IntCmd
+	Expire(key string, expiration time.Duration) *BoolCmd
+	TTL(key string) *DurationCmd
+	HGet(key, field string) *StringCmd
+	HGetAll(key string) *StringStringMapCmd
+	HMGet(key string, fields ...string) *SliceCmd
+	HMSet(key string, fields map[string]interface{}) *StatusCmd
+	HSet(key, field string, value interface{}) *BoolCmd
+	HDel(key string, fields ...string) *IntCmd
+	HIncrBy(key, field string, incr int64) *IntCmd
+	LPush(key string, values ...interface{}) *IntCmd
+	RPush(key string, values ...interface{}) *IntCmd
+	LRange(key string, start, stop int64) *StringSliceCmd
+	LTrim(key string, start, stop int64) *StatusCmd
+	SAdd(key string, members ...interface{}) *IntCmd
+	SRem(key string, members ...interface{}) *IntCmd
+	SMembers(key string) *StringSliceCmd
+	ZAdd(key string, members ...Z) *IntCmd
+	ZIncrBy(key string, increment float64, member string) *FloatCmd
+	ZRem(key string, members ...interface{}) *IntCmd
+	ZScore(key, member string) *FloatCmd
+	ZCard(key string) *IntCmd
+	ZRange(key string, start, stop int64) *StringSliceCmd
+	ZRevRange(key string, start, stop int64) *StringSliceCmd
+	ZRevRangeWithScores(key string, start, stop int64) *ZSliceCmd
+	Eval(script string, keys []string, args ...interface{}) *Cmd
+	EvalSha(sha1 string, keys []string, args ...interface{}) *Cmd
+}
+
+// Pipelined
+//  @Description  执行管道并作为一次操作上报指标，超过 SlowThreshold 时记录慢日志，供缓存适配器使用
+//  @Receiver c
+//  @Param ctx
+//  @Param method 操作名，如 pipeline、txPipeline
+//  @Param run 执行管道，返回执行的命令
+//  @Return error 回调返回的错误或第一个失败命令的错误，不含 Nil
+func (c *CacheConfig) Pipelined(ctx context.Context, method string, run func() ([]Cmder, error)) error {
+	beg := time.Now()
+	cmds, err := run()
+	if err == redis.Nil {
+		err = nil
+		for _, cmd := range cmds {
+			if e := cmd.Err(); e != nil && e != redis.Nil {
+				err = e
+				break
+			}
+		}
+	}
+	cost := time.Since(beg)
+	n := len(cmds)
+
+	if err != nil {
+		metric.LibHandleCounter.Inc(metric.TypeRedis, method, c.addr(), "ERR")
+		c.logger().WithContext(ctx).Error("redis pipeline err", klog.FieldErr(err), klog.FieldMethod(method), klog.FieldName(c.Name), klog.Int("cmds", n))
+	} else {
+		metric.LibHandleCounter.Inc(metric.TypeRedis, method, c.addr(), "OK")
+	}
+	metric.LibHandleHistogram.WithLabelValues(metric.TypeRedis, method, c.addr()).Observe(cost.Seconds())
+	metric.LibHandleSummary.Observe(float64(n), c.Name, method+"_cmds")
+
+	if c.SlowThreshold > time.Duration(0) && c.SlowThreshold < cost {
+		c.logger().WithContext(ctx).Error(
+			"slow",
+			klog.FieldErr(errSlowPipeline),
+			klog.FieldMethod(method),
+			klog.FieldAddr(c.addr()),
+			klog.FieldName(c.Name),
+			klog.FieldCost(cost),
+			klog.Int("cmds", n),
+		)
+	}
+	return err
+}
+
+func (c *CacheConfig) logger() *klog.Logger {
+	if c.Logger != nil {
+		return c.Logger
+	}
+	return klog.KuaigoLogger
+}
+
+// addr 集群模式下为全部节点地址
+func (c *CacheConfig) addr() string {
+	if c.Addr != "" {
+		return c.Addr
+	}
+	return strings.Join(c.Addrs, ",")
+}
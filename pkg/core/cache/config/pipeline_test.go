@@ -0,0 +1,32 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-redis/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheConfig_Pipelined(t *testing.T) {
+	c := &CacheConfig{Name: "test", Addr: "127.0.0.1:6379"}
+	errFailed := errors.New("WRONGTYPE")
+
+	// Nil 不作为错误
+	err := c.Pipelined(context.Background(), "pipeline", func() ([]Cmder, error) {
+		return []Cmder{redis.NewStringResult("", Nil), redis.NewIntResult(1, nil)}, Nil
+	})
+	assert.Nil(t, err)
+
+	// 返回 Nil 之后第一个失败的命令
+	err = c.Pipelined(context.Background(), "pipeline", func() ([]Cmder, error) {
+		return []Cmder{redis.NewStringResult("", Nil), redis.NewIntResult(0, errFailed)}, Nil
+	})
+	assert.Equal(t, errFailed, err)
+
+	err = c.Pipelined(context.Background(), "txPipeline", func() ([]Cmder, error) {
+		return nil, errFailed
+	})
+	assert.Equal(t, errFailed, err)
+}
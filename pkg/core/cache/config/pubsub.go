@@ -0,0 +1,119 @@
+package config
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/kgo"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
+
+	"github.com/go-redis/redis"
+)
+
+type (
+	Message = redis.Message
+	PubSub  = redis.PubSub
+)
+
+const (
+	minResubscribeBackoff = 100 * time.Millisecond
+	maxResubscribeBackoff = 5 * time.Second
+)
+
+// Subscriber 可订阅的 redis 客户端，redis.Client 与 redis.ClusterClient 均已实现
+type Subscriber interface {
+	Subscribe(channels ...string) *PubSub
+}
+
+// Subscription 订阅，连接断开时重新创建连接并重新订阅，Close 后停止
+type Subscription struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu     sync.Mutex
+	pubsub *PubSub
+}
+
+// Close
+//  @Description  取消订阅并等待消息处理结束
+//  @Receiver s
+//  @Return error
+func (s *Subscription) Close() error {
+	s.cancel()
+	<-s.done
+	return nil
+}
+
+// watch ctx 结束时关闭当前连接，使阻塞中的 ReceiveMessage 返回
+func (s *Subscription) watch(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+	case <-s.done:
+		return
+	}
+	s.mu.Lock()
+	if s.pubsub != nil {
+		_ = s.pubsub.Close()
+	}
+	s.mu.Unlock()
+}
+
+func (s *Subscription) setPubSub(ps *PubSub) {
+	s.mu.Lock()
+	s.pubsub = ps
+	s.mu.Unlock()
+}
+
+// Subscribe
+//  @Description  订阅频道，供缓存适配器使用；消息在同一个 goroutine 中依次处理
+//  @Receiver c
+//  @Param ctx 结束时关闭连接并停止重新订阅，与调用 Close 相同
+//  @Param client 每次重新订阅时获取当前的客户端，客户端因配置变更重建后订阅到新的客户端
+//  @Param handler 消息处理
+//  @Param channels 频道
+//  @Return *Subscription
+//  @Return error 首次订阅失败时返回
+func (c *CacheConfig) Subscribe(ctx context.Context, client func() Subscriber, handler func(msg *Message), channels ...string) (*Subscription, error) {
+	ps := client().Subscribe(channels...)
+	if _, err := ps.Receive(); err != nil {
+		_ = ps.Close()
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	s := &Subscription{cancel: cancel, done: make(chan struct{}), pubsub: ps}
+	kgo.Go(func() { s.watch(ctx) })
+	kgo.Go(func() {
+		defer close(s.done)
+		backoff := minResubscribeBackoff
+		for {
+			msg, err := ps.ReceiveMessage()
+			if err == nil {
+				backoff = minResubscribeBackoff
+				handler(msg)
+				continue
+			}
+			_ = ps.Close()
+			if ctx.Err() != nil {
+				return
+			}
+			c.logger().WithContext(ctx).Warn("redis subscribe err, resubscribe", klog.FieldErr(err), klog.FieldName(c.Name), klog.Any("channels", channels))
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			if backoff *= 2; backoff > maxResubscribeBackoff {
+				backoff = maxResubscribeBackoff
+			}
+			ps = client().Subscribe(channels...)
+			s.setPubSub(ps)
+			// Close 与 setPubSub 交错时，新的连接由这里关闭
+			if ctx.Err() != nil {
+				_ = ps.Close()
+				return
+			}
+		}
+	})
+	return s, nil
+}
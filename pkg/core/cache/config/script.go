@@ -0,0 +1,121 @@
+package config
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Script lua 脚本，优先通过 EVALSHA 执行，脚本未缓存时回退到 EVAL
+type Script struct {
+	src  string
+	hash string
+}
+
+// NewScript
+//  @Description  创建脚本
+//  @Param src 脚本内容
+//  @Return *Script
+func NewScript(src string) *Script {
+	h := sha1.Sum([]byte(src))
+	return &Script{src: src, hash: hex.EncodeToString(h[:])}
+}
+
+// Hash 脚本的 sha1
+func (s *Script) Hash() string {
+	return s.hash
+}
+
+// Load
+//  @Description  通过 `SCRIPT LOAD` 缓存脚本，集群模式下在所有主节点缓存
+//  @Receiver s
+//  @Param c
+//  @Return error
+func (s *Script) Load(c IAdvanceCache) error {
+	_, err := c.ScriptLoad(s.src)
+	return err
+}
+
+// Run
+//  @Description  执行脚本，返回 NOSCRIPT 时使用 EVAL 执行，EVAL 同时会缓存脚本
+//  @Receiver s
+//  @Param c
+//  @Param keys 脚本使用的键
+//  @Param args 脚本参数
+//  @Return interface{} 脚本返回值
+//  @Return error
+func (s *Script) Run(c IAdvanceCache, keys []string, args ...interface{}) (interface{}, error) {
+	res, err := c.EvalSha(s.hash, keys, args...)
+	if err != nil && strings.HasPrefix(err.Error(), "NOSCRIPT") {
+		return c.Eval(s.src, keys, args...)
+	}
+	return res, err
+}
+
+// ScriptRegistry 按名称管理脚本，可在启动时统一缓存
+type ScriptRegistry struct {
+	mu      sync.RWMutex
+	scripts map[string]*Script
+}
+
+// NewScriptRegistry ...
+func NewScriptRegistry() *ScriptRegistry {
+	return &ScriptRegistry{scripts: make(map[string]*Script)}
+}
+
+// Register
+//  @Description  注册脚本，同名时替换
+//  @Receiver r
+//  @Param name 脚本名
+//  @Param src 脚本内容
+//  @Return *Script
+func (r *ScriptRegistry) Register(name, src string) *Script {
+	s := NewScript(src)
+	r.mu.Lock()
+	r.scripts[name] = s
+	r.mu.Unlock()
+	return s
+}
+
+// Get ...
+func (r *ScriptRegistry) Get(name string) (*Script, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s, ok := r.scripts[name]
+	return s, ok
+}
+
+// Run
+//  @Description  执行已注册的脚本
+//  @Receiver r
+//  @Param c
+//  @Param name 脚本名
+//  @Param keys 脚本使用的键
+//  @Param args 脚本参数
+//  @Return interface{} 脚本返回值
+//  @Return error 脚本未注册时返回错误
+func (r *ScriptRegistry) Run(c IAdvanceCache, name string, keys []string, args ...interface{}) (interface{}, error) {
+	s, ok := r.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("redis script %s not registered", name)
+	}
+	return s.Run(c, keys, args...)
+}
+
+// Load
+//  @Description  缓存所有已注册的脚本，返回第一个错误
+//  @Receiver r
+//  @Param c
+//  @Return error
+func (r *ScriptRegistry) Load(c IAdvanceCache) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for name, s := range r.scripts {
+		if err := s.Load(c); err != nil {
+			return fmt.Errorf("load redis script %s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// evalCache 只实现脚本相关方法，loaded 为已缓存的脚本
+type evalCache struct {
+	IAdvanceCache
+	loaded map[string]string
+	evals  int
+}
+
+func (c *evalCache) ScriptLoad(script string) (string, error) {
+	s := NewScript(script)
+	c.loaded[s.Hash()] = script
+	return s.Hash(), nil
+}
+
+func (c *evalCache) EvalSha(sha1 string, keys []string, args ...interface{}) (interface{}, error) {
+	if _, ok := c.loaded[sha1]; !ok {
+		return nil, errors.New("NOSCRIPT No matching script. Please use EVAL.")
+	}
+	return keys[0], nil
+}
+
+func (c *evalCache) Eval(script string, keys []string, args ...interface{}) (interface{}, error) {
+	c.evals++
+	_, _ = c.ScriptLoad(script)
+	return keys[0], nil
+}
+
+func TestScriptRegistry(t *testing.T) {
+	c := &evalCache{loaded: map[string]string{}}
+	r := NewScriptRegistry()
+	r.Register("echo", "return KEYS[1]")
+
+	// 未缓存时回退到 EVAL，之后使用 EVALSHA
+	res, err := r.Run(c, "echo", []string{"a"})
+	assert.Nil(t, err)
+	assert.Equal(t, "a", res)
+	res, _ = r.Run(c, "echo", []string{"b"})
+	assert.Equal(t, "b", res)
+	assert.Equal(t, 1, c.evals)
+
+	_, err = r.Run(c, "missing", nil)
+	assert.NotNil(t, err)
+
+	r.Register("other", "return KEYS[2]")
+	assert.Nil(t, r.Load(c))
+	s, ok := r.Get("other")
+	assert.True(t, ok)
+	assert.Contains(t, c.loaded, s.Hash())
+}
@@ -0,0 +1,649 @@
+// @Description 按租户隔离的缓存，key 加上 WithContext 传入的上下文中租户的前缀
+
+package cache
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/cache/config"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/tenant"
+)
+
+// tenantCache 上下文中没有租户时不加前缀，所有租户共享
+type tenantCache struct {
+	cache  config.IAdvanceCache
+	prefix string
+}
+
+func newTenantCache(cache config.IAdvanceCache) *tenantCache {
+	return &tenantCache{cache: cache}
+}
+
+func (t *tenantCache) key(key string) string {
+	return t.prefix + key
+}
+
+func (t *tenantCache) keys(keys []string) []string {
+	if t.prefix == "" {
+		return keys
+	}
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = t.prefix + key
+	}
+	return prefixed
+}
+
+// WithContext ...
+func (t *tenantCache) WithContext(ctx context.Context) config.ICache {
+	return t.WithAdvanceContext(ctx)
+}
+
+// WithAdvanceContext ...
+func (t *tenantCache) WithAdvanceContext(ctx context.Context) config.IAdvanceCache {
+	if ctx == nil {
+		return t
+	}
+	return &tenantCache{
+		cache:  t.cache.WithAdvanceContext(ctx),
+		prefix: tenant.KeyPrefix(tenant.FromContext(ctx)),
+	}
+}
+
+// Get ...
+func (t *tenantCache) Get(key string) string {
+	return t.cache.Get(t.key(key))
+}
+
+// GetRaw ...
+func (t *tenantCache) GetRaw(key string) ([]byte, error) {
+	return t.cache.GetRaw(t.key(key))
+}
+
+// MGet ...
+func (t *tenantCache) MGet(keys ...string) ([]string, error) {
+	return t.cache.MGet(t.keys(keys)...)
+}
+
+// MGets ...
+func (t *tenantCache) MGets(keys []string) ([]interface{}, error) {
+	return t.cache.MGets(t.keys(keys))
+}
+
+// Set ...
+func (t *tenantCache) Set(key string, value interface{}, expire time.Duration) bool {
+	return t.cache.Set(t.key(key), value, expire)
+}
+
+// SetWithErr ...
+func (t *tenantCache) SetWithErr(key string, value interface{}, expire time.Duration) error {
+	return t.cache.SetWithErr(t.key(key), value, expire)
+}
+
+// SetNx ...
+func (t *tenantCache) SetNx(key string, value interface{}, expiration time.Duration) bool {
+	return t.cache.SetNx(t.key(key), value, expiration)
+}
+
+// SetNxWithErr ...
+func (t *tenantCache) SetNxWithErr(key string, value interface{}, expiration time.Duration) (bool, error) {
+	return t.cache.SetNxWithErr(t.key(key), value, expiration)
+}
+
+// Incr ...
+func (t *tenantCache) Incr(key string) bool {
+	return t.cache.Incr(t.key(key))
+}
+
+// IncrWithErr ...
+func (t *tenantCache) IncrWithErr(key string) (int64, error) {
+	return t.cache.IncrWithErr(t.key(key))
+}
+
+// IncrBy ...
+func (t *tenantCache) IncrBy(key string, increment int64) (int64, error) {
+	return t.cache.IncrBy(t.key(key), increment)
+}
+
+// Decr ...
+func (t *tenantCache) Decr(key string) bool {
+	return t.cache.Decr(t.key(key))
+}
+
+// Del ...
+func (t *tenantCache) Del(key ...string) int64 {
+	return t.cache.Del(t.keys(key)...)
+}
+
+// DelWithErr ...
+func (t *tenantCache) DelWithErr(key string) (int64, error) {
+	return t.cache.DelWithErr(t.key(key))
+}
+
+// Exists ...
+func (t *tenantCache) Exists(key string) bool {
+	return t.cache.Exists(t.key(key))
+}
+
+// ExistsWithErr ...
+func (t *tenantCache) ExistsWithErr(key string) (bool, error) {
+	return t.cache.ExistsWithErr(t.key(key))
+}
+
+// Expire ...
+func (t *tenantCache) Expire(key string, expiration time.Duration) (bool, error) {
+	return t.cache.Expire(t.key(key), expiration)
+}
+
+// TTL ...
+func (t *tenantCache) TTL(key string) (int64, error) {
+	return t.cache.TTL(t.key(key))
+}
+
+// GetObject ...
+func (t *tenantCache) GetObject(key string, v interface{}) error {
+	return t.cache.GetObject(t.key(key), v)
+}
+
+// SetObject ...
+func (t *tenantCache) SetObject(key string, v interface{}, expire time.Duration) error {
+	return t.cache.SetObject(t.key(key), v, expire)
+}
+
+// MGetObjects ...
+func (t *tenantCache) MGetObjects(keys []string, dst interface{}) error {
+	return t.cache.MGetObjects(t.keys(keys), dst)
+}
+
+// HGetAll ...
+func (t *tenantCache) HGetAll(key string) map[string]string {
+	return t.cache.HGetAll(t.key(key))
+}
+
+// HGet ...
+func (t *tenantCache) HGet(key string, fields string) (string, error) {
+	return t.cache.HGet(t.key(key), fields)
+}
+
+// HMGet ...
+func (t *tenantCache) HMGet(key string, fields []string) []string {
+	return t.cache.HMGet(t.key(key), fields)
+}
+
+// HMGetMap ...
+func (t *tenantCache) HMGetMap(key string, fields []string) map[string]string {
+	return t.cache.HMGetMap(t.key(key), fields)
+}
+
+// HMSet ...
+func (t *tenantCache) HMSet(key string, hash map[string]interface{}, expire time.Duration) bool {
+	return t.cache.HMSet(t.key(key), hash, expire)
+}
+
+// HSet ...
+func (t *tenantCache) HSet(key string, field string, value interface{}) bool {
+	return t.cache.HSet(t.key(key), field, value)
+}
+
+// HDel ...
+func (t *tenantCache) HDel(key string, field ...string) bool {
+	return t.cache.HDel(t.key(key), field...)
+}
+
+// Scan 只扫描当前租户的 key，返回的 key 去掉租户前缀
+func (t *tenantCache) Scan(cursor uint64, match string, count int64) ([]string, error) {
+	if t.prefix != "" && match == "" {
+		match = "*"
+	}
+	keys, err := t.cache.Scan(cursor, t.key(match), count)
+	if t.prefix != "" {
+		for i, key := range keys {
+			keys[i] = strings.TrimPrefix(key, t.prefix)
+		}
+	}
+	return keys, err
+}
+
+// Type ...
+func (t *tenantCache) Type(key string) (string, error) {
+	return t.cache.Type(t.key(key))
+}
+
+// ZRevRange ...
+func (t *tenantCache) ZRevRange(key string, start, stop int64) ([]string, error) {
+	return t.cache.ZRevRange(t.key(key), start, stop)
+}
+
+// ZRevRangeWithScores ...
+func (t *tenantCache) ZRevRangeWithScores(key string, start, stop int64) ([]config.Z, error) {
+	return t.cache.ZRevRangeWithScores(t.key(key), start, stop)
+}
+
+// ZRange ...
+func (t *tenantCache) ZRange(key string, start, stop int64) ([]string, error) {
+	return t.cache.ZRange(t.key(key), start, stop)
+}
+
+// ZRevRank ...
+func (t *tenantCache) ZRevRank(key string, member string) (int64, error) {
+	return t.cache.ZRevRank(t.key(key), member)
+}
+
+// ZRevRangeByScore ...
+func (t *tenantCache) ZRevRangeByScore(key string, opt config.ZRangeBy) ([]string, error) {
+	return t.cache.ZRevRangeByScore(t.key(key), opt)
+}
+
+// ZRevRangeByScoreWithScores ...
+func (t *tenantCache) ZRevRangeByScoreWithScores(key string, opt config.ZRangeBy) ([]config.Z, error) {
+	return t.cache.ZRevRangeByScoreWithScores(t.key(key), opt)
+}
+
+// ZCard ...
+func (t *tenantCache) ZCard(key string) (int64, error) {
+	return t.cache.ZCard(t.key(key))
+}
+
+// ZScore ...
+func (t *tenantCache) ZScore(key string, member string) (float64, error) {
+	return t.cache.ZScore(t.key(key), member)
+}
+
+// ZAdd ...
+func (t *tenantCache) ZAdd(key string, members ...config.Z) (int64, error) {
+	return t.cache.ZAdd(t.key(key), members...)
+}
+
+// ZCount ...
+func (t *tenantCache) ZCount(key string, min, max string) (int64, error) {
+	return t.cache.ZCount(t.key(key), min, max)
+}
+
+// HIncrBy ...
+func (t *tenantCache) HIncrBy(key string, field string, incr int) int64 {
+	return t.cache.HIncrBy(t.key(key), field, incr)
+}
+
+// HIncrByWithErr ...
+func (t *tenantCache) HIncrByWithErr(key string, field string, incr int) (int64, error) {
+	return t.cache.HIncrByWithErr(t.key(key), field, incr)
+}
+
+// LPush ...
+func (t *tenantCache) LPush(key string, values ...interface{}) (int64, error) {
+	return t.cache.LPush(t.key(key), values...)
+}
+
+// RPush ...
+func (t *tenantCache) RPush(key string, values ...interface{}) (int64, error) {
+	return t.cache.RPush(t.key(key), values...)
+}
+
+// RPop ...
+func (t *tenantCache) RPop(key string) (string, error) {
+	return t.cache.RPop(t.key(key))
+}
+
+// LRange ...
+func (t *tenantCache) LRange(key string, start, stop int64) ([]string, error) {
+	return t.cache.LRange(t.key(key), start, stop)
+}
+
+// LLen ...
+func (t *tenantCache) LLen(key string) int64 {
+	return t.cache.LLen(t.key(key))
+}
+
+// LLenWithErr ...
+func (t *tenantCache) LLenWithErr(key string) (int64, error) {
+	return t.cache.LLenWithErr(t.key(key))
+}
+
+// LRem ...
+func (t *tenantCache) LRem(key string, count int64, value interface{}) int64 {
+	return t.cache.LRem(t.key(key), count, value)
+}
+
+// LIndex ...
+func (t *tenantCache) LIndex(key string, idx int64) (string, error) {
+	return t.cache.LIndex(t.key(key), idx)
+}
+
+// LTrim ...
+func (t *tenantCache) LTrim(key string, start, stop int64) (string, error) {
+	return t.cache.LTrim(t.key(key), start, stop)
+}
+
+// ZRemRangeByRank ...
+func (t *tenantCache) ZRemRangeByRank(key string, start, stop int64) (int64, error) {
+	return t.cache.ZRemRangeByRank(t.key(key), start, stop)
+}
+
+// ZRemRangeByScore ...
+func (t *tenantCache) ZRemRangeByScore(key string, min, max string) (int64, error) {
+	return t.cache.ZRemRangeByScore(t.key(key), min, max)
+}
+
+// ZRem ...
+func (t *tenantCache) ZRem(key string, members ...interface{}) (int64, error) {
+	return t.cache.ZRem(t.key(key), members...)
+}
+
+// SAdd ...
+func (t *tenantCache) SAdd(key string, member ...interface{}) (int64, error) {
+	return t.cache.SAdd(t.key(key), member...)
+}
+
+// SMembers ...
+func (t *tenantCache) SMembers(key string) ([]string, error) {
+	return t.cache.SMembers(t.key(key))
+}
+
+// SIsMember ...
+func (t *tenantCache) SIsMember(key string, member interface{}) (bool, error) {
+	return t.cache.SIsMember(t.key(key), member)
+}
+
+// HKeys ...
+func (t *tenantCache) HKeys(key string) []string {
+	return t.cache.HKeys(t.key(key))
+}
+
+// HLen ...
+func (t *tenantCache) HLen(key string) int64 {
+	return t.cache.HLen(t.key(key))
+}
+
+// GeoAdd ...
+func (t *tenantCache) GeoAdd(key string, location *config.GeoLocation) (int64, error) {
+	return t.cache.GeoAdd(t.key(key), location)
+}
+
+// GeoRadius ...
+func (t *tenantCache) GeoRadius(key string, longitude, latitude float64, query *config.GeoRadiusQuery) ([]config.GeoLocation, error) {
+	return t.cache.GeoRadius(t.key(key), longitude, latitude, query)
+}
+
+// Eval KEYS 加租户前缀，脚本中不应拼接 key
+func (t *tenantCache) Eval(script string, keys []string, args ...interface{}) (interface{}, error) {
+	return t.cache.Eval(script, t.keys(keys), args...)
+}
+
+// EvalSha ...
+func (t *tenantCache) EvalSha(sha1 string, keys []string, args ...interface{}) (interface{}, error) {
+	return t.cache.EvalSha(sha1, t.keys(keys), args...)
+}
+
+// Pipeline 管道中的命令同样加租户前缀
+func (t *tenantCache) Pipeline(fn func(p config.PipelineCmds) error) error {
+	return t.cache.Pipeline(func(p config.PipelineCmds) error {
+		return fn(t.pipeline(p))
+	})
+}
+
+// TxPipeline ...
+func (t *tenantCache) TxPipeline(fn func(p config.PipelineCmds) error) error {
+	return t.cache.TxPipeline(func(p config.PipelineCmds) error {
+		return fn(t.pipeline(p))
+	})
+}
+
+// HMSetWithTTL ...
+func (t *tenantCache) HMSetWithTTL(key string, hash map[string]interface{}, expire time.Duration) error {
+	return t.cache.HMSetWithTTL(t.key(key), hash, expire)
+}
+
+// SAddWithTTL ...
+func (t *tenantCache) SAddWithTTL(key string, expire time.Duration, members ...interface{}) (int64, error) {
+	return t.cache.SAddWithTTL(t.key(key), expire, members...)
+}
+
+// ZAddWithTTL ...
+func (t *tenantCache) ZAddWithTTL(key string, expire time.Duration, members ...config.Z) (int64, error) {
+	return t.cache.ZAddWithTTL(t.key(key), expire, members...)
+}
+
+// RPushWithTTL ...
+func (t *tenantCache) RPushWithTTL(key string, expire time.Duration, values ...interface{}) (int64, error) {
+	return t.cache.RPushWithTTL(t.key(key), expire, values...)
+}
+
+// ScriptLoad ...
+func (t *tenantCache) ScriptLoad(script string) (string, error) {
+	return t.cache.ScriptLoad(script)
+}
+
+// Publish 频道加租户前缀
+func (t *tenantCache) Publish(channel string, message interface{}) (int64, error) {
+	return t.cache.Publish(t.key(channel), message)
+}
+
+// Subscribe 订阅当前租户的频道，handler 收到的频道去掉租户前缀
+func (t *tenantCache) Subscribe(ctx context.Context, handler func(msg *config.Message), channels ...string) (*config.Subscription, error) {
+	if t.prefix == "" {
+		return t.cache.Subscribe(ctx, handler, channels...)
+	}
+	return t.cache.Subscribe(ctx, func(msg *config.Message) {
+		m := *msg
+		m.Channel = strings.TrimPrefix(m.Channel, t.prefix)
+		handler(&m)
+	}, t.keys(channels)...)
+}
+
+// XAdd 流名加租户前缀
+func (t *tenantCache) XAdd(a *config.XAddArgs) (string, error) {
+	args := *a
+	args.Stream = t.key(a.Stream)
+	return t.cache.XAdd(&args)
+}
+
+// XGroupCreate ...
+func (t *tenantCache) XGroupCreate(stream, group, start string) error {
+	return t.cache.XGroupCreate(t.key(stream), group, start)
+}
+
+// XReadGroup 返回的流名去掉租户前缀
+func (t *tenantCache) XReadGroup(a *config.XReadGroupArgs) ([]config.XStream, error) {
+	if t.prefix == "" {
+		return t.cache.XReadGroup(a)
+	}
+	args := *a
+	// Streams 前一半为流名，后一半为 id
+	args.Streams = make([]string, len(a.Streams))
+	copy(args.Streams, a.Streams)
+	for i := 0; i < len(args.Streams)/2; i++ {
+		args.Streams[i] = t.key(args.Streams[i])
+	}
+	streams, err := t.cache.XReadGroup(&args)
+	for i := range streams {
+		streams[i].Stream = strings.TrimPrefix(streams[i].Stream, t.prefix)
+	}
+	return streams, err
+}
+
+// XAck ...
+func (t *tenantCache) XAck(stream, group string, ids ...string) (int64, error) {
+	return t.cache.XAck(t.key(stream), group, ids...)
+}
+
+// XPending ...
+func (t *tenantCache) XPending(stream, group string) (*config.XPending, error) {
+	return t.cache.XPending(t.key(stream), group)
+}
+
+// XPendingExt ...
+func (t *tenantCache) XPendingExt(a *config.XPendingExtArgs) ([]config.XPendingExt, error) {
+	args := *a
+	args.Stream = t.key(a.Stream)
+	return t.cache.XPendingExt(&args)
+}
+
+// XClaim ...
+func (t *tenantCache) XClaim(a *config.XClaimArgs) ([]config.XMessage, error) {
+	args := *a
+	args.Stream = t.key(a.Stream)
+	return t.cache.XClaim(&args)
+}
+
+func (t *tenantCache) pipeline(p config.PipelineCmds) config.PipelineCmds {
+	if t.prefix == "" {
+		return p
+	}
+	return &tenantPipeline{PipelineCmds: p, t: t}
+}
+
+// Close 关闭底层适配器
+func (t *tenantCache) Close() error {
+	if closer, ok := t.cache.(interface{ Close() error }); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// tenantPipeline 管道命令的 key 加租户前缀
+type tenantPipeline struct {
+	config.PipelineCmds
+	t *tenantCache
+}
+
+func (p *tenantPipeline) Get(key string) *config.StringCmd {
+	return p.PipelineCmds.Get(p.t.key(key))
+}
+
+func (p *tenantPipeline) MGet(keys ...string) *config.SliceCmd {
+	return p.PipelineCmds.MGet(p.t.keys(keys)...)
+}
+
+func (p *tenantPipeline) Set(key string, value interface{}, expiration time.Duration) *config.StatusCmd {
+	return p.PipelineCmds.Set(p.t.key(key), value, expiration)
+}
+
+func (p *tenantPipeline) SetNX(key string, value interface{}, expiration time.Duration) *config.BoolCmd {
+	return p.PipelineCmds.SetNX(p.t.key(key), value, expiration)
+}
+
+func (p *tenantPipeline) Incr(key string) *config.IntCmd {
+	return p.PipelineCmds.Incr(p.t.key(key))
+}
+
+func (p *tenantPipeline) IncrBy(key string, value int64) *config.IntCmd {
+	return p.PipelineCmds.IncrBy(p.t.key(key), value)
+}
+
+func (p *tenantPipeline) Decr(key string) *config.IntCmd {
+	return p.PipelineCmds.Decr(p.t.key(key))
+}
+
+func (p *tenantPipeline) Del(keys ...string) *config.IntCmd {
+	return p.PipelineCmds.Del(p.t.keys(keys)...)
+}
+
+func (p *tenantPipeline) Exists(keys ...string) *config.IntCmd {
+	return p.PipelineCmds.Exists(p.t.keys(keys)...)
+}
+
+func (p *tenantPipeline) Expire(key string, expiration time.Duration) *config.BoolCmd {
+	return p.PipelineCmds.Expire(p.t.key(key), expiration)
+}
+
+func (p *tenantPipeline) TTL(key string) *config.DurationCmd {
+	return p.PipelineCmds.TTL(p.t.key(key))
+}
+
+func (p *tenantPipeline) HGet(key, field string) *config.StringCmd {
+	return p.PipelineCmds.HGet(p.t.key(key), field)
+}
+
+func (p *tenantPipeline) HGetAll(key string) *config.StringStringMapCmd {
+	return p.PipelineCmds.HGetAll(p.t.key(key))
+}
+
+func (p *tenantPipeline) HMGet(key string, fields ...string) *config.SliceCmd {
+	return p.PipelineCmds.HMGet(p.t.key(key), fields...)
+}
+
+func (p *tenantPipeline) HMSet(key string, fields map[string]interface{}) *config.StatusCmd {
+	return p.PipelineCmds.HMSet(p.t.key(key), fields)
+}
+
+func (p *tenantPipeline) HSet(key, field string, value interface{}) *config.BoolCmd {
+	return p.PipelineCmds.HSet(p.t.key(key), field, value)
+}
+
+func (p *tenantPipeline) HDel(key string, fields ...string) *config.IntCmd {
+	return p.PipelineCmds.HDel(p.t.key(key), fields...)
+}
+
+func (p *tenantPipeline) HIncrBy(key, field string, incr int64) *config.IntCmd {
+	return p.PipelineCmds.HIncrBy(p.t.key(key), field, incr)
+}
+
+func (p *tenantPipeline) LPush(key string, values ...interface{}) *config.IntCmd {
+	return p.PipelineCmds.LPush(p.t.key(key), values...)
+}
+
+func (p *tenantPipeline) RPush(key string, values ...interface{}) *config.IntCmd {
+	return p.PipelineCmds.RPush(p.t.key(key), values...)
+}
+
+func (p *tenantPipeline) LRange(key string, start, stop int64) *config.StringSliceCmd {
+	return p.PipelineCmds.LRange(p.t.key(key), start, stop)
+}
+
+func (p *tenantPipeline) LTrim(key string, start, stop int64) *config.StatusCmd {
+	return p.PipelineCmds.LTrim(p.t.key(key), start, stop)
+}
+
+func (p *tenantPipeline) SAdd(key string, members ...interface{}) *config.IntCmd {
+	return p.PipelineCmds.SAdd(p.t.key(key), members...)
+}
+
+func (p *tenantPipeline) SRem(key string, members ...interface{}) *config.IntCmd {
+	return p.PipelineCmds.SRem(p.t.key(key), members...)
+}
+
+func (p *tenantPipeline) SMembers(key string) *config.StringSliceCmd {
+	return p.PipelineCmds.SMembers(p.t.key(key))
+}
+
+func (p *tenantPipeline) ZAdd(key string, members ...config.Z) *config.IntCmd {
+	return p.PipelineCmds.ZAdd(p.t.key(key), members...)
+}
+
+func (p *tenantPipeline) ZIncrBy(key string, increment float64, member string) *config.FloatCmd {
+	return p.PipelineCmds.ZIncrBy(p.t.key(key), increment, member)
+}
+
+func (p *tenantPipeline) ZRem(key string, members ...interface{}) *config.IntCmd {
+	return p.PipelineCmds.ZRem(p.t.key(key), members...)
+}
+
+func (p *tenantPipeline) ZScore(key, member string) *config.FloatCmd {
+	return p.PipelineCmds.ZScore(p.t.key(key), member)
+}
+
+func (p *tenantPipeline) ZCard(key string) *config.IntCmd {
+	return p.PipelineCmds.ZCard(p.t.key(key))
+}
+
+func (p *tenantPipeline) ZRange(key string, start, stop int64) *config.StringSliceCmd {
+	return p.PipelineCmds.ZRange(p.t.key(key), start, stop)
+}
+
+func (p *tenantPipeline) ZRevRange(key string, start, stop int64) *config.StringSliceCmd {
+	return p.PipelineCmds.ZRevRange(p.t.key(key), start, stop)
+}
+
+func (p *tenantPipeline) ZRevRangeWithScores(key string, start, stop int64) *config.ZSliceCmd {
+	return p.PipelineCmds.ZRevRangeWithScores(p.t.key(key), start, stop)
+}
+
+func (p *tenantPipeline) Eval(script string, keys []string, args ...interface{}) *config.Cmd {
+	return p.PipelineCmds.Eval(script, p.t.keys(keys), args...)
+}
+
+func (p *tenantPipeline) EvalSha(sha1 string, keys []string, args ...interface{}) *config.Cmd {
+	return p.PipelineCmds.EvalSha(sha1, p.t.keys(keys), args...)
+}
@@ -0,0 +1,147 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/cache/config"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/tenant"
+	"github.com/go-redis/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeCache 只实现用到的方法
+type fakeCache struct {
+	config.IAdvanceCache
+	data     map[string]string
+	pipeline *fakePipeline
+}
+
+func (f *fakeCache) WithAdvanceContext(ctx context.Context) config.IAdvanceCache {
+	return f
+}
+
+func (f *fakeCache) Get(key string) string {
+	return f.data[key]
+}
+
+func (f *fakeCache) Set(key string, value interface{}, expire time.Duration) bool {
+	f.data[key] = value.(string)
+	return true
+}
+
+func (f *fakeCache) Del(keys ...string) int64 {
+	for _, key := range keys {
+		delete(f.data, key)
+	}
+	return int64(len(keys))
+}
+
+func (f *fakeCache) Scan(cursor uint64, match string, count int64) ([]string, error) {
+	var keys []string
+	for key := range f.data {
+		if strings.HasPrefix(key, strings.TrimSuffix(match, "*")) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// fakePipeline 记录命令的 key
+type fakePipeline struct {
+	config.PipelineCmds
+	keys []string
+}
+
+func (p *fakePipeline) Set(key string, value interface{}, expiration time.Duration) *config.StatusCmd {
+	p.keys = append(p.keys, key)
+	return redis.NewStatusResult("OK", nil)
+}
+
+func (p *fakePipeline) Del(keys ...string) *config.IntCmd {
+	p.keys = append(p.keys, keys...)
+	return redis.NewIntResult(int64(len(keys)), nil)
+}
+
+func (f *fakeCache) Pipeline(fn func(p config.PipelineCmds) error) error {
+	return fn(f.pipeline)
+}
+
+func TestTenantCache(t *testing.T) {
+	inner := &fakeCache{data: map[string]string{}}
+	cache := newTenantCache(inner)
+
+	shared := cache.WithAdvanceContext(context.Background())
+	a := cache.WithAdvanceContext(tenant.NewContext(context.Background(), "101"))
+	b := cache.WithContext(tenant.NewContext(context.Background(), "102"))
+
+	assert.True(t, shared.Set("user:1", "s", 0))
+	assert.True(t, a.Set("user:1", "a", 0))
+	assert.True(t, b.Set("user:1", "b", 0))
+	assert.Equal(t, map[string]string{"user:1": "s", "tenant:101:user:1": "a", "tenant:102:user:1": "b"}, inner.data)
+	assert.Equal(t, "a", a.Get("user:1"))
+	assert.Equal(t, "b", b.Get("user:1"))
+
+	keys, err := a.Scan(0, "", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"user:1"}, keys)
+
+	a.Del("user:1")
+	assert.Equal(t, "", a.Get("user:1"))
+	assert.Equal(t, "b", b.Get("user:1"))
+}
+
+func TestTenantCache_Pipeline(t *testing.T) {
+	inner := &fakeCache{pipeline: &fakePipeline{}}
+	cache := newTenantCache(inner).WithAdvanceContext(tenant.NewContext(context.Background(), "101"))
+	err := cache.Pipeline(func(p config.PipelineCmds) error {
+		p.Set("a", "1", 0)
+		p.Del("b", "c")
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"tenant:101:a", "tenant:101:b", "tenant:101:c"}, inner.pipeline.keys)
+}
+
+// streamCache 记录流与频道名
+type streamCache struct {
+	config.IAdvanceCache
+	names []string
+}
+
+func (s *streamCache) WithAdvanceContext(ctx context.Context) config.IAdvanceCache {
+	return s
+}
+
+func (s *streamCache) XAdd(a *config.XAddArgs) (string, error) {
+	s.names = append(s.names, a.Stream)
+	return "1-0", nil
+}
+
+func (s *streamCache) XReadGroup(a *config.XReadGroupArgs) ([]config.XStream, error) {
+	s.names = append(s.names, a.Streams...)
+	return []config.XStream{{Stream: a.Streams[0]}}, nil
+}
+
+func (s *streamCache) Publish(channel string, message interface{}) (int64, error) {
+	s.names = append(s.names, channel)
+	return 1, nil
+}
+
+func TestTenantCache_Stream(t *testing.T) {
+	inner := &streamCache{}
+	cache := newTenantCache(inner).WithAdvanceContext(tenant.NewContext(context.Background(), "101"))
+	args := &config.XAddArgs{Stream: "orders"}
+	_, err := cache.XAdd(args)
+	assert.Nil(t, err)
+	assert.Equal(t, "orders", args.Stream)
+
+	streams, err := cache.XReadGroup(&config.XReadGroupArgs{Group: "g", Consumer: "c", Streams: []string{"orders", ">"}})
+	assert.Nil(t, err)
+	assert.Equal(t, "orders", streams[0].Stream)
+
+	_, _ = cache.Publish("events", "hi")
+	assert.Equal(t, []string{"tenant:101:orders", "tenant:101:orders", ">", "tenant:101:events"}, inner.names)
+}
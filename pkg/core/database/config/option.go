@@ -27,6 +27,15 @@ type IModelConfig interface {
 	CachePrefix() string
 }
 
+// ITenantModelConfig 按租户路由的数据模型，可选实现，上下文中有租户时生效
+type ITenantModelConfig interface {
+	IModelConfig
+	// TenantDatabase 租户使用的数据库配置 key，为空时使用当前数据库
+	TenantDatabase(tenant string) string
+	// TenantTableName 租户使用的表名，为空时使用 TableName
+	TenantTableName(tenant string) string
+}
+
 // IDatabase 数据操作实现接口
 type IDatabase interface {
 	GetRawDB() *DB
@@ -5,6 +5,7 @@ package database
 import (
 	"context"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/core/database/config"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/tenant"
 	"strings"
 
 )
@@ -72,7 +73,7 @@ func (d *database) ForceMaster(forceMaster bool) {
 // 	@Return int64 条数
 func (d *database) Count(c config.IModelConfig, sql string, values ...interface{}) int64 {
 	var total int64 = 0
-	err := d.db.GetField(&total, d.parseTableName(c, sql), values...)
+	err := d.adapter(c).GetField(&total, d.parseTableName(c, sql), values...)
 	if err != nil {
 		return 0
 	}
@@ -88,7 +89,7 @@ func (d *database) Count(c config.IModelConfig, sql string, values ...interface{
 //  @Param values SQL语句参数
 // 	@Return error 错误
 func (d *database) GetField(c config.IModelConfig, dest interface{}, sql string, values ...interface{}) error {
-	return d.db.GetField(dest, d.parseTableName(c, sql), values...)
+	return d.adapter(c).GetField(dest, d.parseTableName(c, sql), values...)
 }
 
 // GetOne
@@ -100,7 +101,7 @@ func (d *database) GetField(c config.IModelConfig, dest interface{}, sql string,
 //  @Param values SQL语句参数
 // 	@Return error 错误
 func (d *database) GetOne(c config.IModelConfig, dest interface{}, sql string, values ...interface{}) error {
-	return d.db.GetOne(dest, d.parseTableName(c, sql), values...)
+	return d.adapter(c).GetOne(dest, d.parseTableName(c, sql), values...)
 }
 
 // Select
@@ -112,7 +113,7 @@ func (d *database) GetOne(c config.IModelConfig, dest interface{}, sql string, v
 //  @Param values SQL语句参数
 // 	@Return error 错误
 func (d *database) Select(c config.IModelConfig, destList interface{}, sql string, values ...interface{}) error {
-	return d.db.Select(destList, d.parseTableName(c, sql), values...)
+	return d.adapter(c).Select(destList, d.parseTableName(c, sql), values...)
 }
 
 // Create
@@ -124,7 +125,7 @@ func (d *database) Select(c config.IModelConfig, destList interface{}, sql strin
 //  @Param values SQL语句参数
 // 	@Return error 错误
 func (d *database) Create(c config.IModelConfig, dest interface{}, sql string, values ...interface{}) error {
-	return d.db.Create(dest, d.parseTableName(c, sql), values...)
+	return d.adapter(c).Create(dest, d.parseTableName(c, sql), values...)
 }
 
 // CreateBatch
@@ -135,7 +136,7 @@ func (d *database) Create(c config.IModelConfig, dest interface{}, sql string, v
 //  @Param values SQL语句参数
 // 	@Return 写入条数，错误
 func (d *database) CreateBatch(c config.IModelConfig, sql string, values ...interface{}) (int64, error) {
-	return d.db.CreateBatch(d.parseTableName(c, sql), values...)
+	return d.adapter(c).CreateBatch(d.parseTableName(c, sql), values...)
 }
 
 // Update
@@ -146,7 +147,7 @@ func (d *database) CreateBatch(c config.IModelConfig, sql string, values ...inte
 //  @Param values SQL语句参数
 // 	@Return error 影响条数和错误
 func (d *database) Update(c config.IModelConfig, sql string, values ...interface{}) (int64, error) {
-	return d.db.Update(d.parseTableName(c, sql), values...)
+	return d.adapter(c).Update(d.parseTableName(c, sql), values...)
 }
 
 // CreateOrUpdate
@@ -157,7 +158,7 @@ func (d *database) Update(c config.IModelConfig, sql string, values ...interface
 //  @Param values SQL语句参数
 // 	@Return error 影响条数和错误
 func (d *database) CreateOrUpdate(c config.IModelConfig, sql string, values ...interface{}) (int64, error) {
-	return d.db.CreateOrUpdate(d.parseTableName(c, sql), values...)
+	return d.adapter(c).CreateOrUpdate(d.parseTableName(c, sql), values...)
 }
 
 // Delete
@@ -168,7 +169,7 @@ func (d *database) CreateOrUpdate(c config.IModelConfig, sql string, values ...i
 //  @Param values SQL语句参数
 // 	@Return error 影响条数和错误
 func (d *database) Delete(c config.IModelConfig, sql string, values ...interface{}) (int64, error) {
-	return d.db.Delete(d.parseTableName(c, sql), values...)
+	return d.adapter(c).Delete(d.parseTableName(c, sql), values...)
 }
 
 // parseTableName
@@ -177,5 +178,37 @@ func (d *database) Delete(c config.IModelConfig, sql string, values ...interface
 //  @Param sql SQL语句模板
 // 	@Return string 更改表名后的sql模板
 func (d *database) parseTableName(c config.IModelConfig, sql string) string {
-	return strings.ReplaceAll(sql, "#TABLE#", "`"+c.TableName()+"`")
+	tableName := c.TableName()
+	if tc, ok := c.(config.ITenantModelConfig); ok {
+		if id := tenant.FromContext(d.ctx); id != "" {
+			if name := tc.TenantTableName(id); name != "" {
+				tableName = name
+			}
+		}
+	}
+	return strings.ReplaceAll(sql, "#TABLE#", "`"+tableName+"`")
+}
+
+// adapter
+// 	@Description 内部获取数据模型使用的数据库适配器，租户数据模型按上下文中的租户路由
+//  @Param c 数据表配置接口
+// 	@Return config.IDataBaseAdapter
+func (d *database) adapter(c config.IModelConfig) config.IDataBaseAdapter {
+	tc, ok := c.(config.ITenantModelConfig)
+	if !ok {
+		return d.db
+	}
+	id := tenant.FromContext(d.ctx)
+	if id == "" {
+		return d.db
+	}
+	conf := tc.TenantDatabase(id)
+	if conf == "" {
+		return d.db
+	}
+	db := GetDatabaseFactoryInstance().getAdapter(d.ctx, conf)
+	if d.ctx != nil {
+		db = db.WithContext(d.ctx)
+	}
+	return db
 }
@@ -54,6 +54,16 @@ func (df *databaseFactory) buildDatabase(ctx context.Context, conf string) confi
 	return df.dataBases[conf]
 }
 
+// getAdapter
+// 	@Description 获取数据库配置key对应的适配器
+// 	@Receiver databaseFactory
+//  @Param ctx 上下文Context
+//	@Param conf 数据库配置key
+// 	@Return IDataBaseAdapter
+func (df *databaseFactory) getAdapter(ctx context.Context, conf string) config.IDataBaseAdapter {
+	return df.GetDatabase(ctx, conf).(*database).db
+}
+
 // buildDatabaseDriver
 // 	@Description 构造数据库适配器内部方法
 // 	@Receiver databaseFactory
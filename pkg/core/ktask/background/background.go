@@ -18,6 +18,7 @@ var (
 	MQNil = errors.New("mq is nil")
 )
 
+// Handler 后台任务处理，GetMQ 返回 nil 时使用任务配置中的 mq
 type Handler interface {
 	ktask.Handler
 	GetMQ() mq.MessageQueer
@@ -31,6 +31,8 @@ type TaskConfig struct {
 	IsDistributedTask bool
 	// DelayExecType skip，queue，concurrent，如果上一个任务执行较慢，到达了新任务执行时间，那么新任务选择跳过，排队，并发执行的策略，新任务默认选择skip策略
 	DelayExecType string
+	// MQ 后台任务使用的消息队列，为 mq 配置中的名字，任务处理的 GetMQ 返回 nil 时使用
+	MQ string
 }
 
 // RawConfig
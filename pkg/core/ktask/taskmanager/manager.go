@@ -7,6 +7,8 @@ import (
 	"github.com/LuoHongLiang0921/kuaigo/pkg/core/ktask/background"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/core/ktask/kcron"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/core/ktask/kjob"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/mq"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/mq/mqmanager"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
 	"sync"
 )
@@ -21,6 +23,8 @@ type Manage struct {
 	tasksByType map[string][]ktask.Tasker
 	tasks       []ktask.Tasker
 	loadOnce    sync.Once
+	mqOnce      sync.Once
+	mqs         map[string]mq.MessageQueer
 }
 
 // Load
@@ -88,6 +92,22 @@ func (m *Manage) addTask(t ktask.Tasker, c *TaskConfig) {
 	m.mu.Unlock()
 }
 
+// getMQ
+// 	@Description 获取 mq 配置中名字为 name 的消息队列，首次使用时载入 mq 配置
+// 	@Receiver m Manage
+//	@Param ctx 上下文
+//	@Param name mq 配置中的名字
+// 	@Return mq.MessageQueer 未配置时为 nil
+func (m *Manage) getMQ(ctx context.Context, name string) mq.MessageQueer {
+	if name == "" {
+		return nil
+	}
+	m.mqOnce.Do(func() {
+		m.mqs = mqmanager.Load(ctx)
+	})
+	return m.mqs[name]
+}
+
 // GetTaskByName
 // 	@Description 根据 任务名字获取任务，配置文件中的name 字段
 // 	@Receiver m Manage
@@ -133,12 +153,16 @@ func (m *Manage) RegisterHandlers(ctx context.Context, handlers []ktask.Handler)
 		}
 		if task != nil {
 			if task.TaskType() == constant.TaskTypeBackground {
-				if backgroundTaskHandler, ok := h.(background.Handler); ok {
-					if backgroundTask, ok := task.(*background.Background); ok {
-						taskMQ := backgroundTaskHandler.GetMQ()
-						if taskMQ != nil {
-							backgroundTask.WithMQ(taskMQ).RegisterHandler(ctx, h)
-						}
+				if backgroundTask, ok := task.(*background.Background); ok {
+					var taskMQ mq.MessageQueer
+					if backgroundTaskHandler, ok := h.(background.Handler); ok {
+						taskMQ = backgroundTaskHandler.GetMQ()
+					}
+					if taskMQ == nil {
+						taskMQ = m.getMQ(ctx, m.Configs[taskName].MQ)
+					}
+					if taskMQ != nil {
+						backgroundTask.WithMQ(taskMQ).RegisterHandler(ctx, h)
 					}
 				}
 			} else {
@@ -0,0 +1,99 @@
+// @Description 分布式锁配置
+
+package lock
+
+import (
+	"context"
+	"time"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/conf"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/cache"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/ecode"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
+
+	"github.com/pkg/errors"
+)
+
+//ModName ..
+const ModName = "core.lock"
+
+// Config 配置
+type Config struct {
+	// Cache 缓存配置 key，redis 与 redisCluster 均可
+	Cache string
+	// Caches 多个相互独立的 redis 实例的缓存配置 key，配置后使用 Redlock，在多数实例上加锁成功才算成功，Cache 不再生效
+	Caches []string
+	// KeyPrefix key 前缀，默认 lock:
+	KeyPrefix string
+	// TTL 锁的有效期，默认 30s，开启 Watchdog 时持有期间自动续期
+	TTL time.Duration
+	// RetryInterval 阻塞加锁时的重试间隔，默认 100ms，实际间隔加上不超过一半的随机抖动
+	RetryInterval time.Duration
+	// Watchdog 持有期间每 TTL/3 自动续期，默认开启
+	Watchdog bool
+	// DriftFactor Redlock 时钟漂移系数，默认 0.01，有效期需扣除 TTL*DriftFactor
+	DriftFactor float64
+
+	logger *klog.Logger
+}
+
+// DefaultConfig ...
+func DefaultConfig() *Config {
+	return &Config{
+		KeyPrefix:     "lock:",
+		TTL:           30 * time.Second,
+		RetryInterval: 100 * time.Millisecond,
+		Watchdog:      true,
+		DriftFactor:   0.01,
+		logger:        klog.KuaigoLogger.With(klog.FieldMod(ModName)),
+	}
+}
+
+// StdConfig ...
+func StdConfig() *Config {
+	return RawConfig("tabby.lock")
+}
+
+// RawConfig ...
+func RawConfig(key string) *Config {
+	var config = DefaultConfig()
+	if err := conf.UnmarshalKey(key, &config); err != nil &&
+		errors.Cause(err) != conf.ErrInvalidKey {
+		config.logger.Panic("lock parse config panic", klog.FieldErrKind(ecode.ErrKindUnmarshalConfigErr), klog.FieldErr(err), klog.FieldKey(key))
+	}
+	return config
+}
+
+// Build
+//  @Description  使用缓存管理器中的 Cache 或 Caches 创建
+//  @Receiver config
+//  @Return *Locker
+func (config *Config) Build() *Locker {
+	keys := config.Caches
+	if len(keys) == 0 {
+		keys = []string{config.Cache}
+	}
+	stores := make([]Store, 0, len(keys))
+	for _, key := range keys {
+		stores = append(stores, NewCacheStore(cache.GetCacheManagerInstance().GetAdvanceCache(context.Background(), key)))
+	}
+	return config.BuildWithStore(stores...)
+}
+
+// BuildWithStore
+//  @Description  使用指定的存储创建，多个存储时使用 Redlock
+//  @Receiver config
+//  @Param stores 至少一个
+//  @Return *Locker
+func (config *Config) BuildWithStore(stores ...Store) *Locker {
+	if len(stores) == 0 {
+		config.logger.Panic("lock build panic: no store")
+	}
+	if config.TTL <= 0 {
+		config.TTL = 30 * time.Second
+	}
+	if config.RetryInterval <= 0 {
+		config.RetryInterval = 100 * time.Millisecond
+	}
+	return &Locker{config: config, stores: stores}
+}
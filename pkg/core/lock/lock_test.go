@@ -0,0 +1,178 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newLocker(ttl time.Duration, stores ...Store) *Locker {
+	config := DefaultConfig()
+	config.TTL = ttl
+	config.RetryInterval = 5 * time.Millisecond
+	if len(stores) == 0 {
+		stores = []Store{NewMemoryStore()}
+	}
+	return config.BuildWithStore(stores...)
+}
+
+// downStore 不可用的存储
+type downStore struct {
+	Store
+}
+
+var errDown = errors.New("down")
+
+func (downStore) Lock(ctx context.Context, key, token string, ttl time.Duration) (bool, error) {
+	return false, errDown
+}
+
+func (downStore) Unlock(ctx context.Context, key, token string) (int64, error) {
+	return 0, errDown
+}
+
+func (downStore) Refresh(ctx context.Context, key, token string, ttl time.Duration) error {
+	return errDown
+}
+
+func TestMutex(t *testing.T) {
+	ctx := context.Background()
+	locker := newLocker(time.Second)
+	a := locker.NewMutex("order:1")
+	b := locker.NewMutex("order:1")
+
+	ok, err := a.TryLock(ctx)
+	assert.True(t, ok)
+	assert.Nil(t, err)
+	ok, _ = b.TryLock(ctx)
+	assert.False(t, ok)
+	// 非持有者不能释放
+	assert.Equal(t, ErrNotHeld, b.Unlock(ctx))
+
+	// 重入
+	ok, _ = a.TryLock(ctx)
+	assert.True(t, ok)
+	shared := locker.NewReentrantMutex("order:1", a.Token())
+	ok, _ = shared.TryLock(ctx)
+	assert.True(t, ok)
+	assert.Nil(t, shared.Unlock(ctx))
+	assert.Nil(t, a.Unlock(ctx))
+	ok, _ = b.TryLock(ctx)
+	assert.False(t, ok)
+	assert.Nil(t, a.Unlock(ctx))
+
+	timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	assert.Nil(t, b.Lock(timeout))
+	assert.Equal(t, context.DeadlineExceeded, a.Lock(timeout))
+	assert.Nil(t, b.Unlock(ctx))
+}
+
+func TestMutexConcurrent(t *testing.T) {
+	ctx := context.Background()
+	locker := newLocker(time.Second)
+	var (
+		wg      sync.WaitGroup
+		holders int32
+		mu      sync.Mutex
+		maxHeld int32
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m := locker.NewMutex("counter")
+			assert.Nil(t, m.Lock(ctx))
+			mu.Lock()
+			holders++
+			if holders > maxHeld {
+				maxHeld = holders
+			}
+			mu.Unlock()
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			holders--
+			mu.Unlock()
+			assert.Nil(t, m.Unlock(ctx))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), maxHeld)
+}
+
+func TestWatchdog(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	locker := newLocker(30*time.Millisecond, store)
+	a := locker.NewMutex("job")
+	ok, _ := a.TryLock(ctx)
+	assert.True(t, ok)
+	time.Sleep(100 * time.Millisecond)
+	ok, _ = locker.NewMutex("job").TryLock(ctx)
+	assert.False(t, ok)
+	assert.Nil(t, a.Unlock(ctx))
+
+	// 关闭续期后锁按 TTL 过期
+	locker.config.Watchdog = false
+	ok, _ = a.TryLock(ctx)
+	assert.True(t, ok)
+	time.Sleep(50 * time.Millisecond)
+	b := locker.NewMutex("job")
+	ok, _ = b.TryLock(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, ErrNotHeld, a.Unlock(ctx))
+	assert.Nil(t, b.Unlock(ctx))
+}
+
+func TestSemaphore(t *testing.T) {
+	ctx := context.Background()
+	locker := newLocker(time.Second)
+	a := locker.NewSemaphore("export", 2)
+	b := locker.NewSemaphore("export", 2)
+	c := locker.NewSemaphore("export", 2)
+
+	assert.Nil(t, a.Acquire(ctx))
+	assert.Nil(t, b.Acquire(ctx))
+	ok, err := c.TryAcquire(ctx)
+	assert.False(t, ok)
+	assert.Nil(t, err)
+	assert.Equal(t, ErrNotHeld, c.Release(ctx))
+
+	assert.Nil(t, a.Release(ctx))
+	ok, _ = c.TryAcquire(ctx)
+	assert.True(t, ok)
+	assert.Nil(t, b.Release(ctx))
+	assert.Nil(t, c.Release(ctx))
+}
+
+func TestRedlock(t *testing.T) {
+	ctx := context.Background()
+	s1, s2 := NewMemoryStore(), NewMemoryStore()
+	locker := newLocker(time.Second, s1, s2, downStore{})
+
+	a := locker.NewMutex("pay")
+	ok, err := a.TryLock(ctx)
+	assert.True(t, ok)
+	assert.Nil(t, err)
+
+	// 另一实例上被其他客户端持有，剩余实例不足多数
+	other := newLocker(time.Second, s2).NewMutex("pay")
+	assert.Nil(t, a.Unlock(ctx))
+	ok, _ = other.TryLock(ctx)
+	assert.True(t, ok)
+	ok, err = a.TryLock(ctx)
+	assert.False(t, ok)
+	assert.Nil(t, err)
+	// 失败时回滚已加锁的实例
+	ok, _ = newLocker(time.Second, s1).NewMutex("pay").TryLock(ctx)
+	assert.True(t, ok)
+
+	// 多数实例不可用
+	ok, err = newLocker(time.Second, s1, downStore{}, downStore{}).NewMutex("pay2").TryLock(ctx)
+	assert.False(t, ok)
+	assert.Equal(t, errDown, err)
+}
@@ -0,0 +1,177 @@
+// @Description 分布式锁与信号量，多个存储时按 Redlock 在多数存储上成功才算成功
+
+package lock
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/kgo"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
+
+	"github.com/pborman/uuid"
+)
+
+// Locker 创建锁与信号量
+type Locker struct {
+	config *Config
+	stores []Store
+}
+
+// NewMutex
+//  @Description  创建锁，持有者为新生成的 token，同一实例可重入
+//  @Receiver l
+//  @Param key
+//  @Return *Mutex
+func (l *Locker) NewMutex(key string) *Mutex {
+	return l.NewReentrantMutex(key, uuid.New())
+}
+
+// NewReentrantMutex
+//  @Description  创建以 token 为持有者的锁，相同 token 的实例之间可重入，如以请求 id 作为 token
+//  @Receiver l
+//  @Param key
+//  @Param token 持有者标识，需全局唯一
+//  @Return *Mutex
+func (l *Locker) NewReentrantMutex(key, token string) *Mutex {
+	return &Mutex{locker: l, key: l.config.KeyPrefix + key, token: token}
+}
+
+// NewSemaphore
+//  @Description  创建计数信号量，一个实例最多持有一个许可
+//  @Receiver l
+//  @Param key
+//  @Param limit 许可数
+//  @Return *Semaphore
+func (l *Locker) NewSemaphore(key string, limit int64) *Semaphore {
+	return &Semaphore{locker: l, key: l.config.KeyPrefix + key, token: uuid.New(), limit: limit}
+}
+
+func (l *Locker) quorum() int {
+	return len(l.stores)/2 + 1
+}
+
+// each 在所有存储上执行，返回成功数与第一个错误
+func (l *Locker) each(fn func(Store) (bool, error)) (int, error) {
+	var (
+		n        int
+		firstErr error
+	)
+	for _, s := range l.stores {
+		ok, err := fn(s)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if ok {
+			n++
+		}
+	}
+	return n, firstErr
+}
+
+// acquire 在多数存储上成功且剩余有效期大于 0 时成功，否则回滚已成功的存储
+func (l *Locker) acquire(try func(Store) (bool, error), undo func(Store)) (bool, error) {
+	start := time.Now()
+	var (
+		acquired []Store
+		failed   int
+		firstErr error
+	)
+	for _, s := range l.stores {
+		ok, err := try(s)
+		if err != nil {
+			failed++
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+		if ok {
+			acquired = append(acquired, s)
+		}
+	}
+	if len(acquired) >= l.quorum() {
+		if len(l.stores) == 1 {
+			return true, nil
+		}
+		drift := time.Duration(float64(l.config.TTL)*l.config.DriftFactor) + 2*time.Millisecond
+		if l.config.TTL-time.Since(start)-drift > 0 {
+			return true, nil
+		}
+	}
+	for _, s := range acquired {
+		undo(s)
+	}
+	// 出错的存储过多，无论其他存储结果如何都不可能成功
+	if failed > len(l.stores)-l.quorum() {
+		return false, firstErr
+	}
+	return false, nil
+}
+
+// wait 按重试间隔加锁，直到成功、出错或 ctx 结束
+func (l *Locker) wait(ctx context.Context, try func(context.Context) (bool, error)) error {
+	for {
+		ok, err := try(ctx)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+		interval := l.config.RetryInterval + time.Duration(rand.Int63n(int64(l.config.RetryInterval)/2+1))
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// watch 开启 Watchdog 时每 TTL/3 续期，关闭返回的 channel 后停止；锁已丢失时停止续期
+func (l *Locker) watch(key string, refresh func(Store) (bool, error)) chan struct{} {
+	stop := make(chan struct{})
+	if !l.config.Watchdog {
+		return stop
+	}
+	kgo.Go(func() {
+		ticker := time.NewTicker(l.config.TTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+			n, err := l.each(refresh)
+			if n >= l.quorum() {
+				continue
+			}
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			l.config.logger.Warn("lock refresh failed", klog.FieldKey(key), klog.FieldErr(err))
+			if errors.Is(err, ErrNotHeld) {
+				return
+			}
+		}
+	})
+	return stop
+}
+
+// release 在所有存储上释放，多数存储成功时成功
+func (l *Locker) release(fn func(Store) error) error {
+	n, err := l.each(func(s Store) (bool, error) {
+		err := fn(s)
+		return err == nil, err
+	})
+	if n >= l.quorum() {
+		return nil
+	}
+	return err
+}
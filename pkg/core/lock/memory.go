@@ -0,0 +1,147 @@
+// @Description 锁的进程内实现，用于单元测试与单实例部署
+
+package lock
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryLock struct {
+	owners   map[string]int64
+	expireAt time.Time
+}
+
+type memoryStore struct {
+	mu    sync.Mutex
+	now   func() time.Time
+	locks map[string]*memoryLock
+	sems  map[string]map[string]time.Time
+}
+
+// NewMemoryStore
+//  @Description  进程内存储，与 redis 实现语义一致
+//  @Return Store
+func NewMemoryStore() Store {
+	return &memoryStore{
+		now:   time.Now,
+		locks: make(map[string]*memoryLock),
+		sems:  make(map[string]map[string]time.Time),
+	}
+}
+
+// lock 返回未过期的锁
+func (s *memoryStore) lock(key string) *memoryLock {
+	l, ok := s.locks[key]
+	if ok && !s.now().Before(l.expireAt) {
+		delete(s.locks, key)
+		return nil
+	}
+	return l
+}
+
+// Lock ...
+func (s *memoryStore) Lock(ctx context.Context, key, token string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l := s.lock(key)
+	if l == nil {
+		l = &memoryLock{owners: map[string]int64{}}
+		s.locks[key] = l
+	} else if _, ok := l.owners[token]; !ok {
+		return false, nil
+	}
+	l.owners[token]++
+	l.expireAt = s.now().Add(ttl)
+	return true, nil
+}
+
+// Unlock ...
+func (s *memoryStore) Unlock(ctx context.Context, key, token string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l := s.lock(key)
+	if l == nil {
+		return 0, ErrNotHeld
+	}
+	if _, ok := l.owners[token]; !ok {
+		return 0, ErrNotHeld
+	}
+	l.owners[token]--
+	if n := l.owners[token]; n > 0 {
+		return n, nil
+	}
+	delete(s.locks, key)
+	return 0, nil
+}
+
+// Refresh ...
+func (s *memoryStore) Refresh(ctx context.Context, key, token string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l := s.lock(key)
+	if l == nil {
+		return ErrNotHeld
+	}
+	if _, ok := l.owners[token]; !ok {
+		return ErrNotHeld
+	}
+	l.expireAt = s.now().Add(ttl)
+	return nil
+}
+
+// permits 返回去掉过期许可后的许可，不存在时为 nil
+func (s *memoryStore) permits(key string) map[string]time.Time {
+	now := s.now()
+	permits := s.sems[key]
+	for token, expireAt := range permits {
+		if !now.Before(expireAt) {
+			delete(permits, token)
+		}
+	}
+	return permits
+}
+
+// Acquire ...
+func (s *memoryStore) Acquire(ctx context.Context, key, token string, limit int64, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	permits := s.permits(key)
+	if _, ok := permits[token]; !ok && int64(len(permits)) >= limit {
+		return false, nil
+	}
+	if permits == nil {
+		permits = make(map[string]time.Time)
+		s.sems[key] = permits
+	}
+	permits[token] = s.now().Add(ttl)
+	return true, nil
+}
+
+// Release ...
+func (s *memoryStore) Release(ctx context.Context, key, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	permits := s.permits(key)
+	if _, ok := permits[token]; !ok {
+		return ErrNotHeld
+	}
+	delete(permits, token)
+	if len(permits) == 0 {
+		delete(s.sems, key)
+	}
+	return nil
+}
+
+// RefreshPermit ...
+func (s *memoryStore) RefreshPermit(ctx context.Context, key, token string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	permits := s.permits(key)
+	if _, ok := permits[token]; !ok {
+		return ErrNotHeld
+	}
+	permits[token] = s.now().Add(ttl)
+	return nil
+}
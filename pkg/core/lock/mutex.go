@@ -0,0 +1,89 @@
+// @Description 可重入的分布式锁
+
+package lock
+
+import (
+	"context"
+	"sync"
+)
+
+// Mutex 分布式锁，以 token 标识持有者，只有持有者能释放与续期
+type Mutex struct {
+	locker *Locker
+	key    string
+	token  string
+
+	mu   sync.Mutex
+	held int
+	stop chan struct{}
+}
+
+// Key ...
+func (m *Mutex) Key() string {
+	return m.key
+}
+
+// Token ...
+func (m *Mutex) Token() string {
+	return m.token
+}
+
+// TryLock
+//  @Description  尝试加锁，不等待
+//  @Receiver m
+//  @Param ctx
+//  @Return bool 是否成功
+//  @Return error
+func (m *Mutex) TryLock(ctx context.Context) (bool, error) {
+	ttl := m.locker.config.TTL
+	ok, err := m.locker.acquire(func(s Store) (bool, error) {
+		return s.Lock(ctx, m.key, m.token, ttl)
+	}, func(s Store) {
+		_, _ = s.Unlock(ctx, m.key, m.token)
+	})
+	if !ok {
+		return false, err
+	}
+	m.mu.Lock()
+	m.held++
+	if m.held == 1 {
+		m.stop = m.locker.watch(m.key, func(s Store) (bool, error) {
+			err := s.Refresh(context.Background(), m.key, m.token, ttl)
+			return err == nil, err
+		})
+	}
+	m.mu.Unlock()
+	return true, nil
+}
+
+// Lock
+//  @Description  加锁，锁被占用时按 RetryInterval 重试
+//  @Receiver m
+//  @Param ctx 取消或超时时返回 ctx.Err()
+//  @Return error
+func (m *Mutex) Lock(ctx context.Context) error {
+	return m.locker.wait(ctx, m.TryLock)
+}
+
+// Unlock
+//  @Description  释放一次，重入次数减到 0 时删除锁并停止续期
+//  @Receiver m
+//  @Param ctx
+//  @Return error 当前实例未持有或锁已过期时返回 ErrNotHeld
+func (m *Mutex) Unlock(ctx context.Context) error {
+	m.mu.Lock()
+	if m.held == 0 {
+		m.mu.Unlock()
+		return ErrNotHeld
+	}
+	m.held--
+	if m.held == 0 {
+		close(m.stop)
+		m.stop = nil
+	}
+	m.mu.Unlock()
+	return m.locker.release(func(s Store) error {
+		_, err := s.Unlock(ctx, m.key, m.token)
+		return err
+	})
+}
@@ -0,0 +1,75 @@
+// @Description 分布式计数信号量
+
+package lock
+
+import (
+	"context"
+	"sync"
+)
+
+// Semaphore 计数信号量，同时最多 limit 个持有者，许可过期后自动释放
+type Semaphore struct {
+	locker *Locker
+	key    string
+	token  string
+	limit  int64
+
+	mu   sync.Mutex
+	stop chan struct{}
+}
+
+// TryAcquire
+//  @Description  尝试获取许可，不等待，已持有时直接返回成功
+//  @Receiver s
+//  @Param ctx
+//  @Return bool 是否成功
+//  @Return error
+func (s *Semaphore) TryAcquire(ctx context.Context) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return true, nil
+	}
+	ttl := s.locker.config.TTL
+	ok, err := s.locker.acquire(func(st Store) (bool, error) {
+		return st.Acquire(ctx, s.key, s.token, s.limit, ttl)
+	}, func(st Store) {
+		_ = st.Release(ctx, s.key, s.token)
+	})
+	if !ok {
+		return false, err
+	}
+	s.stop = s.locker.watch(s.key, func(st Store) (bool, error) {
+		err := st.RefreshPermit(context.Background(), s.key, s.token, ttl)
+		return err == nil, err
+	})
+	return true, nil
+}
+
+// Acquire
+//  @Description  获取许可，没有空闲许可时按 RetryInterval 重试
+//  @Receiver s
+//  @Param ctx 取消或超时时返回 ctx.Err()
+//  @Return error
+func (s *Semaphore) Acquire(ctx context.Context) error {
+	return s.locker.wait(ctx, s.TryAcquire)
+}
+
+// Release
+//  @Description  释放许可并停止续期
+//  @Receiver s
+//  @Param ctx
+//  @Return error 未持有或许可已过期时返回 ErrNotHeld
+func (s *Semaphore) Release(ctx context.Context) error {
+	s.mu.Lock()
+	if s.stop == nil {
+		s.mu.Unlock()
+		return ErrNotHeld
+	}
+	close(s.stop)
+	s.stop = nil
+	s.mu.Unlock()
+	return s.locker.release(func(st Store) error {
+		return st.Release(ctx, s.key, s.token)
+	})
+}
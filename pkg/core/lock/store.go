@@ -0,0 +1,163 @@
+// @Description 锁的存储，redis 实现通过 lua 脚本保证只有持有者能释放与续期
+
+package lock
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/cache/config"
+)
+
+// ErrNotHeld 未持有锁或许可，或已过期
+var ErrNotHeld = errors.New("lock: not held")
+
+// Store 锁的存储，锁以 token 标识持有者，同一 token 可重入
+type Store interface {
+	// Lock 未被其他 token 持有时加锁，持有次数加一并设置有效期
+	Lock(ctx context.Context, key, token string, ttl time.Duration) (bool, error)
+	// Unlock 持有次数减一，返回剩余次数，为 0 时删除；token 未持有时返回 ErrNotHeld
+	Unlock(ctx context.Context, key, token string) (int64, error)
+	// Refresh 续期，token 未持有时返回 ErrNotHeld
+	Refresh(ctx context.Context, key, token string, ttl time.Duration) error
+	// Acquire 未过期的许可少于 limit 时获取一个许可，token 已持有时续期
+	Acquire(ctx context.Context, key, token string, limit int64, ttl time.Duration) (bool, error)
+	// Release 释放许可，token 未持有时返回 ErrNotHeld
+	Release(ctx context.Context, key, token string) error
+	// RefreshPermit 许可续期，token 未持有时返回 ErrNotHeld
+	RefreshPermit(ctx context.Context, key, token string, ttl time.Duration) error
+}
+
+// lockScript 锁为 hash，field 为 token，值为重入次数
+var lockScript = config.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 or redis.call('HEXISTS', KEYS[1], ARGV[1]) == 1 then
+	redis.call('HINCRBY', KEYS[1], ARGV[1], 1)
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	return 1
+end
+return 0
+`)
+
+// unlockScript 返回剩余次数，未持有时返回 -1
+var unlockScript = config.NewScript(`
+if redis.call('HEXISTS', KEYS[1], ARGV[1]) == 0 then
+	return -1
+end
+local n = redis.call('HINCRBY', KEYS[1], ARGV[1], -1)
+if n <= 0 then
+	redis.call('DEL', KEYS[1])
+	return 0
+end
+return n
+`)
+
+var refreshScript = config.NewScript(`
+if redis.call('HEXISTS', KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+redis.call('PEXPIRE', KEYS[1], ARGV[2])
+return 1
+`)
+
+// acquireScript 信号量为 zset，member 为 token，score 为过期时间（毫秒），时间取自客户端，各实例需时钟同步
+var acquireScript = config.NewScript(`
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[4])
+local expire = tonumber(ARGV[4]) + tonumber(ARGV[3])
+if redis.call('ZSCORE', KEYS[1], ARGV[1]) or redis.call('ZCARD', KEYS[1]) < tonumber(ARGV[2]) then
+	redis.call('ZADD', KEYS[1], expire, ARGV[1])
+	if redis.call('PTTL', KEYS[1]) < tonumber(ARGV[3]) then
+		redis.call('PEXPIRE', KEYS[1], ARGV[3])
+	end
+	return 1
+end
+return 0
+`)
+
+var releaseScript = config.NewScript(`
+return redis.call('ZREM', KEYS[1], ARGV[1])
+`)
+
+var refreshPermitScript = config.NewScript(`
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[3])
+if not redis.call('ZSCORE', KEYS[1], ARGV[1]) then
+	return 0
+end
+redis.call('ZADD', KEYS[1], tonumber(ARGV[3]) + tonumber(ARGV[2]), ARGV[1])
+if redis.call('PTTL', KEYS[1]) < tonumber(ARGV[2]) then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 1
+`)
+
+// run 执行脚本，脚本返回整数
+func run(s *config.Script, cache config.IAdvanceCache, keys []string, args ...interface{}) (int64, error) {
+	res, err := s.Run(cache, keys, args...)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.(int64)
+	return n, nil
+}
+
+type cacheStore struct {
+	cache config.IAdvanceCache
+}
+
+// NewCacheStore
+//  @Description  基于缓存管理器的 redis 或 redisCluster 的存储，每个脚本只操作一个 key
+//  @Param cache
+//  @Return Store
+func NewCacheStore(cache config.IAdvanceCache) Store {
+	return &cacheStore{cache: cache}
+}
+
+// Lock ...
+func (s *cacheStore) Lock(ctx context.Context, key, token string, ttl time.Duration) (bool, error) {
+	n, err := run(lockScript, s.cache.WithAdvanceContext(ctx), []string{key}, token, ttl.Milliseconds())
+	return n == 1, err
+}
+
+// Unlock ...
+func (s *cacheStore) Unlock(ctx context.Context, key, token string) (int64, error) {
+	n, err := run(unlockScript, s.cache.WithAdvanceContext(ctx), []string{key}, token)
+	if err == nil && n < 0 {
+		return 0, ErrNotHeld
+	}
+	return n, err
+}
+
+// Refresh ...
+func (s *cacheStore) Refresh(ctx context.Context, key, token string, ttl time.Duration) error {
+	n, err := run(refreshScript, s.cache.WithAdvanceContext(ctx), []string{key}, token, ttl.Milliseconds())
+	if err == nil && n == 0 {
+		return ErrNotHeld
+	}
+	return err
+}
+
+// Acquire ...
+func (s *cacheStore) Acquire(ctx context.Context, key, token string, limit int64, ttl time.Duration) (bool, error) {
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	n, err := run(acquireScript, s.cache.WithAdvanceContext(ctx), []string{key}, token, limit, ttl.Milliseconds(), now)
+	return n == 1, err
+}
+
+// Release ...
+func (s *cacheStore) Release(ctx context.Context, key, token string) error {
+	n, err := run(releaseScript, s.cache.WithAdvanceContext(ctx), []string{key}, token)
+	if err == nil && n == 0 {
+		return ErrNotHeld
+	}
+	return err
+}
+
+// RefreshPermit ...
+func (s *cacheStore) RefreshPermit(ctx context.Context, key, token string, ttl time.Duration) error {
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	n, err := run(refreshPermitScript, s.cache.WithAdvanceContext(ctx), []string{key}, token, ttl.Milliseconds(), now)
+	if err == nil && n == 0 {
+		return ErrNotHeld
+	}
+	return err
+}
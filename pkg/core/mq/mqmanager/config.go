@@ -7,8 +7,10 @@ import (
 	"github.com/LuoHongLiang0921/kuaigo/pkg/core/mq"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/core/mq/kafka"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/core/mq/rabbitmq"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/mq/redisstream"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/core/mq/rocketmq"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
+	logmq "github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/mq"
 	"time"
 
 
@@ -19,7 +21,7 @@ import (
 type Config struct {
 	// Brokers 消息中间件地址列表
 	Brokers []string
-	// Mode 队列模式,kafka,rocketmq,rabbitmq
+	// Mode 队列模式,kafka,rocketmq,rabbitmq,redisstream
 	Mode string
 	// RunType 运行类型  发布："publish" 消费："consumer"
 	RunType mq.RunType
@@ -27,6 +29,7 @@ type Config struct {
 	Kafka  KafkaConfig  `mapstructure:"kafka"`
 	Rabbit RabbitConfig `mapstructure:"rabbit"`
 	Rocket RocketConfig `mapstructure:"rocket"`
+	Redis  RedisConfig  `mapstructure:"redis"`
 }
 
 type KafkaConfig struct {
@@ -95,6 +98,31 @@ type RocketConfig struct {
 	Async bool
 }
 
+type RedisConfig struct {
+	// Cache 缓存配置 key，redis 与 redisCluster 均可
+	Cache string
+	// Stream 默认的流
+	Stream string
+	// Group 消费组
+	Group string
+	// Consumer 消费者名字，默认为 主机名-进程号
+	Consumer string
+	// Start 创建消费组时开始消费的消息 id，默认 $ 只消费新消息，0 为从头消费
+	Start string
+	// MaxLen 流的近似最大长度，0 为不限制
+	MaxLen int64
+	// Count 每次读取的最大消息数
+	Count int64
+	// Block 读取时阻塞等待新消息的时长
+	Block time.Duration
+	// MinIdle 消息超过该时长未确认时重新投递
+	MinIdle time.Duration
+	// ClaimInterval 检查待重新投递消息的间隔
+	ClaimInterval time.Duration
+	// MaxRetry 最大投递次数，超过后丢弃，0 为不限制
+	MaxRetry int64
+}
+
 // Load
 // 	@Description 载入配置，生成多个配置
 //	@Param ctx 上下文
@@ -178,9 +206,29 @@ func doLoad(ctx context.Context) map[string]mq.MessageQueer {
 					IsNackRequeue: cfg.Rabbit.Consumer.IsNackRequeue,
 				},
 			}.Build(ctx)
+		case constant.ModeRedisStream:
+			result[k] = redisstream.Config{
+				Mode:          cfg.Mode,
+				RunType:       cfg.RunType,
+				Cache:         cfg.Redis.Cache,
+				Stream:        cfg.Redis.Stream,
+				Group:         cfg.Redis.Group,
+				Consumer:      cfg.Redis.Consumer,
+				Start:         cfg.Redis.Start,
+				MaxLen:        cfg.Redis.MaxLen,
+				Count:         cfg.Redis.Count,
+				Block:         cfg.Redis.Block,
+				MinIdle:       cfg.Redis.MinIdle,
+				ClaimInterval: cfg.Redis.ClaimInterval,
+				MaxRetry:      cfg.Redis.MaxRetry,
+			}.Build(ctx)
 		default:
 			klog.TaskLogger.WithContext(ctx).Panicf("message queue mode %s not support", cfg.Mode)
 		}
+		// 发布类型的实例可作为日志 mq 输出源
+		if cfg.RunType.IsPublishType() {
+			logmq.RegisterPublisher(k, result[k])
+		}
 	}
 	return result
 }
@@ -0,0 +1,169 @@
+package redisstream
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/cache/config"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/mq"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/kjson"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
+)
+
+const (
+	// fieldBody 消息体字段
+	fieldBody = "body"
+	// fieldHeader 消息头字段，json 编码
+	fieldHeader = "header"
+)
+
+// ErrNoHandler 未注册处理函数
+var ErrNoHandler = errors.New("redis stream handler not registered")
+
+// Client ...
+type Client struct {
+	cfg     *Config
+	cache   config.IAdvanceCache
+	Handler mq.HandlerFunc
+
+	logger *klog.Logger
+	// closing 停止从 redis 读取新的消息
+	closing   chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+func (c *Client) processMessage(ctx context.Context, msg *mq.Message) {
+	c.wg.Add(1)
+	defer c.wg.Done()
+	if c.Handler == nil {
+		c.logger.WithContext(ctx).Warnf("process stream %v err:%v", c.cfg.Stream, ErrNoHandler)
+		msg.NAck()
+		return
+	}
+	// 处理函数未确认时按返回值确认，Ack 与 NAck 均幂等
+	if err := c.Handler(ctx, msg); err != nil {
+		c.logger.WithContext(ctx).Warnf("process stream %v,group %v is err:%v", c.cfg.Stream, c.cfg.Group, err)
+		msg.NAck()
+		return
+	}
+	msg.Ack()
+}
+
+// Consume
+// 	@Description 消费消息，处理函数返回错误时 NAck，否则 Ack，NAck 的消息在 MinIdle 后重新投递
+// 	@Receiver c Client
+//	@Param ctx 上下文
+//	@Param opts 消费配置项，WithConsumerQueue 指定流
+// 	@Return error 错误
+func (c *Client) Consume(ctx context.Context, opts ...mq.ConsumerOption) error {
+	consumerOptions := mq.MergeConsumerOption(opts...)
+	stream := c.cfg.Stream
+	if consumerOptions.Queue != "" {
+		stream = consumerOptions.Queue
+	}
+	msgs, err := c.Subscribe(ctx, stream)
+	if err != nil {
+		return err
+	}
+	for {
+		select {
+		case msg, ok := <-msgs:
+			if !ok {
+				c.logger.Warnf("message consume closed")
+				return nil
+			}
+			c.processMessage(ctx, msg)
+		case <-c.closing:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Publish
+// 	@Description 通过 XADD 发布消息，配置 MaxLen 时近似裁剪流的长度
+// 	@Receiver c Client
+//	@Param ctx 上下文
+//	@Param target 流，为空时使用配置的 Stream
+//	@Param msg 消息
+//	@Param opts 发布配置项，未使用
+// 	@Return *mq.RespMessage 发布消息的响应信息，MsgId 为消息 id
+// 	@Return error
+func (c *Client) Publish(ctx context.Context, target string, msg *mq.Message, opts ...mq.PublishOption) (*mq.RespMessage, error) {
+	stream := target
+	if stream == "" {
+		stream = c.cfg.Stream
+	}
+	values := map[string]interface{}{fieldBody: msg.Body}
+	if len(msg.Header) > 0 {
+		header, err := kjson.EncodeToString(msg.Header)
+		if err != nil {
+			return nil, err
+		}
+		values[fieldHeader] = header
+	}
+	id, err := c.cache.WithAdvanceContext(ctx).XAdd(&config.XAddArgs{
+		Stream:       stream,
+		MaxLenApprox: c.cfg.MaxLen,
+		Values:       values,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &mq.RespMessage{Topic: stream, MsgId: id, Timestamp: time.Now()}, nil
+}
+
+// RegisterHandler
+// 	@Description 注册业务函数
+// 	@Receiver c Client
+//	@Param ctx 上下文
+//	@Param h 执行函数
+// 	@Return error 错误
+func (c *Client) RegisterHandler(ctx context.Context, h mq.HandlerFunc) error {
+	c.Handler = h
+	return nil
+}
+
+// Stop
+// 	@Description 停止消费，缓存实例由缓存管理器管理，不关闭
+// 	@Receiver c Client
+// 	@Return error
+func (c *Client) Stop() error {
+	c.notifyConsumerStop()
+	return nil
+}
+
+// GracefulStop
+// 	@Description 停止消费并等待处理中的消息完成
+// 	@Receiver c Client
+// 	@Return error
+func (c *Client) GracefulStop() error {
+	c.notifyConsumerStop()
+	c.wg.Wait()
+	return nil
+}
+
+func (c *Client) notifyConsumerStop() {
+	c.closeOnce.Do(func() {
+		close(c.closing)
+	})
+}
+
+func newMessage(xm config.XMessage) *mq.Message {
+	var body []byte
+	switch v := xm.Values[fieldBody].(type) {
+	case string:
+		body = []byte(v)
+	case []byte:
+		body = v
+	}
+	msg := mq.NewMessage(body)
+	if header, ok := xm.Values[fieldHeader].(string); ok {
+		_ = kjson.DecodeFromString(header, &msg.Header)
+	}
+	return msg
+}
@@ -0,0 +1,214 @@
+package redisstream
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/constant"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/cache/config"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/mq"
+	"github.com/stretchr/testify/assert"
+)
+
+// memStream 单个流单个消费组的内存实现
+type memStream struct {
+	config.IAdvanceCache
+	mu        sync.Mutex
+	entries   []config.XMessage
+	next      int
+	pending   map[string]*config.XPendingExt
+	delivered map[string]time.Time
+}
+
+func newMemStream() *memStream {
+	return &memStream{pending: map[string]*config.XPendingExt{}, delivered: map[string]time.Time{}}
+}
+
+func (s *memStream) WithAdvanceContext(ctx context.Context) config.IAdvanceCache {
+	return s
+}
+
+func (s *memStream) XAdd(a *config.XAddArgs) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := fmt.Sprintf("%d-0", len(s.entries)+1)
+	values := make(map[string]interface{}, len(a.Values))
+	for k, v := range a.Values {
+		// redis 返回的值均为字符串
+		values[k] = fmt.Sprintf("%s", v)
+	}
+	s.entries = append(s.entries, config.XMessage{ID: id, Values: values})
+	return id, nil
+}
+
+func (s *memStream) XGroupCreate(stream, group, start string) error {
+	return nil
+}
+
+func (s *memStream) XReadGroup(a *config.XReadGroupArgs) ([]config.XStream, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.next >= len(s.entries) {
+		s.mu.Unlock()
+		time.Sleep(a.Block)
+		s.mu.Lock()
+		return nil, nil
+	}
+	msgs := s.entries[s.next:]
+	if int64(len(msgs)) > a.Count {
+		msgs = msgs[:a.Count]
+	}
+	s.next += len(msgs)
+	for _, m := range msgs {
+		s.pending[m.ID] = &config.XPendingExt{Id: m.ID, Consumer: a.Consumer, RetryCount: 1}
+		s.delivered[m.ID] = time.Now()
+	}
+	return []config.XStream{{Stream: a.Streams[0], Messages: msgs}}, nil
+}
+
+func (s *memStream) XAck(stream, group string, ids ...string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for _, id := range ids {
+		if _, ok := s.pending[id]; ok {
+			delete(s.pending, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *memStream) XPendingExt(a *config.XPendingExtArgs) ([]config.XPendingExt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var res []config.XPendingExt
+	for _, m := range s.entries {
+		if p, ok := s.pending[m.ID]; ok && (a.Start == "-" || streamSeq(m.ID) >= streamSeq(a.Start)) {
+			p.Idle = time.Since(s.delivered[m.ID])
+			res = append(res, *p)
+			if int64(len(res)) == a.Count {
+				break
+			}
+		}
+	}
+	return res, nil
+}
+
+// streamSeq 测试中的 id 均为 "n-0"，按 n 与序号比较
+func streamSeq(id string) int {
+	var ms, seq int
+	_, _ = fmt.Sscanf(id, "%d-%d", &ms, &seq)
+	return ms*1000 + seq
+}
+
+func (s *memStream) XClaim(a *config.XClaimArgs) ([]config.XMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var res []config.XMessage
+	for _, m := range s.entries {
+		for _, id := range a.Messages {
+			if p, ok := s.pending[id]; ok && m.ID == id && time.Since(s.delivered[id]) >= a.MinIdle {
+				p.Consumer = a.Consumer
+				p.RetryCount++
+				s.delivered[id] = time.Now()
+				res = append(res, m)
+			}
+		}
+	}
+	return res, nil
+}
+
+func (s *memStream) pendingCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.pending)
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	store := newMemStream()
+	client := Config{
+		Mode:          constant.ModeRedisStream,
+		RunType:       constant.RunTypePublishConsumer,
+		Stream:        "orders",
+		Group:         "g",
+		Block:         5 * time.Millisecond,
+		MinIdle:       20 * time.Millisecond,
+		ClaimInterval: 10 * time.Millisecond,
+		MaxRetry:      3,
+	}.BuildWithCache(store)
+
+	var (
+		mu     sync.Mutex
+		counts = map[string]int{}
+		header = map[string]string{}
+	)
+	_ = client.RegisterHandler(ctx, func(ctx context.Context, msg *mq.Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+		body := string(msg.Body)
+		counts[body]++
+		for k, v := range msg.Header {
+			header[k] = v
+		}
+		switch {
+		case body == "retry" && counts[body] == 1:
+			return fmt.Errorf("retry later")
+		case body == "poison":
+			msg.NAck()
+		}
+		return nil
+	})
+
+	for _, body := range []string{"ok", "retry", "poison"} {
+		msg := mq.NewMessage([]byte(body))
+		msg.Header["trace"] = "t1"
+		resp, err := client.Publish(ctx, "", msg)
+		assert.Nil(t, err)
+		assert.Equal(t, "orders", resp.Topic)
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- client.Consume(ctx)
+	}()
+	// NAck 的消息重新投递，超过 MaxRetry 后丢弃
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return counts["retry"] == 2 && counts["poison"] == 3 && store.pendingCount() == 0
+	}, 2*time.Second, 10*time.Millisecond)
+
+	assert.Nil(t, client.GracefulStop())
+	assert.Nil(t, <-done)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 1, counts["ok"])
+	assert.Equal(t, map[string]string{"trace": "t1"}, header)
+}
+
+func TestClient_ClaimPages(t *testing.T) {
+	store := newMemStream()
+	client := Config{Stream: "orders", Group: "g", Consumer: "c1", Count: 2, MinIdle: time.Millisecond, MaxRetry: 3}.BuildWithCache(store)
+	for i := 0; i < 5; i++ {
+		id, _ := store.XAdd(&config.XAddArgs{Stream: "orders", Values: map[string]interface{}{"body": "m"}})
+		retry := int64(1)
+		// 前 3 条超过重试次数
+		if i < 3 {
+			retry = 3
+		}
+		store.pending[id] = &config.XPendingExt{Id: id, Consumer: "dead", RetryCount: retry}
+		store.delivered[id] = time.Now().Add(-time.Second)
+	}
+
+	msgs, err := client.claim(store, "orders")
+	assert.Nil(t, err)
+	assert.Len(t, msgs, 2)
+	assert.Equal(t, "4-0", msgs[0].ID)
+	assert.Equal(t, "5-0", msgs[1].ID)
+	assert.Equal(t, 2, store.pendingCount())
+}
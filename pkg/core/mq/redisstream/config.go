@@ -0,0 +1,127 @@
+package redisstream
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/conf"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/constant"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/cache"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/cache/config"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/mq"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/kos"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/ktime"
+)
+
+// Config 配置
+type Config struct {
+	// Mode 队列模式,kafka,rocketmq,rabbitmq,redisstream
+	Mode string
+	// RunType
+	RunType mq.RunType
+	// Cache 缓存配置 key，redis 与 redisCluster 均可
+	Cache string
+	// Stream 默认的流，Consume 未指定队列、Publish 未指定 target 时使用
+	Stream string
+	// Group 消费组
+	Group string
+	// Consumer 消费者名字，默认为 主机名-进程号，同一消费组内需唯一
+	Consumer string
+	// Start 创建消费组时开始消费的消息 id，默认 $ 只消费新消息，0 为从头消费
+	Start string
+	// MaxLen 流的近似最大长度，发布时通过 MAXLEN ~ 裁剪，0 为不限制
+	MaxLen int64
+	// Count 每次读取的最大消息数，默认 10
+	Count int64
+	// Block 读取时阻塞等待新消息的时长，默认 2s
+	Block time.Duration
+	// MinIdle 消息超过该时长未确认时重新投递，包括 NAck 的消息与已下线消费者未确认的消息，默认 1m
+	MinIdle time.Duration
+	// ClaimInterval 检查待重新投递消息的间隔，默认 30s
+	ClaimInterval time.Duration
+	// MaxRetry 最大投递次数，超过后确认并丢弃消息，0 为不限制
+	MaxRetry int64
+}
+
+// RawConfig
+// 	@Description  redis stream 配置
+//	@param ctx 上下文
+//	@param key
+// 	@return *Config
+func RawConfig(ctx context.Context, key string) *Config {
+	cfg := getDefaultConfig()
+	if err := conf.UnmarshalKey(key, &cfg); err != nil {
+		klog.KuaigoLogger.WithContext(ctx).Panicf("redis stream config err:%v", err)
+	}
+	return &cfg
+}
+
+func getDefaultConfig() Config {
+	return Config{
+		Mode: constant.ModeRedisStream,
+	}
+}
+
+// Build
+// 	@Description 使用缓存管理器中的 Cache 实例化
+// 	@Receiver c Config
+//	@Param ctx 上下文
+// 	@Return *Client
+func (c Config) Build(ctx context.Context) *Client {
+	c.mustValidConfig()
+	return c.BuildWithCache(cache.GetCacheManagerInstance().GetAdvanceCache(ctx, c.Cache))
+}
+
+// BuildWithCache
+// 	@Description 使用指定的缓存实例化
+// 	@Receiver c Config
+//	@Param cache
+// 	@Return *Client
+func (c Config) BuildWithCache(cache config.IAdvanceCache) *Client {
+	c.setDefaults()
+	return &Client{
+		cfg:     &c,
+		cache:   cache,
+		closing: make(chan struct{}),
+		logger:  klog.KuaigoLogger,
+	}
+}
+
+func (c Config) mustValidConfig() {
+	if c.Mode == "" {
+		klog.KuaigoLogger.Panic("config mode must not empty")
+	}
+	if c.RunType == "" {
+		klog.KuaigoLogger.Panic("config run type must not empty")
+	}
+	if c.Cache == "" {
+		klog.KuaigoLogger.Panic("config cache must not empty")
+	}
+	if c.RunType.IsConsumerType() && c.Group == "" {
+		klog.KuaigoLogger.Panic("redis stream consumer group must not empty")
+	}
+}
+
+func (c *Config) setDefaults() {
+	if c.Consumer == "" {
+		c.Consumer = fmt.Sprintf("%s-%d", kos.GetHostname(), os.Getpid())
+	}
+	if c.Start == "" {
+		c.Start = "$"
+	}
+	if c.Count <= 0 {
+		c.Count = 10
+	}
+	if c.Block <= 0 {
+		c.Block = ktime.Duration("2s")
+	}
+	if c.MinIdle <= 0 {
+		c.MinIdle = ktime.Duration("1m")
+	}
+	if c.ClaimInterval <= 0 {
+		c.ClaimInterval = ktime.Duration("30s")
+	}
+}
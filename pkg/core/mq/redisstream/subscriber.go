@@ -0,0 +1,186 @@
+package redisstream
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/cache/config"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/mq"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/kgo"
+)
+
+// Subscribe
+// 	@Description 以消费组订阅流，消费组不存在时创建。消息需要调用 mq.Message 的 Ack() 或 NAck()，否则会阻塞；
+// 	Ack 时 XACK，NAck 时保留在待确认列表，超过 MinIdle 后重新投递
+// 	@Receiver c Client
+//	@Param ctx 上下文
+//	@Param stream 流
+// 	@Return <-chan *mq.Message
+// 	@Return error
+func (c *Client) Subscribe(ctx context.Context, stream string) (<-chan *mq.Message, error) {
+	if err := c.cache.WithAdvanceContext(ctx).XGroupCreate(stream, c.cfg.Group, c.cfg.Start); err != nil {
+		return nil, err
+	}
+	output := make(chan *mq.Message)
+	c.wg.Add(1)
+	kgo.Go(func() {
+		defer c.wg.Done()
+		c.consumeMessage(ctx, stream, output)
+		close(output)
+	})
+	return output, nil
+}
+
+func (c *Client) consumeMessage(ctx context.Context, stream string, output chan *mq.Message) {
+	cache := c.cache.WithAdvanceContext(ctx)
+	var lastClaim time.Time
+	for {
+		select {
+		case <-c.closing:
+			c.logger.Debug("subscriber closed")
+			return
+		case <-ctx.Done():
+			c.logger.Debug("ctx cancelled")
+			return
+		default:
+		}
+		// 启动时及每隔 ClaimInterval 认领超时未确认的消息
+		if time.Since(lastClaim) >= c.cfg.ClaimInterval {
+			lastClaim = time.Now()
+			msgs, err := c.claim(cache, stream)
+			if err != nil {
+				c.logger.Warnf("claim stream %v pending err:%v", stream, err)
+			}
+			if !c.deliver(ctx, cache, stream, msgs, output) {
+				return
+			}
+		}
+		streams, err := cache.XReadGroup(&config.XReadGroupArgs{
+			Group:    c.cfg.Group,
+			Consumer: c.cfg.Consumer,
+			Streams:  []string{stream, ">"},
+			Count:    c.cfg.Count,
+			Block:    c.cfg.Block,
+		})
+		if err != nil {
+			c.logger.Warnf("read stream %v err:%v", stream, err)
+			select {
+			case <-c.closing:
+				return
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+		for _, s := range streams {
+			if !c.deliver(ctx, cache, stream, s.Messages, output) {
+				return
+			}
+		}
+	}
+}
+
+// claim 认领空闲超过 MinIdle 的待确认消息，包括 NAck 的消息与已下线消费者的消息，超过 MaxRetry 的消息确认后丢弃；
+// 按 Count 分页遍历待确认列表，最多认领 Count 条，避免前面的消息未到 MinIdle 或被丢弃时后面的消息无法认领
+func (c *Client) claim(cache config.IAdvanceCache, stream string) ([]config.XMessage, error) {
+	var ids, dropped []string
+	start := "-"
+	for int64(len(ids)) < c.cfg.Count {
+		pending, err := cache.XPendingExt(&config.XPendingExtArgs{
+			Stream: stream,
+			Group:  c.cfg.Group,
+			Start:  start,
+			End:    "+",
+			Count:  c.cfg.Count,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range pending {
+			if p.Idle < c.cfg.MinIdle {
+				continue
+			}
+			if c.cfg.MaxRetry > 0 && p.RetryCount >= c.cfg.MaxRetry {
+				dropped = append(dropped, p.Id)
+				continue
+			}
+			if int64(len(ids)) < c.cfg.Count {
+				ids = append(ids, p.Id)
+			}
+		}
+		if int64(len(pending)) < c.cfg.Count {
+			break
+		}
+		next, ok := nextStreamID(pending[len(pending)-1].Id)
+		if !ok {
+			break
+		}
+		start = next
+	}
+	if len(dropped) > 0 {
+		c.logger.Errorf("stream %v drop messages %v exceeded max retry %v", stream, dropped, c.cfg.MaxRetry)
+		if _, err := cache.XAck(stream, c.cfg.Group, dropped...); err != nil {
+			return nil, err
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return cache.XClaim(&config.XClaimArgs{
+		Stream:   stream,
+		Group:    c.cfg.Group,
+		Consumer: c.cfg.Consumer,
+		MinIdle:  c.cfg.MinIdle,
+		Messages: ids,
+	})
+}
+
+// nextStreamID 紧随 id 之后的消息 id，作为下一页的起始 id，兼容不支持 "(" 开区间的 redis 版本
+func nextStreamID(id string) (string, bool) {
+	i := strings.LastIndexByte(id, '-')
+	if i < 0 {
+		return "", false
+	}
+	seq, err := strconv.ParseUint(id[i+1:], 10, 64)
+	if err != nil {
+		return "", false
+	}
+	if seq == math.MaxUint64 {
+		ms, err := strconv.ParseUint(id[:i], 10, 64)
+		if err != nil {
+			return "", false
+		}
+		return strconv.FormatUint(ms+1, 10) + "-0", true
+	}
+	return id[:i+1] + strconv.FormatUint(seq+1, 10), true
+}
+
+// deliver 依次投递消息并等待确认，停止时返回 false
+func (c *Client) deliver(ctx context.Context, cache config.IAdvanceCache, stream string, msgs []config.XMessage, output chan *mq.Message) bool {
+	for _, xm := range msgs {
+		msg := newMessage(xm)
+		msg.SetContext(ctx)
+		select {
+		case output <- msg:
+		case <-c.closing:
+			return false
+		case <-ctx.Done():
+			return false
+		}
+		select {
+		case <-msg.Acked():
+			if _, err := cache.XAck(stream, c.cfg.Group, xm.ID); err != nil {
+				c.logger.Warnf("ack stream %v message %v err:%v", stream, xm.ID, err)
+			}
+		case <-msg.NAcked():
+			// 保留在待确认列表中，超过 MinIdle 后重新投递
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
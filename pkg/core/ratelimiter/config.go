@@ -3,6 +3,8 @@
 package ratelimiter
 
 import (
+	"sync"
+
 	kconf "github.com/LuoHongLiang0921/kuaigo/pkg/conf"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
 )
@@ -14,10 +16,16 @@ type Config struct {
 	// RateLimiterPaths
 	// 不同限流策略下多个path
 	// 同一path不能在多个限流策略中,如在不同策略中,会覆盖只保留一个！
-	Path  map[string][]string
-	redis string
+	Path map[string][]string
+	// Algorithm 默认限流算法，规则未配置时使用，默认为 slidingWindow
+	Algorithm string
+	redis     string
+
+	mu sync.RWMutex
 	// resourceAns
 	resourceAns map[string][]string
+	// rules 补全默认值后的规则，请求间只读
+	rules map[string]RLConfig
 }
 
 func (c *Config) setRule() {
@@ -27,7 +35,51 @@ func (c *Config) setRule() {
 			resourceAns[l] = append(resourceAns[l], an)
 		}
 	}
+	rules := make(map[string]RLConfig, len(c.Rule))
+	for name, rule := range c.Rule {
+		if rule == nil {
+			continue
+		}
+		r := *rule
+		if r.Algorithm == "" {
+			r.Algorithm = c.Algorithm
+		}
+		r.setParams()
+		rules[name] = r
+	}
+	c.mu.Lock()
 	c.resourceAns = resourceAns
+	c.rules = rules
+	c.mu.Unlock()
+}
+
+// reload 用新配置替换规则
+func (c *Config) reload(n *Config) {
+	c.mu.Lock()
+	c.Rule = n.Rule
+	c.Path = n.Path
+	c.Algorithm = n.Algorithm
+	c.mu.Unlock()
+	c.setRule()
+}
+
+// getRule
+//  @Description: 获取补全默认值后的规则副本
+//  @Receiver c
+//  @Param name 规则名
+//  @Return RLConfig
+//  @Return bool 规则是否存在
+func (c *Config) getRule(name string) (RLConfig, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	rule, ok := c.rules[name]
+	return rule, ok
+}
+
+func (c *Config) find(resource string) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.resourceAns[resource]
 }
 
 // RawConfig
@@ -43,11 +95,13 @@ func RawConfig(key string) *Config {
 	appCfg.setRule()
 	// 加上次方法可以使apollo实时生效
 	kconf.OnChange(func(cfg *kconf.Configuration) {
-		err := kconf.UnmarshalKey(key, &appCfg)
+		var newCfg Config
+		err := cfg.UnmarshalKey(key, &newCfg)
 		if err != nil {
-			klog.Panic("unmarshal RateLimiter config")
+			klog.Error("unmarshal RateLimiter config", klog.FieldErr(err), klog.FieldKey(key))
+			return
 		}
-		appCfg.setRule()
+		appCfg.reload(&newCfg)
 	})
 	return &appCfg
 }
@@ -58,6 +112,7 @@ func RawConfig(key string) *Config {
 //  @Return *RateLimiter
 func (c *Config) Build() *RateLimiter {
 	return &RateLimiter{
-		cfg: c,
+		cfg:      c,
+		fallback: newMemoryStore(),
 	}
 }
@@ -0,0 +1,142 @@
+// @Description 限流算法的进程内实现，redis 不可用时降级使用
+
+package ratelimiter
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepThreshold 条目数超过该值时清理过期条目
+const sweepThreshold = 10000
+
+var defaultMemoryStore = newMemoryStore()
+
+type memoryEntry struct {
+	expireAt time.Time
+	// 滑动日志
+	log []time.Time
+	// 滑动窗口计数
+	idx  int64
+	cur  int
+	prev int
+	// 令牌桶
+	tokens float64
+	ts     time.Time
+}
+
+// memoryStore 与 redisStore 算法一致，计数仅在当前进程内有效
+type memoryStore struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{entries: make(map[string]*memoryEntry)}
+}
+
+// take ...
+func (s *memoryStore) take(algorithm, key string, window time.Duration, limit int, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key = algorithm + ":" + key
+	e, ok := s.entries[key]
+	if !ok || now.After(e.expireAt) {
+		if len(s.entries) >= sweepThreshold {
+			s.sweep(now)
+		}
+		e = &memoryEntry{}
+		s.entries[key] = e
+	}
+	switch algorithm {
+	case AlgorithmSlidingLog:
+		return e.slidingLog(window, limit, now), nil
+	case AlgorithmTokenBucket:
+		return e.tokenBucket(window, limit, now), nil
+	default:
+		return e.slidingWindow(window, limit, now), nil
+	}
+}
+
+func (s *memoryStore) sweep(now time.Time) {
+	for k, e := range s.entries {
+		if now.After(e.expireAt) {
+			delete(s.entries, k)
+		}
+	}
+}
+
+func (e *memoryEntry) slidingLog(window time.Duration, limit int, now time.Time) Result {
+	start := now.Add(-window)
+	i := 0
+	for i < len(e.log) && !e.log[i].After(start) {
+		i++
+	}
+	e.log = e.log[i:]
+	allowed := len(e.log) < limit
+	if allowed {
+		e.log = append(e.log, now)
+	}
+	e.expireAt = now.Add(window)
+	var reset, retry time.Duration
+	if len(e.log) > 0 {
+		retry = e.log[0].Add(window).Sub(now)
+		reset = e.log[len(e.log)-1].Add(window).Sub(now)
+	}
+	return newResult(allowed, limit, limit-len(e.log), reset, retry)
+}
+
+func (e *memoryEntry) slidingWindow(window time.Duration, limit int, now time.Time) Result {
+	nowMs := now.UnixNano() / int64(time.Millisecond)
+	windowMs := int64(window / time.Millisecond)
+	idx := nowMs / windowMs
+	switch e.idx {
+	case idx:
+	case idx - 1:
+		e.prev, e.cur, e.idx = e.cur, 0, idx
+	default:
+		e.prev, e.cur, e.idx = 0, 0, idx
+	}
+	elapsed := nowMs % windowMs
+	count := int(math.Floor(float64(e.prev)*float64(windowMs-elapsed)/float64(windowMs))) + e.cur
+	allowed := count < limit
+	var retry int64
+	if allowed {
+		e.cur++
+		count++
+	} else {
+		retry = windowMs - elapsed
+		if e.cur < limit && e.prev > 0 {
+			retry = int64(math.Ceil(float64(windowMs-elapsed) - float64(limit-e.cur)*float64(windowMs)/float64(e.prev)))
+			if retry < 1 {
+				retry = 1
+			}
+		}
+	}
+	e.expireAt = time.Unix(0, (idx+2)*windowMs*int64(time.Millisecond))
+	return newResult(allowed, limit, limit-count, time.Duration(windowMs*2-elapsed)*time.Millisecond, time.Duration(retry)*time.Millisecond)
+}
+
+func (e *memoryEntry) tokenBucket(window time.Duration, limit int, now time.Time) Result {
+	capacity := float64(limit)
+	if e.ts.IsZero() {
+		e.tokens = capacity
+		e.ts = now
+	}
+	if now.After(e.ts) {
+		e.tokens = math.Min(capacity, e.tokens+float64(now.Sub(e.ts))*capacity/float64(window))
+	}
+	e.ts = now
+	allowed := e.tokens >= 1
+	var retry time.Duration
+	if allowed {
+		e.tokens--
+	} else {
+		retry = time.Duration(math.Ceil((1 - e.tokens) * float64(window) / capacity))
+	}
+	e.expireAt = now.Add(window)
+	reset := time.Duration(math.Ceil((capacity - e.tokens) * float64(window) / capacity))
+	return newResult(allowed, limit, int(math.Floor(e.tokens)), reset, retry)
+}
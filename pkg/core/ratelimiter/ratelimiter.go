@@ -4,12 +4,15 @@ package ratelimiter
 
 import (
 	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/cache/config"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/core/server/kgin"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/core/storage/redis"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/ecode"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/errs"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
-	"time"
 )
 
 // RateLimiter is a antispam instance.
@@ -17,6 +20,12 @@ type RateLimiter struct {
 	cfg   *Config
 	conf  *RLConfig
 	Redis *redis.Redis
+	// uniqueID ExecuteRequest 传入的限流对象唯一值
+	uniqueID string
+
+	// store redis 存储，未设置或出错时使用 fallback
+	store    store
+	fallback store
 }
 
 // RLConfig antispam config.
@@ -31,6 +40,49 @@ type RLConfig struct {
 	M       int    // one winodw allow M requests.
 
 	UniqueIDs string // unique ids: _udid,_ip
+	// Algorithm 限流算法 slidingLog|slidingWindow|tokenBucket
+	Algorithm string
+	// Keys 限流对象的组成来源，按顺序拼接，配置后代替 UniqueIDs，由中间件解析
+	// 支持 header:<名称>、claim:userID、claim:appID、claim:did、param:<路由参数>、ip、did
+	Keys []string
+	// Tiers 按 app ID 分级的配额，key 为 app ID，未匹配时使用规则本身的配额
+	Tiers map[string]Tier
+}
+
+// Tier 分级配额，为 0 的字段沿用规则本身的配置
+type Tier struct {
+	Minutes int
+	N       int
+	Hour    int
+	M       int
+}
+
+// Request 一次限流请求的参数
+type Request struct {
+	// UniqueID 已组合好的限流对象唯一值，不为空时忽略 UniqueIDs
+	UniqueID string
+	// UDID 设备id
+	UDID string
+	// IP 客户端 ip
+	IP string
+	// Path 接口地址
+	Path string
+	// AppID 用于匹配分级配额
+	AppID int
+}
+
+// Result 限流结果
+type Result struct {
+	// Allowed 是否放行
+	Allowed bool
+	// Limit 窗口内允许的请求数
+	Limit int
+	// Remaining 窗口内剩余的请求数
+	Remaining int
+	// ResetAfter 额度完全恢复的等待时间
+	ResetAfter time.Duration
+	// RetryAfter 被限流时，下一次请求可能放行的等待时间
+	RetryAfter time.Duration
 }
 
 const (
@@ -43,11 +95,12 @@ const (
 )
 const (
 	prefixMinuteKey = "m_%s_%s_%d"
-	prefixHourKey   = "h_%s_%s_%d"
+	// prefixLimitKey 限流 key，{} 内为集群模式下的 hash tag，保证同一限流对象的 key 在同一 slot
+	prefixLimitKey = "rl:%s:{%s:%s}:%d"
 )
 
 // Execute
-//  @Description: 设置执行限流操作
+//  @Description: 设置执行限流操作，返回绑定本次请求参数的副本，不修改共享配置
 //  @Receiver s
 //  @Param name
 //  @Param did
@@ -55,16 +108,41 @@ const (
 //  @Param ip
 //  @Return *RateLimiter
 func (s *RateLimiter) Execute(c *kgin.TContext, name, udid, resource, ip string) *RateLimiter {
-	cfg := s.cfg.Rule[name]
-	if cfg == nil {
+	return s.ExecuteRequest(name, Request{UDID: udid, Path: resource, IP: ip})
+}
+
+// ExecuteRequest
+//  @Description: 按请求参数绑定规则，匹配 app ID 分级配额，返回副本，不修改共享配置
+//  @Receiver s
+//  @Param name 规则名
+//  @Param req 请求参数
+//  @Return *RateLimiter
+func (s *RateLimiter) ExecuteRequest(name string, req Request) *RateLimiter {
+	n := *s
+	n.conf = nil
+	n.uniqueID = ""
+	rule, ok := s.cfg.getRule(name)
+	if !ok {
 		klog.Error("RateLimiter config nil", klog.Any("name", name))
+		return &n
 	}
-	cfg.UDID = udid
-	cfg.Path = resource
-	cfg.IP = ip
-	s.conf = cfg
-	cfg.setParams()
-	return s
+	rule.UDID = req.UDID
+	rule.Path = req.Path
+	rule.IP = req.IP
+	rule.applyTier(req.AppID)
+	n.conf = &rule
+	n.uniqueID = req.UniqueID
+	return &n
+}
+
+// GetRule
+//  @Description: 获取补全默认值后的规则
+//  @Receiver s
+//  @Param name 规则名
+//  @Return RLConfig
+//  @Return bool 规则是否存在
+func (s *RateLimiter) GetRule(name string) (RLConfig, bool) {
+	return s.cfg.getRule(name)
 }
 
 // MinuteKey
@@ -77,17 +155,37 @@ func MinuteKey(uniqueID string, path string, burst int) string {
 	return fmt.Sprintf(prefixMinuteKey, uniqueID, path, burst)
 }
 
-func hourKey(uniqueID string, path string, burst int) string {
-	return fmt.Sprintf(prefixHourKey, uniqueID, path, burst)
+func limitKey(algorithm, uniqueID, path string, window time.Duration) string {
+	return fmt.Sprintf(prefixLimitKey, algorithm, uniqueID, path, int64(window/time.Second))
 }
 
+// WithRedis
+//  @Description: 使用 redis 存储限流计数
+//  @Receiver s
+//  @Param r
+//  @Return *RateLimiter
 func (s *RateLimiter) WithRedis(r *redis.Redis) *RateLimiter {
 	s.Redis = r
+	if r != nil {
+		s.store = newRedisStore(r)
+	}
+	return s
+}
+
+// WithCache
+//  @Description: 使用缓存管理器中的 redis 或 redisCluster 存储限流计数
+//  @Receiver s
+//  @Param c
+//  @Return *RateLimiter
+func (s *RateLimiter) WithCache(c config.IAdvanceCache) *RateLimiter {
+	if c != nil {
+		s.store = newRedisStore(c)
+	}
 	return s
 }
 
 func (s *RateLimiter) Find(resource string) []string {
-	return s.cfg.resourceAns[resource]
+	return s.cfg.find(resource)
 }
 
 // setParams
@@ -114,33 +212,89 @@ func (c *RLConfig) setParams() {
 		if c.UniqueIDs == "" {
 			c.UniqueIDs = defUDid
 		}
+		if !isAlgorithm(c.Algorithm) {
+			c.Algorithm = AlgorithmSlidingWindow
+		}
 	}
 }
 
+// applyTier 使用 app ID 对应的分级配额
+func (c *RLConfig) applyTier(appID int) {
+	if len(c.Tiers) == 0 || appID == 0 {
+		return
+	}
+	tier, ok := c.Tiers[strconv.Itoa(appID)]
+	if !ok {
+		return
+	}
+	if tier.Minutes > 0 {
+		c.Minutes = tier.Minutes
+	}
+	if tier.N > 0 {
+		c.N = tier.N
+	}
+	if tier.Hour > 0 {
+		c.Hour = tier.Hour
+	}
+	if tier.M > 0 {
+		c.M = tier.M
+	}
+	c.setParams()
+}
+
+// uniqueID 限流对象的唯一值
+func (c *RLConfig) uniqueID() string {
+	switch c.UniqueIDs {
+	case defUDid:
+		return c.UDID
+	case defIp:
+		return c.IP
+	}
+	return ""
+}
+
 // RateLimiter
 //  @Description: 限流操作
 //  @Receiver s 超过限流阈值 true
 //  @Return bool
 func (s *RateLimiter) RateLimiter() bool {
-	if s.conf.On {
-		uStr := ""
-		if s.conf.UniqueIDs == defUDid {
-			uStr = s.conf.UDID
-		} else if s.conf.UniqueIDs == defIp {
-			uStr = s.conf.IP
-		}
-		if uStr == "" {
-			return false
-		}
-		if err := s.HourTotal(uStr, s.conf.Path, s.conf.Hour, s.conf.M); err != nil {
-			return true
+	return !s.Take().Allowed
+}
+
+// Take
+//  @Description: 按 Execute 绑定的规则消耗一次额度，依次检查小时和分钟窗口，返回被限流或剩余额度最少的窗口结果
+//  @Receiver s
+//  @Return Result 规则不存在、未开启或缺少唯一值时直接放行
+func (s *RateLimiter) Take() Result {
+	if s.conf == nil || !s.conf.On {
+		return Result{Allowed: true}
+	}
+	uStr := s.uniqueID
+	if uStr == "" {
+		uStr = s.conf.uniqueID()
+	}
+	if uStr == "" {
+		return Result{Allowed: true}
+	}
+	windows := []struct {
+		window time.Duration
+		limit  int
+	}{
+		{time.Duration(s.conf.Hour) * time.Hour, s.conf.M},
+		{time.Duration(s.conf.Minutes) * time.Minute, s.conf.N},
+	}
+	var result Result
+	for i, w := range windows {
+		res := s.take(s.conf.Algorithm, limitKey(s.conf.Algorithm, uStr, s.conf.Path, w.window), w.window, w.limit)
+		if !res.Allowed {
+			klog.Infof("The key: %s:%s has been current limited", uStr, s.conf.Path)
+			return res
 		}
-		if err := s.MinuteRate(uStr, s.conf.Path, s.conf.Minutes, s.conf.N); err != nil {
-			return true
+		if i == 0 || res.Remaining < result.Remaining {
+			result = res
 		}
 	}
-
-	return false
+	return result
 }
 
 // MinuteRate
@@ -152,11 +306,8 @@ func (s *RateLimiter) RateLimiter() bool {
 //  @Param count 每minutes单位限流上限
 //  @Return err
 func (s *RateLimiter) MinuteRate(uniqueID, path string, minutes, count int) (err error) {
-	curMinutes := int(time.Now().Unix() / 60)
-	burst := curMinutes - curMinutes%minutes
-	key := MinuteKey(uniqueID, path, burst)
-	second := int64(minutes * 60)
-	return s.doRateLimit(key, uniqueID, second, count)
+	window := time.Duration(minutes) * time.Minute
+	return s.doRateLimit(limitKey(AlgorithmSlidingWindow, uniqueID, path, window), window, count)
 }
 
 // HourTotal
@@ -168,33 +319,39 @@ func (s *RateLimiter) MinuteRate(uniqueID, path string, minutes, count int) (err
 //  @Param count 每hour单位限流上限
 //  @Return err
 func (s *RateLimiter) HourTotal(uniqueID, path string, hour, count int) (err error) {
-	curHour := int(time.Now().Unix() / 3600)
-	burst := curHour - curHour%hour
-	key := hourKey(uniqueID, path, burst)
-	second := int64((curHour+hour)*3600) - time.Now().Unix()
-	return s.doRateLimit(key, uniqueID, second, count)
+	window := time.Duration(hour) * time.Hour
+	return s.doRateLimit(limitKey(AlgorithmSlidingWindow, uniqueID, path, window), window, count)
 }
 
-// antispam
-//  @Description: 限流redis操作
+// doRateLimit
+//  @Description: 按滑动窗口计数限流，计数与过期在同一个 lua 脚本中原子执行
 //  @Receiver s
 //  @Param key 限流 redis key
-//  @Param uniqueID 唯一值
-//  @Param interval 过期时间 单位秒
-//  @Param count 计数
-//  @Return err
-func (s *RateLimiter) doRateLimit(key, uniqueID string, expireTime int64, count int) (err error) {
-	curInt, err := s.Redis.IncrWithErr(key)
-	if err != nil {
-		klog.Errorf("doRateLimte redis incr key:%s err:%v", key, err)
-	}
-	if (curInt - 1) >= int64(count) {
+//  @Param window 窗口大小
+//  @Param count 窗口内上限
+//  @Return err 超过上限时返回限流错误
+func (s *RateLimiter) doRateLimit(key string, window time.Duration, count int) (err error) {
+	if !s.take(AlgorithmSlidingWindow, key, window, count).Allowed {
 		klog.Infof("The key: %s has been current limited", key)
 		return errs.NewError(ecode.CodeRateLimitError)
 	}
-	resExpire, err := s.Redis.Expire(key, time.Duration(expireTime)*time.Second)
-	if !resExpire || err != nil {
-		klog.Errorf("doRateLimte redis expire key:%s time:%v err:%v", key, expireTime, err)
-	}
 	return nil
 }
+
+// take 优先使用 redis 存储，未设置或出错时降级到进程内存储
+func (s *RateLimiter) take(algorithm, key string, window time.Duration, limit int) Result {
+	now := time.Now()
+	if s.store != nil {
+		res, err := s.store.take(algorithm, key, window, limit, now)
+		if err == nil {
+			return res
+		}
+		klog.Warn("RateLimiter redis unavailable, fallback to memory", klog.FieldErr(err), klog.FieldKey(key))
+	}
+	fallback := s.fallback
+	if fallback == nil {
+		fallback = defaultMemoryStore
+	}
+	res, _ := fallback.take(algorithm, key, window, limit, now)
+	return res
+}
@@ -0,0 +1,194 @@
+package ratelimiter
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore_SlidingLog(t *testing.T) {
+	s := newMemoryStore()
+	now := time.Unix(1600000000, 0)
+	for i := 0; i < 3; i++ {
+		res, _ := s.take(AlgorithmSlidingLog, "k", time.Minute, 3, now.Add(time.Duration(i)*time.Second))
+		assert.True(t, res.Allowed)
+		assert.Equal(t, 2-i, res.Remaining)
+	}
+	res, _ := s.take(AlgorithmSlidingLog, "k", time.Minute, 3, now.Add(10*time.Second))
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 50*time.Second, res.RetryAfter)
+
+	res, _ = s.take(AlgorithmSlidingLog, "k", time.Minute, 3, now.Add(time.Minute+time.Millisecond))
+	assert.True(t, res.Allowed)
+}
+
+func TestMemoryStore_SlidingWindow(t *testing.T) {
+	s := newMemoryStore()
+	// 窗口开始后 50s 用完额度
+	start := time.Unix(1600000020, 0).Truncate(time.Minute)
+	for i := 0; i < 10; i++ {
+		res, _ := s.take(AlgorithmSlidingWindow, "k", time.Minute, 10, start.Add(50*time.Second))
+		assert.True(t, res.Allowed)
+	}
+	// 跨过窗口边界，上一窗口按剩余占比加权，不会出现两倍突发
+	res, _ := s.take(AlgorithmSlidingWindow, "k", time.Minute, 10, start.Add(61*time.Second))
+	assert.True(t, res.Allowed)
+	res, _ = s.take(AlgorithmSlidingWindow, "k", time.Minute, 10, start.Add(61*time.Second))
+	assert.False(t, res.Allowed)
+	assert.True(t, res.RetryAfter > 0)
+
+	res, _ = s.take(AlgorithmSlidingWindow, "k", time.Minute, 10, start.Add(67*time.Second))
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+}
+
+func TestMemoryStore_TokenBucket(t *testing.T) {
+	s := newMemoryStore()
+	now := time.Unix(1600000000, 0)
+	for i := 0; i < 5; i++ {
+		res, _ := s.take(AlgorithmTokenBucket, "k", 5*time.Second, 5, now)
+		assert.True(t, res.Allowed)
+	}
+	res, _ := s.take(AlgorithmTokenBucket, "k", 5*time.Second, 5, now)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, time.Second, res.RetryAfter)
+
+	res, _ = s.take(AlgorithmTokenBucket, "k", 5*time.Second, 5, now.Add(time.Second))
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+}
+
+type fakeEvaler struct {
+	mu      sync.Mutex
+	scripts map[string]bool
+	evals   int
+	err     error
+}
+
+func (f *fakeEvaler) Eval(script string, keys []string, args ...interface{}) (interface{}, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.evals++
+	f.scripts[newScript(script).sha] = true
+	return []interface{}{int64(1), int64(4), int64(1000), int64(0)}, nil
+}
+
+func (f *fakeEvaler) EvalSha(sha1 string, keys []string, args ...interface{}) (interface{}, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	if !f.scripts[sha1] {
+		return nil, errors.New("NOSCRIPT No matching script. Please use EVAL.")
+	}
+	return []interface{}{int64(0), int64(0), int64(1000), int64(500)}, nil
+}
+
+func TestRedisStore(t *testing.T) {
+	ev := &fakeEvaler{scripts: map[string]bool{}}
+	s := newRedisStore(ev)
+
+	res, err := s.take(AlgorithmTokenBucket, "k", time.Second, 5, time.Now())
+	assert.Nil(t, err)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 4, res.Remaining)
+	assert.Equal(t, 1, ev.evals)
+
+	res, err = s.take(AlgorithmTokenBucket, "k", time.Second, 5, time.Now())
+	assert.Nil(t, err)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 500*time.Millisecond, res.RetryAfter)
+	assert.Equal(t, 1, ev.evals)
+}
+
+func TestRateLimiter_Fallback(t *testing.T) {
+	cfg := &Config{
+		Rule: map[string]*RLConfig{
+			"login": {On: true, Minutes: 1, N: 2, Hour: 1, M: 100, UniqueIDs: defIp},
+		},
+		Path: map[string][]string{"login": {"/login"}},
+	}
+	cfg.setRule()
+	rl := cfg.Build().WithCache(nil)
+	rl.store = newRedisStore(&fakeEvaler{err: errors.New("connection refused")})
+
+	assert.Equal(t, []string{"login"}, rl.Find("/login"))
+	assert.False(t, rl.Execute(nil, "login", "", "/login", "1.1.1.1").RateLimiter())
+	res := rl.Execute(nil, "login", "", "/login", "1.1.1.1").Take()
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+	assert.True(t, rl.Execute(nil, "login", "", "/login", "1.1.1.1").RateLimiter())
+	assert.False(t, rl.Execute(nil, "login", "", "/login", "2.2.2.2").RateLimiter())
+
+	// 规则不存在时放行
+	assert.False(t, rl.Execute(nil, "missing", "", "/login", "1.1.1.1").RateLimiter())
+}
+
+func TestRateLimiter_Concurrent(t *testing.T) {
+	cfg := &Config{
+		Rule: map[string]*RLConfig{
+			"api": {On: true, Minutes: 1, N: 60, Hour: 1, M: 100},
+		},
+	}
+	cfg.setRule()
+	rl := cfg.Build()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	limited := 0
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if rl.Execute(nil, "api", fmt.Sprintf("did%d", i%2), "/api", "").RateLimiter() {
+				mu.Lock()
+				limited++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, 0, limited)
+	rule, _ := cfg.getRule("api")
+	assert.Equal(t, "", rule.UDID)
+	assert.Equal(t, AlgorithmSlidingWindow, rule.Algorithm)
+}
+
+func TestRateLimiter_Tiers(t *testing.T) {
+	cfg := &Config{
+		Rule: map[string]*RLConfig{
+			"feed": {
+				On: true, Minutes: 1, N: 1, Hour: 1, M: 100,
+				Keys:  []string{"claim:userID"},
+				Tiers: map[string]Tier{"104": {N: 3}},
+			},
+		},
+	}
+	cfg.setRule()
+	rl := cfg.Build()
+
+	req := Request{UniqueID: "u1", Path: "/feed"}
+	assert.True(t, rl.ExecuteRequest("feed", req).Take().Allowed)
+	assert.False(t, rl.ExecuteRequest("feed", req).Take().Allowed)
+
+	req = Request{UniqueID: "u2", Path: "/feed", AppID: 104}
+	for i := 0; i < 3; i++ {
+		res := rl.ExecuteRequest("feed", req).Take()
+		assert.True(t, res.Allowed)
+		assert.Equal(t, 3, res.Limit)
+	}
+	res := rl.ExecuteRequest("feed", req).Take()
+	assert.False(t, res.Allowed)
+	assert.True(t, res.RetryAfter > 0)
+
+	rule, _ := rl.GetRule("feed")
+	assert.Equal(t, 1, rule.N)
+}
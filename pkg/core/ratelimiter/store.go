@@ -0,0 +1,220 @@
+// @Description 限流算法的 redis 实现
+
+package ratelimiter
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// AlgorithmSlidingLog 滑动日志，记录窗口内每次请求的时间，精确但占用内存与请求数成正比
+	AlgorithmSlidingLog = "slidingLog"
+	// AlgorithmSlidingWindow 滑动窗口计数，按上一窗口的剩余占比加权估算，内存占用固定
+	AlgorithmSlidingWindow = "slidingWindow"
+	// AlgorithmTokenBucket 令牌桶，容量为窗口上限，按 上限/窗口 的速率补充令牌，允许短时突发
+	AlgorithmTokenBucket = "tokenBucket"
+)
+
+func isAlgorithm(algorithm string) bool {
+	switch algorithm {
+	case AlgorithmSlidingLog, AlgorithmSlidingWindow, AlgorithmTokenBucket:
+		return true
+	}
+	return false
+}
+
+// store 限流计数存储
+type store interface {
+	// take 消耗一次额度
+	take(algorithm, key string, window time.Duration, limit int, now time.Time) (Result, error)
+}
+
+// Evaler 可执行 lua 脚本的 redis 客户端，storage/redis.Redis 以及缓存管理器的 redis、redisCluster 均已实现
+type Evaler interface {
+	Eval(script string, keys []string, args ...interface{}) (interface{}, error)
+	EvalSha(sha1 string, keys []string, args ...interface{}) (interface{}, error)
+}
+
+// script 优先通过 EVALSHA 执行，脚本未缓存时回退到 EVAL
+type script struct {
+	src string
+	sha string
+}
+
+func newScript(src string) *script {
+	h := sha1.Sum([]byte(src))
+	return &script{src: src, sha: hex.EncodeToString(h[:])}
+}
+
+func (s *script) run(ev Evaler, keys []string, args ...interface{}) (interface{}, error) {
+	res, err := ev.EvalSha(s.sha, keys, args...)
+	if err != nil && strings.HasPrefix(err.Error(), "NOSCRIPT") {
+		return ev.Eval(s.src, keys, args...)
+	}
+	return res, err
+}
+
+// 脚本统一参数 ARGV[1] 当前毫秒时间，ARGV[2] 窗口毫秒数，ARGV[3] 上限
+// 统一返回 {是否放行, 剩余额度, 额度完全恢复毫秒数, 下次可能放行毫秒数}
+var (
+	// slidingLogScript KEYS[1] 有序集合，ARGV[4] 本次请求的唯一成员
+	slidingLogScript = newScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+local count = redis.call('ZCARD', key)
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', key, now, ARGV[4])
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', key, window)
+local reset = 0
+local retry = 0
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+if oldest[2] then
+	retry = tonumber(oldest[2]) + window - now
+	local newest = redis.call('ZRANGE', key, -1, -1, 'WITHSCORES')
+	reset = tonumber(newest[2]) + window - now
+end
+if allowed == 1 then
+	retry = 0
+end
+return {allowed, limit - count, reset, retry}
+`)
+
+	// slidingWindowScript KEYS[1] 当前窗口计数，KEYS[2] 上一窗口计数
+	slidingWindowScript = newScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+local elapsed = now % window
+local prev = tonumber(redis.call('GET', KEYS[2]) or '0')
+local cur = tonumber(redis.call('GET', KEYS[1]) or '0')
+local count = math.floor(prev * (window - elapsed) / window) + cur
+local allowed = 0
+if count < limit then
+	redis.call('INCR', KEYS[1])
+	redis.call('PEXPIRE', KEYS[1], window * 2)
+	count = count + 1
+	allowed = 1
+end
+local retry = 0
+if allowed == 0 then
+	retry = window - elapsed
+	if cur < limit and prev > 0 then
+		retry = math.ceil((window - elapsed) - (limit - cur) * window / prev)
+		if retry < 1 then
+			retry = 1
+		end
+	end
+end
+return {allowed, limit - count, window * 2 - elapsed, retry}
+`)
+
+	// tokenBucketScript KEYS[1] 哈希，保存剩余令牌数与上次补充时间
+	tokenBucketScript = newScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local capacity = tonumber(ARGV[3])
+local data = redis.call('HMGET', key, 'tokens', 'ts')
+local tokens = tonumber(data[1])
+local ts = tonumber(data[2])
+if tokens == nil or ts == nil then
+	tokens = capacity
+	ts = now
+end
+if now > ts then
+	tokens = math.min(capacity, tokens + (now - ts) * capacity / window)
+end
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HMSET', key, 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', key, window)
+local retry = 0
+if allowed == 0 then
+	retry = math.ceil((1 - tokens) * window / capacity)
+end
+return {allowed, math.floor(tokens), math.ceil((capacity - tokens) * window / capacity), retry}
+`)
+)
+
+// redisStore 使用 lua 脚本保证计数与过期原子执行，所有 key 带相同 hash tag，可用于集群模式
+type redisStore struct {
+	ev  Evaler
+	seq uint64
+}
+
+func newRedisStore(ev Evaler) *redisStore {
+	return &redisStore{ev: ev}
+}
+
+// take ...
+func (s *redisStore) take(algorithm, key string, window time.Duration, limit int, now time.Time) (Result, error) {
+	nowMs := now.UnixNano() / int64(time.Millisecond)
+	windowMs := int64(window / time.Millisecond)
+	var (
+		res interface{}
+		err error
+	)
+	switch algorithm {
+	case AlgorithmSlidingLog:
+		member := strconv.FormatInt(nowMs, 10) + "-" + strconv.FormatUint(atomic.AddUint64(&s.seq, 1), 10)
+		res, err = slidingLogScript.run(s.ev, []string{key}, nowMs, windowMs, limit, member)
+	case AlgorithmTokenBucket:
+		res, err = tokenBucketScript.run(s.ev, []string{key}, nowMs, windowMs, limit)
+	default:
+		idx := nowMs / windowMs
+		keys := []string{key + ":" + strconv.FormatInt(idx, 10), key + ":" + strconv.FormatInt(idx-1, 10)}
+		res, err = slidingWindowScript.run(s.ev, keys, nowMs, windowMs, limit)
+	}
+	if err != nil {
+		return Result{}, err
+	}
+	return parseResult(res, limit)
+}
+
+func parseResult(res interface{}, limit int) (Result, error) {
+	values, ok := res.([]interface{})
+	if !ok || len(values) != 4 {
+		return Result{}, fmt.Errorf("ratelimiter: unexpected script result %v", res)
+	}
+	nums := make([]int64, len(values))
+	for i, v := range values {
+		n, ok := v.(int64)
+		if !ok {
+			return Result{}, fmt.Errorf("ratelimiter: unexpected script result %v", res)
+		}
+		nums[i] = n
+	}
+	return newResult(nums[0] == 1, limit, int(nums[1]), time.Duration(nums[2])*time.Millisecond, time.Duration(nums[3])*time.Millisecond), nil
+}
+
+func newResult(allowed bool, limit, remaining int, resetAfter, retryAfter time.Duration) Result {
+	if remaining < 0 {
+		remaining = 0
+	}
+	if allowed {
+		retryAfter = 0
+	}
+	return Result{
+		Allowed:    allowed,
+		Limit:      limit,
+		Remaining:  remaining,
+		ResetAfter: resetAfter,
+		RetryAfter: retryAfter,
+	}
+}
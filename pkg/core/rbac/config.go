@@ -0,0 +1,103 @@
+// @Description 权限控制配置
+
+package rbac
+
+import (
+	"context"
+	"time"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/conf"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/database"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/ecode"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
+
+	"github.com/pkg/errors"
+)
+
+//ModName ..
+const ModName = "core.rbac"
+
+// Config 配置
+type Config struct {
+	// Policy 配置中的策略
+	Policy `mapstructure:",squash"`
+	// Database 数据库配置 key，不为空时合并数据库中的策略
+	Database string
+	// Tables 数据库策略表名
+	Tables TableConfig
+	// Interval 重新加载数据库策略的间隔，默认 1m
+	Interval time.Duration
+	// CacheSize 鉴权结果缓存条数，默认 10000，小于 0 时不缓存
+	CacheSize int
+
+	key    string
+	logger *klog.Logger
+}
+
+// DefaultConfig ...
+func DefaultConfig() *Config {
+	return &Config{
+		Interval:  time.Minute,
+		CacheSize: 10000,
+		logger:    klog.KuaigoLogger.With(klog.FieldMod(ModName)),
+	}
+}
+
+// RawConfig ...
+func RawConfig(key string) *Config {
+	var config = DefaultConfig()
+	if err := conf.UnmarshalKey(key, &config); err != nil &&
+		errors.Cause(err) != conf.ErrInvalidKey {
+		config.logger.Panic("rbac parse config panic", klog.FieldErrKind(ecode.ErrKindUnmarshalConfigErr), klog.FieldErr(err), klog.FieldKey(key))
+	}
+	config.key = key
+	return config
+}
+
+// WithLogger ...
+func (config *Config) WithLogger(logger *klog.Logger) *Config {
+	config.logger = logger
+	return config
+}
+
+// Build
+//  @Description  创建鉴权器，配置了 Database 时按 Interval 重新加载数据库策略，由 RawConfig 创建时配置变更后重新加载
+//  @Receiver config
+//  @Return *Enforcer
+func (config *Config) Build() *Enforcer {
+	var loader Loader
+	if config.Database != "" {
+		db := database.GetDatabaseFactoryInstance().GetDatabase(context.Background(), config.Database)
+		loader = NewDatabaseLoader(db, config.Tables)
+	}
+	return config.BuildWithLoader(loader)
+}
+
+// BuildWithLoader
+//  @Description  使用指定的加载器创建鉴权器
+//  @Receiver config
+//  @Param loader 为空时只使用配置中的策略
+//  @Return *Enforcer
+func (config *Config) BuildWithLoader(loader Loader) *Enforcer {
+	e := newEnforcer(config, loader)
+	if err := e.Reload(context.Background()); err != nil {
+		config.logger.Panic("rbac load policy panic", klog.FieldErr(err), klog.FieldKey(config.key))
+	}
+	if loader != nil && config.Interval > 0 {
+		e.watch(config.Interval)
+	}
+	if config.key != "" {
+		conf.OnChange(func(c *conf.Configuration) {
+			next := DefaultConfig()
+			if err := c.UnmarshalKey(config.key, &next); err != nil {
+				config.logger.Warn("rbac reload config failed", klog.FieldErr(err), klog.FieldKey(config.key))
+				return
+			}
+			e.setStatic(next.Policy)
+			if err := e.Reload(context.Background()); err != nil {
+				config.logger.Warn("rbac reload policy failed", klog.FieldErr(err), klog.FieldKey(config.key))
+			}
+		})
+	}
+	return e
+}
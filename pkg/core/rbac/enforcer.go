@@ -0,0 +1,135 @@
+// @Description 鉴权器，缓存展开后的策略与鉴权结果，策略重新加载时清空缓存
+
+package rbac
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/kgo"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
+)
+
+// Enforcer 鉴权器
+type Enforcer struct {
+	config *Config
+	loader Loader
+
+	mu     sync.RWMutex
+	static Policy
+	policy *compiled
+	cache  map[string]bool
+
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+func newEnforcer(config *Config, loader Loader) *Enforcer {
+	return &Enforcer{
+		config: config,
+		loader: loader,
+		static: config.Policy,
+		policy: (&Policy{}).compile(),
+		cache:  map[string]bool{},
+		done:   make(chan struct{}),
+	}
+}
+
+func (e *Enforcer) setStatic(p Policy) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.static = p
+}
+
+// Reload
+//  @Description  重新加载策略，加载失败时保留原策略
+//  @Receiver e
+//  @Param ctx
+//  @Return error
+func (e *Enforcer) Reload(ctx context.Context) error {
+	var loaded *Policy
+	if e.loader != nil {
+		var err error
+		if loaded, err = e.loader.Load(ctx); err != nil {
+			return err
+		}
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	static := e.static
+	e.policy = merge(&static, loaded).compile()
+	e.cache = map[string]bool{}
+	return nil
+}
+
+// watch 定时重新加载
+func (e *Enforcer) watch(interval time.Duration) {
+	kgo.Go(func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-e.done:
+				return
+			case <-ticker.C:
+				if err := e.Reload(context.Background()); err != nil {
+					e.config.logger.Warn("rbac reload policy failed", klog.FieldErr(err), klog.FieldKey(e.config.key))
+				}
+			}
+		}
+	})
+}
+
+// Close
+//  @Description  停止定时加载
+//  @Receiver e
+func (e *Enforcer) Close() {
+	e.closeOnce.Do(func() {
+		close(e.done)
+	})
+}
+
+// Roles
+//  @Description  后台用户的角色
+//  @Receiver e
+//  @Param id 后台用户 id
+//  @Param name 后台用户登录名
+//  @Return []string
+func (e *Enforcer) Roles(id, name string) []string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.policy.rolesOf(id, name)
+}
+
+// Allow
+//  @Description  后台用户是否可访问资源，结果在策略重新加载前缓存
+//  @Receiver e
+//  @Param resource 资源，路由资源使用 Resource 生成
+//  @Param id 后台用户 id
+//  @Param name 后台用户登录名
+//  @Return bool
+func (e *Enforcer) Allow(resource, id, name string) bool {
+	key := resource + "\x00" + id + "\x00" + name
+	e.mu.RLock()
+	allowed, ok := e.cache[key]
+	policy := e.policy
+	e.mu.RUnlock()
+	if ok {
+		return allowed
+	}
+	allowed = policy.allow(policy.rolesOf(id, name), resource)
+	if e.config.CacheSize < 0 {
+		return allowed
+	}
+	e.mu.Lock()
+	// 策略已重新加载时不缓存旧结果
+	if e.policy == policy {
+		if len(e.cache) >= e.config.CacheSize {
+			e.cache = map[string]bool{}
+		}
+		e.cache[key] = allowed
+	}
+	e.mu.Unlock()
+	return allowed
+}
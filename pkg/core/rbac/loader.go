@@ -0,0 +1,169 @@
+// @Description 从数据库加载策略
+
+package rbac
+
+import (
+	"context"
+
+	dbconfig "github.com/LuoHongLiang0921/kuaigo/pkg/core/database/config"
+)
+
+// Loader 策略加载器，加载的策略与配置中的策略合并
+type Loader interface {
+	Load(ctx context.Context) (*Policy, error)
+}
+
+// LoaderFunc 函数形式的 Loader
+type LoaderFunc func(ctx context.Context) (*Policy, error)
+
+// Load ...
+func (f LoaderFunc) Load(ctx context.Context) (*Policy, error) {
+	return f(ctx)
+}
+
+// TableConfig 策略表名
+type TableConfig struct {
+	// Permission 权限表，字段 name、resource，默认 rbac_permission
+	Permission string
+	// RolePermission 角色权限表，字段 role、permission，permission 为权限名或资源规则，默认 rbac_role_permission
+	RolePermission string
+	// RoleInherit 角色继承表，字段 role、parent，默认 rbac_role_inherit
+	RoleInherit string
+	// AdminRole 后台用户角色表，字段 admin、role，admin 为 "id:" 加后台用户 id 或 "name:" 加登录名，默认 rbac_admin_role
+	AdminRole string
+}
+
+func (t *TableConfig) setDefault() {
+	if t.Permission == "" {
+		t.Permission = "rbac_permission"
+	}
+	if t.RolePermission == "" {
+		t.RolePermission = "rbac_role_permission"
+	}
+	if t.RoleInherit == "" {
+		t.RoleInherit = "rbac_role_inherit"
+	}
+	if t.AdminRole == "" {
+		t.AdminRole = "rbac_admin_role"
+	}
+}
+
+// table 实现 IModelConfig
+type table string
+
+// TableName ...
+func (t table) TableName() string {
+	return string(t)
+}
+
+// PrimaryKey ...
+func (t table) PrimaryKey() string {
+	return "id"
+}
+
+// CachePrefix ...
+func (t table) CachePrefix() string {
+	return ""
+}
+
+type databaseLoader struct {
+	db     dbconfig.IDatabase
+	tables TableConfig
+}
+
+// NewDatabaseLoader
+//  @Description  从数据库加载策略
+//  @Param db
+//  @Param tables 表名，为空的使用默认表名
+//  @Return Loader
+func NewDatabaseLoader(db dbconfig.IDatabase, tables TableConfig) Loader {
+	tables.setDefault()
+	return &databaseLoader{db: db, tables: tables}
+}
+
+// Load ...
+func (l *databaseLoader) Load(ctx context.Context) (*Policy, error) {
+	db := l.db.WithContext(ctx)
+	policy := &Policy{
+		Permissions: map[string][]string{},
+		Roles:       map[string]Role{},
+		Admins:      map[string][]string{},
+	}
+
+	var permissions []struct {
+		Name     string
+		Resource string
+	}
+	if err := db.Select(table(l.tables.Permission), &permissions, "SELECT name, resource FROM #TABLE#"); err != nil {
+		return nil, err
+	}
+	for _, p := range permissions {
+		policy.Permissions[p.Name] = append(policy.Permissions[p.Name], p.Resource)
+	}
+
+	var rolePermissions []struct {
+		Role       string
+		Permission string
+	}
+	if err := db.Select(table(l.tables.RolePermission), &rolePermissions, "SELECT role, permission FROM #TABLE#"); err != nil {
+		return nil, err
+	}
+	for _, rp := range rolePermissions {
+		role := policy.Roles[rp.Role]
+		role.Permissions = append(role.Permissions, rp.Permission)
+		policy.Roles[rp.Role] = role
+	}
+
+	var inherits []struct {
+		Role   string
+		Parent string
+	}
+	if err := db.Select(table(l.tables.RoleInherit), &inherits, "SELECT role, parent FROM #TABLE#"); err != nil {
+		return nil, err
+	}
+	for _, ri := range inherits {
+		role := policy.Roles[ri.Role]
+		role.Inherits = append(role.Inherits, ri.Parent)
+		policy.Roles[ri.Role] = role
+	}
+
+	var adminRoles []struct {
+		Admin string
+		Role  string
+	}
+	if err := db.Select(table(l.tables.AdminRole), &adminRoles, "SELECT admin, role FROM #TABLE#"); err != nil {
+		return nil, err
+	}
+	for _, ar := range adminRoles {
+		policy.Admins[ar.Admin] = append(policy.Admins[ar.Admin], ar.Role)
+	}
+	return policy, nil
+}
+
+// merge 合并策略，同名的权限、角色与后台用户的规则取并集
+func merge(policies ...*Policy) *Policy {
+	merged := &Policy{
+		Permissions: map[string][]string{},
+		Roles:       map[string]Role{},
+		Admins:      map[string][]string{},
+	}
+	for _, p := range policies {
+		if p == nil {
+			continue
+		}
+		for name, resources := range p.Permissions {
+			merged.Permissions[name] = append(merged.Permissions[name], resources...)
+		}
+		for name, role := range p.Roles {
+			r := merged.Roles[name]
+			r.Permissions = append(r.Permissions, role.Permissions...)
+			r.Inherits = append(r.Inherits, role.Inherits...)
+			merged.Roles[name] = r
+		}
+		for admin, roles := range p.Admins {
+			merged.Admins[admin] = append(merged.Admins[admin], roles...)
+		}
+		merged.Supers = append(merged.Supers, p.Supers...)
+	}
+	return merged
+}
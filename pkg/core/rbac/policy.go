@@ -0,0 +1,164 @@
+// @Description 角色、权限与资源匹配规则
+
+package rbac
+
+import (
+	"path"
+	"strings"
+)
+
+// 资源格式
+//  路由："METHOD /path"，path 为 gin 注册的路由，如 "GET /admin/user/:id"
+//  动作：不含空格的名称，如 "user:export"
+// 规则格式
+//  路由：method 为 * 时匹配所有方法，path 中 * 匹配一段，以 /** 结尾时匹配所有子路径
+//  动作：* 匹配任意字符，如 "user:*"
+const (
+	anyMethod  = "*"
+	anySubPath = "/**"
+)
+
+// 后台用户在 Policy.Admins 中的 key 格式，区分 id 与登录名，避免登录名与其他用户的 id 相同时继承其角色
+const (
+	// SubjectIDPrefix 后台用户 id 前缀，如 "id:1"
+	SubjectIDPrefix = "id:"
+	// SubjectNamePrefix 后台用户登录名前缀，如 "name:alice"
+	SubjectNamePrefix = "name:"
+)
+
+// Role 角色
+type Role struct {
+	// Permissions 权限名或资源规则
+	Permissions []string
+	// Inherits 继承的角色
+	Inherits []string
+}
+
+// Policy 策略
+type Policy struct {
+	// Permissions 权限名到资源规则的映射
+	Permissions map[string][]string
+	// Roles 角色名到角色的映射
+	Roles map[string]Role
+	// Admins 后台用户到角色的映射，key 为 "id:" 加用户 id 或 "name:" 加登录名，见 SubjectIDPrefix、SubjectNamePrefix
+	Admins map[string][]string
+	// Supers 超级管理员角色，可访问所有资源
+	Supers []string
+}
+
+// compiled 展开继承与权限名后的策略，请求间只读
+type compiled struct {
+	// roles 角色到资源规则
+	roles  map[string][]string
+	admins map[string][]string
+	supers map[string]bool
+}
+
+// compile 展开角色继承，循环继承时忽略重复的角色
+func (p *Policy) compile() *compiled {
+	c := &compiled{
+		roles:  make(map[string][]string, len(p.Roles)),
+		admins: p.Admins,
+		supers: make(map[string]bool, len(p.Supers)),
+	}
+	for _, name := range p.Supers {
+		c.supers[name] = true
+	}
+	for name := range p.Roles {
+		seen := map[string]bool{}
+		c.roles[name] = p.expand(name, seen, nil)
+	}
+	return c
+}
+
+func (p *Policy) expand(name string, seen map[string]bool, patterns []string) []string {
+	if seen[name] {
+		return patterns
+	}
+	seen[name] = true
+	role := p.Roles[name]
+	for _, perm := range role.Permissions {
+		if resources, ok := p.Permissions[perm]; ok {
+			patterns = append(patterns, resources...)
+		} else {
+			patterns = append(patterns, perm)
+		}
+	}
+	for _, parent := range role.Inherits {
+		patterns = p.expand(parent, seen, patterns)
+	}
+	return patterns
+}
+
+// rolesOf 后台用户的角色，id 与登录名按各自的前缀查找后合并
+func (c *compiled) rolesOf(id, name string) []string {
+	var roles []string
+	if id != "" {
+		roles = append(roles, c.admins[SubjectIDPrefix+id]...)
+	}
+	if name != "" {
+		roles = append(roles, c.admins[SubjectNamePrefix+name]...)
+	}
+	return roles
+}
+
+// allow 任一角色有匹配的规则时允许
+func (c *compiled) allow(roles []string, resource string) bool {
+	for _, role := range roles {
+		if c.supers[role] {
+			return true
+		}
+		for _, pattern := range c.roles[role] {
+			if Match(pattern, resource) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Resource
+//  @Description  路由资源名
+//  @Param method
+//  @Param route
+//  @Return string
+func Resource(method, route string) string {
+	return strings.ToUpper(method) + " " + route
+}
+
+// Match
+//  @Description  资源是否匹配规则
+//  @Param pattern 规则
+//  @Param resource 资源
+//  @Return bool
+func Match(pattern, resource string) bool {
+	pm, pp, pRoute := splitResource(pattern)
+	rm, rp, rRoute := splitResource(resource)
+	if pRoute != rRoute {
+		return false
+	}
+	if !pRoute {
+		ok, _ := path.Match(pattern, resource)
+		return ok
+	}
+	if pm != anyMethod && !strings.EqualFold(pm, rm) {
+		return false
+	}
+	if strings.HasSuffix(pp, anySubPath) {
+		prefix := strings.TrimSuffix(pp, anySubPath)
+		if rp == prefix || strings.HasPrefix(rp, prefix+"/") {
+			return true
+		}
+		return false
+	}
+	ok, _ := path.Match(pp, rp)
+	return ok
+}
+
+func splitResource(s string) (method, route string, isRoute bool) {
+	i := strings.IndexByte(s, ' ')
+	if i < 0 {
+		return "", s, false
+	}
+	return s[:i], strings.TrimSpace(s[i+1:]), true
+}
@@ -0,0 +1,63 @@
+package rbac
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch(t *testing.T) {
+	cases := []struct {
+		pattern, resource string
+		want              bool
+	}{
+		{"GET /admin/user/:id", "GET /admin/user/:id", true},
+		{"get /admin/user/*", "GET /admin/user/:id", true},
+		{"* /admin/user/*", "DELETE /admin/user/:id", true},
+		{"GET /admin/user/*", "POST /admin/user/:id", false},
+		{"GET /admin/user/*", "GET /admin/user/:id/roles", false},
+		{"* /admin/user/**", "GET /admin/user/:id/roles", true},
+		{"* /admin/user/**", "GET /admin/user", true},
+		{"* /admin/user/**", "GET /admin/users", false},
+		{"user:*", "user:export", true},
+		{"user:*", "GET /user:export", false},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.want, Match(c.pattern, c.resource), c.pattern+" => "+c.resource)
+	}
+}
+
+func TestEnforcer(t *testing.T) {
+	config := DefaultConfig()
+	config.Policy = Policy{
+		Permissions: map[string][]string{"user.read": {"GET /admin/user/**"}},
+		Roles: map[string]Role{
+			"viewer": {Permissions: []string{"user.read"}},
+			"editor": {Permissions: []string{"* /admin/user/**", "user:export"}, Inherits: []string{"viewer", "editor"}},
+		},
+		Admins: map[string][]string{"id:1": {"viewer"}, "name:root": {"admin"}, "id:9": {"admin"}},
+		Supers: []string{"admin"},
+	}
+	var db *Policy
+	e := config.BuildWithLoader(LoaderFunc(func(ctx context.Context) (*Policy, error) {
+		return db, nil
+	}))
+	defer e.Close()
+
+	assert.True(t, e.Allow(Resource("GET", "/admin/user/:id"), "1", "alice"))
+	assert.False(t, e.Allow(Resource("DELETE", "/admin/user/:id"), "1", "alice"))
+	assert.False(t, e.Allow("user:export", "1", "alice"))
+	assert.True(t, e.Allow("order:refund", "2", "root"))
+	assert.False(t, e.Allow(Resource("GET", "/admin/user/:id"), "3", ""))
+	// 登录名与其他用户的 id 相同时不继承其角色
+	assert.False(t, e.Allow("order:refund", "3", "9"))
+
+	// 数据库中给登录名授予角色，重新加载后生效
+	db = &Policy{Admins: map[string][]string{"name:alice": {"editor"}}}
+	assert.False(t, e.Allow("user:export", "1", "alice"))
+	assert.Nil(t, e.Reload(context.Background()))
+	assert.True(t, e.Allow("user:export", "1", "alice"))
+	assert.True(t, e.Allow(Resource("DELETE", "/admin/user/:id"), "1", "alice"))
+	assert.ElementsMatch(t, []string{"viewer", "editor"}, e.Roles("1", "alice"))
+}
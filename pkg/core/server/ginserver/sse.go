@@ -0,0 +1,37 @@
+package ginserver
+
+import (
+	"time"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/cache/config"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/server/khttp"
+)
+
+type (
+	//SSEEvent ..
+	SSEEvent = khttp.SSEEvent
+	//SSEConfig ..
+	SSEConfig = khttp.SSEConfig
+	//SSEStream ..
+	SSEStream = khttp.SSEStream
+	//ReplayBuffer ..
+	ReplayBuffer = khttp.ReplayBuffer
+)
+
+//ErrSSEClosed ..
+var ErrSSEClosed = khttp.ErrSSEClosed
+
+//SSE 见 khttp.SSE
+func SSE(c *TContext, config SSEConfig, fn func(s *SSEStream) error) error {
+	return khttp.SSE(c, config, fn)
+}
+
+//NewMemoryReplayBuffer ..
+func NewMemoryReplayBuffer(size int, ttl time.Duration) ReplayBuffer {
+	return khttp.NewMemoryReplayBuffer(size, ttl)
+}
+
+//NewCacheReplayBuffer ..
+func NewCacheReplayBuffer(cache config.IAdvanceCache, size int, ttl time.Duration) ReplayBuffer {
+	return khttp.NewCacheReplayBuffer(cache, size, ttl)
+}
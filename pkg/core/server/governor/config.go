@@ -5,6 +5,7 @@ import (
 	"github.com/LuoHongLiang0921/kuaigo/pkg/conf"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/util/knet"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/ktls"
 )
 
 //ModName ..
@@ -20,6 +21,10 @@ type Config struct {
 
 	// ServiceAddress service address in registry info, default to 'Host:Port'
 	ServiceAddress string
+	// TLS 证书配置，为空时使用明文 HTTP
+	TLS *ktls.Config
+	// H2C 未开启 TLS 时支持明文 HTTP/2
+	H2C bool
 }
 
 // StdConfig represents Standard gRPC Server config
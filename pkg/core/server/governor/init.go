@@ -3,6 +3,9 @@ package governor
 import (
 	"github.com/LuoHongLiang0921/kuaigo/pkg"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/conf"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
+	loghttp "github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/http"
+	logmq "github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/mq"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/util/kstring"
 	"net/http"
 	"os"
@@ -42,6 +45,81 @@ func init() {
 		_, _ = w.Write(kstring.PrettyJSONBytes(conf.Traverse(".")))
 	})
 
+	HandleFunc("/config/history", func(w http.ResponseWriter, r *http.Request) {
+		_ = jsoniter.NewEncoder(w).Encode(conf.History())
+	})
+
+	HandleFunc("/config/diff", func(w http.ResponseWriter, r *http.Request) {
+		from, err := strconv.ParseInt(r.URL.Query().Get("from"), 10, 64)
+		if err != nil {
+			http.Error(w, "invalid from revision", http.StatusBadRequest)
+			return
+		}
+		to, err := strconv.ParseInt(r.URL.Query().Get("to"), 10, 64)
+		if err != nil {
+			http.Error(w, "invalid to revision", http.StatusBadRequest)
+			return
+		}
+		diffs, err := conf.Diff(from, to)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		_ = jsoniter.NewEncoder(w).Encode(diffs)
+	})
+
+	HandleFunc("/config/rollback", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		revision, err := strconv.ParseInt(r.URL.Query().Get("revision"), 10, 64)
+		if err != nil {
+			http.Error(w, "invalid revision", http.StatusBadRequest)
+			return
+		}
+		if err := conf.Rollback(revision); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	HandleFunc("/debug/log/level", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		switch r.Method {
+		case http.MethodPost:
+			var ttl time.Duration
+			if v := query.Get("ttl"); v != "" {
+				d, err := time.ParseDuration(v)
+				if err != nil {
+					http.Error(w, "invalid ttl", http.StatusBadRequest)
+					return
+				}
+				ttl = d
+			}
+			if query.Get("mod") == "" {
+				http.Error(w, "mod required", http.StatusBadRequest)
+				return
+			}
+			if err := klog.SetModuleLevel(query.Get("mod"), query.Get("level"), ttl); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		case http.MethodDelete:
+			klog.ResetModuleLevel(query.Get("mod"))
+		}
+		_ = jsoniter.NewEncoder(w).Encode(klog.ModuleLevels())
+	})
+
+	HandleFunc("/debug/log/mq", func(w http.ResponseWriter, r *http.Request) {
+		_ = jsoniter.NewEncoder(w).Encode(logmq.GetStats())
+	})
+
+	HandleFunc("/debug/log/http", func(w http.ResponseWriter, r *http.Request) {
+		_ = jsoniter.NewEncoder(w).Encode(loghttp.GetStats())
+	})
+
 	HandleFunc("/debug/env", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(200)
 		_ = jsoniter.NewEncoder(w).Encode(os.Environ())
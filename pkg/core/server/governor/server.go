@@ -2,11 +2,16 @@ package governor
 
 import (
 	"context"
+	"crypto/tls"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/constant"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/core/server"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/knet"
 	"net"
 	"net/http"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 // Server ...
@@ -14,6 +19,8 @@ type Server struct {
 	*http.Server
 	listener net.Listener
 	*Config
+	// tlsConfig 开启 TLS 时不为空
+	tlsConfig *tls.Config
 }
 
 // newServer
@@ -21,24 +28,40 @@ type Server struct {
 //  @Param config 配置
 //  @Return *Server
 func newServer(config *Config) *Server {
-	var listener, err = net.Listen("tcp4", config.Address())
+	var listener, err = knet.Listen("tcp4", config.Address())
 	if err != nil {
 		klog.Panic("governor start error", klog.FieldErr(err))
 	}
+	var handler http.Handler = DefaultServeMux
+	var tlsConfig *tls.Config
+	if config.TLS.Enabled() {
+		if tlsConfig, err = config.TLS.Build(); err != nil {
+			klog.Panic("governor tls error", klog.FieldErr(err))
+		}
+	} else if config.H2C {
+		handler = h2c.NewHandler(handler, &http2.Server{})
+	}
 
 	return &Server{
 		Server: &http.Server{
-			Addr:    config.Address(),
-			Handler: DefaultServeMux,
+			Addr:      config.Address(),
+			Handler:   handler,
+			TLSConfig: tlsConfig,
 		},
-		listener: listener,
-		Config:   config,
+		listener:  listener,
+		Config:    config,
+		tlsConfig: tlsConfig,
 	}
 }
 
 //Serve ..
 func (s *Server) Serve() error {
-	err := s.Server.Serve(s.listener)
+	var err error
+	if s.tlsConfig != nil {
+		err = s.Server.ServeTLS(s.listener, "", "")
+	} else {
+		err = s.Server.Serve(s.listener)
+	}
 	if err == http.ErrServerClosed {
 		return nil
 	}
@@ -64,8 +87,12 @@ func (s *Server) Info() *server.ServiceInfo {
 		serviceAddr = s.Config.ServiceAddress
 	}
 
+	scheme := "http"
+	if s.tlsConfig != nil {
+		scheme = "https"
+	}
 	info := server.ApplyOptions(
-		server.WithScheme("http"),
+		server.WithScheme(scheme),
 		server.WithAddress(serviceAddr),
 		server.WithKind(constant.ServiceGovernor),
 	)
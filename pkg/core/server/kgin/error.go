@@ -1,6 +1,7 @@
 package kgin
 
 import (
+	"github.com/LuoHongLiang0921/kuaigo/pkg/errs"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -16,6 +17,8 @@ var (
 )
 
 // HTTPError wraps handler error.
+//
+// Deprecated: 使用 errs.Error，errors.As 可将 HTTPError 转为 errs.Error
 type HTTPError struct {
 	Code    int
 	Message string
@@ -36,6 +39,15 @@ func (e HTTPError) Error() string {
 	return e.Message
 }
 
+// As 转为 errs.Error，Code 同时作为业务码与 HTTP 状态码
+func (e HTTPError) As(target interface{}) bool {
+	if t, ok := target.(*errs.Error); ok {
+		*t = errs.New(e.Code, e.Message).WithStatus(e.Code)
+		return true
+	}
+	return false
+}
+
 // ErrNotFound defines StatusNotFound error.
 var ErrNotFound = HTTPError{
 	Code:    StatusNotFound,
@@ -7,6 +7,7 @@ import (
 	"github.com/LuoHongLiang0921/kuaigo/pkg/constant"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/flag"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/ktls"
 	"google.golang.org/grpc"
 )
 
@@ -19,6 +20,8 @@ type Config struct {
 	DisableMetric             bool
 	SlowQueryThresholdInMilli int64
 	ServiceAddress            string
+	// TLS 证书配置，为空时不加密
+	TLS                       *ktls.Config
 	serverOptions             []grpc.ServerOption
 	streamInterceptors        []grpc.StreamServerInterceptor
 	unaryInterceptors         []grpc.UnaryServerInterceptor
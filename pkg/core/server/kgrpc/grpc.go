@@ -12,6 +12,7 @@ import (
 	"net"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 // Server ...
@@ -26,15 +27,23 @@ type Server struct {
 //	@Param config
 // 	@Return *Server
 func NewServer(ctx context.Context, config *Config) *Server {
-	var streamInterceptors = append(
-		[]grpc.StreamServerInterceptor{defaultStreamServerInterceptor(ctx, config.logger, config.SlowQueryThresholdInMilli)},
-		config.streamInterceptors...,
-	)
+	var streamInterceptors = []grpc.StreamServerInterceptor{defaultStreamServerInterceptor(ctx, config.logger, config.SlowQueryThresholdInMilli)}
+	var unaryInterceptors = []grpc.UnaryServerInterceptor{defaultUnaryServerInterceptor(ctx, config.logger, config.SlowQueryThresholdInMilli)}
 
-	var unaryInterceptors = append(
-		[]grpc.UnaryServerInterceptor{defaultUnaryServerInterceptor(ctx, config.logger, config.SlowQueryThresholdInMilli)},
-		config.unaryInterceptors...,
-	)
+	if config.TLS.Enabled() {
+		tlsConfig, err := config.TLS.Build()
+		if err != nil {
+			config.logger.Panic("new grpc server tls err", klog.FieldErrKind(ecode.ErrKindUnmarshalConfigErr), klog.FieldErr(err))
+		}
+		config.serverOptions = append(config.serverOptions, grpc.Creds(credentials.NewTLS(tlsConfig)))
+		// 客户端身份先于自定义拦截器放入上下文
+		if config.TLS.VerifiesClient() {
+			streamInterceptors = append(streamInterceptors, identityStreamServerInterceptor())
+			unaryInterceptors = append(unaryInterceptors, identityUnaryServerInterceptor())
+		}
+	}
+	streamInterceptors = append(streamInterceptors, config.streamInterceptors...)
+	unaryInterceptors = append(unaryInterceptors, config.unaryInterceptors...)
 
 	config.serverOptions = append(config.serverOptions,
 		grpc.StreamInterceptor(StreamInterceptorChain(streamInterceptors...)),
@@ -42,7 +51,7 @@ func NewServer(ctx context.Context, config *Config) *Server {
 	)
 
 	newServer := grpc.NewServer(config.serverOptions...)
-	listener, err := net.Listen(config.Network, config.Address())
+	listener, err := knet.Listen(config.Network, config.Address())
 	if err != nil {
 		config.logger.Panic("new grpc server err", klog.FieldErrKind(ecode.ErrKindListenErr), klog.FieldErr(err))
 	}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/ktls"
 
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
 
@@ -146,3 +148,44 @@ func getPeer(ctx context.Context) map[string]string {
 	return peerMeta
 
 }
+
+// identityFromPeer 从 TLS 连接中提取校验通过的客户端身份
+func identityFromPeer(ctx context.Context) context.Context {
+	pr, ok := peer.FromContext(ctx)
+	if !ok {
+		return ctx
+	}
+	info, ok := pr.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return ctx
+	}
+	if id := ktls.FromConnectionState(&info.State); id != nil {
+		return ktls.NewContext(ctx, id)
+	}
+	return ctx
+}
+
+// identityUnaryServerInterceptor 将 mTLS 客户端身份放入上下文，通过 ktls.FromContext 获取
+func identityUnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(identityFromPeer(ctx), req)
+	}
+}
+
+// identityServerStream 覆盖 Context，携带客户端身份
+type identityServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context ...
+func (s *identityServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// identityStreamServerInterceptor 将 mTLS 客户端身份放入流的上下文
+func identityStreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &identityServerStream{ServerStream: stream, ctx: identityFromPeer(stream.Context())})
+	}
+}
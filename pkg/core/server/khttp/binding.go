@@ -0,0 +1,33 @@
+// @Description 请求参数校验
+
+package khttp
+
+import (
+	"sync"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/kvalidator"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+var (
+	setupValidatorOnce sync.Once
+	setupValidatorErr  error
+)
+
+// SetupValidator
+//  @Description  在 gin 的全局校验器上注册 kvalidator 的校验标签、字段名解析与错误翻译，
+//  之后 ShouldBind 等即可使用 binding:"mobile" 等标签，错误字段名改为 json、form 等标签中的名称。
+//  会修改全局校验器，需显式调用或开启 Config.Validator，重复调用只生效一次
+//  @Return error
+func SetupValidator() error {
+	setupValidatorOnce.Do(func() {
+		v, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			return
+		}
+		setupValidatorErr = kvalidator.Setup(v)
+	})
+	return setupValidatorErr
+}
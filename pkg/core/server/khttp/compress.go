@@ -0,0 +1,329 @@
+// @Description 响应压缩中间件
+
+package khttp
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// EncodingGzip gzip 压缩
+	EncodingGzip = "gzip"
+	// EncodingBrotli brotli 压缩
+	EncodingBrotli = "br"
+)
+
+// CompressConfig 响应压缩配置
+type CompressConfig struct {
+	// Enable 开启压缩
+	Enable bool
+	// Level 压缩级别，含义由压缩实现决定，gzip 为 -1~9，brotli 为 0~11，超出范围时 brotli 使用默认级别
+	Level int
+	// MinLength 响应体小于该长度时不压缩，单位字节
+	MinLength int
+	// Encodings 按优先级排列的压缩算法，默认 br、gzip，未注册的算法忽略
+	Encodings []string
+	// ExcludedPaths 不压缩的路径
+	ExcludedPaths []string
+}
+
+// DefaultCompressConfig ...
+func DefaultCompressConfig() CompressConfig {
+	return CompressConfig{
+		Level:     gzip.DefaultCompression,
+		MinLength: 1024,
+		Encodings: []string{EncodingBrotli, EncodingGzip},
+	}
+}
+
+// Compressor 创建压缩 writer，Close 时写出剩余数据
+type Compressor func(w io.Writer, level int) (io.WriteCloser, error)
+
+var (
+	compressorMu sync.RWMutex
+	compressors  = map[string]Compressor{
+		EncodingBrotli: brotliCompressor,
+		EncodingGzip:   gzipCompressor,
+	}
+)
+
+// RegisterCompressor
+//
+//	@Description  注册压缩算法，已注册的算法会被覆盖
+//	@Param encoding Content-Encoding 名称
+//	@Param c 压缩实现
+func RegisterCompressor(encoding string, c Compressor) {
+	compressorMu.Lock()
+	defer compressorMu.Unlock()
+	compressors[encoding] = c
+}
+
+func getCompressor(encoding string) (Compressor, bool) {
+	compressorMu.RLock()
+	defer compressorMu.RUnlock()
+	c, ok := compressors[encoding]
+	return c, ok
+}
+
+var gzipPools sync.Map
+
+type gzipWriter struct {
+	*gzip.Writer
+	pool *sync.Pool
+}
+
+// Close 写出剩余数据后放回对象池
+func (w *gzipWriter) Close() error {
+	err := w.Writer.Close()
+	w.pool.Put(w.Writer)
+	return err
+}
+
+func gzipCompressor(w io.Writer, level int) (io.WriteCloser, error) {
+	v, _ := gzipPools.LoadOrStore(level, &sync.Pool{})
+	pool := v.(*sync.Pool)
+	if gw, ok := pool.Get().(*gzip.Writer); ok {
+		gw.Reset(w)
+		return &gzipWriter{Writer: gw, pool: pool}, nil
+	}
+	gw, err := gzip.NewWriterLevel(w, level)
+	if err != nil {
+		return nil, err
+	}
+	return &gzipWriter{Writer: gw, pool: pool}, nil
+}
+
+var brotliPools sync.Map
+
+type brotliWriter struct {
+	*brotli.Writer
+	pool *sync.Pool
+}
+
+// Close 写出剩余数据后放回对象池
+func (w *brotliWriter) Close() error {
+	err := w.Writer.Close()
+	w.pool.Put(w.Writer)
+	return err
+}
+
+func brotliCompressor(w io.Writer, level int) (io.WriteCloser, error) {
+	if level < brotli.BestSpeed || level > brotli.BestCompression {
+		level = brotli.DefaultCompression
+	}
+	v, _ := brotliPools.LoadOrStore(level, &sync.Pool{})
+	pool := v.(*sync.Pool)
+	if bw, ok := pool.Get().(*brotli.Writer); ok {
+		bw.Reset(w)
+		return &brotliWriter{Writer: bw, pool: pool}, nil
+	}
+	return &brotliWriter{Writer: brotli.NewWriterLevel(w, level), pool: pool}, nil
+}
+
+// negotiateEncoding 按配置的优先级选择客户端接受的压缩算法
+func negotiateEncoding(acceptEncoding string, encodings []string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+	accepted := make(map[string]bool)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		part = strings.TrimSpace(part)
+		name, q := part, 1.0
+		if i := strings.Index(part, ";"); i >= 0 {
+			name = strings.TrimSpace(part[:i])
+			param := strings.TrimSpace(part[i+1:])
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		accepted[strings.ToLower(name)] = q > 0
+	}
+	for _, encoding := range encodings {
+		ok, found := accepted[encoding]
+		if !found {
+			ok = accepted["*"]
+		}
+		if !ok {
+			continue
+		}
+		if _, registered := getCompressor(encoding); registered {
+			return encoding
+		}
+	}
+	return ""
+}
+
+// incompressible 已压缩或流式的内容类型不再压缩
+func incompressible(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	if strings.HasPrefix(contentType, "image/svg") {
+		return false
+	}
+	for _, prefix := range []string{"image/", "video/", "audio/", "application/zip", "application/gzip",
+		"application/x-gzip", "application/x-brotli", "text/event-stream"} {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Compress
+//
+//	@Description  按 Accept-Encoding 压缩响应，响应体达到 MinLength 或处理函数主动 Flush 时开始压缩
+//	@Param config 配置
+//	@Return HandlerFunc
+func Compress(config CompressConfig) HandlerFunc {
+	encodings := config.Encodings
+	if len(encodings) == 0 {
+		encodings = DefaultCompressConfig().Encodings
+	}
+	excluded := make(map[string]struct{}, len(config.ExcludedPaths))
+	for _, path := range config.ExcludedPaths {
+		excluded[path] = struct{}{}
+	}
+	return func(c *TContext) {
+		if _, ok := excluded[c.Request.URL.Path]; ok || c.Request.Method == http.MethodHead ||
+			c.GetHeader("Upgrade") != "" {
+			c.Next()
+			return
+		}
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"), encodings)
+		if encoding == "" {
+			c.Next()
+			return
+		}
+		compressor, _ := getCompressor(encoding)
+		w := &compressWriter{
+			ResponseWriter: c.Writer,
+			encoding:       encoding,
+			compressor:     compressor,
+			level:          config.Level,
+			minLength:      config.MinLength,
+		}
+		c.Writer = w
+		defer func() {
+			w.close()
+			c.Writer = w.ResponseWriter
+		}()
+		c.Next()
+	}
+}
+
+// compressWriter 缓冲响应体直到可以决定是否压缩
+type compressWriter struct {
+	gin.ResponseWriter
+	encoding   string
+	compressor Compressor
+	level      int
+	minLength  int
+
+	buf       []byte
+	decided   bool
+	headerNow bool
+	cw        io.WriteCloser
+}
+
+// WriteHeaderNow 延迟到决定是否压缩之后再写出响应头
+func (w *compressWriter) WriteHeaderNow() {
+	if w.decided {
+		w.ResponseWriter.WriteHeaderNow()
+		return
+	}
+	w.headerNow = true
+}
+
+// Written ...
+func (w *compressWriter) Written() bool {
+	return w.decided || w.headerNow || len(w.buf) > 0 || w.ResponseWriter.Written()
+}
+
+// Write ...
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if w.decided {
+		return w.write(data)
+	}
+	w.buf = append(w.buf, data...)
+	if len(w.buf) >= w.minLength {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+// WriteString ...
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush 流式响应直接开始压缩
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		if err := w.decide(true); err != nil {
+			return
+		}
+	}
+	if f, ok := w.cw.(interface{ Flush() error }); ok {
+		_ = f.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *compressWriter) write(data []byte) (int, error) {
+	if w.cw != nil {
+		return w.cw.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// decide 决定是否压缩并写出已缓冲的数据
+func (w *compressWriter) decide(compress bool) error {
+	w.decided = true
+	h := w.Header()
+	status := w.Status()
+	if compress && h.Get("Content-Encoding") == "" && !incompressible(h.Get("Content-Type")) &&
+		status >= http.StatusOK && status != http.StatusNoContent && status != http.StatusNotModified {
+		if h.Get("Content-Type") == "" && len(w.buf) > 0 {
+			h.Set("Content-Type", http.DetectContentType(w.buf))
+		}
+		cw, err := w.compressor(w.ResponseWriter, w.level)
+		if err == nil {
+			h.Set("Content-Encoding", w.encoding)
+			h.Del("Content-Length")
+			w.cw = cw
+		}
+	}
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		if w.headerNow || w.cw != nil {
+			w.ResponseWriter.WriteHeaderNow()
+		}
+		return nil
+	}
+	_, err := w.write(buf)
+	return err
+}
+
+// close 响应结束，未达到压缩长度的数据原样写出
+func (w *compressWriter) close() {
+	if !w.decided {
+		_ = w.decide(false)
+	}
+	if w.cw != nil {
+		_ = w.cw.Close()
+		w.cw = nil
+	}
+}
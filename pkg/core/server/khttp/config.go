@@ -0,0 +1,188 @@
+// @Description http 服务配置，kgin 与 ginserver 共用
+
+package khttp
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/conf"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/ecode"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/kcolor"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/knet"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/ktls"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+//ModName ..
+const ModName = "server.http"
+
+// Config HTTP config
+type Config struct {
+	Host          string
+	Port          int
+	Deployment    string
+	Mode          string
+	DisableMetric bool
+	DisableTrace  bool
+	// ServiceAddress service address in registry info, default to 'Host:Port'
+	ServiceAddress string
+
+	SlowQueryThresholdInMilli int64
+
+	// AccessLog 访问日志，默认开启
+	AccessLog AccessLogConfig
+	// RequestID 请求 id，默认开启
+	RequestID RequestIDConfig
+	// CORS 跨域配置，为空时不处理跨域
+	CORS *CORSConfig
+	// Compress 响应压缩，默认关闭
+	Compress CompressConfig
+	// MaxBodySize 请求体大小上限，单位字节，0 不限制
+	MaxBodySize int64
+	// Timeout 请求处理超时时间，0 不限制
+	Timeout time.Duration
+	// Routes 按路由覆盖超时时间与请求体上限，key 为 "METHOD /path/:param" 或 "/path/:param"
+	Routes map[string]RouteConfig
+	// TrustedProxies 可信代理的 ip 或网段，来自可信代理的请求按 RemoteIPHeaders 从右向左取真实 ip
+	// 为空时沿用 gin 默认行为
+	TrustedProxies []string
+	// RemoteIPHeaders 携带客户端 ip 的请求头，默认 X-Forwarded-For、X-Real-IP
+	RemoteIPHeaders []string
+	// TLS 证书配置，为空时使用明文 HTTP
+	TLS *ktls.Config
+	// H2C 未开启 TLS 时支持明文 HTTP/2，用于内部服务调用
+	H2C bool
+	// Validator 构建时调用 SetupValidator 注册 kvalidator 校验标签，默认关闭
+	Validator bool
+
+	logger *klog.Logger
+}
+
+// AccessLogConfig 访问日志配置
+type AccessLogConfig struct {
+	// Disable 关闭访问日志
+	Disable bool
+	// SkipPaths 不记录访问日志的路径，如健康检查
+	SkipPaths []string
+}
+
+// RouteConfig 单个路由的配置，为 0 的字段沿用全局配置
+type RouteConfig struct {
+	// Timeout 请求处理超时时间
+	Timeout time.Duration
+	// MaxBodySize 请求体大小上限，单位字节
+	MaxBodySize int64
+}
+
+// DefaultConfig ...
+func DefaultConfig() *Config {
+	return &Config{
+		Host:                      "127.0.0.1",
+		Port:                      9091,
+		Mode:                      gin.ReleaseMode,
+		SlowQueryThresholdInMilli: 500, // 500ms
+		Compress:                  DefaultCompressConfig(),
+		logger:                    klog.KuaigoLogger.With(klog.FieldMod(ModName)),
+	}
+}
+
+// StdConfig Jupiter Standard HTTP Server config
+func StdConfig(name string) *Config {
+	return RawConfig("tabby.server." + name)
+}
+
+// RawConfig ...
+func RawConfig(key string) *Config {
+	var config = DefaultConfig()
+	if err := conf.UnmarshalKey(key, &config); err != nil &&
+		errors.Cause(err) != conf.ErrInvalidKey {
+		config.logger.Panic("http server parse config panic", klog.FieldErrKind(ecode.ErrKindUnmarshalConfigErr), klog.FieldErr(err), klog.FieldKey(key), klog.FieldValueAny(config))
+	}
+	return config
+}
+
+// WithLogger ...
+func (config *Config) WithLogger(logger *klog.Logger) *Config {
+	config.logger = logger
+	return config
+}
+
+// WithHost ...
+func (config *Config) WithHost(host string) *Config {
+	config.Host = host
+	return config
+}
+
+// WithPort ...
+func (config *Config) WithPort(port int) *Config {
+	config.Port = port
+	return config
+}
+
+func (config *Config) getContext() context.Context {
+	return context.TODO()
+}
+
+// Build
+//  @Description  构建并创建服务器实例，按配置依次挂载恢复与访问日志、请求 id、跨域、请求体上限、超时、压缩、监控中间件
+//  @Receiver config
+//  @Return *Server
+func (config *Config) Build() *Server {
+	if config.Validator {
+		if err := SetupValidator(); err != nil {
+			config.logger.Panic("http server setup validator panic", klog.FieldErr(err))
+		}
+	}
+	server := newServer(config)
+	server.engine.Use(recoverMiddleware(config.getContext(), config.logger, config.SlowQueryThresholdInMilli, config.AccessLog))
+	if server.tlsConfig != nil && config.TLS.VerifiesClient() {
+		server.engine.Use(clientIdentity())
+	}
+	if !config.RequestID.Disable {
+		server.engine.Use(RequestID(config.RequestID))
+	}
+	if config.CORS != nil {
+		server.engine.Use(CORS(*config.CORS))
+	}
+	if config.MaxBodySize > 0 || config.Timeout > 0 || len(config.Routes) > 0 {
+		server.engine.Use(routeLimitMiddleware(config))
+	}
+	if config.Compress.Enable {
+		server.engine.Use(Compress(config.Compress))
+	}
+
+	if !config.DisableMetric {
+		server.engine.Use(metricServerInterceptor())
+	}
+
+	fmt.Println(kcolor.Green("Web Server run at:"))
+	scheme := "http"
+	if server.tlsConfig != nil {
+		scheme = "https"
+	}
+	fmt.Printf("-  Local:   %s://localhost:%d/ \r\n", scheme, config.Port)
+	fmt.Printf("-  Network: %s://%s:%d/ \r\n", scheme, knet.LocalIP(), config.Port)
+	return server
+}
+
+// Address ...
+func (config *Config) Address() string {
+	return fmt.Sprintf("%s:%d", config.Host, config.Port)
+}
+
+// route 匹配路由配置，优先匹配带请求方法的 key
+func (config *Config) route(method, fullPath string) (RouteConfig, bool) {
+	if len(config.Routes) == 0 || fullPath == "" {
+		return RouteConfig{}, false
+	}
+	if rc, ok := config.Routes[method+" "+fullPath]; ok {
+		return rc, true
+	}
+	rc, ok := config.Routes[fullPath]
+	return rc, ok
+}
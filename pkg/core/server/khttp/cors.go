@@ -0,0 +1,115 @@
+// @Description 跨域中间件
+
+package khttp
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig 跨域配置
+type CORSConfig struct {
+	// AllowOrigins 允许的来源，支持 * 与 https://*.example.com 形式的子域通配
+	AllowOrigins []string
+	// AllowMethods 允许的请求方法，默认 GET、POST、PUT、PATCH、DELETE、HEAD、OPTIONS
+	AllowMethods []string
+	// AllowHeaders 允许的请求头，为空时回写预检请求的 Access-Control-Request-Headers
+	AllowHeaders []string
+	// ExposeHeaders 允许客户端读取的响应头
+	ExposeHeaders []string
+	// AllowCredentials 是否允许携带 cookie，开启后不会回写 *
+	AllowCredentials bool
+	// MaxAge 预检结果缓存时间
+	MaxAge time.Duration
+}
+
+var defaultCORSMethods = []string{
+	http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodDelete, http.MethodHead, http.MethodOptions,
+}
+
+// allowOrigin 判断来源是否允许
+func (config CORSConfig) allowOrigin(origin string) bool {
+	for _, o := range config.AllowOrigins {
+		if o == "*" || strings.EqualFold(o, origin) {
+			return true
+		}
+		if i := strings.Index(o, "*."); i >= 0 {
+			prefix, suffix := o[:i], o[i+1:]
+			if len(origin) > len(prefix)+len(suffix) &&
+				strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (config CORSConfig) allowAll() bool {
+	for _, o := range config.AllowOrigins {
+		if o == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// CORS
+//  @Description  跨域中间件，预检请求直接返回 204，不允许的来源不回写跨域响应头
+//  @Param config 配置
+//  @Return HandlerFunc
+func CORS(config CORSConfig) HandlerFunc {
+	methods := config.AllowMethods
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+	allowMethods := strings.Join(methods, ",")
+	allowHeaders := strings.Join(config.AllowHeaders, ",")
+	exposeHeaders := strings.Join(config.ExposeHeaders, ",")
+	maxAge := strconv.FormatInt(int64(config.MaxAge/time.Second), 10)
+	wildcard := config.allowAll() && !config.AllowCredentials
+
+	return func(c *TContext) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+		c.Writer.Header().Add("Vary", "Origin")
+		if !config.allowOrigin(origin) {
+			if c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != "" {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
+		if wildcard {
+			c.Header("Access-Control-Allow-Origin", "*")
+		} else {
+			c.Header("Access-Control-Allow-Origin", origin)
+		}
+		if config.AllowCredentials {
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+		if c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != "" {
+			c.Header("Access-Control-Allow-Methods", allowMethods)
+			if allowHeaders != "" {
+				c.Header("Access-Control-Allow-Headers", allowHeaders)
+			} else if reqHeaders := c.GetHeader("Access-Control-Request-Headers"); reqHeaders != "" {
+				c.Header("Access-Control-Allow-Headers", reqHeaders)
+			}
+			if config.MaxAge > 0 {
+				c.Header("Access-Control-Max-Age", maxAge)
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		if exposeHeaders != "" {
+			c.Header("Access-Control-Expose-Headers", exposeHeaders)
+		}
+		c.Next()
+	}
+}
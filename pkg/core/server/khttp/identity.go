@@ -0,0 +1,34 @@
+// @Description mTLS 客户端身份中间件
+
+package khttp
+
+import (
+	"github.com/LuoHongLiang0921/kuaigo/pkg/constant"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/ktls"
+)
+
+// clientIdentity
+//  @Description  将握手时校验通过的客户端证书身份放入上下文 constant.KeyClientIdentity 与请求 context
+//  @Return HandlerFunc
+func clientIdentity() HandlerFunc {
+	return func(c *TContext) {
+		if id := ktls.FromConnectionState(c.Request.TLS); id != nil {
+			c.Set(constant.KeyClientIdentity, id)
+			c.Request = c.Request.WithContext(ktls.NewContext(c.Request.Context(), id))
+		}
+		c.Next()
+	}
+}
+
+// GetClientIdentity
+//  @Description  获取 mTLS 校验通过的客户端身份
+//  @Param c
+//  @Return *ktls.Identity 未开启客户端证书校验或客户端未提供证书时为 nil
+func GetClientIdentity(c *TContext) *ktls.Identity {
+	if v, ok := c.Get(constant.KeyClientIdentity); ok {
+		if id, ok := v.(*ktls.Identity); ok {
+			return id
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+// @Description 请求体上限与超时中间件
+
+package khttp
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// routeLimitMiddleware
+//  @Description  按路由配置限制请求体大小与处理时间，未配置的路由使用全局配置
+//  @Param config 配置
+//  @Return HandlerFunc
+func routeLimitMiddleware(config *Config) HandlerFunc {
+	return func(c *TContext) {
+		maxBodySize, timeout := config.MaxBodySize, config.Timeout
+		if rc, ok := config.route(c.Request.Method, c.FullPath()); ok {
+			if rc.MaxBodySize > 0 {
+				maxBodySize = rc.MaxBodySize
+			}
+			if rc.Timeout > 0 {
+				timeout = rc.Timeout
+			}
+		}
+		if maxBodySize > 0 && !limitBody(c, maxBodySize) {
+			return
+		}
+		if timeout > 0 {
+			withTimeout(c, timeout)
+			return
+		}
+		c.Next()
+	}
+}
+
+// BodyLimit
+//  @Description  限制请求体大小，Content-Length 超过上限时直接返回 413，否则读取超过上限时报错
+//  @Param n 上限，单位字节
+//  @Return HandlerFunc
+func BodyLimit(n int64) HandlerFunc {
+	return func(c *TContext) {
+		if limitBody(c, n) {
+			c.Next()
+		}
+	}
+}
+
+func limitBody(c *TContext, n int64) bool {
+	if c.Request.ContentLength > n {
+		c.AbortWithStatus(http.StatusRequestEntityTooLarge)
+		return false
+	}
+	if c.Request.Body != nil {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, n)
+	}
+	return true
+}
+
+// Timeout
+//  @Description  为请求上下文设置超时，处理函数需使用 c.Request.Context() 感知超时；超时且未写响应时返回 504
+//  @Param d 超时时间
+//  @Return HandlerFunc
+func Timeout(d time.Duration) HandlerFunc {
+	return func(c *TContext) {
+		withTimeout(c, d)
+	}
+}
+
+func withTimeout(c *TContext, d time.Duration) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+	defer cancel()
+	c.Request = c.Request.WithContext(ctx)
+	c.Next()
+	if ctx.Err() == context.DeadlineExceeded && !c.Writer.Written() {
+		c.AbortWithStatus(http.StatusGatewayTimeout)
+	}
+}
@@ -1,11 +1,9 @@
-package kgin
+package khttp
 
 import (
 	"bytes"
 	"context"
 	"fmt"
-	"github.com/LuoHongLiang0921/kuaigo/pkg/metric"
-	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
 	"io/ioutil"
 	"net"
 	"net/http"
@@ -14,6 +12,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/LuoHongLiang0921/kuaigo/pkg/constant"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/metric"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
 
 	"github.com/gin-gonic/gin"
 
@@ -31,10 +32,21 @@ func extractAID(ctx *gin.Context) string {
 	return ctx.Request.Header.Get("AID")
 }
 
-func recoverMiddleware(ctx context.Context, logger *klog.Logger, slowQueryThresholdInMilli int64) gin.HandlerFunc {
+// recoverMiddleware
+//  @Description  恢复 panic 并记录访问日志，访问日志沿用业务中间件放入上下文的 klog.Common
+//  @Param ctx
+//  @Param logger panic 日志
+//  @Param slowQueryThresholdInMilli 慢请求阈值
+//  @Param accessLog 访问日志配置
+//  @Return gin.HandlerFunc
+func recoverMiddleware(ctx context.Context, logger *klog.Logger, slowQueryThresholdInMilli int64, accessLog AccessLogConfig) gin.HandlerFunc {
+	skipPaths := make(map[string]struct{}, len(accessLog.SkipPaths))
+	for _, path := range accessLog.SkipPaths {
+		skipPaths[path] = struct{}{}
+	}
 	return func(c *gin.Context) {
 		var beg = time.Now()
-		var fields = make([]klog.Field, 0, 8)
+		var fields = make([]klog.Field, 0, 12)
 		var brokenPipe bool
 		defer func() {
 			//Latency
@@ -52,36 +64,76 @@ func recoverMiddleware(ctx context.Context, logger *klog.Logger, slowQueryThresh
 						}
 					}
 				}
-				var err = rec.(error)
+				err, ok := rec.(error)
+				if !ok {
+					err = fmt.Errorf("%v", rec)
+				}
 				fields = append(fields, zap.ByteString("stack", stack(3)))
 				fields = append(fields, zap.String("err", err.Error()))
-				//logger.Error("access", fields...)
+				logger.Error("recover", fields...)
 				// If the connection is dead, we can't write a status to it.
 				if brokenPipe {
 					c.Error(err) // nolint: errcheck
 					c.Abort()
-					return
+				} else {
+					c.AbortWithStatus(http.StatusInternalServerError)
 				}
-				c.AbortWithStatus(http.StatusInternalServerError)
+			}
+			if accessLog.Disable {
+				return
+			}
+			if _, ok := skipPaths[c.Request.URL.Path]; ok {
 				return
 			}
-			// httpRequest, _ := httputil.DumpRequest(c.Request, false)
-			// fields = append(fields, zap.ByteString("request", httpRequest))
-			//fields = append(fields,
-			//	zap.String("method", c.Request.Method),
-			//	zap.Int("code", c.Writer.Status()),
-			//	zap.Int("size", c.Writer.Size()),
-			//	zap.String("host", c.Request.Host),
-			//	zap.String("path", c.Request.URL.Path),
-			//	zap.String("ip", c.ClientIP()),
-			//	zap.String("err", c.Errors.ByType(gin.ErrorTypePrivate).String()),
-			//)
-			//logger.Info(ctx, "access", fields...)
+			fields = append(fields,
+				zap.String("method", c.Request.Method),
+				zap.Int("status", c.Writer.Status()),
+				zap.Int("size", c.Writer.Size()),
+				zap.String("host", c.Request.Host),
+				zap.String("route", c.FullPath()),
+				zap.String("ua", c.Request.UserAgent()),
+			)
+			if errMsg := c.Errors.ByType(gin.ErrorTypePrivate).String(); errMsg != "" {
+				fields = append(fields, zap.String("err", errMsg))
+			}
+			klog.AccessLogger.WithContext(klog.WithCommonLog(ctx, accessCommon(c, beg))).Info("access", fields...)
 		}()
 		c.Next()
 	}
 }
 
+// accessCommon
+//  @Description  生成访问日志的 common，优先使用业务中间件放入上下文的 common，缺失的字段按请求补全
+//  @Param c
+//  @Param beg 请求开始时间
+//  @Return klog.Common
+func accessCommon(c *gin.Context, beg time.Time) klog.Common {
+	var com klog.Common
+	if v, ok := c.Get(constant.CommonKey); ok {
+		if vc, ok := v.(klog.Common); ok {
+			com = vc
+		}
+	}
+	if com.RequestIp == "" {
+		com.RequestIp = c.ClientIP()
+	}
+	if com.RequestUri == "" {
+		com.RequestUri = c.Request.RequestURI
+	}
+	if com.TraceId == "" {
+		com.TraceId = c.GetString(constant.KeyRequestID)
+	}
+	com.ProcessCode = klog.ProcessCodeResponse
+	com.CostTime = int64(time.Since(beg) / time.Millisecond)
+	com.Code = c.Writer.Status()
+	if code, ok := c.Get(constant.RespCode); ok {
+		if code, ok := code.(int); ok {
+			com.Code = code
+		}
+	}
+	return com
+}
+
 // stack returns a nicely formatted stack frame, skipping skip frames.
 func stack(skip int) []byte {
 	buf := new(bytes.Buffer) // the returned data
@@ -143,17 +195,11 @@ func function(pc uintptr) []byte {
 	return name
 }
 
-func timeFormat(t time.Time) string {
-	timeString := t.Format("2006/01/02 - 15:04:05")
-	return timeString
-}
-
 func metricServerInterceptor() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		beg := time.Now()
 		c.Next()
 		metric.ServerHandleHistogram.Observe(time.Since(beg).Seconds(), metric.TypeHTTP, c.Request.Method+"."+c.Request.URL.Path, extractAID(c))
 		metric.ServerHandleCounter.Inc(metric.TypeHTTP, c.Request.Method+"."+c.Request.URL.Path, extractAID(c), http.StatusText(c.Writer.Status()))
-		return
 	}
 }
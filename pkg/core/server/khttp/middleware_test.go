@@ -0,0 +1,214 @@
+package khttp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/constant"
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestEngine(config *Config, handlers ...HandlerFunc) *Engine {
+	gin.SetMode(gin.TestMode)
+	engine := newEngine(config)
+	engine.Use(handlers...)
+	return engine
+}
+
+func serve(engine *Engine, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestRequestID(t *testing.T) {
+	engine := newTestEngine(DefaultConfig(), RequestID(RequestIDConfig{}))
+	engine.GET("/id", func(c *TContext) {
+		c.String(http.StatusOK, GetRequestID(c))
+	})
+
+	w := serve(engine, httptest.NewRequest(http.MethodGet, "/id", nil))
+	assert.NotEmpty(t, w.Body.String())
+	assert.Equal(t, w.Body.String(), w.Header().Get(constant.HeaderRequestID))
+
+	req := httptest.NewRequest(http.MethodGet, "/id", nil)
+	req.Header.Set(constant.HeaderRequestID, "abc")
+	w = serve(engine, req)
+	assert.Equal(t, "abc", w.Body.String())
+}
+
+func TestCORS(t *testing.T) {
+	engine := newTestEngine(DefaultConfig(), CORS(CORSConfig{
+		AllowOrigins:     []string{"https://*.example.com"},
+		AllowCredentials: true,
+		MaxAge:           time.Hour,
+	}))
+	engine.GET("/api", func(c *TContext) { c.String(http.StatusOK, "ok") })
+
+	req := httptest.NewRequest(http.MethodOptions, "/api", nil)
+	req.Header.Set("Origin", "https://m.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	req.Header.Set("Access-Control-Request-Headers", "X-Token")
+	w := serve(engine, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://m.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "X-Token", w.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "3600", w.Header().Get("Access-Control-Max-Age"))
+
+	req = httptest.NewRequest(http.MethodGet, "/api", nil)
+	req.Header.Set("Origin", "https://evil.com")
+	w = serve(engine, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestRouteLimit(t *testing.T) {
+	config := DefaultConfig()
+	config.MaxBodySize = 8
+	config.Routes = map[string]RouteConfig{
+		"POST /upload": {MaxBodySize: 1024},
+		"/slow":        {Timeout: 10 * time.Millisecond},
+	}
+	engine := newTestEngine(config, routeLimitMiddleware(config))
+	echo := func(c *TContext) {
+		body, err := ioutil.ReadAll(c.Request.Body)
+		if err != nil {
+			c.String(http.StatusRequestEntityTooLarge, err.Error())
+			return
+		}
+		c.String(http.StatusOK, string(body))
+	}
+	engine.POST("/echo", echo)
+	engine.POST("/upload", echo)
+	engine.GET("/slow", func(c *TContext) {
+		<-c.Request.Context().Done()
+	})
+
+	w := serve(engine, httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader("0123456789")))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	w = serve(engine, httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("0123456789")))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0123456789", w.Body.String())
+
+	w = serve(engine, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+}
+
+func TestCompress(t *testing.T) {
+	config := DefaultCompressConfig()
+	config.MinLength = 16
+	engine := newTestEngine(DefaultConfig(), Compress(config))
+	large := strings.Repeat("kuaigo", 100)
+	engine.GET("/large", func(c *TContext) { c.String(http.StatusOK, large) })
+	engine.GET("/small", func(c *TContext) { c.String(http.StatusOK, "ok") })
+	engine.GET("/empty", func(c *TContext) { c.AbortWithStatus(http.StatusUnauthorized) })
+
+	req := httptest.NewRequest(http.MethodGet, "/large", nil)
+	req.Header.Set("Accept-Encoding", "br;q=0, gzip")
+	w := serve(engine, req)
+	assert.Equal(t, EncodingGzip, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+	r, err := gzip.NewReader(bytes.NewReader(w.Body.Bytes()))
+	assert.Nil(t, err)
+	body, _ := ioutil.ReadAll(r)
+	assert.Equal(t, large, string(body))
+
+	req = httptest.NewRequest(http.MethodGet, "/large", nil)
+	req.Header.Set("Accept-Encoding", "gzip, br")
+	w = serve(engine, req)
+	assert.Equal(t, EncodingBrotli, w.Header().Get("Content-Encoding"))
+	body, _ = ioutil.ReadAll(brotli.NewReader(bytes.NewReader(w.Body.Bytes())))
+	assert.Equal(t, large, string(body))
+
+	req = httptest.NewRequest(http.MethodGet, "/small", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w = serve(engine, req)
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "ok", w.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/empty", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w = serve(engine, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	encodings := []string{EncodingBrotli, EncodingGzip}
+	assert.Equal(t, EncodingBrotli, negotiateEncoding("gzip, deflate, br", encodings))
+	assert.Equal(t, EncodingGzip, negotiateEncoding("gzip, deflate", encodings))
+	assert.Equal(t, EncodingBrotli, negotiateEncoding("*", encodings))
+	assert.Equal(t, "", negotiateEncoding("gzip;q=0", encodings))
+	assert.Equal(t, "", negotiateEncoding("", encodings))
+}
+
+func TestTrustedProxies(t *testing.T) {
+	config := DefaultConfig()
+	config.TrustedProxies = []string{"10.0.0.0/8"}
+	engine := newTestEngine(config)
+	engine.GET("/ip", func(c *TContext) { c.String(http.StatusOK, c.ClientIP()) })
+
+	req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+	req.RemoteAddr = "10.0.0.2:1234"
+	req.Header.Set("X-Forwarded-For", "6.6.6.6, 1.2.3.4, 10.0.0.1")
+	assert.Equal(t, "1.2.3.4", serve(engine, req).Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/ip", nil)
+	req.RemoteAddr = "8.8.8.8:1234"
+	req.Header.Set("X-Forwarded-For", "1.2.3.4")
+	assert.Equal(t, "8.8.8.8", serve(engine, req).Body.String())
+}
+
+func TestAccessCommon(t *testing.T) {
+	engine := newTestEngine(DefaultConfig(), RequestID(RequestIDConfig{}))
+	engine.GET("/user/:id", func(c *TContext) {
+		c.Set(constant.RespCode, 1001)
+		com := accessCommon(c, time.Now())
+		assert.Equal(t, 1001, com.Code)
+		assert.Equal(t, "/user/1?a=b", com.RequestUri)
+		assert.Equal(t, GetRequestID(c), com.TraceId)
+		c.Status(http.StatusOK)
+	})
+	serve(engine, httptest.NewRequest(http.MethodGet, "/user/1?a=b", nil))
+}
+
+func TestRecoverMiddleware(t *testing.T) {
+	config := DefaultConfig()
+	engine := newTestEngine(config, recoverMiddleware(config.getContext(), config.logger, 0, AccessLogConfig{}))
+	engine.GET("/panic", func(c *TContext) { panic("boom") })
+
+	w := serve(engine, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestBindingTags(t *testing.T) {
+	type req struct {
+		Mobile string `form:"mobile" binding:"required,mobile"`
+	}
+	assert.Nil(t, SetupValidator())
+	engine := newTestEngine(DefaultConfig())
+	engine.GET("/bind", func(c *TContext) {
+		var r req
+		if err := c.ShouldBindQuery(&r); err != nil {
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+		c.String(http.StatusOK, r.Mobile)
+	})
+
+	w := serve(engine, httptest.NewRequest(http.MethodGet, "/bind?mobile=13312345678", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = serve(engine, httptest.NewRequest(http.MethodGet, "/bind?mobile=123", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "'mobile' tag")
+}
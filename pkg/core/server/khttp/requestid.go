@@ -0,0 +1,49 @@
+// @Description 请求 id 中间件
+
+package khttp
+
+import (
+	"github.com/LuoHongLiang0921/kuaigo/pkg/constant"
+
+	"github.com/pborman/uuid"
+)
+
+// maxRequestIDLen 透传请求 id 的最大长度，超过时重新生成
+const maxRequestIDLen = 128
+
+// RequestIDConfig 请求 id 配置
+type RequestIDConfig struct {
+	// Disable 关闭请求 id
+	Disable bool
+	// Header 读取与回写请求 id 的请求头，默认 X-Request-Id
+	Header string
+}
+
+// RequestID
+//  @Description  透传或生成请求 id，写入上下文 constant.KeyRequestID 与响应头，访问日志缺少 traceId 时使用
+//  @Param config 配置
+//  @Return HandlerFunc
+func RequestID(config RequestIDConfig) HandlerFunc {
+	header := config.Header
+	if header == "" {
+		header = constant.HeaderRequestID
+	}
+	return func(c *TContext) {
+		id := c.GetHeader(header)
+		if id == "" || len(id) > maxRequestIDLen {
+			id = uuid.New()
+			c.Request.Header.Set(header, id)
+		}
+		c.Set(constant.KeyRequestID, id)
+		c.Header(header, id)
+		c.Next()
+	}
+}
+
+// GetRequestID
+//  @Description  获取请求 id
+//  @Param c
+//  @Return string
+func GetRequestID(c *TContext) string {
+	return c.GetString(constant.KeyRequestID)
+}
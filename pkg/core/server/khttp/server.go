@@ -0,0 +1,187 @@
+package khttp
+
+import (
+	"context"
+	"crypto/tls"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/constant"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/server"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/ecode"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/knet"
+	"net/http"
+	"sync"
+
+	"net"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// Server ...
+type Server struct {
+	engine   *Engine
+	Server   *http.Server
+	config   *Config
+	listener net.Listener
+	// tlsConfig 开启 TLS 时不为空
+	tlsConfig *tls.Config
+	// shutdown 停止时关闭，通知 SSE 等长连接结束
+	shutdown     chan struct{}
+	shutdownOnce sync.Once
+}
+
+// newServer
+//  @Description  实例化gin server
+//  @Param config 配置
+//  @Return *Server
+func newServer(config *Config) *Server {
+	listener, err := knet.Listen("tcp", config.Address())
+	if err != nil {
+		config.logger.Panic("new http server err", klog.FieldErrKind(ecode.ErrKindListenErr), klog.FieldErr(err))
+	}
+	config.Port = listener.Addr().(*net.TCPAddr).Port
+	var tlsConfig *tls.Config
+	if config.TLS.Enabled() {
+		if tlsConfig, err = config.TLS.Build(); err != nil {
+			config.logger.Panic("new http server tls err", klog.FieldErrKind(ecode.ErrKindUnmarshalConfigErr), klog.FieldErr(err))
+		}
+	}
+	gin.SetMode(config.Mode)
+	return &Server{
+		engine:    newEngine(config),
+		config:    config,
+		listener:  listener,
+		tlsConfig: tlsConfig,
+		shutdown:  make(chan struct{}),
+	}
+}
+
+// newEngine
+//  @Description  实例化 gin engine，配置可信代理与客户端 ip 请求头，c.ClientIP() 据此取真实 ip
+//  @Param config 配置
+//  @Return *Engine
+func newEngine(config *Config) *Engine {
+	engine := gin.New()
+	if len(config.TrustedProxies) > 0 {
+		if err := engine.SetTrustedProxies(config.TrustedProxies); err != nil {
+			config.logger.Panic("http server trusted proxies invalid", klog.FieldErrKind(ecode.ErrKindUnmarshalConfigErr), klog.FieldErr(err), klog.FieldValueAny(config.TrustedProxies))
+		}
+	}
+	if len(config.RemoteIPHeaders) > 0 {
+		engine.RemoteIPHeaders = config.RemoteIPHeaders
+	}
+	return engine
+}
+
+// Engine
+//  @Description  获取底层 gin engine
+//  @Receiver s
+//  @Return *Engine
+func (s *Server) Engine() *Engine {
+	return s.engine
+}
+
+//Upgrade protocol to WebSocket
+//  @Description  注册 websocket 路由，handlers 在升级前执行，可用于鉴权
+func (s *Server) Upgrade(ws *WebSocket, handlers ...HandlerFunc) gin.IRoutes {
+	handlers = append(handlers, ws.upgrade)
+	return s.engine.GET(ws.Pattern, handlers...)
+}
+
+// Serve 实现serve接口
+//  @Description  启动server服务
+//  @Receiver s
+//  @Return error
+func (s *Server) Serve() error {
+	// s.Gin.StdLogger = xlog.TabbyLogger.StdLog()
+	for _, route := range s.engine.Routes() {
+		s.config.logger.Info("add route", klog.FieldMethod(route.Method), klog.String("path", route.Path))
+	}
+	s.Server = &http.Server{
+		Addr:    s.config.Address(),
+		Handler: s.handler(),
+		BaseContext: func(net.Listener) context.Context {
+			return context.WithValue(context.Background(), shutdownKey{}, s.shutdown)
+		},
+	}
+	var err error
+	if s.tlsConfig != nil {
+		// 证书由 tlsConfig.GetCertificate 提供，同时支持 HTTP/2
+		s.Server.TLSConfig = s.tlsConfig
+		err = s.Server.ServeTLS(s.listener, "", "")
+	} else {
+		err = s.Server.Serve(s.listener)
+	}
+	if err == http.ErrServerClosed {
+		s.config.logger.Info("close gin", klog.FieldAddr(s.config.Address()))
+		return nil
+	}
+
+	return err
+}
+
+// handler
+//  @Description  未开启 TLS 时按配置支持 h2c
+//  @Receiver s
+//  @Return http.Handler
+func (s *Server) handler() http.Handler {
+	if s.config.H2C && s.tlsConfig == nil {
+		return h2c.NewHandler(s.engine, &http2.Server{})
+	}
+	return s.engine
+}
+
+// Stop 实现stop接口
+//  @Description 立即终止gin服务器
+//  @Receiver s
+//  @Return error
+func (s *Server) Stop() error {
+	s.closeShutdown()
+	return s.Server.Close()
+}
+
+// GracefulStop 实现GracefulStop接口
+//  @Description  优雅停止gin服务器
+//  @Receiver s
+//  @Param ctx
+//  @Return error
+func (s *Server) GracefulStop(ctx context.Context) error {
+	// Shutdown 不会中断进行中的请求，先通知 SSE 等长连接结束
+	s.closeShutdown()
+	return s.Server.Shutdown(ctx)
+}
+
+func (s *Server) closeShutdown() {
+	s.shutdownOnce.Do(func() {
+		close(s.shutdown)
+	})
+}
+
+// Info
+//  @Description  初始化server信息
+//  @Receiver s
+//  @Return *server.ServiceInfo
+func (s *Server) Info() *server.ServiceInfo {
+	serviceAddr := s.listener.Addr().String()
+	if s.config.ServiceAddress != "" {
+		serviceAddr = s.config.ServiceAddress
+	}
+
+	scheme := "http"
+	if s.tlsConfig != nil {
+		scheme = "https"
+	}
+	info := server.ApplyOptions(
+		server.WithScheme(scheme),
+		server.WithAddress(serviceAddr),
+		server.WithKind(constant.ServiceProvider),
+	)
+	// info.Name = info.Name + "." + ModName
+	return &info
+}
+
+//SetLogger 设置日志
+func (s *Server) SetLogger(l *klog.Logger) {
+	s.config.logger = l
+}
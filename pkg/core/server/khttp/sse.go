@@ -0,0 +1,221 @@
+// @Description Server-Sent Events 推送
+
+package khttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-contrib/sse"
+)
+
+// HeaderLastEventID 客户端重连时携带的最后一个事件 id
+const HeaderLastEventID = "Last-Event-ID"
+
+// ErrSSEClosed 客户端已断开或服务正在停止
+var ErrSSEClosed = errors.New("khttp: sse stream closed")
+
+type shutdownKey struct{}
+
+// SSEEvent 事件
+type SSEEvent struct {
+	// ID 事件 id，配置了 ReplayBuffer 时由其分配
+	ID string
+	// Event 事件类型，为空时客户端按 message 处理
+	Event string
+	// Data 数据，非字符串时以 json 编码
+	Data interface{}
+}
+
+// SSEConfig 配置
+type SSEConfig struct {
+	// Stream 事件流名称，同一事件流的事件共享 id 序列与重放缓冲
+	Stream string
+	// Buffer 重放缓冲，客户端携带 Last-Event-ID 重连时补发之后的事件，为空时不补发
+	Buffer ReplayBuffer
+	// KeepAlive 发送保活注释的间隔，默认 15s，小于 0 时不发送
+	KeepAlive time.Duration
+	// Retry 建议客户端的重连间隔，0 时使用浏览器默认值
+	Retry time.Duration
+}
+
+// SSEStream 事件流连接
+type SSEStream struct {
+	c      *TContext
+	config SSEConfig
+
+	mu     sync.Mutex
+	closed chan struct{}
+	once   sync.Once
+}
+
+// SSE
+//  @Description  将请求转为事件流，补发 Last-Event-ID 之后的事件并定时发送保活注释，fn 返回后结束响应
+//  @Description  客户端断开或服务优雅停止时 Done 关闭，Send 返回 ErrSSEClosed，路由不应配置 Timeout
+//  @Param c
+//  @Param config 配置
+//  @Param fn 推送事件，应在 Done 关闭后尽快返回
+//  @Return error 补发事件或 fn 返回的错误
+func SSE(c *TContext, config SSEConfig, fn func(s *SSEStream) error) error {
+	s := &SSEStream{c: c, config: config, closed: make(chan struct{})}
+	header := c.Writer.Header()
+	header.Set("Content-Type", sse.ContentType)
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	// 关闭 nginx 缓冲
+	header.Set("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	stop := s.watch(c.Request.Context())
+	defer stop()
+
+	s.mu.Lock()
+	if config.Retry > 0 {
+		_, _ = c.Writer.WriteString("retry:" + strconv.FormatInt(int64(config.Retry/time.Millisecond), 10) + "\n\n")
+	}
+	c.Writer.Flush()
+	s.mu.Unlock()
+
+	if err := s.replay(); err != nil {
+		return err
+	}
+	return fn(s)
+}
+
+// LastEventID
+//  @Description  客户端重连时携带的最后一个事件 id，也支持 query 参数 lastEventId
+//  @Receiver s
+//  @Return string
+func (s *SSEStream) LastEventID() string {
+	if id := s.c.GetHeader(HeaderLastEventID); id != "" {
+		return id
+	}
+	return s.c.Query("lastEventId")
+}
+
+// Done
+//  @Description  客户端断开或服务停止时关闭
+//  @Receiver s
+//  @Return <-chan struct{}
+func (s *SSEStream) Done() <-chan struct{} {
+	return s.closed
+}
+
+// Send
+//  @Description  发送事件，配置了 Buffer 时先写入重放缓冲并分配 id
+//  @Receiver s
+//  @Param event
+//  @Return error 连接已关闭时返回 ErrSSEClosed
+func (s *SSEStream) Send(event SSEEvent) error {
+	select {
+	case <-s.closed:
+		return ErrSSEClosed
+	default:
+	}
+	if s.config.Buffer != nil {
+		if err := s.config.Buffer.Append(s.config.Stream, &event); err != nil {
+			return err
+		}
+	}
+	return s.write(event)
+}
+
+func (s *SSEStream) write(event SSEEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-s.closed:
+		return ErrSSEClosed
+	default:
+	}
+	err := sse.Encode(s.c.Writer, sse.Event{Id: event.ID, Event: event.Event, Data: event.Data})
+	if err != nil {
+		s.close()
+		return err
+	}
+	s.c.Writer.Flush()
+	return nil
+}
+
+// replay 补发 Last-Event-ID 之后的事件
+func (s *SSEStream) replay() error {
+	lastID := s.LastEventID()
+	if s.config.Buffer == nil || lastID == "" {
+		return nil
+	}
+	events, err := s.config.Buffer.Since(s.config.Stream, lastID)
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		if err := s.write(*event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// watch 监听客户端断开、服务停止并定时发送保活注释
+func (s *SSEStream) watch(ctx context.Context) func() {
+	shutdown, _ := ctx.Value(shutdownKey{}).(chan struct{})
+	keepAlive := s.config.KeepAlive
+	if keepAlive == 0 {
+		keepAlive = 15 * time.Second
+	}
+	var tick <-chan time.Time
+	var ticker *time.Ticker
+	if keepAlive > 0 {
+		ticker = time.NewTicker(keepAlive)
+		tick = ticker.C
+	}
+	finished := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				s.close()
+				return
+			case <-shutdown:
+				s.close()
+				return
+			case <-finished:
+				return
+			case <-tick:
+				s.comment("keepalive")
+			}
+		}
+	}()
+	return func() {
+		if ticker != nil {
+			ticker.Stop()
+		}
+		close(finished)
+		s.close()
+	}
+}
+
+// comment 发送注释，客户端会忽略
+func (s *SSEStream) comment(text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-s.closed:
+		return
+	default:
+	}
+	if _, err := s.c.Writer.WriteString(": " + text + "\n\n"); err != nil {
+		s.close()
+		return
+	}
+	s.c.Writer.Flush()
+}
+
+func (s *SSEStream) close() {
+	s.once.Do(func() {
+		close(s.closed)
+	})
+}
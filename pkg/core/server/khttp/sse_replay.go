@@ -0,0 +1,189 @@
+// @Description SSE 重放缓冲，保存最近的事件供客户端断线重连后补发
+
+package khttp
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/cache/config"
+)
+
+// ReplayBuffer 重放缓冲
+type ReplayBuffer interface {
+	// Append 保存事件并为其分配递增的 id
+	Append(stream string, event *SSEEvent) error
+	// Since 获取 lastID 之后的事件，按 id 升序
+	Since(stream, lastID string) ([]*SSEEvent, error)
+}
+
+type memoryStream struct {
+	seq    uint64
+	events []*SSEEvent
+	active time.Time
+}
+
+type memoryReplayBuffer struct {
+	size int
+	ttl  time.Duration
+
+	mu      sync.Mutex
+	streams map[string]*memoryStream
+	swept   time.Time
+}
+
+// NewMemoryReplayBuffer
+//  @Description  进程内重放缓冲，适用于单实例部署，多实例时客户端可能重连到其他实例
+//  @Param size 每个事件流保留的事件数，不大于 0 时不限制
+//  @Param ttl 事件流空闲超过该时间后被清理，0 时不清理
+//  @Return ReplayBuffer
+func NewMemoryReplayBuffer(size int, ttl time.Duration) ReplayBuffer {
+	return &memoryReplayBuffer{
+		size:    size,
+		ttl:     ttl,
+		streams: make(map[string]*memoryStream),
+		swept:   time.Now(),
+	}
+}
+
+// Append ...
+func (b *memoryReplayBuffer) Append(stream string, event *SSEEvent) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	b.sweep(now)
+	s, ok := b.streams[stream]
+	if !ok {
+		s = &memoryStream{}
+		b.streams[stream] = s
+	}
+	s.seq++
+	s.active = now
+	event.ID = strconv.FormatUint(s.seq, 10)
+	e := *event
+	s.events = append(s.events, &e)
+	if b.size > 0 && len(s.events) > b.size {
+		s.events = s.events[len(s.events)-b.size:]
+	}
+	return nil
+}
+
+// Since ...
+func (b *memoryReplayBuffer) Since(stream, lastID string) ([]*SSEEvent, error) {
+	last, err := strconv.ParseUint(lastID, 10, 64)
+	if err != nil {
+		return nil, nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s, ok := b.streams[stream]
+	if !ok {
+		return nil, nil
+	}
+	var events []*SSEEvent
+	for _, e := range s.events {
+		if id, _ := strconv.ParseUint(e.ID, 10, 64); id > last {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+// sweep 清理空闲的事件流，最多每 ttl 执行一次
+func (b *memoryReplayBuffer) sweep(now time.Time) {
+	if b.ttl <= 0 || now.Sub(b.swept) < b.ttl {
+		return
+	}
+	b.swept = now
+	for name, s := range b.streams {
+		if now.Sub(s.active) > b.ttl {
+			delete(b.streams, name)
+		}
+	}
+}
+
+// cacheReplayKeyPrefix 缓存 key 前缀，事件流名称作为 hash tag 保证集群下两个 key 在同一个 slot
+const cacheReplayKeyPrefix = "kuaigo:sse:"
+
+// appendScript 分配 id，写入有序集合并裁剪，size 不大于 0 时不裁剪，ttl 为 0 时不过期
+const appendScript = `
+local id = redis.call('INCR', KEYS[2])
+redis.call('ZADD', KEYS[1], id, id .. '\n' .. ARGV[1])
+local size = tonumber(ARGV[2])
+if size > 0 then
+	redis.call('ZREMRANGEBYRANK', KEYS[1], 0, -size - 1)
+end
+local ttl = tonumber(ARGV[3])
+if ttl > 0 then
+	redis.call('EXPIRE', KEYS[1], ttl)
+	redis.call('EXPIRE', KEYS[2], ttl)
+end
+return id
+`
+
+type cacheReplayBuffer struct {
+	cache config.IAdvanceCache
+	size  int
+	ttl   time.Duration
+}
+
+// NewCacheReplayBuffer
+//  @Description  基于 redis 有序集合的重放缓冲，多实例共享事件 id 序列，非字符串的 Data 以 json 保存
+//  @Param cache 缓存
+//  @Param size 每个事件流保留的事件数，不大于 0 时不限制
+//  @Param ttl 事件流空闲超过该时间后过期，0 时不过期，不足 1 秒按 1 秒
+//  @Return ReplayBuffer
+func NewCacheReplayBuffer(cache config.IAdvanceCache, size int, ttl time.Duration) ReplayBuffer {
+	return &cacheReplayBuffer{cache: cache, size: size, ttl: ttl}
+}
+
+func (b *cacheReplayBuffer) keys(stream string) []string {
+	prefix := cacheReplayKeyPrefix + "{" + stream + "}:"
+	return []string{prefix + "events", prefix + "seq"}
+}
+
+// Append ...
+func (b *cacheReplayBuffer) Append(stream string, event *SSEEvent) error {
+	data, ok := event.Data.(string)
+	if !ok {
+		raw, err := json.Marshal(event.Data)
+		if err != nil {
+			return err
+		}
+		data = string(raw)
+	}
+	var ttl int64
+	if b.ttl > 0 {
+		ttl = int64((b.ttl + time.Second - 1) / time.Second)
+	}
+	id, err := b.cache.Eval(appendScript, b.keys(stream), event.Event+"\n"+data, b.size, ttl)
+	if err != nil {
+		return err
+	}
+	event.ID = strconv.FormatInt(id.(int64), 10)
+	return nil
+}
+
+// Since ...
+func (b *cacheReplayBuffer) Since(stream, lastID string) ([]*SSEEvent, error) {
+	if _, err := strconv.ParseUint(lastID, 10, 64); err != nil {
+		return nil, nil
+	}
+	members, err := b.cache.ZRevRangeByScore(b.keys(stream)[0], config.ZRangeBy{Min: "(" + lastID, Max: "+inf"})
+	if err != nil && err != config.Nil {
+		return nil, err
+	}
+	events := make([]*SSEEvent, 0, len(members))
+	for i := len(members) - 1; i >= 0; i-- {
+		// id\nevent\ndata
+		parts := strings.SplitN(members[i], "\n", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		events = append(events, &SSEEvent{ID: parts[0], Event: parts[1], Data: parts[2]})
+	}
+	return events, nil
+}
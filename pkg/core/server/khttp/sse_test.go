@@ -0,0 +1,131 @@
+package khttp
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/cache/config"
+	"github.com/stretchr/testify/assert"
+)
+
+type evalCache struct {
+	config.IAdvanceCache
+	args []interface{}
+}
+
+func (c *evalCache) Eval(script string, keys []string, args ...interface{}) (interface{}, error) {
+	c.args = args
+	return int64(1), nil
+}
+
+func TestSSE_Replay(t *testing.T) {
+	buffer := NewMemoryReplayBuffer(2, time.Minute)
+	engine := newTestEngine(DefaultConfig())
+	engine.GET("/events", func(c *TContext) {
+		_ = SSE(c, SSEConfig{Stream: "s", Buffer: buffer, KeepAlive: -1, Retry: time.Second}, func(s *SSEStream) error {
+			for _, data := range strings.Split(c.Query("send"), ",") {
+				if err := s.Send(SSEEvent{Event: "msg", Data: data}); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+
+	w := serve(engine, httptest.NewRequest(http.MethodGet, "/events?send=a,b,c", nil))
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	assert.Equal(t, "retry:1000\n\nid:1\nevent:msg\ndata:a\n\nid:2\nevent:msg\ndata:b\n\nid:3\nevent:msg\ndata:c\n\n", w.Body.String())
+
+	// 缓冲只保留最近 2 个事件，补发 id 1 之后仍在缓冲中的事件
+	req := httptest.NewRequest(http.MethodGet, "/events?send=d", nil)
+	req.Header.Set(HeaderLastEventID, "1")
+	w = serve(engine, req)
+	assert.Equal(t, "retry:1000\n\nid:2\nevent:msg\ndata:b\n\nid:3\nevent:msg\ndata:c\n\nid:4\nevent:msg\ndata:d\n\n", w.Body.String())
+
+	w = serve(engine, httptest.NewRequest(http.MethodGet, "/events?send=e&lastEventId=4", nil))
+	assert.Equal(t, "retry:1000\n\nid:5\nevent:msg\ndata:e\n\n", w.Body.String())
+}
+
+func TestCacheReplayBuffer_Append(t *testing.T) {
+	cache := &evalCache{}
+	event := &SSEEvent{Event: "msg", Data: "a"}
+	assert.Nil(t, NewCacheReplayBuffer(cache, 0, 0).Append("s", event))
+	assert.Equal(t, "1", event.ID)
+	assert.Equal(t, []interface{}{"msg\na", 0, int64(0)}, cache.args)
+
+	assert.Nil(t, NewCacheReplayBuffer(cache, 10, 1500*time.Millisecond).Append("s", event))
+	assert.Equal(t, []interface{}{"msg\na", 10, int64(2)}, cache.args)
+}
+
+func TestMemoryReplayBuffer_Unbounded(t *testing.T) {
+	buffer := NewMemoryReplayBuffer(0, 0)
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, buffer.Append("s", &SSEEvent{Data: i}))
+	}
+	events, err := buffer.Since("s", "0")
+	assert.Nil(t, err)
+	assert.Len(t, events, 3)
+}
+
+func TestSSE_Stop(t *testing.T) {
+	server := DefaultConfig().WithPort(0).Build()
+	done := make(chan error, 2)
+	server.Engine().GET("/events", func(c *TContext) {
+		_ = SSE(c, SSEConfig{KeepAlive: 20 * time.Millisecond}, func(s *SSEStream) error {
+			<-s.Done()
+			done <- s.Send(SSEEvent{Data: "late"})
+			return nil
+		})
+	})
+	go func() { _ = server.Serve() }()
+	url := "http://127.0.0.1:" + strconv.Itoa(server.config.Port) + "/events"
+
+	get := func(ctx context.Context) *bufio.Reader {
+		var resp *http.Response
+		waitFor(t, func() bool {
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			var err error
+			resp, err = http.DefaultClient.Do(req)
+			return err == nil
+		})
+		reader := bufio.NewReader(resp.Body)
+		line, err := reader.ReadString('\n')
+		assert.Nil(t, err)
+		assert.Equal(t, ": keepalive\n", line)
+		return reader
+	}
+
+	// 客户端断开
+	ctx, cancel := context.WithCancel(context.Background())
+	get(ctx)
+	cancel()
+	assert.Equal(t, ErrSSEClosed, <-done)
+
+	// 优雅停止时结束事件流
+	reader := get(context.Background())
+	stopCtx, stopCancel := context.WithTimeout(context.Background(), time.Second)
+	defer stopCancel()
+	assert.Nil(t, server.GracefulStop(stopCtx))
+	assert.Equal(t, ErrSSEClosed, <-done)
+	for {
+		if _, err := reader.ReadString('\n'); err != nil {
+			break
+		}
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,100 @@
+package khttp
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+//WebSocketConn websocket conn, see websocket.Conn
+type WebSocketConn interface {
+	Subprotocol() string
+	Close() error
+	LocalAddr() net.Addr
+	RemoteAddr() net.Addr
+	WriteControl(messageType int, data []byte, deadline time.Time) error
+	NextWriter(messageType int) (io.WriteCloser, error)
+	WritePreparedMessage(pm *websocket.PreparedMessage) error
+	WriteMessage(messageType int, data []byte) error
+	SetWriteDeadline(t time.Time) error
+	NextReader() (messageType int, r io.Reader, err error)
+	ReadMessage() (messageType int, p []byte, err error)
+	SetReadDeadline(t time.Time) error
+	SetReadLimit(limit int64)
+	CloseHandler() func(code int, text string) error
+	SetCloseHandler(h func(code int, text string) error)
+	PingHandler() func(appData string) error
+	SetPingHandler(h func(appData string) error)
+	PongHandler() func(appData string) error
+	SetPongHandler(h func(appData string) error)
+	UnderlyingConn() net.Conn
+	EnableWriteCompression(enable bool)
+	SetCompressionLevel(level int) error
+}
+
+//WebSocketFunc ..
+type WebSocketFunc func(WebSocketConn, error)
+
+// WebSocketContextFunc 携带升级请求上下文的处理函数，可读取鉴权等中间件写入的信息
+type WebSocketContextFunc func(*TContext, WebSocketConn, error)
+
+//WebSocket ..
+type WebSocket struct {
+	Pattern string
+	Handler WebSocketFunc
+	// ContextHandler 不为空时代替 Handler
+	ContextHandler WebSocketContextFunc
+	*websocket.Upgrader
+	Header http.Header
+}
+
+//Upgrade get upgrage request
+func (ws *WebSocket) Upgrade(w http.ResponseWriter, r *http.Request) {
+	conn, err := ws.Upgrader.Upgrade(w, r, ws.Header)
+	if err == nil {
+		defer conn.Close()
+	}
+	ws.Handler(conn, err)
+}
+
+// upgrade 升级 gin 请求，连接在处理函数返回后关闭
+func (ws *WebSocket) upgrade(c *TContext) {
+	if ws.ContextHandler == nil {
+		ws.Upgrade(c.Writer, c.Request)
+		return
+	}
+	conn, err := ws.Upgrader.Upgrade(c.Writer, c.Request, ws.Header)
+	if err == nil {
+		defer conn.Close()
+	}
+	ws.ContextHandler(c, conn, err)
+}
+
+//WebSocketOption ..
+type WebSocketOption func(*WebSocket)
+
+// WithWebSocketContextHandler
+//  @Description  使用携带请求上下文的处理函数
+//  @Param handler
+//  @Return WebSocketOption
+func WithWebSocketContextHandler(handler WebSocketContextFunc) WebSocketOption {
+	return func(ws *WebSocket) {
+		ws.ContextHandler = handler
+	}
+}
+
+//WebSocketOptions ..
+func WebSocketOptions(pattern string, handler WebSocketFunc, opts ...WebSocketOption) *WebSocket {
+	ws := &WebSocket{
+		Pattern:  pattern,
+		Handler:  handler,
+		Upgrader: &websocket.Upgrader{},
+	}
+	for _, opt := range opts {
+		opt(ws)
+	}
+	return ws
+}
@@ -0,0 +1,55 @@
+// @Description 跨实例广播通道
+
+package wshub
+
+import (
+	"context"
+
+	"github.com/go-redis/redis"
+)
+
+// Broker 跨实例广播通道，每个实例发布的消息会投递给所有订阅的实例，包括自己
+type Broker interface {
+	// Publish 发布消息
+	Publish(ctx context.Context, channel string, payload []byte) error
+	// Subscribe 订阅频道，阻塞直到 ctx 结束
+	Subscribe(ctx context.Context, channel string, handler func(payload []byte)) error
+}
+
+type redisBroker struct {
+	client redis.UniversalClient
+}
+
+// NewRedisBroker
+//  @Description  基于 redis pub/sub 的广播通道，断线后由 go-redis 自动重新订阅
+//  @Param client redis.Client 或 redis.ClusterClient
+//  @Return Broker
+func NewRedisBroker(client redis.UniversalClient) Broker {
+	return &redisBroker{client: client}
+}
+
+// Publish ...
+func (b *redisBroker) Publish(ctx context.Context, channel string, payload []byte) error {
+	return b.client.Publish(channel, payload).Err()
+}
+
+// Subscribe ...
+func (b *redisBroker) Subscribe(ctx context.Context, channel string, handler func(payload []byte)) error {
+	pubsub := b.client.Subscribe(channel)
+	defer pubsub.Close()
+	if _, err := pubsub.Receive(); err != nil {
+		return err
+	}
+	ch := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			handler([]byte(msg.Payload))
+		}
+	}
+}
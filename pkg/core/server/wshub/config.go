@@ -0,0 +1,136 @@
+// @Description websocket 连接管理配置
+
+package wshub
+
+import (
+	"context"
+	"time"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/conf"
+	cacheconfig "github.com/LuoHongLiang0921/kuaigo/pkg/core/cache/config"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/ecode"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
+
+	"github.com/go-redis/redis"
+	"github.com/pkg/errors"
+)
+
+//ModName ..
+const ModName = "server.wshub"
+
+// Config 配置
+type Config struct {
+	// Name 名称，用于监控与默认广播频道
+	Name string
+	// SendQueueSize 每个连接的发送队列长度，队列满时断开该连接，默认 256
+	SendQueueSize int
+	// PingInterval 发送 ping 的间隔，默认 30s
+	PingInterval time.Duration
+	// IdleTimeout 超过该时间未收到消息或 pong 时断开连接，默认 75s
+	IdleTimeout time.Duration
+	// WriteTimeout 写超时，默认 10s
+	WriteTimeout time.Duration
+	// MaxMessageSize 读取消息的最大字节数，默认 64KB
+	MaxMessageSize int64
+	// Redis 跨实例广播使用的缓存配置 key，为空时只在本实例内投递
+	Redis string
+	// Channel 跨实例广播的 redis 频道，默认 wshub:{appName}:{Name}
+	Channel string
+
+	logger *klog.Logger
+}
+
+// DefaultConfig ...
+func DefaultConfig() *Config {
+	return &Config{
+		Name:           "default",
+		SendQueueSize:  256,
+		PingInterval:   30 * time.Second,
+		IdleTimeout:    75 * time.Second,
+		WriteTimeout:   10 * time.Second,
+		MaxMessageSize: 64 << 10,
+		logger:         klog.KuaigoLogger.With(klog.FieldMod(ModName)),
+	}
+}
+
+// StdConfig ...
+func StdConfig(name string) *Config {
+	config := RawConfig("tabby.wshub." + name)
+	if config.Name == "default" {
+		config.Name = name
+	}
+	return config
+}
+
+// RawConfig ...
+func RawConfig(key string) *Config {
+	var config = DefaultConfig()
+	if err := conf.UnmarshalKey(key, &config); err != nil &&
+		errors.Cause(err) != conf.ErrInvalidKey {
+		config.logger.Panic("wshub parse config panic", klog.FieldErrKind(ecode.ErrKindUnmarshalConfigErr), klog.FieldErr(err), klog.FieldKey(key), klog.FieldValueAny(config))
+	}
+	return config
+}
+
+// WithLogger ...
+func (config *Config) WithLogger(logger *klog.Logger) *Config {
+	config.logger = logger
+	return config
+}
+
+// Build
+//  @Description  构建 Hub，配置了 Redis 时使用 redis pub/sub 跨实例广播
+//  @Receiver config
+//  @Return *Hub
+func (config *Config) Build() *Hub {
+	var broker Broker
+	if config.Redis != "" {
+		broker = NewRedisBroker(newRedisClient(config.Redis))
+	}
+	return newHub(config, broker)
+}
+
+// BuildWithBroker
+//  @Description  使用自定义的广播通道构建 Hub
+//  @Receiver config
+//  @Param broker
+//  @Return *Hub
+func (config *Config) BuildWithBroker(broker Broker) *Hub {
+	return newHub(config, broker)
+}
+
+func (config *Config) channel() string {
+	if config.Channel != "" {
+		return config.Channel
+	}
+	return "wshub:" + pkg.GetAppName() + ":" + config.Name
+}
+
+// newRedisClient 按缓存配置创建订阅用的 redis 客户端
+func newRedisClient(key string) redis.UniversalClient {
+	cc := cacheconfig.GetConfig(context.Background(), key)
+	if cc.Type == "redisCluster" {
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:        cc.Addrs,
+			Password:     cc.Password,
+			MaxRetries:   cc.MaxRetries,
+			DialTimeout:  cc.DialTimeout,
+			ReadTimeout:  cc.ReadTimeout,
+			WriteTimeout: cc.WriteTimeout,
+			PoolSize:     cc.PoolSize,
+			IdleTimeout:  cc.IdleTimeout,
+		})
+	}
+	return redis.NewClient(&redis.Options{
+		Addr:         cc.Addr,
+		Password:     cc.Password,
+		DB:           cc.DB,
+		MaxRetries:   cc.MaxRetries,
+		DialTimeout:  cc.DialTimeout,
+		ReadTimeout:  cc.ReadTimeout,
+		WriteTimeout: cc.WriteTimeout,
+		PoolSize:     cc.PoolSize,
+		IdleTimeout:  cc.IdleTimeout,
+	})
+}
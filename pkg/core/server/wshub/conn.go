@@ -0,0 +1,184 @@
+// @Description 单个 websocket 连接
+
+package wshub
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/server/khttp"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
+
+	"github.com/gorilla/websocket"
+)
+
+var (
+	// ErrQueueFull 发送队列已满，连接会被断开
+	ErrQueueFull = errors.New("wshub: send queue full")
+	// ErrClosed 连接已关闭
+	ErrClosed = errors.New("wshub: connection closed")
+)
+
+type message struct {
+	messageType int
+	data        []byte
+}
+
+// Conn 由 Hub 管理的连接
+type Conn struct {
+	// ID 连接 id
+	ID string
+	// UserID 用户 id
+	UserID string
+	// DeviceID 设备 id，同一设备只保留最新的连接
+	DeviceID string
+
+	hub   *Hub
+	conn  khttp.WebSocketConn
+	send  chan message
+	rooms map[string]struct{}
+
+	closeOnce sync.Once
+	done      chan struct{}
+	// writerDone 写协程退出后关闭
+	writerDone chan struct{}
+}
+
+func newConn(hub *Hub, conn khttp.WebSocketConn, id, userID, deviceID string) *Conn {
+	return &Conn{
+		ID:         id,
+		UserID:     userID,
+		DeviceID:   deviceID,
+		hub:        hub,
+		conn:       conn,
+		send:       make(chan message, hub.config.SendQueueSize),
+		rooms:      make(map[string]struct{}),
+		done:       make(chan struct{}),
+		writerDone: make(chan struct{}),
+	}
+}
+
+// Send
+//  @Description  发送文本消息，只放入发送队列，不等待写入
+//  @Receiver c
+//  @Param data
+//  @Return error 队列已满时断开连接并返回 ErrQueueFull
+func (c *Conn) Send(data []byte) error {
+	return c.enqueue(message{messageType: websocket.TextMessage, data: data})
+}
+
+// SendBinary
+//  @Description  发送二进制消息，见 Send
+//  @Receiver c
+//  @Param data
+//  @Return error
+func (c *Conn) SendBinary(data []byte) error {
+	return c.enqueue(message{messageType: websocket.BinaryMessage, data: data})
+}
+
+func (c *Conn) enqueue(m message) error {
+	select {
+	case <-c.done:
+		return ErrClosed
+	default:
+	}
+	select {
+	case c.send <- m:
+		return nil
+	default:
+		c.hub.config.logger.Warn("wshub send queue full, close connection",
+			klog.String("user", c.UserID), klog.String("device", c.DeviceID), klog.Int("queue", cap(c.send)))
+		c.Close()
+		return ErrQueueFull
+	}
+}
+
+// Join
+//  @Description  加入房间
+//  @Receiver c
+//  @Param room
+func (c *Conn) Join(room string) {
+	c.hub.join(c, room)
+}
+
+// Leave
+//  @Description  离开房间
+//  @Receiver c
+//  @Param room
+func (c *Conn) Leave(room string) {
+	c.hub.leave(c, room)
+}
+
+// Rooms
+//  @Description  已加入的房间
+//  @Receiver c
+//  @Return []string
+func (c *Conn) Rooms() []string {
+	c.hub.mu.RLock()
+	defer c.hub.mu.RUnlock()
+	rooms := make([]string, 0, len(c.rooms))
+	for room := range c.rooms {
+		rooms = append(rooms, room)
+	}
+	return rooms
+}
+
+// Close
+//  @Description  关闭连接，发送 close 帧后断开
+//  @Receiver c
+func (c *Conn) Close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+}
+
+// readPump 读取消息直到出错或连接关闭，收到消息或 pong 时延长空闲超时
+func (c *Conn) readPump() {
+	config := c.hub.config
+	c.conn.SetReadLimit(config.MaxMessageSize)
+	_ = c.conn.SetReadDeadline(time.Now().Add(config.IdleTimeout))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(config.IdleTimeout))
+	})
+	for {
+		messageType, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		_ = c.conn.SetReadDeadline(time.Now().Add(config.IdleTimeout))
+		if c.hub.onMessage != nil {
+			c.hub.onMessage(c, messageType, data)
+		}
+	}
+}
+
+// writePump 串行写入消息与 ping，连接关闭时发送 close 帧并断开底层连接
+func (c *Conn) writePump() {
+	config := c.hub.config
+	ticker := time.NewTicker(config.PingInterval)
+	defer func() {
+		ticker.Stop()
+		_ = c.conn.Close()
+		close(c.writerDone)
+	}()
+	for {
+		select {
+		case m := <-c.send:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(config.WriteTimeout))
+			if err := c.conn.WriteMessage(m.messageType, m.data); err != nil {
+				c.Close()
+				return
+			}
+		case <-ticker.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(config.WriteTimeout)); err != nil {
+				c.Close()
+				return
+			}
+		case <-c.done:
+			_ = c.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(config.WriteTimeout))
+			return
+		}
+	}
+}
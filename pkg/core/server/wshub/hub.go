@@ -0,0 +1,370 @@
+// @Description websocket 连接管理，按用户、设备与房间投递消息，跨实例广播走 Broker
+
+package wshub
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/server/khttp"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/metric"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/kgo"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
+
+	"github.com/gorilla/websocket"
+	"github.com/pborman/uuid"
+)
+
+// 投递目标
+const (
+	targetAll    = "all"
+	targetUser   = "user"
+	targetDevice = "device"
+	targetRoom   = "room"
+)
+
+// IdentifyFunc 从升级请求中获取用户 id 与设备 id，通常读取鉴权中间件写入的信息
+type IdentifyFunc func(c *khttp.TContext) (userID, deviceID string)
+
+// envelope 跨实例广播的消息
+type envelope struct {
+	Node        string `json:"node"`
+	Target      string `json:"target"`
+	ID          string `json:"id,omitempty"`
+	MessageType int    `json:"type"`
+	Data        []byte `json:"data"`
+}
+
+// Hub 连接管理
+type Hub struct {
+	config *Config
+	broker Broker
+	// node 实例 id，忽略自己发布的广播
+	node string
+
+	mu      sync.RWMutex
+	conns   map[*Conn]struct{}
+	users   map[string]map[*Conn]struct{}
+	devices map[string]*Conn
+	rooms   map[string]map[*Conn]struct{}
+
+	onConnect    func(*Conn)
+	onDisconnect func(*Conn)
+	onMessage    func(c *Conn, messageType int, data []byte)
+
+	cancel context.CancelFunc
+}
+
+func newHub(config *Config, broker Broker) *Hub {
+	ctx, cancel := context.WithCancel(context.Background())
+	h := &Hub{
+		config:  config,
+		broker:  broker,
+		node:    uuid.New(),
+		conns:   make(map[*Conn]struct{}),
+		users:   make(map[string]map[*Conn]struct{}),
+		devices: make(map[string]*Conn),
+		rooms:   make(map[string]map[*Conn]struct{}),
+		cancel:  cancel,
+	}
+	if broker != nil {
+		kgo.SafeGo(func() {
+			if err := broker.Subscribe(ctx, config.channel(), h.receive); err != nil {
+				config.logger.Error("wshub subscribe failed", klog.FieldErr(err), klog.String("channel", config.channel()))
+			}
+		}, func(err error) {
+			config.logger.Error("wshub subscribe panic", klog.FieldErr(err))
+		})
+	}
+	return h
+}
+
+// OnConnect
+//  @Description  连接注册后回调
+//  @Receiver h
+//  @Param fn
+func (h *Hub) OnConnect(fn func(*Conn)) {
+	h.onConnect = fn
+}
+
+// OnDisconnect
+//  @Description  连接断开后回调
+//  @Receiver h
+//  @Param fn
+func (h *Hub) OnDisconnect(fn func(*Conn)) {
+	h.onDisconnect = fn
+}
+
+// OnMessage
+//  @Description  收到客户端消息时回调，在连接的读协程中执行
+//  @Receiver h
+//  @Param fn
+func (h *Hub) OnMessage(fn func(c *Conn, messageType int, data []byte)) {
+	h.onMessage = fn
+}
+
+// WebSocket
+//  @Description  创建由 Hub 管理连接的 websocket 路由，使用 server.Upgrade 注册
+//  @Receiver h
+//  @Param pattern 路由
+//  @Param identify 获取用户与设备 id
+//  @Param opts
+//  @Return *khttp.WebSocket
+func (h *Hub) WebSocket(pattern string, identify IdentifyFunc, opts ...khttp.WebSocketOption) *khttp.WebSocket {
+	opts = append([]khttp.WebSocketOption{khttp.WithWebSocketContextHandler(func(c *khttp.TContext, conn khttp.WebSocketConn, err error) {
+		if err != nil {
+			h.config.logger.Warn("wshub upgrade failed", klog.FieldErr(err))
+			return
+		}
+		userID, deviceID := identify(c)
+		h.Serve(conn, userID, deviceID)
+	})}, opts...)
+	return khttp.WebSocketOptions(pattern, nil, opts...)
+}
+
+// Serve
+//  @Description  管理已升级的连接，阻塞直到连接断开
+//  @Receiver h
+//  @Param conn
+//  @Param userID 用户 id，为空时不能按用户投递
+//  @Param deviceID 设备 id，同一设备的旧连接会被断开
+func (h *Hub) Serve(conn khttp.WebSocketConn, userID, deviceID string) {
+	c := newConn(h, conn, uuid.New(), userID, deviceID)
+	h.register(c)
+	metric.ServerConnectionGauge.Inc(metric.TypeWebsocket, h.config.Name)
+	if h.onConnect != nil {
+		h.onConnect(c)
+	}
+
+	go c.writePump()
+	c.readPump()
+	c.Close()
+	<-c.writerDone
+
+	h.unregister(c)
+	metric.ServerConnectionGauge.Add(-1, metric.TypeWebsocket, h.config.Name)
+	if h.onDisconnect != nil {
+		h.onDisconnect(c)
+	}
+}
+
+func (h *Hub) register(c *Conn) {
+	h.mu.Lock()
+	h.conns[c] = struct{}{}
+	if c.UserID != "" {
+		addConn(h.users, c.UserID, c)
+	}
+	var old *Conn
+	if c.DeviceID != "" {
+		old = h.devices[c.DeviceID]
+		h.devices[c.DeviceID] = c
+	}
+	h.mu.Unlock()
+	if old != nil {
+		old.Close()
+	}
+}
+
+func (h *Hub) unregister(c *Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.conns, c)
+	if c.UserID != "" {
+		removeConn(h.users, c.UserID, c)
+	}
+	if c.DeviceID != "" && h.devices[c.DeviceID] == c {
+		delete(h.devices, c.DeviceID)
+	}
+	for room := range c.rooms {
+		removeConn(h.rooms, room, c)
+	}
+}
+
+func (h *Hub) join(c *Conn, room string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.conns[c]; !ok {
+		return
+	}
+	c.rooms[room] = struct{}{}
+	addConn(h.rooms, room, c)
+}
+
+func (h *Hub) leave(c *Conn, room string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(c.rooms, room)
+	removeConn(h.rooms, room, c)
+}
+
+func addConn(index map[string]map[*Conn]struct{}, key string, c *Conn) {
+	set, ok := index[key]
+	if !ok {
+		set = make(map[*Conn]struct{})
+		index[key] = set
+	}
+	set[c] = struct{}{}
+}
+
+func removeConn(index map[string]map[*Conn]struct{}, key string, c *Conn) {
+	if set, ok := index[key]; ok {
+		delete(set, c)
+		if len(set) == 0 {
+			delete(index, key)
+		}
+	}
+}
+
+// Count
+//  @Description  本实例的连接数
+//  @Receiver h
+//  @Return int
+func (h *Hub) Count() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.conns)
+}
+
+// UserConns
+//  @Description  本实例中用户的连接
+//  @Receiver h
+//  @Param userID
+//  @Return []*Conn
+func (h *Hub) UserConns(userID string) []*Conn {
+	return h.targets(targetUser, userID)
+}
+
+// RoomConns
+//  @Description  本实例中房间内的连接
+//  @Receiver h
+//  @Param room
+//  @Return []*Conn
+func (h *Hub) RoomConns(room string) []*Conn {
+	return h.targets(targetRoom, room)
+}
+
+// DeviceConn
+//  @Description  本实例中设备的连接
+//  @Receiver h
+//  @Param deviceID
+//  @Return *Conn
+//  @Return bool
+func (h *Hub) DeviceConn(deviceID string) (*Conn, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	c, ok := h.devices[deviceID]
+	return c, ok
+}
+
+func (h *Hub) targets(target, id string) []*Conn {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	var set map[*Conn]struct{}
+	switch target {
+	case targetAll:
+		set = h.conns
+	case targetUser:
+		set = h.users[id]
+	case targetRoom:
+		set = h.rooms[id]
+	case targetDevice:
+		if c, ok := h.devices[id]; ok {
+			return []*Conn{c}
+		}
+		return nil
+	}
+	conns := make([]*Conn, 0, len(set))
+	for c := range set {
+		conns = append(conns, c)
+	}
+	return conns
+}
+
+// SendToUser
+//  @Description  向用户的所有连接发送文本消息，包括其他实例上的连接
+//  @Receiver h
+//  @Param ctx
+//  @Param userID
+//  @Param data
+//  @Return error 跨实例广播失败
+func (h *Hub) SendToUser(ctx context.Context, userID string, data []byte) error {
+	return h.dispatch(ctx, targetUser, userID, websocket.TextMessage, data)
+}
+
+// SendToDevice
+//  @Description  向设备发送文本消息，见 SendToUser
+//  @Receiver h
+//  @Param ctx
+//  @Param deviceID
+//  @Param data
+//  @Return error
+func (h *Hub) SendToDevice(ctx context.Context, deviceID string, data []byte) error {
+	return h.dispatch(ctx, targetDevice, deviceID, websocket.TextMessage, data)
+}
+
+// SendToRoom
+//  @Description  向房间内的所有连接发送文本消息，见 SendToUser
+//  @Receiver h
+//  @Param ctx
+//  @Param room
+//  @Param data
+//  @Return error
+func (h *Hub) SendToRoom(ctx context.Context, room string, data []byte) error {
+	return h.dispatch(ctx, targetRoom, room, websocket.TextMessage, data)
+}
+
+// Broadcast
+//  @Description  向所有连接发送文本消息，见 SendToUser
+//  @Receiver h
+//  @Param ctx
+//  @Param data
+//  @Return error
+func (h *Hub) Broadcast(ctx context.Context, data []byte) error {
+	return h.dispatch(ctx, targetAll, "", websocket.TextMessage, data)
+}
+
+// dispatch 先投递本实例，再广播给其他实例
+func (h *Hub) dispatch(ctx context.Context, target, id string, messageType int, data []byte) error {
+	h.deliver(target, id, messageType, data)
+	if h.broker == nil {
+		return nil
+	}
+	payload, err := json.Marshal(&envelope{Node: h.node, Target: target, ID: id, MessageType: messageType, Data: data})
+	if err != nil {
+		return err
+	}
+	return h.broker.Publish(ctx, h.config.channel(), payload)
+}
+
+func (h *Hub) deliver(target, id string, messageType int, data []byte) {
+	m := message{messageType: messageType, data: data}
+	for _, c := range h.targets(target, id) {
+		_ = c.enqueue(m)
+	}
+}
+
+// receive 投递其他实例的广播
+func (h *Hub) receive(payload []byte) {
+	var env envelope
+	if err := json.Unmarshal(payload, &env); err != nil {
+		h.config.logger.Warn("wshub invalid broadcast", klog.FieldErr(err))
+		return
+	}
+	if env.Node == h.node {
+		return
+	}
+	h.deliver(env.Target, env.ID, env.MessageType, env.Data)
+}
+
+// Close
+//  @Description  停止订阅并断开本实例的所有连接
+//  @Receiver h
+//  @Return error
+func (h *Hub) Close() error {
+	h.cancel()
+	for _, c := range h.targets(targetAll, "") {
+		c.Close()
+	}
+	return nil
+}
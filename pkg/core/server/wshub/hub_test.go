@@ -0,0 +1,182 @@
+package wshub
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/server/khttp"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryBroker 进程内的广播通道
+type memoryBroker struct {
+	mu   sync.Mutex
+	subs []func([]byte)
+}
+
+func (b *memoryBroker) Publish(ctx context.Context, channel string, payload []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, sub := range b.subs {
+		sub(payload)
+	}
+	return nil
+}
+
+func (b *memoryBroker) Subscribe(ctx context.Context, channel string, handler func([]byte)) error {
+	b.mu.Lock()
+	b.subs = append(b.subs, handler)
+	b.mu.Unlock()
+	<-ctx.Done()
+	return nil
+}
+
+func (b *memoryBroker) len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+
+func newTestHub(broker Broker) (*Hub, *httptest.Server) {
+	gin.SetMode(gin.TestMode)
+	config := DefaultConfig()
+	config.PingInterval = 50 * time.Millisecond
+	config.IdleTimeout = 200 * time.Millisecond
+	config.SendQueueSize = 4
+	hub := config.BuildWithBroker(broker)
+
+	server := khttp.DefaultConfig().WithPort(0).Build()
+	server.Upgrade(hub.WebSocket("/ws", func(c *khttp.TContext) (string, string) {
+		return c.Query("user"), c.Query("device")
+	}))
+	return hub, httptest.NewServer(server.Engine())
+}
+
+func dial(t *testing.T, ts *httptest.Server, query string) *websocket.Conn {
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/ws?"+query, nil)
+	assert.Nil(t, err)
+	return conn
+}
+
+func read(t *testing.T, conn *websocket.Conn) string {
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, data, err := conn.ReadMessage()
+	assert.Nil(t, err)
+	return string(data)
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestHub_Send(t *testing.T) {
+	broker := &memoryBroker{}
+	hub1, ts1 := newTestHub(broker)
+	defer ts1.Close()
+	defer hub1.Close()
+	hub2, ts2 := newTestHub(broker)
+	defer ts2.Close()
+	defer hub2.Close()
+
+	hub1.OnConnect(func(c *Conn) { c.Join("lobby") })
+	hub2.OnConnect(func(c *Conn) { c.Join("lobby") })
+	echo := make(chan string, 1)
+	hub1.OnMessage(func(c *Conn, messageType int, data []byte) { echo <- c.UserID + ":" + string(data) })
+
+	a := dial(t, ts1, "user=u1&device=d1")
+	defer a.Close()
+	b := dial(t, ts2, "user=u2&device=d2")
+	defer b.Close()
+	waitFor(t, func() bool { return hub1.Count() == 1 && hub2.Count() == 1 && broker.len() == 2 })
+
+	assert.Nil(t, a.WriteMessage(websocket.TextMessage, []byte("hi")))
+	assert.Equal(t, "u1:hi", <-echo)
+
+	// 跨实例投递
+	assert.Nil(t, hub1.SendToUser(context.Background(), "u2", []byte("to u2")))
+	assert.Equal(t, "to u2", read(t, b))
+	assert.Nil(t, hub2.SendToDevice(context.Background(), "d1", []byte("to d1")))
+	assert.Equal(t, "to d1", read(t, a))
+	assert.Nil(t, hub1.SendToRoom(context.Background(), "lobby", []byte("room")))
+	assert.Equal(t, "room", read(t, a))
+	assert.Equal(t, "room", read(t, b))
+	assert.Nil(t, hub2.Broadcast(context.Background(), []byte("all")))
+	assert.Equal(t, "all", read(t, a))
+	assert.Equal(t, "all", read(t, b))
+}
+
+func TestHub_Device(t *testing.T) {
+	hub, ts := newTestHub(nil)
+	defer ts.Close()
+	defer hub.Close()
+
+	old := dial(t, ts, "user=u1&device=d1")
+	defer old.Close()
+	waitFor(t, func() bool { return hub.Count() == 1 })
+	c := dial(t, ts, "user=u1&device=d1")
+	defer c.Close()
+
+	// 同一设备的旧连接被断开
+	_ = old.SetReadDeadline(time.Now().Add(time.Second))
+	_, _, err := old.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure))
+	waitFor(t, func() bool { return hub.Count() == 1 })
+	conn, ok := hub.DeviceConn("d1")
+	assert.True(t, ok)
+	assert.Equal(t, 1, len(hub.UserConns("u1")))
+	assert.Equal(t, conn, hub.UserConns("u1")[0])
+}
+
+func TestHub_Heartbeat(t *testing.T) {
+	hub, ts := newTestHub(nil)
+	defer ts.Close()
+	defer hub.Close()
+
+	pings := make(chan struct{}, 10)
+	alive := dial(t, ts, "user=u1")
+	defer alive.Close()
+	alive.SetPingHandler(func(data string) error {
+		pings <- struct{}{}
+		return alive.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+	})
+	// 读协程处理 ping 并回复 pong
+	go func() {
+		for {
+			if _, _, err := alive.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+	// 不读取的连接不会回复 pong，超过空闲时间后被断开
+	idle := dial(t, ts, "user=u2")
+	defer idle.Close()
+
+	waitFor(t, func() bool { return hub.Count() == 2 })
+	time.Sleep(400 * time.Millisecond)
+	assert.NotEmpty(t, pings)
+	assert.Equal(t, 1, hub.Count())
+	assert.Equal(t, 1, len(hub.UserConns("u1")))
+}
+
+func TestConn_QueueFull(t *testing.T) {
+	hub := DefaultConfig().BuildWithBroker(nil)
+	defer hub.Close()
+	hub.config.SendQueueSize = 2
+	c := newConn(hub, nil, "id", "u1", "")
+	assert.Nil(t, c.Send([]byte("1")))
+	assert.Nil(t, c.Send([]byte("2")))
+	assert.Equal(t, ErrQueueFull, c.Send([]byte("3")))
+	assert.Equal(t, ErrClosed, c.Send([]byte("4")))
+}
@@ -54,9 +54,9 @@ func metricInterceptor(ctx context.Context, dsn *DSN, op string, options *Config
 			// error metric
 			if scope.HasError() {
 				metric.LibHandleCounter.WithLabelValues(metric.TypeGorm, dsn.DBName+"."+scope.TableName(), dsn.Addr, "ERR").Inc()
-				// todo sql语句，需要转换成脱密状态才能记录到日志
+				// sql 字段输出时由日志脱敏规则替换字面量
 				if scope.DB().Error != ErrRecordNotFound {
-					options.logger.WithContext(ctx).Error("mysql err", klog.FieldErr(scope.DB().Error), klog.FieldName(dsn.DBName+"."+scope.TableName()), klog.FieldMethod(op))
+					options.logger.WithContext(ctx).Error("mysql err", klog.FieldErr(scope.DB().Error), klog.FieldName(dsn.DBName+"."+scope.TableName()), klog.FieldMethod(op), klog.FieldSQL(logSQL(scope.SQL, scope.SQLVars, options.DetailSQL)))
 				} else {
 					options.logger.WithContext(ctx).Warn("record not found", klog.FieldErr(scope.DB().Error), klog.FieldName(dsn.DBName+"."+scope.TableName()), klog.FieldMethod(op))
 				}
@@ -71,7 +71,7 @@ func metricInterceptor(ctx context.Context, dsn *DSN, op string, options *Config
 					"slow",
 					klog.FieldErr(errSlowCommand),
 					klog.FieldMethod(op),
-					klog.FieldExtMessage(logSQL(scope.SQL, scope.SQLVars, options.DetailSQL)),
+					klog.FieldSQL(logSQL(scope.SQL, scope.SQLVars, options.DetailSQL)),
 					klog.FieldAddr(dsn.Addr),
 					klog.FieldName(dsn.DBName+"."+scope.TableName()),
 					klog.FieldCost(cost),
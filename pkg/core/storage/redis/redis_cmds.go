@@ -876,6 +876,30 @@ func (r *Redis) GeoRadius(key string, longitude, latitude float64, query *GeoRad
 	return res, nil
 }
 
+// Eval
+// 	@Description 通过 `EVAL script numkeys key [key ...] arg [arg ...]` 执行 lua 脚本，集群模式下 keys 需在同一 slot
+// 	@Receiver r Redis
+//	@Param script 脚本内容
+//	@Param keys 脚本使用的键
+//	@Param args 脚本参数
+// 	@Return interface{} 脚本返回值
+// 	@Return error 错误
+func (r *Redis) Eval(script string, keys []string, args ...interface{}) (interface{}, error) {
+	return r.Client.Eval(script, keys, args...).Result()
+}
+
+// EvalSha
+// 	@Description 通过 `EVALSHA sha1 numkeys key [key ...] arg [arg ...]` 执行已缓存的 lua 脚本，脚本不存在时返回 NOSCRIPT 错误
+// 	@Receiver r Redis
+//	@Param sha1 脚本 sha1
+//	@Param keys 脚本使用的键
+//	@Param args 脚本参数
+// 	@Return interface{} 脚本返回值
+// 	@Return error 错误
+func (r *Redis) EvalSha(sha1 string, keys []string, args ...interface{}) (interface{}, error) {
+	return r.Client.EvalSha(sha1, keys, args...).Result()
+}
+
 // TTL 查询过期时间
 // TTL
 // 	@Description 通过 `TTL key` 查询对应键的过期时间
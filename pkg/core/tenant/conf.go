@@ -0,0 +1,80 @@
+// @Description 租户配置覆盖，tenants.<租户 id> 下的配置覆盖同名的全局配置
+
+package tenant
+
+import (
+	"context"
+	"time"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/conf"
+
+	"github.com/pkg/errors"
+)
+
+// ConfigRoot 租户配置根节点
+const ConfigRoot = "tenants"
+
+// Key
+//  @Description  租户覆盖配置的完整 key
+//  @Param id 租户 id
+//  @Param key 全局配置 key
+//  @Return string
+func Key(id, key string) string {
+	return ConfigRoot + "." + id + "." + key
+}
+
+// resolve 上下文中的租户覆盖了 key 时返回覆盖的 key
+func resolve(ctx context.Context, key string) string {
+	if id := FromContext(ctx); id != "" {
+		if tk := Key(id, key); conf.Get(tk) != nil {
+			return tk
+		}
+	}
+	return key
+}
+
+// Get
+//  @Description  获取配置，优先使用租户覆盖的值
+//  @Param ctx
+//  @Param key
+//  @Return interface{}
+func Get(ctx context.Context, key string) interface{} {
+	return conf.Get(resolve(ctx, key))
+}
+
+// GetString ...
+func GetString(ctx context.Context, key string) string {
+	return conf.GetString(resolve(ctx, key))
+}
+
+// GetInt ...
+func GetInt(ctx context.Context, key string) int {
+	return conf.GetInt(resolve(ctx, key))
+}
+
+// GetBool ...
+func GetBool(ctx context.Context, key string) bool {
+	return conf.GetBool(resolve(ctx, key))
+}
+
+// GetDuration ...
+func GetDuration(ctx context.Context, key string) time.Duration {
+	return conf.GetDuration(resolve(ctx, key))
+}
+
+// UnmarshalKey
+//  @Description  解析全局配置后，用租户覆盖的字段覆盖
+//  @Param ctx
+//  @Param key
+//  @Param rawVal
+//  @Return error 全局与租户配置都不存在时返回 conf.ErrInvalidKey
+func UnmarshalKey(ctx context.Context, key string, rawVal interface{}) error {
+	err := conf.UnmarshalKey(key, rawVal)
+	if err != nil && errors.Cause(err) != conf.ErrInvalidKey {
+		return err
+	}
+	if tk := resolve(ctx, key); tk != key {
+		return conf.UnmarshalKey(tk, rawVal)
+	}
+	return err
+}
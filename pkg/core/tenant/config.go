@@ -0,0 +1,103 @@
+// @Description 租户解析配置
+
+package tenant
+
+import (
+	"net"
+	"strings"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/conf"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/ecode"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
+
+	"github.com/pkg/errors"
+)
+
+// 租户来源
+const (
+	SourceHeader    = "header"
+	SourceJWT       = "jwt"
+	SourceSubdomain = "subdomain"
+)
+
+// Config 配置
+type Config struct {
+	// Sources 租户来源，按顺序尝试，默认 jwt、header，请求头、子域名与已鉴权的 app id 不一致时拒绝请求
+	Sources []string
+	// Header 租户请求头，默认 appId
+	Header string
+	// Domain 以子域名作为租户时的根域名，如 example.com
+	Domain string
+	// Tenants 允许的租户，为空时不限制，此时租户指标不区分租户
+	Tenants []string
+	// Required 未解析到租户时是否拒绝请求
+	Required bool
+	// Quota 租户总配额使用的限流规则名，为空时不限流
+	Quota string
+
+	logger *klog.Logger
+}
+
+// DefaultConfig ...
+func DefaultConfig() *Config {
+	return &Config{
+		Sources: []string{SourceJWT, SourceHeader},
+		Header:  "appId",
+		logger:  klog.KuaigoLogger.With(klog.FieldMod(ModName)),
+	}
+}
+
+// StdConfig ...
+func StdConfig() *Config {
+	return RawConfig("tabby.tenant")
+}
+
+// RawConfig ...
+func RawConfig(key string) *Config {
+	var config = DefaultConfig()
+	if err := conf.UnmarshalKey(key, &config); err != nil &&
+		errors.Cause(err) != conf.ErrInvalidKey {
+		config.logger.Panic("tenant parse config panic", klog.FieldErrKind(ecode.ErrKindUnmarshalConfigErr), klog.FieldErr(err), klog.FieldKey(key))
+	}
+	return config
+}
+
+// Allowed
+//  @Description  租户是否允许访问
+//  @Receiver config
+//  @Param id
+//  @Return bool
+func (config *Config) Allowed(id string) bool {
+	if len(config.Tenants) == 0 {
+		return true
+	}
+	for _, t := range config.Tenants {
+		if t == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Subdomain
+//  @Description  从 host 中取根域名下的第一级子域名
+//  @Receiver config
+//  @Param host 请求的 host，可带端口
+//  @Return string 不在根域名下时为空
+func (config *Config) Subdomain(host string) string {
+	if config.Domain == "" {
+		return ""
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	suffix := "." + strings.TrimPrefix(config.Domain, ".")
+	if !strings.HasSuffix(host, suffix) {
+		return ""
+	}
+	sub := strings.TrimSuffix(host, suffix)
+	if i := strings.LastIndexByte(sub, '.'); i >= 0 {
+		sub = sub[i+1:]
+	}
+	return sub
+}
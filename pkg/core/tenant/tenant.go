@@ -0,0 +1,59 @@
+// @Description 多租户，以 app ID 作为租户，在上下文中传递，缓存、数据库与配置按租户隔离
+
+package tenant
+
+import (
+	"context"
+	"strconv"
+)
+
+//ModName ..
+const ModName = "core.tenant"
+
+type tenantContextKey struct{}
+
+// NewContext
+//  @Description  在上下文中设置租户
+//  @Param ctx
+//  @Param id 租户 id，为空时返回原上下文
+//  @Return context.Context
+func NewContext(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, tenantContextKey{}, id)
+}
+
+// FromContext
+//  @Description  获取上下文中的租户
+//  @Param ctx
+//  @Return string 未设置时为空
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(tenantContextKey{}).(string)
+	return id
+}
+
+// FromAppID
+//  @Description  app ID 转为租户 id
+//  @Param appID
+//  @Return string appID 为 0 时为空
+func FromAppID(appID int) string {
+	if appID == 0 {
+		return ""
+	}
+	return strconv.Itoa(appID)
+}
+
+// KeyPrefix
+//  @Description  租户的缓存 key 前缀
+//  @Param id
+//  @Return string id 为空时为空
+func KeyPrefix(id string) string {
+	if id == "" {
+		return ""
+	}
+	return "tenant:" + id + ":"
+}
@@ -0,0 +1,55 @@
+package tenant
+
+import (
+	"context"
+	"testing"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/conf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, "", FromContext(nil))
+	assert.Equal(t, ctx, NewContext(ctx, ""))
+	assert.Equal(t, "101", FromContext(NewContext(ctx, FromAppID(101))))
+	assert.Equal(t, "", FromAppID(0))
+	assert.Equal(t, "tenant:101:", KeyPrefix("101"))
+	assert.Equal(t, "", KeyPrefix(""))
+}
+
+func TestConfig(t *testing.T) {
+	config := DefaultConfig()
+	config.Domain = "example.com"
+	assert.Equal(t, "acme", config.Subdomain("acme.example.com:8080"))
+	assert.Equal(t, "acme", config.Subdomain("api.acme.example.com"))
+	assert.Equal(t, "", config.Subdomain("example.com"))
+	assert.Equal(t, "", config.Subdomain("acme.other.com"))
+
+	assert.True(t, config.Allowed("101"))
+	config.Tenants = []string{"101"}
+	assert.True(t, config.Allowed("101"))
+	assert.False(t, config.Allowed("102"))
+}
+
+func TestConf(t *testing.T) {
+	defer conf.Reset()
+	assert.Nil(t, conf.Apply(map[string]interface{}{
+		"app": map[string]interface{}{"name": "kuaigo", "port": 80},
+		ConfigRoot: map[string]interface{}{
+			"101": map[string]interface{}{"app": map[string]interface{}{"port": 81}},
+		},
+	}))
+	ctx := NewContext(context.Background(), "101")
+	assert.Equal(t, 81, GetInt(ctx, "app.port"))
+	assert.Equal(t, 80, GetInt(context.Background(), "app.port"))
+	assert.Equal(t, 80, GetInt(NewContext(context.Background(), "102"), "app.port"))
+
+	var app struct {
+		Name string
+		Port int
+	}
+	assert.Nil(t, UnmarshalKey(ctx, "app", &app))
+	assert.Equal(t, "kuaigo", app.Name)
+	assert.Equal(t, 81, app.Port)
+}
@@ -0,0 +1,291 @@
+// @Description 多语言消息目录，从本地文件加载，可追加远程状态码服务等消息来源
+
+package ecode
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/conf"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/errs"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultLang 默认语言
+const DefaultLang = "zh"
+
+// DefaultCatalog 默认消息目录，kentity 返回错误响应时使用，应用启动时可替换为 CatalogConfig.Build 的结果
+var DefaultCatalog = NewCatalog(DefaultLang)
+
+// MessageSource 消息来源
+type MessageSource interface {
+	// Message 获取 lang 语言下 key 对应的消息模板
+	Message(ctx context.Context, lang, key string) (string, bool)
+}
+
+// CatalogConfig 消息目录配置
+type CatalogConfig struct {
+	// Path 消息文件目录，文件名为语言，如 zh.json、en.yaml，嵌套的 key 以 . 连接
+	Path string
+	// Fallback 请求语言没有对应消息时使用的语言，默认 zh
+	Fallback string
+
+	logger *klog.Logger
+}
+
+// DefaultCatalogConfig ...
+func DefaultCatalogConfig() *CatalogConfig {
+	return &CatalogConfig{
+		Fallback: DefaultLang,
+		logger:   klog.KuaigoLogger.With(klog.FieldMod("ecode.catalog")),
+	}
+}
+
+// StdCatalogConfig ...
+func StdCatalogConfig() *CatalogConfig {
+	return RawCatalogConfig("tabby.ecode.catalog")
+}
+
+// RawCatalogConfig ...
+func RawCatalogConfig(key string) *CatalogConfig {
+	var config = DefaultCatalogConfig()
+	if err := conf.UnmarshalKey(key, &config); err != nil &&
+		errors.Cause(err) != conf.ErrInvalidKey {
+		config.logger.Panic("catalog parse config panic", klog.FieldErrKind(ErrKindUnmarshalConfigErr), klog.FieldErr(err), klog.FieldKey(key), klog.FieldValueAny(config))
+	}
+	return config
+}
+
+// Build
+//  @Description  创建消息目录并加载 Path 下的消息文件
+//  @Receiver config
+//  @Return *Catalog
+func (config *CatalogConfig) Build() *Catalog {
+	c := NewCatalog(config.Fallback)
+	if config.Path != "" {
+		if err := c.Load(config.Path); err != nil {
+			config.logger.Panic("catalog load panic", klog.FieldErrKind(ErrKindUnmarshalConfigErr), klog.FieldErr(err), klog.String("path", config.Path))
+		}
+	}
+	return c
+}
+
+// Catalog 消息目录
+type Catalog struct {
+	fallback string
+
+	mu       sync.RWMutex
+	messages map[string]map[string]string
+	sources  []MessageSource
+}
+
+// NewCatalog
+//  @Description  创建空的消息目录
+//  @Param fallback 请求语言没有对应消息时使用的语言
+//  @Return *Catalog
+func NewCatalog(fallback string) *Catalog {
+	if fallback == "" {
+		fallback = DefaultLang
+	}
+	return &Catalog{
+		fallback: normalizeLang(fallback),
+		messages: make(map[string]map[string]string),
+	}
+}
+
+// Load
+//  @Description  加载目录下的 json、yaml 消息文件，同一语言已有的消息被整体替换，可用于热更新
+//  @Receiver c
+//  @Param path 目录
+//  @Return error
+func (c *Catalog) Load(path string) error {
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		return err
+	}
+	loaded := make(map[string]map[string]string)
+	for _, file := range files {
+		ext := filepath.Ext(file.Name())
+		if file.IsDir() || (ext != ".json" && ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(path, file.Name()))
+		if err != nil {
+			return err
+		}
+		var raw map[string]interface{}
+		if ext == ".json" {
+			err = json.Unmarshal(data, &raw)
+		} else {
+			err = yaml.Unmarshal(data, &raw)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", file.Name(), err)
+		}
+		lang := normalizeLang(strings.TrimSuffix(file.Name(), ext))
+		if loaded[lang] == nil {
+			loaded[lang] = make(map[string]string)
+		}
+		flatten("", raw, loaded[lang])
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for lang, messages := range loaded {
+		c.messages[lang] = messages
+	}
+	return nil
+}
+
+// flatten 将嵌套的 key 以 . 连接
+func flatten(prefix string, raw interface{}, out map[string]string) {
+	switch v := raw.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			flatten(join(prefix, key), value, out)
+		}
+	case map[interface{}]interface{}:
+		for key, value := range v {
+			flatten(join(prefix, fmt.Sprint(key)), value, out)
+		}
+	case nil:
+	default:
+		out[prefix] = fmt.Sprint(v)
+	}
+}
+
+func join(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// Add
+//  @Description  添加消息，覆盖同名 key
+//  @Receiver c
+//  @Param lang 语言
+//  @Param messages key 为业务码或消息 key，值为消息模板
+func (c *Catalog) Add(lang string, messages map[string]string) {
+	lang = normalizeLang(lang)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.messages[lang] == nil {
+		c.messages[lang] = make(map[string]string, len(messages))
+	}
+	for key, message := range messages {
+		c.messages[lang][key] = message
+	}
+}
+
+// AddSource
+//  @Description  追加消息来源，本地没有对应消息时按添加顺序查询，如远程状态码服务 ECode
+//  @Receiver c
+//  @Param source
+func (c *Catalog) AddSource(source MessageSource) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sources = append(c.sources, source)
+}
+
+// Languages
+//  @Description  已加载的语言
+//  @Receiver c
+//  @Return []string
+func (c *Catalog) Languages() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	langs := make([]string, 0, len(c.messages))
+	for lang := range c.messages {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Match
+//  @Description  按顺序匹配已加载的语言，支持 Accept-Language 格式，先匹配完整的语言标签再匹配主标签
+//  @Receiver c
+//  @Param langs 如 Accept-Language 请求头、客户端系统语言
+//  @Return string 都不匹配时为 fallback 语言
+func (c *Catalog) Match(langs ...string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, lang := range langs {
+		for _, part := range strings.Split(lang, ",") {
+			tag := normalizeLang(strings.SplitN(part, ";", 2)[0])
+			if tag == "" {
+				continue
+			}
+			if _, ok := c.messages[tag]; ok {
+				return tag
+			}
+			if primary := strings.SplitN(tag, "-", 2)[0]; primary != tag {
+				if _, ok := c.messages[primary]; ok {
+					return primary
+				}
+			}
+		}
+	}
+	return c.fallback
+}
+
+// Message
+//  @Description  获取消息模板，依次查找 lang、消息来源、fallback 语言
+//  @Receiver c
+//  @Param ctx
+//  @Param lang 语言，见 Match
+//  @Param key 业务码或消息 key
+//  @Return string
+//  @Return bool
+func (c *Catalog) Message(ctx context.Context, lang, key string) (string, bool) {
+	lang = normalizeLang(lang)
+	if message, ok := c.lookup(ctx, lang, key); ok {
+		return message, true
+	}
+	if lang != c.fallback {
+		return c.lookup(ctx, c.fallback, key)
+	}
+	return "", false
+}
+
+func (c *Catalog) lookup(ctx context.Context, lang, key string) (string, bool) {
+	c.mu.RLock()
+	message, ok := c.messages[lang][key]
+	sources := c.sources
+	c.mu.RUnlock()
+	if ok {
+		return message, true
+	}
+	for _, source := range sources {
+		if message, ok := source.Message(ctx, lang, key); ok {
+			return message, true
+		}
+	}
+	return "", false
+}
+
+// Localize
+//  @Description  获取错误在请求语言下的消息，没有对应消息时使用错误的默认消息
+//  @Receiver c
+//  @Param ctx
+//  @Param err
+//  @Param langs 见 Match
+//  @Return string
+func (c *Catalog) Localize(ctx context.Context, err errs.Error, langs ...string) string {
+	template, _ := c.Message(ctx, c.Match(langs...), err.MessageKey())
+	return err.Format(template)
+}
+
+// normalizeLang 统一为小写并以 - 分隔，如 zh_CN 转为 zh-cn
+func normalizeLang(lang string) string {
+	return strings.ToLower(strings.Replace(strings.TrimSpace(lang), "_", "-", -1))
+}
@@ -0,0 +1,51 @@
+package ecode
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/errs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCatalog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "catalog")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "zh.json"), []byte(`{"4005": "参数错误", "param": {"invalid": "%s 不合法"}}`), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "en.yaml"), []byte("\"4005\": invalid parameter\nparam:\n  invalid: invalid %s\n"), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "zh_TW.yml"), []byte("\"4005\": 參數錯誤\n"), 0644))
+
+	config := DefaultCatalogConfig()
+	config.Path = dir
+	c := config.Build()
+	assert.Equal(t, []string{"en", "zh", "zh-tw"}, c.Languages())
+
+	assert.Equal(t, "en", c.Match("en-US,en;q=0.9"))
+	assert.Equal(t, "zh-tw", c.Match("zh-TW"))
+	assert.Equal(t, "zh", c.Match("zh-CN"))
+	assert.Equal(t, "zh", c.Match("fr", ""))
+	assert.Equal(t, "en", c.Match("fr", "en"))
+
+	ctx := context.Background()
+	assert.Equal(t, "invalid parameter", c.Localize(ctx, errs.New(CodeInvalidParameter, ""), "en"))
+	assert.Equal(t, "參數錯誤", c.Localize(ctx, errs.New(CodeInvalidParameter, ""), "zh-TW"))
+	assert.Equal(t, "invalid phone", c.Localize(ctx, errs.New(CodeInvalidParameter, "").WithKey("param.invalid", "phone"), "en"))
+	// zh-tw 没有的消息使用 fallback 语言
+	assert.Equal(t, "phone 不合法", c.Localize(ctx, errs.New(CodeInvalidParameter, "").WithKey("param.invalid", "phone"), "zh-TW"))
+	// 都没有时使用默认消息
+	assert.Equal(t, "服务器错误", c.Localize(ctx, errs.New(CodeInternalServerError, "服务器错误"), "en"))
+
+	// 远程消息来源
+	c.AddSource(&ECode{Lang: "en", CodeMsgMapper: map[int]string{CodeInternalServerError: "internal server error"}})
+	assert.Equal(t, "internal server error", c.Localize(ctx, errs.New(CodeInternalServerError, "服务器错误"), "en"))
+	assert.Equal(t, "服务器错误", c.Localize(ctx, errs.New(CodeInternalServerError, "服务器错误"), "zh"))
+
+	// 重新加载
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "en.yaml"), []byte("\"4005\": bad parameter\n"), 0644))
+	assert.Nil(t, c.Load(dir))
+	assert.Equal(t, "bad parameter", c.Localize(ctx, errs.New(CodeInvalidParameter, ""), "en"))
+}
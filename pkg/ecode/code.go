@@ -7,6 +7,7 @@ import (
 	"github.com/LuoHongLiang0921/kuaigo/pkg/conf"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/core/net/khttp"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
+	"strconv"
 	"sync"
 
 )
@@ -179,6 +180,8 @@ type ECode struct {
 	StatusCodeCreateUrl string `json:"statusCodeCreateUrl"`
 	//状态码列表查询地址
 	StatusCodeListUrl string `json:"statusCodeListUrl"`
+	//Lang 已加载消息的语言，作为 Catalog 的消息来源时只响应该语言，为空时响应所有语言
+	Lang string `json:"lang"`
 }
 
 type statusCodeListReq struct {
@@ -278,7 +281,7 @@ func (c *ECode) LoadUpdateCodeMsg(ctx context.Context, appId int, code int, msg
 	codeResp := &statusCodeResp{}
 	resp,err := khttp.PostJson(ctx, c.StatusCodeUpdateUrl, req)
 	if err != nil {
-		klog.Errorf("", klog.FieldCommon(nil), klog.FieldParams(map[string]interface{}{
+		klog.Error("", klog.FieldErr(err), klog.FieldCommon(nil), klog.FieldParams(map[string]interface{}{
 			"msg": "LoadUpdateCodeMsg",
 		}), klog.String("type", "bizLog"))
 		return
@@ -298,7 +301,7 @@ func (c *ECode) LoadCodeMsg(ctx context.Context, code int) {
 	codeResp := &statusCodeResp{}
 	resp,err := khttp.PostJson(ctx, c.StatusCodeURL, req)
 	if err != nil {
-		klog.Errorf("", klog.FieldCommon(nil), klog.FieldParams(map[string]interface{}{
+		klog.Error("", klog.FieldErr(err), klog.FieldCommon(nil), klog.FieldParams(map[string]interface{}{
 			"msg": "LoadCodeMsg",
 		}), klog.String("type", "bizLog"))
 		return
@@ -318,7 +321,7 @@ func (c *ECode) LoadCodeListMsg(ctx context.Context, serviceID int) {
 	var codeResp statusCodeListResp
 	resp,err := khttp.PostJson(ctx, c.StatusCodeListUrl, req)
 	if err != nil {
-		klog.Errorf("", klog.FieldCommon(nil), klog.FieldParams(map[string]interface{}{
+		klog.Error("", klog.FieldErr(err), klog.FieldCommon(nil), klog.FieldParams(map[string]interface{}{
 			"msg": "LoadCodeMsg",
 		}), klog.String("type", "bizLog"))
 		return
@@ -344,3 +347,23 @@ func (c *ECode) GetMsg(ctx context.Context, code int) string {
 	}
 	return ""
 }
+
+// Message
+//  @Description  实现 MessageSource，可通过 Catalog.AddSource 作为远程消息来源
+//  @Receiver c
+//  @Param ctx
+//  @Param lang
+//  @Param key 业务码
+//  @Return string
+//  @Return bool
+func (c *ECode) Message(ctx context.Context, lang, key string) (string, bool) {
+	if c.Lang != "" && normalizeLang(c.Lang) != lang {
+		return "", false
+	}
+	code, err := strconv.Atoi(key)
+	if err != nil {
+		return "", false
+	}
+	msg := c.GetMsg(ctx, code)
+	return msg, msg != ""
+}
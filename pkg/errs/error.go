@@ -2,7 +2,15 @@
 
 package errs
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
 
 var (
 	//ErrNoDefined 未定义
@@ -15,9 +23,30 @@ var (
 	ErrZeroRowsAffected = errors.New("zero rows affect")
 )
 
+// Error 统一错误，同时携带业务码、HTTP 状态码、gRPC 状态码与多语言消息 key。
+// 模板参数与附加信息保存在不可变的 extra 指针中，使 Error 仍可用 == 比较
 type Error struct {
+	// Code 业务码，见 ecode
 	Code int
-	Msg  string
+	// Msg 默认消息，消息目录中没有 Key 对应的翻译时使用
+	Msg string
+	// Status HTTP 状态码，0 时响应 200
+	Status int
+	// GRPCCode gRPC 状态码，OK 时按 Status 推导
+	GRPCCode codes.Code
+	// Key 消息目录中的 key，为空时使用 Code
+	Key string
+
+	extra *extra
+	cause error
+}
+
+// extra 不可比较的字段，创建后不再修改，With 系列方法会复制一份
+type extra struct {
+	// args 消息模板参数
+	args []interface{}
+	// details 附加信息，随响应返回
+	details []interface{}
 }
 
 //NewCustomError ...
@@ -30,10 +59,202 @@ func NewError(code int) error {
 	return Error{Code: code}
 }
 
+// New
+//  @Description  创建错误，可链式设置其他字段
+//  @Param code 业务码
+//  @Param msg 默认消息
+//  @Return Error
+func New(code int, msg string) Error {
+	return Error{Code: code, Msg: msg}
+}
+
+// Wrap
+//  @Description  以 err 为原因创建错误，errors.Is/As 可穿透到 err
+//  @Param err 原因
+//  @Param code 业务码
+//  @Param msg 默认消息
+//  @Return Error
+func Wrap(err error, code int, msg string) Error {
+	return Error{Code: code, Msg: msg, cause: err}
+}
+
+// FromError
+//  @Description  从错误链中取出 Error
+//  @Param err
+//  @Return Error
+//  @Return bool 错误链中没有 Error 时为 false
+func FromError(err error) (Error, bool) {
+	var e Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+	var pe *Error
+	if errors.As(err, &pe) && pe != nil {
+		return *pe, true
+	}
+	return Error{}, false
+}
+
 func (e Error) Error() string {
+	if e.cause != nil {
+		if e.Msg == "" {
+			return e.cause.Error()
+		}
+		return e.Msg + ": " + e.cause.Error()
+	}
 	return e.Msg
 }
 
+// Unwrap 返回原因
 func (e Error) Unwrap() error {
+	return e.cause
+}
+
+// Is 业务码相同即视为同一错误，用于 errors.Is(err, errs.NewError(code))
+func (e Error) Is(target error) bool {
+	switch t := target.(type) {
+	case Error:
+		return e.Code == t.Code
+	case *Error:
+		return t != nil && e.Code == t.Code
+	}
+	return false
+}
+
+// MessageKey
+//  @Description  消息目录中的 key，Key 为空时为业务码
+//  @Receiver e
+//  @Return string
+func (e Error) MessageKey() string {
+	if e.Key != "" {
+		return e.Key
+	}
+	return strconv.Itoa(e.Code)
+}
+
+// Format
+//  @Description  使用模板参数格式化消息
+//  @Receiver e
+//  @Param template 消息模板，为空时使用 Msg
+//  @Return string
+func (e Error) Format(template string) string {
+	if template == "" {
+		template = e.Msg
+	}
+	args := e.Args()
+	if len(args) == 0 {
+		return template
+	}
+	return fmt.Sprintf(template, args...)
+}
+
+// Args 消息模板参数
+func (e Error) Args() []interface{} {
+	if e.extra == nil {
+		return nil
+	}
+	return e.extra.args
+}
+
+// Details 附加信息，随响应返回
+func (e Error) Details() []interface{} {
+	if e.extra == nil {
+		return nil
+	}
+	return e.extra.details
+}
+
+// Cause 原因
+func (e Error) Cause() error {
+	return e.cause
+}
+
+// WithMsg 设置默认消息
+func (e Error) WithMsg(msg string) Error {
+	e.Msg = msg
+	return e
+}
+
+// WithStatus 设置 HTTP 状态码
+func (e Error) WithStatus(status int) Error {
+	e.Status = status
+	return e
+}
+
+// WithGRPCCode 设置 gRPC 状态码
+func (e Error) WithGRPCCode(code codes.Code) Error {
+	e.GRPCCode = code
+	return e
+}
+
+// WithKey 设置消息 key 与模板参数
+func (e Error) WithKey(key string, args ...interface{}) Error {
+	e.Key = key
+	return e.WithArgs(args...)
+}
+
+// WithArgs 设置消息模板参数
+func (e Error) WithArgs(args ...interface{}) Error {
+	e.extra = &extra{args: args, details: e.Details()}
 	return e
 }
+
+// WithDetails 追加附加信息
+func (e Error) WithDetails(details ...interface{}) Error {
+	e.extra = &extra{args: e.Args(), details: append(append([]interface{}{}, e.Details()...), details...)}
+	return e
+}
+
+// WithCause 设置原因
+func (e Error) WithCause(err error) Error {
+	e.cause = err
+	return e
+}
+
+// HTTPStatus
+//  @Description  HTTP 状态码，Status 为 0 时为 200
+//  @Receiver e
+//  @Return int
+func (e Error) HTTPStatus() int {
+	if e.Status == 0 {
+		return http.StatusOK
+	}
+	return e.Status
+}
+
+// GRPCStatus
+//  @Description  转为 gRPC 状态，status.FromError 与 grpc 服务端会调用
+//  @Receiver e
+//  @Return *status.Status
+func (e Error) GRPCStatus() *status.Status {
+	code := e.GRPCCode
+	if code == codes.OK {
+		code = grpcCode(e.Status)
+	}
+	return status.New(code, e.Error())
+}
+
+// grpcCode HTTP 状态码到 gRPC 状态码的默认映射
+func grpcCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout, http.StatusRequestTimeout:
+		return codes.DeadlineExceeded
+	}
+	return codes.Unknown
+}
@@ -0,0 +1,52 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestError(t *testing.T) {
+	cause := errors.New("dial timeout")
+	err := fmt.Errorf("load user: %w", Wrap(cause, 5006, "数据库错误").WithStatus(http.StatusServiceUnavailable).WithDetails("db=user"))
+
+	assert.True(t, errors.Is(err, NewError(5006)))
+	assert.False(t, errors.Is(err, NewError(5009)))
+	assert.True(t, errors.Is(err, cause))
+
+	e, ok := FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, 5006, e.Code)
+	assert.Equal(t, "数据库错误: dial timeout", e.Error())
+	assert.Equal(t, http.StatusServiceUnavailable, e.HTTPStatus())
+	assert.Equal(t, []interface{}{"db=user"}, e.Details())
+	assert.Equal(t, "5006", e.MessageKey())
+
+	s, ok := status.FromError(e)
+	assert.True(t, ok)
+	assert.Equal(t, codes.Unavailable, s.Code())
+	assert.Equal(t, codes.NotFound, e.WithGRPCCode(codes.NotFound).GRPCStatus().Code())
+
+	_, ok = FromError(errors.New("plain"))
+	assert.False(t, ok)
+	assert.Equal(t, http.StatusOK, New(4005, "").HTTPStatus())
+}
+
+func TestError_Format(t *testing.T) {
+	e := New(4005, "invalid %s").WithKey("param.invalid", "phone")
+	assert.Equal(t, "param.invalid", e.MessageKey())
+	assert.Equal(t, "invalid phone", e.Format(""))
+	assert.Equal(t, "phone 不合法", e.Format("%s 不合法"))
+}
+
+func TestError_Comparable(t *testing.T) {
+	var err error = New(4005, "invalid").WithArgs("phone").WithDetails("field=phone")
+	assert.NotPanics(t, func() { _ = err == NewError(4005) })
+	assert.False(t, err == NewError(4005))
+	assert.True(t, NewError(4005) == NewError(4005))
+}
@@ -64,6 +64,27 @@ var (
 		Labels:    []string{"type", "method", "peer"},
 	}.Build()
 
+	// TenantHandleCounter 按租户统计的请求数
+	TenantHandleCounter = CounterVecOpts{
+		Namespace: DefaultNamespace,
+		Name:      "tenant_handle_total",
+		Labels:    []string{"type", "tenant", "method", "code"},
+	}.Build()
+
+	// TenantHandleHistogram 按租户统计的请求耗时
+	TenantHandleHistogram = HistogramVecOpts{
+		Namespace: DefaultNamespace,
+		Name:      "tenant_handle_seconds",
+		Labels:    []string{"type", "tenant", "method"},
+	}.Build()
+
+	// ServerConnectionGauge 长连接数，如 websocket
+	ServerConnectionGauge = GaugeVecOpts{
+		Namespace: DefaultNamespace,
+		Name:      "server_connections",
+		Labels:    []string{"type", "name"},
+	}.Build()
+
 	// ClientHandleCounter ...
 	ClientHandleCounter = CounterVecOpts{
 		Namespace: DefaultNamespace,
@@ -10,3 +10,6 @@ import (
 )
 
 var shutdownSignals = []os.Signal{syscall.SIGQUIT, os.Interrupt, syscall.SIGTERM}
+
+// restartSignals 热重启信号
+var restartSignals = []os.Signal{syscall.SIGUSR2}
@@ -0,0 +1,21 @@
+// +build !windows
+
+package signals
+
+import (
+	"syscall"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRestartSIGUSR2(t *testing.T) {
+	restarted := make(chan struct{})
+	Convey("test restart signal by SIGUSR2", t, func(c C) {
+		Restart(func() {
+			close(restarted)
+		})
+		kill(syscall.SIGUSR2)
+		<-restarted
+	})
+}
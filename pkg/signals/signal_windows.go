@@ -10,3 +10,6 @@ import (
 )
 
 var shutdownSignals = []os.Signal{syscall.SIGQUIT, os.Interrupt}
+
+// restartSignals windows 下不支持热重启
+var restartSignals []os.Signal
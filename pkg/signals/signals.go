@@ -26,3 +26,19 @@ func Shutdown(stop func(grace bool)) {
 		os.Exit(128 + int(s.(syscall.Signal))) // second signal. Exit directly.
 	}()
 }
+
+// Restart
+// 	@Description  监听热重启信号 SIGUSR2，每次收到信号执行一次 restart，windows 下不监听
+//	@param restart 收到信号后执行的函数
+func Restart(restart func()) {
+	if len(restartSignals) == 0 {
+		return
+	}
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, restartSignals...)
+	go func() {
+		for range sig {
+			restart()
+		}
+	}()
+}
@@ -0,0 +1,42 @@
+// @Description 自定义 claims
+
+package kjwt
+
+import (
+	"encoding/json"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// tokenTypeRefresh refresh token 头部的 typ，避免 refresh token 被当作 access token 使用
+const tokenTypeRefresh = "refresh"
+
+// Claims 自定义 claims，嵌入 StandardClaims 即可实现
+type Claims interface {
+	jwt.Claims
+	// Standard 标准字段，签发时设置 jti、iat、exp
+	Standard() *jwt.StandardClaims
+}
+
+// StandardClaims 嵌入到自定义 claims 中
+type StandardClaims struct {
+	jwt.StandardClaims
+}
+
+// Standard ...
+func (c *StandardClaims) Standard() *jwt.StandardClaims {
+	return &c.StandardClaims
+}
+
+// Standard ...
+func (c *MyClaims) Standard() *jwt.StandardClaims {
+	return &c.StandardClaims
+}
+
+// refreshClaims refresh token，payload 保存签发时的自定义 claims，刷新时原样签发新的 access token
+type refreshClaims struct {
+	jwt.StandardClaims
+	// Family 同一次登录的 refresh token 共享 family，检测到重用时整体吊销
+	Family  string          `json:"fid"`
+	Payload json.RawMessage `json:"pld"`
+}
@@ -0,0 +1,49 @@
+// @Description 在治理服务上提供 JWKS 公钥集合
+
+package kjwt
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/server/governor"
+)
+
+var (
+	jwksMu   sync.RWMutex
+	jwksSets []*KeySet
+)
+
+func init() {
+	governor.HandleFunc("/jwks.json", JWKSHandler())
+}
+
+// registerJWKS 由 Config.Build 调用，公钥出现在 /jwks.json 中
+func registerJWKS(ks *KeySet) {
+	jwksMu.Lock()
+	defer jwksMu.Unlock()
+	jwksSets = append(jwksSets, ks)
+}
+
+// JWKSHandler
+//  @Description  返回所有 Manager 的公钥，需要对外提供时可挂载到业务服务的 /.well-known/jwks.json
+//  @Param sets 为空时使用 Config.Build 创建的所有密钥集合
+//  @Return http.HandlerFunc
+func JWKSHandler(sets ...*KeySet) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		current := sets
+		if len(current) == 0 {
+			jwksMu.RLock()
+			current = append([]*KeySet{}, jwksSets...)
+			jwksMu.RUnlock()
+		}
+		all := JWKS{Keys: []JWK{}}
+		for _, ks := range current {
+			all.Keys = append(all.Keys, ks.JWKS().Keys...)
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		_ = json.NewEncoder(w).Encode(all)
+	}
+}
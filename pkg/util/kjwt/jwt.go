@@ -24,6 +24,12 @@ func NewClient(jwtKey string, expire time.Duration) *Client {
 	return &Client{JwtKey: jwtKey, Expire: expire}
 }
 
+// 登录角色，0 为兼容老版本的真实用户
+const (
+	LoginRoleUser  = 1
+	LoginRoleGuest = 2
+)
+
 // MyClaims ...
 // todo 需要解耦改为interface
 type MyClaims struct {
@@ -31,7 +37,7 @@ type MyClaims struct {
 	AppID     int    `json:"appID"`
 	DID       string `json:"did"`
 	PlatToken string `json:"platToken"` // 兼容 bobo 旧服务， 新框架使用的是新的token
-	LoginRole int    `json:"loginRole"` // 登录角色 0 游客， 1 真实用户
+	LoginRole int    `json:"loginRole"` // 登录角色 0,1 真实用户， 2 游客，见 LoginRoleGuest
 	error     error  `json:"-"`
 	jwt.StandardClaims
 }
@@ -88,6 +94,8 @@ func (c *Client) ParseToken(tokenStr string) (*MyClaims, error) {
 //  @Receiver c
 //  @Param tokenStr token 字符串
 //  @Return string
+//
+// Deprecated: 不检测重用也不能吊销，使用 Manager.Refresh
 func (c *Client) RefreshToken(tokenStr string) string {
 	claims, err := c.ParseToken(tokenStr)
 	if err != nil {
@@ -0,0 +1,341 @@
+// @Description 密钥集合，token 头部携带 kid，支持 HS256、RS256、ES256 与新旧密钥重叠轮换
+
+package kjwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// 支持的签名算法
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+)
+
+var (
+	// ErrNoSigningKey 未设置签名密钥
+	ErrNoSigningKey = errors.New("kjwt: no signing key")
+	// ErrUnknownKey token 的 kid 不在密钥集合中
+	ErrUnknownKey = errors.New("kjwt: unknown key id")
+)
+
+// Key 密钥
+type Key struct {
+	// ID kid
+	ID string
+	// Algorithm 算法，见 AlgHS256 等
+	Algorithm string
+	// Secret HS256 密钥
+	Secret []byte
+	// PrivateKey RS256 为 *rsa.PrivateKey，ES256 为 *ecdsa.PrivateKey，只用于验签的密钥为空
+	PrivateKey interface{}
+	// PublicKey RS256 为 *rsa.PublicKey，ES256 为 *ecdsa.PublicKey，为空时取自 PrivateKey
+	PublicKey interface{}
+}
+
+// KeyConfig 密钥配置
+type KeyConfig struct {
+	// ID kid
+	ID string
+	// Algorithm 算法，默认 HS256
+	Algorithm string
+	// Secret HS256 密钥
+	Secret string
+	// PrivateKey RS256、ES256 私钥 PEM 文件路径，只用于验签时可为空
+	PrivateKey string
+	// PublicKey RS256、ES256 公钥 PEM 文件路径，为空时取自私钥
+	PublicKey string
+}
+
+// Build
+//  @Description  读取 PEM 文件创建密钥
+//  @Receiver config
+//  @Return *Key
+//  @Return error
+func (config KeyConfig) Build() (*Key, error) {
+	key := &Key{ID: config.ID, Algorithm: config.Algorithm}
+	if key.Algorithm == "" {
+		key.Algorithm = AlgHS256
+	}
+	var err error
+	switch key.Algorithm {
+	case AlgHS256:
+		if config.Secret == "" {
+			return nil, fmt.Errorf("kjwt: key %s secret is empty", config.ID)
+		}
+		key.Secret = []byte(config.Secret)
+	case AlgRS256, AlgES256:
+		if config.PrivateKey != "" {
+			if key.PrivateKey, err = readPEM(config.PrivateKey, key.Algorithm, true); err != nil {
+				return nil, err
+			}
+		}
+		if config.PublicKey != "" {
+			if key.PublicKey, err = readPEM(config.PublicKey, key.Algorithm, false); err != nil {
+				return nil, err
+			}
+		}
+		if key.PrivateKey == nil && key.PublicKey == nil {
+			return nil, fmt.Errorf("kjwt: key %s has neither private nor public key", config.ID)
+		}
+	default:
+		return nil, fmt.Errorf("kjwt: key %s algorithm %s not supported", config.ID, config.Algorithm)
+	}
+	return key, nil
+}
+
+func readPEM(path, alg string, private bool) (interface{}, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case alg == AlgRS256 && private:
+		return jwt.ParseRSAPrivateKeyFromPEM(data)
+	case alg == AlgRS256:
+		return jwt.ParseRSAPublicKeyFromPEM(data)
+	case private:
+		return jwt.ParseECPrivateKeyFromPEM(data)
+	default:
+		return jwt.ParseECPublicKeyFromPEM(data)
+	}
+}
+
+func (k *Key) method() jwt.SigningMethod {
+	return jwt.GetSigningMethod(k.Algorithm)
+}
+
+// signingKey 签名使用的密钥
+func (k *Key) signingKey() interface{} {
+	if k.Algorithm == AlgHS256 {
+		return k.Secret
+	}
+	return k.PrivateKey
+}
+
+// verifyKey 验签使用的密钥
+func (k *Key) verifyKey() interface{} {
+	if k.Algorithm == AlgHS256 {
+		return k.Secret
+	}
+	if k.PublicKey != nil {
+		return k.PublicKey
+	}
+	switch pk := k.PrivateKey.(type) {
+	case *rsa.PrivateKey:
+		return &pk.PublicKey
+	case *ecdsa.PrivateKey:
+		return &pk.PublicKey
+	}
+	return nil
+}
+
+// KeySet 密钥集合
+type KeySet struct {
+	mu      sync.RWMutex
+	keys    map[string]*Key
+	signing string
+}
+
+// NewKeySet
+//  @Description  创建密钥集合
+//  @Param signing 签名使用的 kid
+//  @Param keys 所有可用于验签的密钥，包括轮换中的旧密钥
+//  @Return *KeySet
+//  @Return error signing 不在 keys 中或不能签名时返回错误
+func NewKeySet(signing string, keys ...*Key) (*KeySet, error) {
+	ks := &KeySet{}
+	if err := ks.Replace(signing, keys...); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// Replace
+//  @Description  替换所有密钥，用于配置热更新
+//  @Receiver ks
+//  @Param signing 签名使用的 kid
+//  @Param keys
+//  @Return error
+func (ks *KeySet) Replace(signing string, keys ...*Key) error {
+	m := make(map[string]*Key, len(keys))
+	for _, key := range keys {
+		m[key.ID] = key
+	}
+	if key, ok := m[signing]; !ok || key.signingKey() == nil {
+		return fmt.Errorf("kjwt: signing key %s not found or has no private key", signing)
+	}
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.keys = m
+	ks.signing = signing
+	return nil
+}
+
+// Add
+//  @Description  添加密钥，轮换时先添加新密钥，等所有实例都能验签后再 SetSigning
+//  @Receiver ks
+//  @Param key
+func (ks *KeySet) Add(key *Key) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.keys[key.ID] = key
+}
+
+// SetSigning
+//  @Description  切换签名密钥
+//  @Receiver ks
+//  @Param kid
+//  @Return error
+func (ks *KeySet) SetSigning(kid string) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if key, ok := ks.keys[kid]; !ok || key.signingKey() == nil {
+		return ErrUnknownKey
+	}
+	ks.signing = kid
+	return nil
+}
+
+// Remove
+//  @Description  移除密钥，旧密钥签发的 token 全部过期后再移除
+//  @Receiver ks
+//  @Param kid 不能移除签名密钥
+func (ks *KeySet) Remove(kid string) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if kid != ks.signing {
+		delete(ks.keys, kid)
+	}
+}
+
+// Sign
+//  @Description  使用签名密钥签发 token，头部携带 kid
+//  @Receiver ks
+//  @Param claims
+//  @Return string
+//  @Return error
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	return ks.sign(claims, "")
+}
+
+// sign typ 不为空时写入头部
+func (ks *KeySet) sign(claims jwt.Claims, typ string) (string, error) {
+	ks.mu.RLock()
+	key := ks.keys[ks.signing]
+	ks.mu.RUnlock()
+	if key == nil {
+		return "", ErrNoSigningKey
+	}
+	token := jwt.NewWithClaims(key.method(), claims)
+	token.Header["kid"] = key.ID
+	if typ != "" {
+		token.Header["typ"] = typ
+	}
+	return token.SignedString(key.signingKey())
+}
+
+// Parse
+//  @Description  按 kid 选择密钥验签并解析到 claims，算法必须与密钥一致
+//  @Receiver ks
+//  @Param tokenStr
+//  @Param claims 指针
+//  @Return error
+func (ks *KeySet) Parse(tokenStr string, claims jwt.Claims) error {
+	_, err := ks.parse(tokenStr, claims)
+	return err
+}
+
+func (ks *KeySet) parse(tokenStr string, claims jwt.Claims) (*jwt.Token, error) {
+	token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		ks.mu.RLock()
+		key := ks.keys[kid]
+		ks.mu.RUnlock()
+		if key == nil {
+			return nil, ErrUnknownKey
+		}
+		if token.Method.Alg() != key.Algorithm {
+			return nil, fmt.Errorf("kjwt: unexpected signing method %s", token.Method.Alg())
+		}
+		return key.verifyKey(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, errors.New("kjwt: invalid token")
+	}
+	return token, nil
+}
+
+// JWK 公钥，见 RFC 7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS 公钥集合
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS
+//  @Description  导出 RS256、ES256 公钥，HS256 密钥不导出
+//  @Receiver ks
+//  @Return JWKS
+func (ks *KeySet) JWKS() JWKS {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	set := JWKS{Keys: []JWK{}}
+	for _, key := range ks.keys {
+		jwk := JWK{Kid: key.ID, Use: "sig", Alg: key.Algorithm}
+		switch pub := key.verifyKey().(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = b64(pub.N.Bytes())
+			jwk.E = b64(big.NewInt(int64(pub.E)).Bytes())
+		case *ecdsa.PublicKey:
+			size := (pub.Curve.Params().BitSize + 7) / 8
+			jwk.Kty = "EC"
+			jwk.Crv = pub.Curve.Params().Name
+			jwk.X = b64(pad(pub.X.Bytes(), size))
+			jwk.Y = b64(pad(pub.Y.Bytes(), size))
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// pad 左侧补零到固定长度
+func pad(data []byte, size int) []byte {
+	if len(data) >= size {
+		return data
+	}
+	return append(make([]byte, size-len(data)), data...)
+}
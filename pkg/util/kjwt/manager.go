@@ -0,0 +1,323 @@
+// @Description token 签发、刷新与吊销
+
+package kjwt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/conf"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/cache"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/ecode"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
+
+	"github.com/dgrijalva/jwt-go"
+	perrors "github.com/pkg/errors"
+	"github.com/pborman/uuid"
+)
+
+//ModName ..
+const ModName = "util.kjwt"
+
+// ErrTokenType access token 与 refresh token 混用
+var ErrTokenType = errors.New("kjwt: unexpected token type")
+
+// Config 配置
+type Config struct {
+	// Keys 密钥，轮换时新旧密钥同时配置
+	Keys []KeyConfig
+	// Signing 签名使用的 kid
+	Signing string
+	// Issuer 签发方
+	Issuer string
+	// AccessTTL access token 有效期，默认 2h
+	AccessTTL time.Duration
+	// RefreshTTL refresh token 有效期，默认 720h
+	RefreshTTL time.Duration
+	// Cache 吊销列表使用的缓存配置 key，为空时保存在进程内
+	Cache string
+
+	key    string
+	logger *klog.Logger
+}
+
+// DefaultConfig ...
+func DefaultConfig() *Config {
+	return &Config{
+		AccessTTL:  2 * time.Hour,
+		RefreshTTL: 720 * time.Hour,
+		logger:     klog.KuaigoLogger.With(klog.FieldMod(ModName)),
+	}
+}
+
+// StdConfig ...
+func StdConfig(name string) *Config {
+	return RawConfig("tabby.jwt." + name)
+}
+
+// RawConfig ...
+func RawConfig(key string) *Config {
+	var config = DefaultConfig()
+	if err := conf.UnmarshalKey(key, &config); err != nil &&
+		perrors.Cause(err) != conf.ErrInvalidKey {
+		config.logger.Panic("jwt parse config panic", klog.FieldErrKind(ecode.ErrKindUnmarshalConfigErr), klog.FieldErr(err), klog.FieldKey(key))
+	}
+	config.key = key
+	return config
+}
+
+// WithLogger ...
+func (config *Config) WithLogger(logger *klog.Logger) *Config {
+	config.logger = logger
+	return config
+}
+
+func (config *Config) buildKeySet() (*KeySet, error) {
+	keys := make([]*Key, 0, len(config.Keys))
+	for _, kc := range config.Keys {
+		key, err := kc.Build()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return NewKeySet(config.Signing, keys...)
+}
+
+// Build
+//  @Description  创建 Manager，公钥注册到治理服务的 /jwks.json，由 RawConfig 创建时配置变更后重新加载密钥
+//  @Receiver config
+//  @Return *Manager
+func (config *Config) Build() *Manager {
+	var store Store
+	if config.Cache != "" {
+		store = NewCacheStore(cache.GetCacheManagerInstance().GetAdvanceCache(context.Background(), config.Cache))
+	} else {
+		store = NewMemoryStore()
+	}
+	return config.BuildWithStore(store)
+}
+
+// BuildWithStore
+//  @Description  使用指定的吊销列表创建 Manager
+//  @Receiver config
+//  @Param store
+//  @Return *Manager
+func (config *Config) BuildWithStore(store Store) *Manager {
+	keys, err := config.buildKeySet()
+	if err != nil {
+		config.logger.Panic("jwt build keyset panic", klog.FieldErrKind(ecode.ErrKindUnmarshalConfigErr), klog.FieldErr(err), klog.FieldKey(config.key))
+	}
+	registerJWKS(keys)
+	if config.key != "" {
+		conf.OnChange(func(c *conf.Configuration) {
+			next := DefaultConfig()
+			if err := c.UnmarshalKey(config.key, &next); err != nil {
+				config.logger.Warn("jwt reload config failed", klog.FieldErr(err), klog.FieldKey(config.key))
+				return
+			}
+			fresh, err := next.buildKeySet()
+			if err != nil {
+				config.logger.Warn("jwt reload keyset failed", klog.FieldErr(err), klog.FieldKey(config.key))
+				return
+			}
+			keys.mu.Lock()
+			keys.keys, keys.signing = fresh.keys, fresh.signing
+			keys.mu.Unlock()
+		})
+	}
+	return &Manager{config: config, keys: keys, store: store}
+}
+
+// TokenPair access token 与 refresh token
+type TokenPair struct {
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken"`
+	// ExpiresIn access token 有效期，单位秒
+	ExpiresIn int64 `json:"expiresIn"`
+}
+
+// Manager token 签发、刷新与吊销
+type Manager struct {
+	config *Config
+	keys   *KeySet
+	store  Store
+}
+
+// KeySet
+//  @Description  密钥集合，可用于手动轮换
+//  @Receiver m
+//  @Return *KeySet
+func (m *Manager) KeySet() *KeySet {
+	return m.keys
+}
+
+// Issue
+//  @Description  签发 access token，设置 jti、iat、iss，exp 为 0 时按 AccessTTL 设置
+//  @Receiver m
+//  @Param claims
+//  @Return string
+//  @Return error
+func (m *Manager) Issue(claims Claims) (string, error) {
+	std := claims.Standard()
+	now := time.Now()
+	std.Id = uuid.New()
+	std.IssuedAt = now.Unix()
+	if std.ExpiresAt == 0 {
+		std.ExpiresAt = now.Add(m.config.AccessTTL).Unix()
+	}
+	if m.config.Issuer != "" {
+		std.Issuer = m.config.Issuer
+	}
+	return m.keys.Sign(claims)
+}
+
+// IssuePair
+//  @Description  登录时签发 access token 与新 family 的 refresh token
+//  @Receiver m
+//  @Param ctx
+//  @Param claims
+//  @Return *TokenPair
+//  @Return error
+func (m *Manager) IssuePair(ctx context.Context, claims Claims) (*TokenPair, error) {
+	return m.issuePair(ctx, claims, uuid.New(), "")
+}
+
+// issuePair old 为空时创建 family，否则将 family 的当前 refresh token 从 old 轮换为新 token
+func (m *Manager) issuePair(ctx context.Context, claims Claims, family, old string) (*TokenPair, error) {
+	claims.Standard().ExpiresAt = 0
+	access, err := m.Issue(claims)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	rc := &refreshClaims{Family: family, Payload: payload}
+	rc.Id = uuid.New()
+	rc.IssuedAt = now.Unix()
+	rc.ExpiresAt = now.Add(m.config.RefreshTTL).Unix()
+	rc.Issuer = m.config.Issuer
+	refresh, err := m.keys.sign(rc, tokenTypeRefresh)
+	if err != nil {
+		return nil, err
+	}
+	if old == "" {
+		err = m.store.SetRefresh(ctx, family, rc.Id, m.config.RefreshTTL)
+	} else {
+		err = m.store.RotateRefresh(ctx, family, old, rc.Id, m.config.RefreshTTL)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &TokenPair{AccessToken: access, RefreshToken: refresh, ExpiresIn: int64(m.config.AccessTTL / time.Second)}, nil
+}
+
+// Refresh
+//  @Description  使用 refresh token 换取新的 token 对，旧 refresh token 随即失效，重复使用时吊销整个 family
+//  @Receiver m
+//  @Param ctx
+//  @Param refreshToken
+//  @Param claims 自定义 claims 的零值指针，填充为签发时的 claims 后重新签发
+//  @Return *TokenPair
+//  @Return error ErrRefreshReused、ErrRefreshExpired、ErrTokenType 或解析错误
+func (m *Manager) Refresh(ctx context.Context, refreshToken string, claims Claims) (*TokenPair, error) {
+	rc, err := m.parseRefresh(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(rc.Payload, claims); err != nil {
+		return nil, err
+	}
+	return m.issuePair(ctx, claims, rc.Family, rc.Id)
+}
+
+func (m *Manager) parseRefresh(refreshToken string) (*refreshClaims, error) {
+	rc := &refreshClaims{}
+	token, err := m.keys.parse(refreshToken, rc)
+	if err != nil {
+		return nil, err
+	}
+	if token.Header["typ"] != tokenTypeRefresh {
+		return nil, ErrTokenType
+	}
+	return rc, nil
+}
+
+// Parse
+//  @Description  解析 access token 并检查吊销列表
+//  @Receiver m
+//  @Param ctx
+//  @Param tokenStr
+//  @Param claims 自定义 claims 指针
+//  @Return error ErrRevoked、ErrTokenType 或解析错误
+func (m *Manager) Parse(ctx context.Context, tokenStr string, claims Claims) error {
+	token, err := m.keys.parse(tokenStr, claims)
+	if err != nil {
+		return err
+	}
+	if token.Header["typ"] == tokenTypeRefresh {
+		return ErrTokenType
+	}
+	revoked, err := m.IsRevoked(ctx, claims)
+	if err != nil {
+		return err
+	}
+	if revoked {
+		return ErrRevoked
+	}
+	return nil
+}
+
+// IsRevoked
+//  @Description  access token 是否已吊销
+//  @Receiver m
+//  @Param ctx
+//  @Param claims
+//  @Return bool
+//  @Return error
+func (m *Manager) IsRevoked(ctx context.Context, claims Claims) (bool, error) {
+	jti := claims.Standard().Id
+	if jti == "" {
+		return false, nil
+	}
+	return m.store.IsRevoked(ctx, jti)
+}
+
+// Revoke
+//  @Description  吊销 access token，直到其过期
+//  @Receiver m
+//  @Param ctx
+//  @Param claims
+//  @Return error
+func (m *Manager) Revoke(ctx context.Context, claims Claims) error {
+	std := claims.Standard()
+	ttl := time.Until(time.Unix(std.ExpiresAt, 0))
+	if std.Id == "" || ttl <= 0 {
+		return nil
+	}
+	return m.store.Revoke(ctx, std.Id, ttl)
+}
+
+// RevokeRefresh
+//  @Description  吊销 refresh token 所在的 family，用于退出登录
+//  @Receiver m
+//  @Param ctx
+//  @Param refreshToken
+//  @Return error
+func (m *Manager) RevokeRefresh(ctx context.Context, refreshToken string) error {
+	rc, err := m.parseRefresh(refreshToken)
+	if err != nil {
+		var ve *jwt.ValidationError
+		// 已过期的 refresh token 无需吊销
+		if errors.As(err, &ve) && ve.Errors&jwt.ValidationErrorExpired != 0 {
+			return nil
+		}
+		return err
+	}
+	return m.store.RevokeFamily(ctx, rc.Family)
+}
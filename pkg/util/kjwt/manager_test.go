@@ -0,0 +1,119 @@
+package kjwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newManager(t *testing.T) *Manager {
+	config := DefaultConfig()
+	config.Issuer = "kuaigo"
+	config.Signing = "hs1"
+	config.Keys = []KeyConfig{{ID: "hs1", Secret: "f165058924942e1bd19ce6ded681984c"}}
+	return config.BuildWithStore(NewMemoryStore())
+}
+
+func TestKeySet_Rotate(t *testing.T) {
+	m := newManager(t)
+	old, err := m.Issue(&MyClaims{UserID: "1"})
+	assert.Nil(t, err)
+
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	m.KeySet().Add(&Key{ID: "es1", Algorithm: AlgES256, PrivateKey: ecKey})
+	m.KeySet().Add(&Key{ID: "rs1", Algorithm: AlgRS256, PrivateKey: rsaKey})
+
+	for _, kid := range []string{"es1", "rs1"} {
+		assert.Nil(t, m.KeySet().SetSigning(kid))
+		token, err := m.Issue(&MyClaims{UserID: "2"})
+		assert.Nil(t, err)
+		claims := &MyClaims{}
+		assert.Nil(t, m.Parse(context.Background(), token, claims))
+		assert.Equal(t, "2", claims.UserID)
+		assert.Equal(t, "kuaigo", claims.Issuer)
+	}
+
+	// 轮换后旧密钥签发的 token 仍然有效，移除旧密钥后失效
+	assert.Nil(t, m.Parse(context.Background(), old, &MyClaims{}))
+	m.KeySet().Remove("hs1")
+	assert.NotNil(t, m.Parse(context.Background(), old, &MyClaims{}))
+	assert.Equal(t, ErrUnknownKey, m.KeySet().SetSigning("hs1"))
+
+	// 只导出非对称公钥
+	set := m.KeySet().JWKS()
+	assert.Len(t, set.Keys, 2)
+	assert.Equal(t, "EC", set.Keys[0].Kty)
+	assert.Equal(t, "P-256", set.Keys[0].Crv)
+	assert.Equal(t, "RSA", set.Keys[1].Kty)
+	assert.Equal(t, "AQAB", set.Keys[1].E)
+
+	w := httptest.NewRecorder()
+	JWKSHandler(m.KeySet())(w, httptest.NewRequest(http.MethodGet, "/jwks.json", nil))
+	var got JWKS
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.Equal(t, set, got)
+}
+
+func TestManager_Refresh(t *testing.T) {
+	m := newManager(t)
+	ctx := context.Background()
+	pair, err := m.IssuePair(ctx, &MyClaims{UserID: "1", AppID: 101})
+	assert.Nil(t, err)
+
+	// refresh token 不能当作 access token 使用
+	assert.Equal(t, ErrTokenType, m.Parse(ctx, pair.RefreshToken, &MyClaims{}))
+	_, err = m.Refresh(ctx, pair.AccessToken, &MyClaims{})
+	assert.Equal(t, ErrTokenType, err)
+
+	next, err := m.Refresh(ctx, pair.RefreshToken, &MyClaims{})
+	assert.Nil(t, err)
+	claims := &MyClaims{}
+	assert.Nil(t, m.Parse(ctx, next.AccessToken, claims))
+	assert.Equal(t, "1", claims.UserID)
+	assert.Equal(t, 101, claims.AppID)
+
+	// 重用旧 refresh token 后整个 family 失效
+	_, err = m.Refresh(ctx, pair.RefreshToken, &MyClaims{})
+	assert.Equal(t, ErrRefreshReused, err)
+	_, err = m.Refresh(ctx, next.RefreshToken, &MyClaims{})
+	assert.Equal(t, ErrRefreshExpired, err)
+
+	// 退出登录
+	pair, _ = m.IssuePair(ctx, &MyClaims{UserID: "1"})
+	assert.Nil(t, m.RevokeRefresh(ctx, pair.RefreshToken))
+	_, err = m.Refresh(ctx, pair.RefreshToken, &MyClaims{})
+	assert.Equal(t, ErrRefreshExpired, err)
+}
+
+func TestManager_Revoke(t *testing.T) {
+	m := newManager(t)
+	ctx := context.Background()
+	token, _ := m.Issue(&MyClaims{UserID: "1"})
+	claims := &MyClaims{}
+	assert.Nil(t, m.Parse(ctx, token, claims))
+	assert.Nil(t, m.Revoke(ctx, claims))
+	assert.Equal(t, ErrRevoked, m.Parse(ctx, token, &MyClaims{}))
+	revoked, err := m.IsRevoked(ctx, claims)
+	assert.Nil(t, err)
+	assert.True(t, revoked)
+}
+
+func TestMemoryStore_Sweep(t *testing.T) {
+	s := NewMemoryStore().(*memoryStore)
+	ctx := context.Background()
+	assert.Nil(t, s.Revoke(ctx, "old", time.Millisecond))
+	s.swept = time.Now().Add(-memorySweepInterval)
+	time.Sleep(2 * time.Millisecond)
+	assert.Nil(t, s.Revoke(ctx, "new", time.Minute))
+	assert.Len(t, s.entries, 1)
+}
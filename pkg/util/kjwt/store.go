@@ -0,0 +1,200 @@
+// @Description token 吊销列表与 refresh token 轮换记录
+
+package kjwt
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/cache/config"
+)
+
+var (
+	// ErrRevoked token 已吊销
+	ErrRevoked = errors.New("kjwt: token revoked")
+	// ErrRefreshReused refresh token 已被使用过，同一 family 的 token 全部吊销
+	ErrRefreshReused = errors.New("kjwt: refresh token reused")
+	// ErrRefreshExpired refresh token 已过期或已吊销
+	ErrRefreshExpired = errors.New("kjwt: refresh token expired or revoked")
+)
+
+// Store 吊销列表与 refresh token 记录
+type Store interface {
+	// Revoke 吊销 jti，ttl 为 token 的剩余有效期
+	Revoke(ctx context.Context, jti string, ttl time.Duration) error
+	// IsRevoked jti 是否已吊销
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+	// SetRefresh 记录 family 当前有效的 refresh token
+	SetRefresh(ctx context.Context, family, jti string, ttl time.Duration) error
+	// RotateRefresh 当前有效的是 old 时替换为 next，不是 old 时删除 family 并返回 ErrRefreshReused，family 不存在时返回 ErrRefreshExpired
+	RotateRefresh(ctx context.Context, family, old, next string, ttl time.Duration) error
+	// RevokeFamily 吊销 family
+	RevokeFamily(ctx context.Context, family string) error
+}
+
+const (
+	revokedKeyPrefix = "kuaigo:jwt:revoked:"
+	refreshKeyPrefix = "kuaigo:jwt:refresh:"
+)
+
+// rotateScript 返回 1 成功，0 重用，-1 不存在
+const rotateScript = `
+local cur = redis.call('GET', KEYS[1])
+if not cur then
+	return -1
+end
+if cur ~= ARGV[1] then
+	redis.call('DEL', KEYS[1])
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+return 1
+`
+
+type cacheStore struct {
+	cache config.IAdvanceCache
+}
+
+// NewCacheStore
+//  @Description  基于缓存的吊销列表，多实例共享
+//  @Param cache
+//  @Return Store
+func NewCacheStore(cache config.IAdvanceCache) Store {
+	return &cacheStore{cache: cache}
+}
+
+// Revoke ...
+func (s *cacheStore) Revoke(ctx context.Context, jti string, ttl time.Duration) error {
+	return s.cache.WithAdvanceContext(ctx).SetWithErr(revokedKeyPrefix+jti, 1, ttl)
+}
+
+// IsRevoked ...
+func (s *cacheStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	return s.cache.WithAdvanceContext(ctx).ExistsWithErr(revokedKeyPrefix + jti)
+}
+
+// SetRefresh ...
+func (s *cacheStore) SetRefresh(ctx context.Context, family, jti string, ttl time.Duration) error {
+	return s.cache.WithAdvanceContext(ctx).SetWithErr(refreshKeyPrefix+family, jti, ttl)
+}
+
+// RotateRefresh ...
+func (s *cacheStore) RotateRefresh(ctx context.Context, family, old, next string, ttl time.Duration) error {
+	res, err := s.cache.WithAdvanceContext(ctx).Eval(rotateScript, []string{refreshKeyPrefix + family}, old, next, strconv.FormatInt(int64(ttl/time.Millisecond), 10))
+	if err != nil {
+		return err
+	}
+	switch res.(int64) {
+	case 1:
+		return nil
+	case 0:
+		return ErrRefreshReused
+	}
+	return ErrRefreshExpired
+}
+
+// RevokeFamily ...
+func (s *cacheStore) RevokeFamily(ctx context.Context, family string) error {
+	_, err := s.cache.WithAdvanceContext(ctx).DelWithErr(refreshKeyPrefix + family)
+	return err
+}
+
+type memoryEntry struct {
+	value  string
+	expire time.Time
+}
+
+// memorySweepInterval 内存吊销列表清理过期记录的最小间隔
+const memorySweepInterval = time.Minute
+
+type memoryStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	swept   time.Time
+}
+
+// NewMemoryStore
+//  @Description  进程内的吊销列表，只适用于单实例部署与测试，写入时清理过期记录
+//  @Return Store
+func NewMemoryStore() Store {
+	return &memoryStore{entries: make(map[string]memoryEntry), swept: time.Now()}
+}
+
+func (s *memoryStore) get(key string) (string, bool) {
+	e, ok := s.entries[key]
+	if !ok || time.Now().After(e.expire) {
+		delete(s.entries, key)
+		return "", false
+	}
+	return e.value, true
+}
+
+func (s *memoryStore) set(key, value string, ttl time.Duration) {
+	now := time.Now()
+	s.sweep(now)
+	s.entries[key] = memoryEntry{value: value, expire: now.Add(ttl)}
+}
+
+// sweep 清理过期记录，最多每 memorySweepInterval 执行一次
+func (s *memoryStore) sweep(now time.Time) {
+	if now.Sub(s.swept) < memorySweepInterval {
+		return
+	}
+	s.swept = now
+	for key, e := range s.entries {
+		if now.After(e.expire) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// Revoke ...
+func (s *memoryStore) Revoke(ctx context.Context, jti string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.set(revokedKeyPrefix+jti, "1", ttl)
+	return nil
+}
+
+// IsRevoked ...
+func (s *memoryStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.get(revokedKeyPrefix + jti)
+	return ok, nil
+}
+
+// SetRefresh ...
+func (s *memoryStore) SetRefresh(ctx context.Context, family, jti string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.set(refreshKeyPrefix+family, jti, ttl)
+	return nil
+}
+
+// RotateRefresh ...
+func (s *memoryStore) RotateRefresh(ctx context.Context, family, old, next string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cur, ok := s.get(refreshKeyPrefix + family)
+	if !ok {
+		return ErrRefreshExpired
+	}
+	if cur != old {
+		delete(s.entries, refreshKeyPrefix+family)
+		return ErrRefreshReused
+	}
+	s.set(refreshKeyPrefix+family, next, ttl)
+	return nil
+}
+
+// RevokeFamily ...
+func (s *memoryStore) RevokeFamily(ctx context.Context, family string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, refreshKeyPrefix+family)
+	return nil
+}
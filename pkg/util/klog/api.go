@@ -8,6 +8,7 @@ package klog
 import (
 	"context"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/conf"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/redact"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/zap"
 )
 
@@ -201,12 +202,14 @@ func InitLogger(configVersion, serviceName string) {
 	accessKey := "access"
 	errKey := "error"
 	taskKey := "task"
+	redactKey := "redact"
 	if configVersion > "" {
 		runningKey = "logging.running"
 		tabbyKey = "logging.default"
 		accessKey = "logging.access"
 		errKey = "logging.error"
 		taskKey = "logging.task"
+		redactKey = "logging.redact"
 	}
 	if conf.Get(runningKey) != nil {
 		RunningLogger = RawConfig(runningKey).WithConfigVersion(configVersion).Build().clone()
@@ -233,4 +236,31 @@ func InitLogger(configVersion, serviceName string) {
 	}
 	TaskLogger.SetServiceName(serviceName)
 
+	initRedactor(redactKey)
+}
+
+// initRedactor
+// 	@Description 从配置中加载脱敏规则，并在配置变更时重新加载，未配置时使用默认规则
+//	@Param key 配置key
+func initRedactor(key string) {
+	load := func(get func(string) interface{}, unmarshal func(string, interface{}, ...conf.GetOption) error) {
+		if get(key) == nil {
+			return
+		}
+		cfg := redact.DefaultConfig()
+		if err := unmarshal(key, &cfg); err != nil {
+			KuaigoLogger.Error("unmarshal redact config", FieldMod("klog"), FieldErr(err), FieldKey(key))
+			return
+		}
+		r, err := cfg.Build()
+		if err != nil {
+			KuaigoLogger.Error("build redact config", FieldMod("klog"), FieldErr(err), FieldKey(key))
+			return
+		}
+		redact.SetDefault(r)
+	}
+	load(conf.Get, conf.UnmarshalKey)
+	conf.OnChange(func(config *conf.Configuration) {
+		load(config.Get, config.UnmarshalKey)
+	})
 }
@@ -0,0 +1,173 @@
+// @Description 按行切分缓冲刷新的日志，分批交给后台协程发送，供 mq、http 等远程输出源使用
+
+package batch
+
+import (
+	"bytes"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// syncTimeout Sync 等待队列发送完成的最长时间
+const syncTimeout = 3 * time.Second
+
+// Transport 批次的发送方式，均在后台协程中依次调用
+type Transport interface {
+	// Send 发送一个批次
+	Send(lines [][]byte)
+	// Drop 队列已满时丢弃的批次
+	Drop(lines [][]byte)
+}
+
+// Writer 按行切分缓冲刷新的数据，按 batchSize 分批放入队列，由后台协程交给 Transport 发送
+type Writer struct {
+	transport Transport
+	batchSize int
+
+	mu      sync.Mutex
+	pending []byte
+
+	queue    chan [][]byte
+	flushReq chan chan struct{}
+	done     chan struct{}
+	stopped  chan struct{}
+	once     sync.Once
+}
+
+// NewWriter
+// 	@Description 创建并启动后台协程
+//	@Param transport 发送方式
+//	@Param queueSize 队列长度，队列满时丢弃
+//	@Param batchSize 每个批次的最大行数，0 为每次写入作为一个批次
+// 	@Return *Writer
+func NewWriter(transport Transport, queueSize, batchSize int) *Writer {
+	w := &Writer{
+		transport: transport,
+		batchSize: batchSize,
+		queue:     make(chan [][]byte, queueSize),
+		flushReq:  make(chan chan struct{}),
+		done:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Write
+// 	@Description 写入缓冲刷新的数据，不完整的行留到下次写入
+// 	@Receiver w
+//	@Param p
+// 	@Return int
+// 	@Return error
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	w.pending = append(w.pending, p...)
+	idx := bytes.LastIndexByte(w.pending, '\n')
+	if idx < 0 {
+		w.mu.Unlock()
+		return len(p), nil
+	}
+	var lines [][]byte
+	for _, line := range bytes.Split(w.pending[:idx], []byte{'\n'}) {
+		if len(line) == 0 {
+			continue
+		}
+		lines = append(lines, append([]byte(nil), line...))
+	}
+	w.pending = append(w.pending[:0], w.pending[idx+1:]...)
+	w.mu.Unlock()
+
+	for len(lines) > 0 {
+		n := len(lines)
+		if w.batchSize > 0 && n > w.batchSize {
+			n = w.batchSize
+		}
+		select {
+		case w.queue <- lines[:n]:
+		default:
+			w.transport.Drop(lines[:n])
+		}
+		lines = lines[n:]
+	}
+	return len(p), nil
+}
+
+// Sync
+// 	@Description 等待队列中的批次发送完成
+// 	@Receiver w
+// 	@Return error
+func (w *Writer) Sync() error {
+	ack := make(chan struct{})
+	select {
+	case w.flushReq <- ack:
+	case <-w.stopped:
+		return nil
+	case <-time.After(syncTimeout):
+		return nil
+	}
+	select {
+	case <-ack:
+	case <-time.After(syncTimeout):
+	}
+	return nil
+}
+
+// Close
+// 	@Description 发送剩余批次后停止后台协程
+// 	@Receiver w
+// 	@Return error
+func (w *Writer) Close() error {
+	w.once.Do(func() {
+		close(w.done)
+	})
+	<-w.stopped
+	return nil
+}
+
+// Pressure 队列积压是否超过一半
+func (w *Writer) Pressure() bool {
+	return len(w.queue)*2 >= cap(w.queue)
+}
+
+func (w *Writer) run() {
+	defer close(w.stopped)
+	for {
+		select {
+		case lines := <-w.queue:
+			w.transport.Send(lines)
+		case ack := <-w.flushReq:
+			w.drain()
+			close(ack)
+		case <-w.done:
+			w.drain()
+			return
+		}
+	}
+}
+
+func (w *Writer) drain() {
+	for {
+		select {
+		case lines := <-w.queue:
+			w.transport.Send(lines)
+		default:
+			return
+		}
+	}
+}
+
+// FlushSyncer 缓冲刷新后继续等待发送完成，保证 klog.FlushAll 时日志已发出
+type FlushSyncer struct {
+	zapcore.WriteSyncer
+	Writer interface{ Sync() error }
+}
+
+// Sync ...
+func (s *FlushSyncer) Sync() error {
+	if err := s.WriteSyncer.Sync(); err != nil {
+		return err
+	}
+	return s.Writer.Sync()
+}
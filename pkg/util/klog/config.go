@@ -9,10 +9,13 @@ import (
 	"github.com/LuoHongLiang0921/kuaigo/pkg/conf"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/console"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/file"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/http"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/manager"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/mq"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/redis"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/zap"
 	"github.com/mitchellh/mapstructure"
+	"time"
 )
 
 // Config 日志配置项
@@ -21,7 +24,7 @@ type Config struct {
 	ConfigVersion string
 	// Deprecated: 输出类型 file:1, redis:2,http:4
 	store int
-	// Deprecated: Store 输出类型 file redis http
+	// Deprecated: Store 输出类型 file redis http，请使用 Output 配置
 	Store []string
 	// ServiceName 服务名
 	ServiceName string
@@ -39,11 +42,39 @@ type Config struct {
 	LoggerType string
 	//Output 日志输出源配置
 	Output map[string]interface{}
+	//Sampling 按日志消息采样，为空时不采样
+	Sampling *SamplingConfig
 
 	//Alias 配置别名 full
 	Alias string `yaml:"-" mapstructure:"-"`
 }
 
+// SamplingConfig 采样配置，相同级别和消息的日志在每个 Tick 内先输出 Initial 条，之后每 Thereafter 条输出1条
+type SamplingConfig struct {
+	// Initial 每个周期内先输出的条数
+	Initial int
+	// Thereafter 超出 Initial 后每多少条输出1条
+	Thereafter int
+	// Tick 采样周期，默认1s
+	Tick time.Duration
+}
+
+// wrap
+// 	@Description 为 core 增加采样
+// 	@Receiver s
+//	@Param core
+// 	@Return zap.Core
+func (s *SamplingConfig) wrap(core zap.Core) zap.Core {
+	if s.Initial <= 0 || s.Thereafter <= 0 {
+		return core
+	}
+	tick := s.Tick
+	if tick <= 0 {
+		tick = time.Second
+	}
+	return zap.NewSamplerWithOptions(core, tick, s.Initial, s.Thereafter)
+}
+
 // RawConfig ...
 func RawConfig(key string) *Config {
 	var config Config
@@ -100,6 +131,8 @@ func (c Config) RegisterOutput() Config {
 	console.RegisterOutputCreatorHandler()
 	file.RegisterOutputCreatorHandler()
 	redis.RegisterOutputCreatorHandler()
+	mq.RegisterOutputCreatorHandler()
+	http.RegisterOutputCreatorHandler()
 	return c
 }
 
@@ -161,6 +194,34 @@ func (c Config) Build() *Logger {
 			redisCfg.SetDefaultConfig()
 			redisCfg.SetParent(c.Alias).SetAutoLevel()
 			outputCfg = redisCfg
+		case mq.OutputMQ:
+			var mqCfg mq.Config
+			err := mapstructure.Decode(v, &mqCfg)
+			if err != nil {
+				panic(err)
+			}
+			mqCfg.SetDefaultConfig()
+			if mqCfg.Level == "" {
+				mqCfg.Level = c.Level
+			}
+			mqCfg.SetParent(c.Alias).SetAutoLevel()
+			outputCfg = mqCfg
+		case http.OutputHTTP:
+			var httpCfg http.Config
+			err := mapstructure.Decode(v, &httpCfg)
+			if err != nil {
+				panic(err)
+			}
+			httpCfg.SetDefaultConfig()
+			if httpCfg.Level == "" {
+				httpCfg.Level = c.Level
+			}
+			if httpCfg.ServiceName == "" {
+				httpCfg.ServiceName = c.ServiceName
+			}
+			httpCfg.LogType = c.LoggerType
+			httpCfg.SetParent(c.Alias).SetAutoLevel()
+			outputCfg = httpCfg
 		}
 		ok, fn := manager.GetCreator(outputType)
 		if ok {
@@ -171,8 +232,12 @@ func (c Config) Build() *Logger {
 	if len(cores) <= 0 {
 		panic("logger no output,please register logger output")
 	}
+	core := zap.NewTee(cores...)
+	if c.Sampling != nil {
+		core = c.Sampling.wrap(core)
+	}
 	zapLogger := zap.New(
-		zap.NewTee(cores...),
+		newModuleCore(core),
 		zapOptions...,
 	)
 
@@ -7,6 +7,8 @@ package klog
 
 import (
 	"github.com/LuoHongLiang0921/kuaigo/pkg/conf"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/zap"
+	"go.uber.org/zap/zaptest/observer"
 	"testing"
 
 	yaml "gopkg.in/yaml.v3"
@@ -69,3 +71,16 @@ func TestConfig_Build(t *testing.T) {
 		}
 	}
 }
+
+func TestSamplingConfig_wrap(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	zapLogger := zap.New(newModuleCore((&SamplingConfig{Initial: 2, Thereafter: 5}).wrap(core)))
+	lg := &Logger{desugar: zapLogger, config: &Config{}, sugar: zapLogger.Sugar()}
+
+	for i := 0; i < 12; i++ {
+		lg.Info("same message")
+	}
+	lg.Info("other message")
+	// 前2条 + 第7、12条 + 其他消息1条
+	assert.Equal(t, 5, logs.Len())
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/LuoHongLiang0921/kuaigo/pkg/defers"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/buffer"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/property"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/redact"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/zap"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/util/ktime"
 	"os"
@@ -154,7 +155,7 @@ func (c Config) Build() zap.Core {
 	}
 	core := zap.NewCore(
 		func() zap.Encoder {
-			return NewConsoleEncoder(encoderCfg)
+			return redact.NewEncoder(NewConsoleEncoder(encoderCfg))
 		}(),
 		ws,
 		c.lv,
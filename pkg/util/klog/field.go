@@ -138,6 +138,14 @@ func FieldKey(value string) zap.Field {
 	return zap.String("key", value)
 }
 
+// FieldSQL
+// 	@Description 设置 sql 字段值，输出时语句中的字面量会被脱敏
+//	@Param value sql 语句
+// 	@Return Field 设置 sql 字段值后的字段
+func FieldSQL(value string) zap.Field {
+	return zap.String("sql", value)
+}
+
 // FieldValueAny
 // 	@Description 设置 value 字段值
 //	@Param value value 值
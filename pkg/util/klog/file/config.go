@@ -7,6 +7,7 @@ import (
 	"github.com/LuoHongLiang0921/kuaigo/pkg/conf"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/defers"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/buffer"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/redact"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/rotate"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/zap"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/util/ktime"
@@ -116,7 +117,7 @@ func (c *Config) Build() zap.Core {
 
 	fileCore := zap.NewCore(
 		func() zap.Encoder {
-			return zap.NewJSONEncoder(getFileEncoderConfig())
+			return redact.NewEncoder(zap.NewJSONEncoder(getFileEncoderConfig()))
 		}(),
 		ws,
 		c.lv,
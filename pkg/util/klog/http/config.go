@@ -0,0 +1,204 @@
+// @Description
+
+package http
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/conf"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/defers"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/batch"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/buffer"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/redact"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/zap"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/ktime"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	OutputHTTP = "http"
+
+	// ProtocolJSON 以 klog.Log 结构的 json 数组发送
+	ProtocolJSON = "json"
+	// ProtocolOTLP 以 OTLP/HTTP json 格式发送
+	ProtocolOTLP = "otlp"
+)
+
+type Config struct {
+	// Level 日志等级
+	Level string
+	// Endpoint 收集端地址，otlp 协议一般为 http://host:4318/v1/logs
+	Endpoint string
+	// Protocol 发送协议，json 或 otlp，默认 json
+	Protocol string
+	// Headers 请求附带的头部，如鉴权信息
+	Headers map[string]string
+	// Timeout 单次请求超时时间
+	Timeout string
+
+	// FlushInterval 缓冲刷新间隔
+	FlushInterval string
+	// BufferSize 缓冲大小
+	BufferSize int
+	// BatchSize 每次请求最多发送的日志条数
+	BatchSize int
+	// QueueSize 待发送批次的队列长度，队列满时丢弃
+	QueueSize int
+
+	// MaxRetries 发送失败后按退避间隔重放落盘批次的次数，超过后按 ReplayInterval 重放
+	MaxRetries int
+	// Backoff 发送失败后首次重放的等待时间，之后每次翻倍
+	Backoff string
+	// MaxBackoff 退避等待时间上限
+	MaxBackoff string
+
+	// SpoolDir 收集端不可用时批次落盘的目录，默认为临时目录下的 klog-spool
+	SpoolDir string
+	// SpoolMaxSize 落盘总大小上限，超出后删除最早的批次
+	SpoolMaxSize int64
+	// ReplayInterval 退避重试用尽或无待重放批次时的检查间隔
+	ReplayInterval string
+
+	// LogType 日志类型，对应 klog.Log 的 type 字段，由日志配置的 LoggerType 填充
+	LogType string `mapstructure:"-"`
+	// ServiceName 服务名，otlp 协议下作为 service.name 资源属性，未配置时使用日志配置的服务名
+	ServiceName string
+
+	parentKey string           `mapstructure:"-"`
+	lv        *zap.AtomicLevel `mapstructure:"-"`
+}
+
+// SetDefaultConfig
+// 	@Description 设置默认配置
+// 	@Receiver c
+// 	@Return *Config
+func (c *Config) SetDefaultConfig() *Config {
+	if c.Protocol == "" {
+		c.Protocol = ProtocolJSON
+	}
+	if c.Timeout == "" {
+		c.Timeout = "3s"
+	}
+	if c.FlushInterval == "" {
+		c.FlushInterval = "1s"
+	}
+	if c.BufferSize == 0 {
+		c.BufferSize = 256 * 1024
+	}
+	if c.BatchSize == 0 {
+		c.BatchSize = 500
+	}
+	if c.QueueSize == 0 {
+		c.QueueSize = 256
+	}
+	if c.MaxRetries == 0 {
+		c.MaxRetries = 3
+	}
+	if c.Backoff == "" {
+		c.Backoff = "200ms"
+	}
+	if c.MaxBackoff == "" {
+		c.MaxBackoff = "5s"
+	}
+	if c.SpoolDir == "" {
+		c.SpoolDir = filepath.Join(os.TempDir(), "klog-spool")
+	}
+	if c.SpoolMaxSize == 0 {
+		c.SpoolMaxSize = 64 * 1024 * 1024
+	}
+	if c.ReplayInterval == "" {
+		c.ReplayInterval = "5s"
+	}
+	return c
+}
+
+// SetParent
+// 	@Description 设置父级key
+// 	@Receiver c
+//	@Param k
+// 	@Return *Config
+func (c *Config) SetParent(k string) *Config {
+	c.parentKey = k
+	return c
+}
+
+// SetAutoLevel
+// 	@Description 设置auto level
+// 	@Receiver c
+// 	@Return *Config
+func (c *Config) SetAutoLevel() *Config {
+	var lv zap.Level
+
+	err := lv.Set(c.Level)
+	if err != nil {
+		panic(err)
+	}
+	alv := zap.NewAtomicLevelAt(lv)
+	c.lv = &alv
+	if c.parentKey == "" {
+		return c
+	}
+	conf.OnChange(func(config *conf.Configuration) {
+		lvText := strings.ToLower(config.GetString(c.parentKey + ".output.http.level"))
+		if lvText != "" {
+			err := c.lv.UnmarshalText([]byte(lvText))
+			if err != nil {
+				return
+			}
+		}
+	})
+	return c
+}
+
+// Build
+// 	@Description 构建 http 输出源，日志先写入缓冲，按批次异步发送，失败时落盘并在恢复后重放
+// 	@Receiver c
+// 	@Return zapcore.Core
+func (c Config) Build() zapcore.Core {
+	if c.Endpoint == "" {
+		panic(fmt.Errorf("%s http output endpoint is empty", c.parentKey))
+	}
+	if c.Protocol != ProtocolJSON && c.Protocol != ProtocolOTLP {
+		panic(fmt.Errorf("%s http output protocol %s is not supported", c.parentKey, c.Protocol))
+	}
+	if c.lv == nil {
+		panic(fmt.Errorf("%s atom level is empty", c.parentKey))
+	}
+
+	writer, err := newSendWriter(c)
+	if err != nil {
+		panic(err)
+	}
+	ws, close := buffer.Buffer(writer, c.BufferSize, ktime.Duration(c.FlushInterval))
+	defers.Register(func() error {
+		_ = close()
+		return writer.Close()
+	})
+
+	return zap.NewCore(
+		redact.NewEncoder(zap.NewJSONEncoder(getHTTPEncoderConfig())),
+		&batch.FlushSyncer{WriteSyncer: ws, Writer: writer},
+		c.lv,
+	)
+}
+
+func getHTTPEncoderConfig() zap.EncoderConfig {
+	return zap.EncoderConfig{
+		TimeKey:          "timestamp",
+		LevelKey:         levelKey,
+		NameKey:          zap.OmitKey,
+		CallerKey:        "caller",
+		MessageKey:       "msg",
+		StacktraceKey:    "stack",
+		LineEnding:       zap.DefaultLineEnding,
+		EncodeLevel:      zap.LowercaseLevelEncoder,
+		EncodeTime:       zap.EpochMillisTimeEncoder,
+		EncodeDuration:   zap.SecondsDurationEncoder,
+		EncodeCaller:     zap.ShortCallerEncoder,
+		EncodeName:       zap.FullNameEncoder,
+		ConsoleSeparator: " ",
+	}
+}
@@ -0,0 +1,232 @@
+// @Description 批次编码
+
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	levelKey     = "level"
+	messageKey   = "msg"
+	timestampKey = "timestamp"
+)
+
+// commonKeys 归入 klog.Log common 部分的字段，其余字段放入 params
+var commonKeys = map[string]struct{}{
+	"appId":         {},
+	"traceId":       {},
+	"logLevel":      {},
+	"serviceSource": {},
+	"serviceName":   {},
+	"fileName":      {},
+	"line":          {},
+	"requestIp":     {},
+	"requestUri":    {},
+	timestampKey:    {},
+	"processCode":   {},
+	"costTime":      {},
+	"code":          {},
+	"uid":           {},
+	"p":             {},
+}
+
+// encoder 将一个批次的 json 行编码为请求体
+type encoder interface {
+	ContentType() string
+	Encode(lines [][]byte) ([]byte, error)
+}
+
+func newEncoder(c Config) encoder {
+	if c.Protocol == ProtocolOTLP {
+		return &otlpEncoder{serviceName: c.ServiceName}
+	}
+	return &logEncoder{logType: c.LogType}
+}
+
+func decodeLine(line []byte) (map[string]interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	var record map[string]interface{}
+	if err := dec.Decode(&record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// logEncoder 编码为 klog.Log 数组
+type logEncoder struct {
+	logType string
+}
+
+type logRecord struct {
+	Common  map[string]interface{} `json:"common"`
+	Params  map[string]interface{} `json:"params"`
+	LogType string                 `json:"type"`
+}
+
+// ContentType ...
+func (e *logEncoder) ContentType() string {
+	return "application/json"
+}
+
+// Encode ...
+func (e *logEncoder) Encode(lines [][]byte) ([]byte, error) {
+	records := make([]logRecord, 0, len(lines))
+	for _, line := range lines {
+		record, err := decodeLine(line)
+		if err != nil {
+			continue
+		}
+		r := logRecord{
+			Common:  make(map[string]interface{}),
+			Params:  make(map[string]interface{}),
+			LogType: e.logType,
+		}
+		level, _ := record[levelKey].(string)
+		delete(record, levelKey)
+		for k, v := range record {
+			if _, ok := commonKeys[k]; ok {
+				r.Common[k] = v
+				continue
+			}
+			r.Params[k] = v
+		}
+		if lv, _ := r.Common["logLevel"].(string); lv == "" && level != "" {
+			r.Common["logLevel"] = strings.ToUpper(level)
+		}
+		records = append(records, r)
+	}
+	return json.Marshal(records)
+}
+
+// otlpEncoder 编码为 OTLP/HTTP json 格式的 ExportLogsServiceRequest
+type otlpEncoder struct {
+	serviceName string
+}
+
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano   string         `json:"timeUnixNano"`
+	SeverityNumber int            `json:"severityNumber"`
+	SeverityText   string         `json:"severityText"`
+	Body           otlpValue      `json:"body"`
+	Attributes     []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpScopeLogs struct {
+	Scope      map[string]string `json:"scope"`
+	LogRecords []otlpLogRecord   `json:"logRecords"`
+}
+
+type otlpResourceLogs struct {
+	Resource  map[string][]otlpKeyValue `json:"resource"`
+	ScopeLogs []otlpScopeLogs           `json:"scopeLogs"`
+}
+
+type otlpRequest struct {
+	ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+}
+
+// severityNumbers zap 级别对应的 OTLP SeverityNumber
+var severityNumbers = map[string]int{
+	"debug":  5,
+	"info":   9,
+	"warn":   13,
+	"error":  17,
+	"dpanic": 21,
+	"panic":  21,
+	"fatal":  21,
+}
+
+// ContentType ...
+func (e *otlpEncoder) ContentType() string {
+	return "application/json"
+}
+
+// Encode ...
+func (e *otlpEncoder) Encode(lines [][]byte) ([]byte, error) {
+	logRecords := make([]otlpLogRecord, 0, len(lines))
+	for _, line := range lines {
+		record, err := decodeLine(line)
+		if err != nil {
+			continue
+		}
+		level, _ := record[levelKey].(string)
+		msg, _ := record[messageKey].(string)
+		var ts string
+		if n, ok := record[timestampKey].(json.Number); ok {
+			if ms, err := n.Float64(); err == nil {
+				ts = strconv.FormatInt(int64(ms*1e6), 10)
+			}
+		}
+		delete(record, levelKey)
+		delete(record, messageKey)
+		delete(record, timestampKey)
+
+		keys := make([]string, 0, len(record))
+		for k := range record {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		attrs := make([]otlpKeyValue, 0, len(keys))
+		for _, k := range keys {
+			attrs = append(attrs, otlpKeyValue{Key: k, Value: toOTLPValue(record[k])})
+		}
+		logRecords = append(logRecords, otlpLogRecord{
+			TimeUnixNano:   ts,
+			SeverityNumber: severityNumbers[level],
+			SeverityText:   strings.ToUpper(level),
+			Body:           otlpValue{StringValue: &msg},
+			Attributes:     attrs,
+		})
+	}
+	serviceName := e.serviceName
+	req := otlpRequest{
+		ResourceLogs: []otlpResourceLogs{{
+			Resource: map[string][]otlpKeyValue{
+				"attributes": {{Key: "service.name", Value: otlpValue{StringValue: &serviceName}}},
+			},
+			ScopeLogs: []otlpScopeLogs{{
+				Scope:      map[string]string{"name": "klog"},
+				LogRecords: logRecords,
+			}},
+		}},
+	}
+	return json.Marshal(req)
+}
+
+func toOTLPValue(v interface{}) otlpValue {
+	switch val := v.(type) {
+	case string:
+		return otlpValue{StringValue: &val}
+	case bool:
+		return otlpValue{BoolValue: &val}
+	case json.Number:
+		if _, err := val.Int64(); err == nil {
+			s := val.String()
+			return otlpValue{IntValue: &s}
+		}
+		if f, err := val.Float64(); err == nil {
+			return otlpValue{DoubleValue: &f}
+		}
+	}
+	bs, _ := json.Marshal(v)
+	s := string(bs)
+	return otlpValue{StringValue: &s}
+}
@@ -0,0 +1,25 @@
+// @Description
+
+package http
+
+import (
+	"sync"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/manager"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/zap"
+)
+
+var once sync.Once
+
+// RegisterOutputCreatorHandler
+// 	@Description 注册 http 输出源
+func RegisterOutputCreatorHandler() {
+	once.Do(func() {
+		manager.Register(OutputHTTP, func(cfg interface{}) []zap.Core {
+			if httpCfg, ok := cfg.(Config); ok {
+				return []zap.Core{httpCfg.Build()}
+			}
+			return nil
+		})
+	})
+}
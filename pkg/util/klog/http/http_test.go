@@ -0,0 +1,149 @@
+package http
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/zap"
+	"github.com/stretchr/testify/assert"
+)
+
+type collector struct {
+	mu     sync.Mutex
+	bodies [][]byte
+	down   int32
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if atomic.LoadInt32(&c.down) == 1 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	body, _ := ioutil.ReadAll(r.Body)
+	c.mu.Lock()
+	c.bodies = append(c.bodies, body)
+	c.mu.Unlock()
+}
+
+func (c *collector) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.bodies)
+}
+
+func newTestConfig(t *testing.T, endpoint string) Config {
+	cfg := Config{
+		Level:          "info",
+		Endpoint:       endpoint,
+		FlushInterval:  "1h",
+		Backoff:        "1ms",
+		MaxRetries:     2,
+		ReplayInterval: "1h",
+		SpoolDir:       t.TempDir(),
+		LogType:        "running",
+	}
+	cfg.SetDefaultConfig().SetAutoLevel()
+	return cfg
+}
+
+func TestConfig_Build(t *testing.T) {
+	c := &collector{}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	logger := zap.New(newTestConfig(t, srv.URL).Build())
+	logger.Info("hello", zap.String("traceId", "t1"), zap.String("k", "v"))
+	logger.Debug("ignored")
+	assert.Nil(t, logger.Sync())
+	assert.Equal(t, 1, c.len())
+
+	var records []logRecord
+	assert.Nil(t, json.Unmarshal(c.bodies[0], &records))
+	assert.Len(t, records, 1)
+	assert.Equal(t, "running", records[0].LogType)
+	assert.Equal(t, "t1", records[0].Common["traceId"])
+	assert.Equal(t, "INFO", records[0].Common["logLevel"])
+	assert.Equal(t, "hello", records[0].Params["msg"])
+	assert.Equal(t, "v", records[0].Params["k"])
+}
+
+func TestSendWriter_SpoolAndReplay(t *testing.T) {
+	c := &collector{down: 1}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	w, err := newSendWriter(newTestConfig(t, srv.URL))
+	assert.Nil(t, err)
+	defer w.Close()
+
+	_, _ = w.Write([]byte(`{"msg":"a","level":"info"}` + "\n"))
+	_, _ = w.Write([]byte(`{"msg":"b","level":"info"}` + "\n"))
+	assert.Nil(t, w.Sync())
+	assert.Equal(t, 0, c.len())
+	assert.Equal(t, uint64(2), w.stats().Spooled)
+	assert.Equal(t, 2, w.stats().Pending)
+
+	atomic.StoreInt32(&c.down, 0)
+	_, _ = w.Write([]byte(`{"msg":"c","level":"info"}` + "\n"))
+	assert.Nil(t, w.Sync())
+	assert.Equal(t, 3, c.len())
+	assert.Contains(t, string(c.bodies[0]), `"msg":"a"`)
+	assert.Contains(t, string(c.bodies[1]), `"msg":"b"`)
+	assert.Contains(t, string(c.bodies[2]), `"msg":"c"`)
+	stats := w.stats()
+	assert.Equal(t, uint64(3), stats.Spooled)
+	assert.Equal(t, uint64(3), stats.Replayed)
+	assert.Equal(t, uint64(3), stats.Sent)
+	assert.Equal(t, 0, stats.Pending)
+}
+
+func TestSendWriter_Rejected(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	w, err := newSendWriter(newTestConfig(t, srv.URL))
+	assert.Nil(t, err)
+	_, _ = w.Write([]byte(`{"msg":"a"}` + "\n"))
+	assert.Nil(t, w.Close())
+	assert.Equal(t, uint64(1), w.stats().Failed)
+	assert.Equal(t, 0, w.stats().Pending)
+}
+
+func TestSpool_Trim(t *testing.T) {
+	sp, err := newSpool(t.TempDir(), 10)
+	assert.Nil(t, err)
+	_, _ = sp.push([]byte("123456"))
+	evicted, err := sp.push([]byte("abcdef"))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, evicted)
+
+	name, body, ok := sp.peek()
+	assert.True(t, ok)
+	assert.Equal(t, "abcdef", string(body))
+	sp.remove(name)
+	assert.Equal(t, 0, sp.len())
+}
+
+func TestOTLPEncoder(t *testing.T) {
+	enc := newEncoder(Config{Protocol: ProtocolOTLP, ServiceName: "demo"})
+	body, err := enc.Encode([][]byte{[]byte(`{"level":"warn","timestamp":1600000000000,"msg":"hi","appId":1,"ok":true}`)})
+	assert.Nil(t, err)
+
+	var req otlpRequest
+	assert.Nil(t, json.Unmarshal(body, &req))
+	record := req.ResourceLogs[0].ScopeLogs[0].LogRecords[0]
+	assert.Equal(t, "demo", *req.ResourceLogs[0].Resource["attributes"][0].Value.StringValue)
+	assert.Equal(t, "1600000000000000000", record.TimeUnixNano)
+	assert.Equal(t, 13, record.SeverityNumber)
+	assert.Equal(t, "hi", *record.Body.StringValue)
+	assert.Equal(t, "appId", record.Attributes[0].Key)
+	assert.Equal(t, "1", *record.Attributes[0].Value.IntValue)
+	assert.True(t, *record.Attributes[1].Value.BoolValue)
+}
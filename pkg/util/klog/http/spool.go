@@ -0,0 +1,129 @@
+// @Description 发送失败的批次落盘
+
+package http
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const spoolExt = ".batch"
+
+// spool 有界的磁盘队列，每个文件保存一个已编码的请求体，按文件名顺序重放
+type spool struct {
+	dir     string
+	maxSize int64
+
+	mu  sync.Mutex
+	seq uint64
+}
+
+func newSpool(dir string, maxSize int64) (*spool, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &spool{dir: dir, maxSize: maxSize}, nil
+}
+
+// push
+// 	@Description 写入一个批次，超出总大小上限时删除最早的批次
+// 	@Receiver s
+//	@Param body 请求体
+// 	@Return int 因超出上限而删除的批次数
+// 	@Return error
+func (s *spool) push(body []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq++
+	name := fmt.Sprintf("%020d-%06d%s", time.Now().UnixNano(), s.seq%1000000, spoolExt)
+	tmp := filepath.Join(s.dir, name+".tmp")
+	if err := ioutil.WriteFile(tmp, body, 0644); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp, filepath.Join(s.dir, name)); err != nil {
+		_ = os.Remove(tmp)
+		return 0, err
+	}
+	return s.trim(), nil
+}
+
+// trim 删除最早的批次直到总大小不超过上限，至少保留最新的一个批次
+func (s *spool) trim() int {
+	files := s.files()
+	var total int64
+	for _, f := range files {
+		total += f.Size()
+	}
+	removed := 0
+	for i := 0; total > s.maxSize && i < len(files)-1; i++ {
+		if err := os.Remove(filepath.Join(s.dir, files[i].Name())); err != nil {
+			continue
+		}
+		total -= files[i].Size()
+		removed++
+	}
+	return removed
+}
+
+// files 按时间顺序返回所有批次文件
+func (s *spool) files() []os.FileInfo {
+	infos, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil
+	}
+	files := infos[:0]
+	for _, info := range infos {
+		if info.IsDir() || !strings.HasSuffix(info.Name(), spoolExt) {
+			continue
+		}
+		files = append(files, info)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name() < files[j].Name()
+	})
+	return files
+}
+
+// peek
+// 	@Description 读取最早的批次
+// 	@Receiver s
+// 	@Return string 批次文件名，用于发送成功后删除
+// 	@Return []byte 请求体
+// 	@Return bool 是否存在批次
+func (s *spool) peek() (string, []byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, f := range s.files() {
+		body, err := ioutil.ReadFile(filepath.Join(s.dir, f.Name()))
+		if err != nil {
+			continue
+		}
+		return f.Name(), body, true
+	}
+	return "", nil, false
+}
+
+// remove
+// 	@Description 删除已重放的批次
+// 	@Receiver s
+//	@Param name 批次文件名
+func (s *spool) remove(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_ = os.Remove(filepath.Join(s.dir, name))
+}
+
+// len 当前落盘的批次数
+func (s *spool) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.files())
+}
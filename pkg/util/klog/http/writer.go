@@ -0,0 +1,320 @@
+// @Description 日志批量发送到 http 收集端
+
+package http
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/batch"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/ktime"
+)
+
+// errPermanent 收集端拒绝请求，重试和重放都不会成功
+var errPermanent = errors.New("klog http output: request rejected")
+
+// syncTimeout Sync 等待重放完成的最长时间
+const syncTimeout = 3 * time.Second
+
+// Stats 输出源计数，均为批次数
+type Stats struct {
+	// Sent 发送成功的批次数，包含重放成功的批次
+	Sent uint64 `json:"sent"`
+	// Failed 被收集端拒绝或编码失败而丢弃的批次数
+	Failed uint64 `json:"failed"`
+	// Dropped 因队列已满或落盘失败、超出落盘上限而丢弃的批次数
+	Dropped uint64 `json:"dropped"`
+	// Spooled 发送失败或存在待重放批次时落盘的批次数
+	Spooled uint64 `json:"spooled"`
+	// Replayed 从磁盘重放成功的批次数
+	Replayed uint64 `json:"replayed"`
+	// Pending 当前磁盘中待重放的批次数
+	Pending int `json:"pending"`
+}
+
+var writers sync.Map
+
+// GetStats
+// 	@Description 获取所有 http 输出源的计数，key 为日志配置 key，未设置时为收集端地址
+// 	@Return map[string]Stats
+func GetStats() map[string]Stats {
+	stats := make(map[string]Stats)
+	writers.Range(func(key, value interface{}) bool {
+		w := value.(*sendWriter)
+		stats[key.(string)] = w.stats()
+		return true
+	})
+	return stats
+}
+
+// sendWriter 按 BatchSize 分批编码发送，失败的批次落盘后重放
+type sendWriter struct {
+	*batch.Writer
+	endpoint       string
+	headers        map[string]string
+	client         *http.Client
+	enc            encoder
+	spool          *spool
+	maxRetries     int
+	backoff        time.Duration
+	maxBackoff     time.Duration
+	replayInterval time.Duration
+
+	// wake 发送失败后通知重放协程进入退避重试
+	wake chan struct{}
+	// replayReq Sync 请求立即重放，重放结束后关闭传入的 chan
+	replayReq chan chan struct{}
+	done      chan struct{}
+	stopped   chan struct{}
+	once      sync.Once
+
+	sent     uint64
+	failed   uint64
+	dropped  uint64
+	spooled  uint64
+	replayed uint64
+}
+
+func newSendWriter(c Config) (*sendWriter, error) {
+	name := c.parentKey
+	if name == "" {
+		name = c.Endpoint
+	}
+	sp, err := newSpool(filepath.Join(c.SpoolDir, spoolName(name)), c.SpoolMaxSize)
+	if err != nil {
+		return nil, err
+	}
+	w := &sendWriter{
+		endpoint:       c.Endpoint,
+		headers:        c.Headers,
+		client:         &http.Client{Timeout: ktime.Duration(c.Timeout)},
+		enc:            newEncoder(c),
+		spool:          sp,
+		maxRetries:     c.MaxRetries,
+		backoff:        ktime.Duration(c.Backoff),
+		maxBackoff:     ktime.Duration(c.MaxBackoff),
+		replayInterval: ktime.Duration(c.ReplayInterval),
+		wake:           make(chan struct{}, 1),
+		replayReq:      make(chan chan struct{}),
+		done:           make(chan struct{}),
+		stopped:        make(chan struct{}),
+	}
+	w.Writer = batch.NewWriter(w, c.QueueSize, c.BatchSize)
+	writers.Store(name, w)
+	go w.run()
+	return w, nil
+}
+
+// spoolName 将名称转换为可用作目录名的字符串
+func spoolName(name string) string {
+	bs := []byte(name)
+	for i, b := range bs {
+		switch {
+		case b >= 'a' && b <= 'z', b >= 'A' && b <= 'Z', b >= '0' && b <= '9', b == '.', b == '-':
+		default:
+			bs[i] = '_'
+		}
+	}
+	return string(bs)
+}
+
+// Sync
+// 	@Description 发送队列中的批次，并立即重放一次落盘批次
+// 	@Receiver w
+// 	@Return error
+func (w *sendWriter) Sync() error {
+	if err := w.Writer.Sync(); err != nil {
+		return err
+	}
+	ack := make(chan struct{})
+	select {
+	case w.replayReq <- ack:
+	case <-w.done:
+		return nil
+	case <-time.After(syncTimeout):
+		return nil
+	}
+	select {
+	case <-ack:
+	case <-time.After(syncTimeout):
+	}
+	return nil
+}
+
+// Close
+// 	@Description 剩余批次尝试发送一次，失败则落盘等待下次启动重放，之后停止重放协程
+// 	@Receiver w
+// 	@Return error
+func (w *sendWriter) Close() error {
+	_ = w.Writer.Close()
+	w.once.Do(func() {
+		close(w.done)
+	})
+	<-w.stopped
+	return nil
+}
+
+func (w *sendWriter) stats() Stats {
+	return Stats{
+		Sent:     atomic.LoadUint64(&w.sent),
+		Failed:   atomic.LoadUint64(&w.failed),
+		Dropped:  atomic.LoadUint64(&w.dropped),
+		Spooled:  atomic.LoadUint64(&w.spooled),
+		Replayed: atomic.LoadUint64(&w.replayed),
+		Pending:  w.spool.len(),
+	}
+}
+
+// run 重放落盘的批次，发送失败后按指数退避重试，超过重试次数或落盘为空时按重放间隔检查
+func (w *sendWriter) run() {
+	defer close(w.stopped)
+	// 启动时立即重放上次运行遗留的批次
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	failures := 0
+	for {
+		select {
+		case <-timer.C:
+			if w.replay() {
+				failures = 0
+			} else {
+				failures++
+			}
+			timer.Reset(w.delay(failures))
+		case ack := <-w.replayReq:
+			if w.replay() {
+				failures = 0
+			}
+			close(ack)
+		case <-w.wake:
+			if failures > 0 {
+				continue
+			}
+			failures = 1
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(w.delay(failures))
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// delay 计算下次重放的等待时间
+func (w *sendWriter) delay(failures int) time.Duration {
+	if failures == 0 || failures > w.maxRetries {
+		return w.replayInterval
+	}
+	backoff := w.backoff << uint(failures-1)
+	if w.maxBackoff > 0 && (backoff > w.maxBackoff || backoff <= 0) {
+		backoff = w.maxBackoff
+	}
+	return backoff
+}
+
+// Send 编码并发送一个批次，存在待重放批次时直接落盘以保持顺序，
+// 发送失败同样落盘并通知重放协程重试，不阻塞批量写入协程
+func (w *sendWriter) Send(lines [][]byte) {
+	body, err := w.enc.Encode(lines)
+	if err != nil {
+		atomic.AddUint64(&w.failed, 1)
+		return
+	}
+	if w.spool.len() > 0 {
+		w.save(body)
+		return
+	}
+	switch err := w.post(body); err {
+	case nil:
+		atomic.AddUint64(&w.sent, 1)
+	case errPermanent:
+		atomic.AddUint64(&w.failed, 1)
+	default:
+		w.save(body)
+		select {
+		case w.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Drop ...
+func (w *sendWriter) Drop(lines [][]byte) {
+	atomic.AddUint64(&w.dropped, 1)
+}
+
+func (w *sendWriter) save(body []byte) {
+	evicted, err := w.spool.push(body)
+	if err != nil {
+		atomic.AddUint64(&w.dropped, 1)
+		return
+	}
+	atomic.AddUint64(&w.spooled, 1)
+	atomic.AddUint64(&w.dropped, uint64(evicted))
+}
+
+// replay 按顺序重放落盘批次，遇到失败即停止，等待下次重放，全部重放完成返回 true
+func (w *sendWriter) replay() bool {
+	for {
+		select {
+		case <-w.done:
+			return false
+		default:
+		}
+		name, body, ok := w.spool.peek()
+		if !ok {
+			return true
+		}
+		switch err := w.post(body); err {
+		case nil:
+			w.spool.remove(name)
+			atomic.AddUint64(&w.sent, 1)
+			atomic.AddUint64(&w.replayed, 1)
+		case errPermanent:
+			w.spool.remove(name)
+			atomic.AddUint64(&w.failed, 1)
+		default:
+			return false
+		}
+	}
+}
+
+// post 发送一次请求，408、429 和 5xx 及网络错误可重试，其余非 2xx 状态视为永久失败
+func (w *sendWriter) post(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, w.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return errPermanent
+	}
+	req.Header.Set("Content-Type", w.enc.ContentType())
+	for k, v := range w.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusRequestTimeout, resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode >= 500:
+		return fmt.Errorf("klog http output: status %d", resp.StatusCode)
+	default:
+		return errPermanent
+	}
+}
@@ -0,0 +1,225 @@
+// @Description 按模块动态调整日志级别
+
+package klog
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// modKey FieldMod 使用的字段名
+const modKey = "mod"
+
+// ModuleLevel 模块级别覆盖配置
+type ModuleLevel struct {
+	// Module 模块名，与 FieldMod 的取值一致，如 govern、server.grpc、gorm
+	Module string `json:"module"`
+	// Level 覆盖后的日志级别
+	Level string `json:"level"`
+	// ExpireAt 自动恢复的时间，为零值时表示永久生效
+	ExpireAt time.Time `json:"expireAt,omitempty"`
+}
+
+type moduleOverride struct {
+	level    zap.Level
+	expireAt time.Time
+	timer    *time.Timer
+}
+
+type moduleLevels struct {
+	mu        sync.RWMutex
+	overrides map[string]*moduleOverride
+	// active 当前生效的覆盖数量，为0时跳过查找
+	active int32
+}
+
+var defaultModuleLevels = &moduleLevels{
+	overrides: make(map[string]*moduleOverride),
+}
+
+// lookup
+// 	@Description 查找模块的覆盖级别，按 "." 分段做最长前缀匹配，server 可匹配 server.grpc
+// 	@Receiver m
+//	@Param mod 模块名
+// 	@Return zap.Level
+// 	@Return bool 是否存在覆盖
+func (m *moduleLevels) lookup(mod string) (zap.Level, bool) {
+	if mod == "" || atomic.LoadInt32(&m.active) == 0 {
+		return 0, false
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for {
+		if o, ok := m.overrides[mod]; ok {
+			return o.level, true
+		}
+		idx := strings.LastIndexByte(mod, '.')
+		if idx < 0 {
+			return 0, false
+		}
+		mod = mod[:idx]
+	}
+}
+
+func (m *moduleLevels) set(mod string, lv zap.Level, ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.removeLocked(mod)
+	o := &moduleOverride{level: lv}
+	if ttl > 0 {
+		o.expireAt = time.Now().Add(ttl)
+		o.timer = time.AfterFunc(ttl, func() {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			// 期间可能已被重新设置，只清理自己
+			if m.overrides[mod] == o {
+				m.removeLocked(mod)
+			}
+		})
+	}
+	m.overrides[mod] = o
+	atomic.AddInt32(&m.active, 1)
+}
+
+func (m *moduleLevels) remove(mod string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.removeLocked(mod)
+}
+
+func (m *moduleLevels) removeLocked(mod string) {
+	o, ok := m.overrides[mod]
+	if !ok {
+		return
+	}
+	if o.timer != nil {
+		o.timer.Stop()
+	}
+	delete(m.overrides, mod)
+	atomic.AddInt32(&m.active, -1)
+}
+
+func (m *moduleLevels) list() []ModuleLevel {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	list := make([]ModuleLevel, 0, len(m.overrides))
+	for mod, o := range m.overrides {
+		list = append(list, ModuleLevel{Module: mod, Level: o.level.String(), ExpireAt: o.expireAt})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Module < list[j].Module
+	})
+	return list
+}
+
+// SetModuleLevel
+// 	@Description 覆盖模块的日志级别，对所有输出源生效
+//	@Param mod 模块名，与 FieldMod 的取值一致
+//	@Param level 日志级别，如 debug、info
+//	@Param ttl 有效期，到期后自动恢复，小于等于0表示永久生效
+// 	@Return error 级别不合法时返回错误
+func SetModuleLevel(mod string, level string, ttl time.Duration) error {
+	var lv zap.Level
+	if err := lv.Set(strings.ToLower(level)); err != nil {
+		return err
+	}
+	defaultModuleLevels.set(normalizeMod(mod), lv, ttl)
+	return nil
+}
+
+// ResetModuleLevel
+// 	@Description 取消模块的日志级别覆盖，恢复为输出源的级别
+//	@Param mod 模块名
+func ResetModuleLevel(mod string) {
+	defaultModuleLevels.remove(normalizeMod(mod))
+}
+
+// ModuleLevels
+// 	@Description 当前生效的模块级别覆盖
+// 	@Return []ModuleLevel
+func ModuleLevels() []ModuleLevel {
+	return defaultModuleLevels.list()
+}
+
+func normalizeMod(mod string) string {
+	return strings.Replace(mod, " ", ".", -1)
+}
+
+// moduleCore 根据 mod 字段判断是否使用模块覆盖级别
+// 存在覆盖时跳过输出源自身的级别判断，直接写入
+type moduleCore struct {
+	zapcore.Core
+	mod    string
+	levels *moduleLevels
+}
+
+func newModuleCore(core zapcore.Core) zapcore.Core {
+	return &moduleCore{Core: core, levels: defaultModuleLevels}
+}
+
+// Enabled ...
+func (c *moduleCore) Enabled(lv zapcore.Level) bool {
+	if override, ok := c.levels.lookup(c.mod); ok {
+		return override.Enabled(lv)
+	}
+	return c.Core.Enabled(lv)
+}
+
+// With ...
+func (c *moduleCore) With(fields []zapcore.Field) zapcore.Core {
+	mod := c.mod
+	if m, ok := modFromFields(fields); ok {
+		mod = m
+	}
+	return &moduleCore{
+		Core:   c.Core.With(fields),
+		mod:    mod,
+		levels: c.levels,
+	}
+}
+
+// Check ...
+func (c *moduleCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if override, ok := c.levels.lookup(c.mod); ok {
+		if override.Enabled(ent.Level) {
+			return ce.AddCore(ent, c)
+		}
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
+
+func modFromFields(fields []zapcore.Field) (string, bool) {
+	for _, f := range fields {
+		if f.Key == modKey && (f.Type == zapcore.StringType || f.Type == zapcore.SkipType) {
+			return f.String, true
+		}
+	}
+	return "", false
+}
+
+// withModule
+// 	@Description 日志调用时直接传入 FieldMod 的情况，只在该模块存在覆盖时才附加模块信息
+// 	@Receiver lg
+//	@Param fields 本次调用的字段
+// 	@Return *zap.Logger
+func (lg *Logger) withModule(fields []zap.Field) *zap.Logger {
+	if atomic.LoadInt32(&defaultModuleLevels.active) == 0 {
+		return lg.desugar
+	}
+	mod, ok := modFromFields(fields)
+	if !ok {
+		return lg.desugar
+	}
+	if _, ok := defaultModuleLevels.lookup(mod); !ok {
+		return lg.desugar
+	}
+	// SkipType 字段不会被编码输出，只用于传递模块名
+	return lg.desugar.With(zap.Field{Key: modKey, Type: zapcore.SkipType, String: mod})
+}
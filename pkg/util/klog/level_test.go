@@ -0,0 +1,58 @@
+package klog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/zap"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newObservedLogger(lv zap.Level) (*Logger, *observer.ObservedLogs) {
+	core, logs := observer.New(lv)
+	zapLogger := zap.New(newModuleCore(core))
+	return &Logger{
+		desugar: zapLogger,
+		config:  &Config{},
+		sugar:   zapLogger.Sugar(),
+	}, logs
+}
+
+func TestSetModuleLevel(t *testing.T) {
+	lg, logs := newObservedLogger(zap.InfoLevel)
+	grpcLogger := lg.With(FieldMod("server.grpc"))
+
+	grpcLogger.Debug("before")
+	assert.Equal(t, 0, logs.Len())
+
+	assert.Nil(t, SetModuleLevel("server", "debug", 0))
+	defer ResetModuleLevel("server")
+	grpcLogger.Debug("prefix match")
+	lg.Debug("call with mod", FieldMod("server.http"))
+	lg.Debug("other module", FieldMod("gorm"))
+	lg.Debug("no module")
+	assert.Equal(t, 2, logs.Len())
+
+	assert.Nil(t, SetModuleLevel("server.grpc", "error", 0))
+	defer ResetModuleLevel("server.grpc")
+	grpcLogger.Info("longest match")
+	assert.Equal(t, 2, logs.Len())
+
+	assert.NotNil(t, SetModuleLevel("gorm", "verbose", 0))
+	assert.Len(t, ModuleLevels(), 2)
+}
+
+func TestSetModuleLevel_TTL(t *testing.T) {
+	lg, logs := newObservedLogger(zap.InfoLevel)
+	gormLogger := lg.With(FieldMod("gorm"))
+
+	assert.Nil(t, SetModuleLevel("gorm", "debug", 50*time.Millisecond))
+	gormLogger.Debug("within ttl")
+	assert.Equal(t, 1, logs.Len())
+
+	time.Sleep(100 * time.Millisecond)
+	gormLogger.Debug("expired")
+	assert.Equal(t, 1, logs.Len())
+	assert.Len(t, ModuleLevels(), 0)
+}
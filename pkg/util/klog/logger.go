@@ -148,7 +148,7 @@ func (lg *Logger) StdLog() *log.Logger {
 func (lg *Logger) Debug(msg string, fields ...zap.Field) {
 	fss := lg.makeFields(zap.DebugLevel)
 	fss = append(fss, fields...)
-	lg.desugar.Debug(msg, fss...)
+	lg.withModule(fields).Debug(msg, fss...)
 }
 
 // Debugf
@@ -168,7 +168,7 @@ func (lg *Logger) Debugf(template string, args ...interface{}) {
 func (lg *Logger) Info(msg string, fields ...zap.Field) {
 	fss := lg.makeFields(zap.InfoLevel)
 	fss = append(fss, fields...)
-	lg.desugar.Info(msg, fss...)
+	lg.withModule(fields).Info(msg, fss...)
 }
 
 // Infof
@@ -188,7 +188,7 @@ func (lg *Logger) Infof(template string, args ...interface{}) {
 func (lg *Logger) Warn(msg string, fields ...zap.Field) {
 	fss := lg.makeFields(zap.WarnLevel)
 	fss = append(fss, fields...)
-	lg.desugar.Warn(msg, fss...)
+	lg.withModule(fields).Warn(msg, fss...)
 }
 
 // Warnf
@@ -208,7 +208,7 @@ func (lg *Logger) Warnf(template string, args ...interface{}) {
 func (lg *Logger) Error(msg string, fields ...zap.Field) {
 	fss := lg.makeFields(zap.ErrorLevel)
 	fss = append(fss, fields...)
-	lg.desugar.Error(msg, fss...)
+	lg.withModule(fields).Error(msg, fss...)
 }
 
 // Errorf
@@ -228,7 +228,7 @@ func (lg *Logger) Errorf(template string, args ...interface{}) {
 func (lg *Logger) Panic(msg string, fields ...zap.Field) {
 	fss := lg.makeFields(zap.PanicLevel)
 	fss = append(fss, fields...)
-	lg.desugar.Panic(msg, fss...)
+	lg.withModule(fields).Panic(msg, fss...)
 }
 
 // Panicf
@@ -249,7 +249,7 @@ func (lg *Logger) Panicf(template string, args ...interface{}) {
 func (lg *Logger) DPanic(msg string, fields ...zap.Field) {
 	fss := lg.makeFields(zap.DPanicLevel)
 	fss = append(fss, fields...)
-	lg.desugar.DPanic(msg, fss...)
+	lg.withModule(fields).DPanic(msg, fss...)
 }
 
 // DPanicf
@@ -269,7 +269,7 @@ func (lg *Logger) DPanicf(template string, args ...interface{}) {
 func (lg *Logger) Fatal(msg string, fields ...zap.Field) {
 	fss := lg.makeFields(zap.FatalLevel)
 	fss = append(fss, fields...)
-	lg.desugar.Fatal(msg, fss...)
+	lg.withModule(fields).Fatal(msg, fss...)
 }
 
 func (lg *Logger) SetLevel(l zap.Level) {
@@ -6,16 +6,143 @@
 package mq
 
 import (
+	"fmt"
+	"strings"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/conf"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/defers"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/batch"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/buffer"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/redact"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/zap"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/ktime"
 	"go.uber.org/zap/zapcore"
 )
 
+const (
+	OutputMQ = "mq"
+)
+
 type Config struct {
 	// Level 日志等级
 	Level string
-	//
+	// Source mq 实例名，对应 mq 配置下的 key，需为发布类型
 	Source string
+	// Topic 发布的 topic，kafka 为 topic，rabbit mq 为 queue
+	Topic string
+
+	// FlushInterval 缓冲刷新间隔
+	FlushInterval string
+	// BufferSize 缓冲大小
+	BufferSize int
+	// QueueSize 待发布批次的队列长度，队列满时丢弃
+	QueueSize int
+	// SampleRate 队列积压超过一半时，warn 以下级别的日志每 SampleRate 条保留1条，小于等于1不采样
+	SampleRate int
+
+	parentKey string           `mapstructure:"-"`
+	lv        *zap.AtomicLevel `mapstructure:"-"`
+}
+
+// SetDefaultConfig
+// 	@Description 设置默认配置
+// 	@Receiver c
+// 	@Return *Config
+func (c *Config) SetDefaultConfig() *Config {
+	if c.FlushInterval == "" {
+		c.FlushInterval = "1s"
+	}
+	if c.BufferSize == 0 {
+		c.BufferSize = 256 * 1024
+	}
+	if c.QueueSize == 0 {
+		c.QueueSize = 1024
+	}
+	if c.SampleRate == 0 {
+		c.SampleRate = 10
+	}
+	return c
+}
+
+// SetParent
+// 	@Description 设置父级key
+// 	@Receiver c
+//	@Param k
+// 	@Return *Config
+func (c *Config) SetParent(k string) *Config {
+	c.parentKey = k
+	return c
+}
+
+// SetAutoLevel
+// 	@Description 设置auto level
+// 	@Receiver c
+// 	@Return *Config
+func (c *Config) SetAutoLevel() *Config {
+	var lv zap.Level
+
+	err := lv.Set(c.Level)
+	if err != nil {
+		panic(err)
+	}
+	alv := zap.NewAtomicLevelAt(lv)
+	c.lv = &alv
+	if c.parentKey == "" {
+		return c
+	}
+	conf.OnChange(func(config *conf.Configuration) {
+		lvText := strings.ToLower(config.GetString(c.parentKey + ".output.mq.level"))
+		if lvText != "" {
+			err := c.lv.UnmarshalText([]byte(lvText))
+			if err != nil {
+				return
+			}
+		}
+	})
+	return c
 }
 
+// Build
+// 	@Description 构建 mq 输出源，日志先写入缓冲，按批次异步发布
+// 	@Receiver c
+// 	@Return zapcore.Core
 func (c Config) Build() zapcore.Core {
-	panic("implement me")
+	if c.Source == "" || c.Topic == "" {
+		panic(fmt.Errorf("%s mq output source or topic is empty", c.parentKey))
+	}
+	if c.lv == nil {
+		panic(fmt.Errorf("%s atom level is empty", c.parentKey))
+	}
+
+	writer := newPublishWriter(c.Source, c.Topic, c.QueueSize)
+	ws, close := buffer.Buffer(writer, c.BufferSize, ktime.Duration(c.FlushInterval))
+	defers.Register(func() error {
+		_ = close()
+		return writer.Close()
+	})
+
+	core := zap.NewCore(
+		redact.NewEncoder(zap.NewJSONEncoder(getMQEncoderConfig())),
+		&batch.FlushSyncer{WriteSyncer: ws, Writer: writer},
+		c.lv,
+	)
+	return newSamplingCore(core, writer, c.SampleRate)
+}
+
+func getMQEncoderConfig() zap.EncoderConfig {
+	return zap.EncoderConfig{
+		TimeKey:          "timestamp",
+		LevelKey:         zap.OmitKey,
+		NameKey:          zap.OmitKey,
+		CallerKey:        "caller",
+		MessageKey:       "msg",
+		StacktraceKey:    "stack",
+		LineEnding:       zap.DefaultLineEnding,
+		EncodeLevel:      zap.LowercaseLevelEncoder,
+		EncodeTime:       zap.EpochMillisTimeEncoder,
+		EncodeDuration:   zap.SecondsDurationEncoder,
+		EncodeCaller:     zap.ShortCallerEncoder,
+		EncodeName:       zap.FullNameEncoder,
+		ConsoleSeparator: " ",
+	}
 }
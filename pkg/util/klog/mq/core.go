@@ -0,0 +1,53 @@
+// @Description 积压时采样
+
+package mq
+
+import (
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// samplingCore 发布队列积压时，对 warn 以下级别的日志按比例采样
+type samplingCore struct {
+	zapcore.Core
+	writer  *publishWriter
+	rate    uint64
+	counter *uint64
+}
+
+func newSamplingCore(core zapcore.Core, writer *publishWriter, rate int) zapcore.Core {
+	if rate <= 1 {
+		return core
+	}
+	return &samplingCore{
+		Core:    core,
+		writer:  writer,
+		rate:    uint64(rate),
+		counter: new(uint64),
+	}
+}
+
+// With ...
+func (c *samplingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &samplingCore{
+		Core:    c.Core.With(fields),
+		writer:  c.writer,
+		rate:    c.rate,
+		counter: c.counter,
+	}
+}
+
+// Check ...
+func (c *samplingCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+	if ent.Level < zapcore.WarnLevel && c.writer.Pressure() {
+		if atomic.AddUint64(c.counter, 1)%c.rate != 0 {
+			atomic.AddUint64(&c.writer.sampled, 1)
+			return ce
+		}
+	}
+	return c.Core.Check(ent, ce)
+}
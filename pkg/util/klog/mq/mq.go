@@ -0,0 +1,25 @@
+// @Description
+
+package mq
+
+import (
+	"sync"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/manager"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/zap"
+)
+
+var once sync.Once
+
+// RegisterOutputCreatorHandler
+// 	@Description 注册 mq 输出源
+func RegisterOutputCreatorHandler() {
+	once.Do(func() {
+		manager.Register(OutputMQ, func(cfg interface{}) []zap.Core {
+			if mqCfg, ok := cfg.(Config); ok {
+				return []zap.Core{mqCfg.Build()}
+			}
+			return nil
+		})
+	})
+}
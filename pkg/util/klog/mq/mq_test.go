@@ -0,0 +1,61 @@
+package mq
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	kmq "github.com/LuoHongLiang0921/kuaigo/pkg/core/mq"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/zap"
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryPublisher struct {
+	mu   sync.Mutex
+	msgs []string
+}
+
+func (p *memoryPublisher) Publish(ctx context.Context, target string, msg *kmq.Message, opt ...kmq.PublishOption) (*kmq.RespMessage, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.msgs = append(p.msgs, string(msg.Body))
+	return &kmq.RespMessage{Topic: target}, nil
+}
+
+func (p *memoryPublisher) len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.msgs)
+}
+
+func TestConfig_Build(t *testing.T) {
+	publisher := &memoryPublisher{}
+	RegisterPublisher("test", publisher)
+
+	cfg := Config{Level: "info", Source: "test", Topic: "log", FlushInterval: "1h"}
+	cfg.SetDefaultConfig().SetAutoLevel()
+	logger := zap.New(cfg.Build())
+
+	logger.Info("hello", zap.String("k", "v"))
+	logger.Debug("ignored")
+	logger.Warn("world")
+	assert.Equal(t, 0, publisher.len())
+
+	assert.Nil(t, logger.Sync())
+	assert.Equal(t, 2, publisher.len())
+	assert.Contains(t, publisher.msgs[0], `"msg":"hello"`)
+	assert.Equal(t, uint64(2), GetStats()["test/log"].Published)
+}
+
+func TestPublishWriter_Drop(t *testing.T) {
+	w := newPublishWriter("missing", "log", 1)
+	defer w.Close()
+
+	_, _ = w.Write([]byte("a\nb\nc"))
+	assert.Nil(t, w.Sync())
+	assert.Equal(t, uint64(2), w.stats().Dropped)
+
+	_, _ = w.Write([]byte("\n"))
+	assert.Nil(t, w.Close())
+	assert.Equal(t, uint64(3), w.stats().Dropped)
+}
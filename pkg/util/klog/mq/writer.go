@@ -0,0 +1,109 @@
+// @Description 日志发布到消息队列
+
+package mq
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	kmq "github.com/LuoHongLiang0921/kuaigo/pkg/core/mq"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/batch"
+)
+
+var publishers sync.Map
+
+// RegisterPublisher
+// 	@Description 注册 mq 输出源使用的发布者，mq 实例可能晚于日志初始化，发布时才会查找
+//	@Param source mq 实例名，与输出源配置中的 Source 一致
+//	@Param p 发布者
+func RegisterPublisher(source string, p kmq.Publisher) {
+	publishers.Store(source, p)
+}
+
+func getPublisher(source string) (kmq.Publisher, bool) {
+	v, ok := publishers.Load(source)
+	if !ok {
+		return nil, false
+	}
+	return v.(kmq.Publisher), true
+}
+
+// Stats 输出源计数
+type Stats struct {
+	// Published 发布成功的日志条数
+	Published uint64 `json:"published"`
+	// Failed 发布失败的日志条数
+	Failed uint64 `json:"failed"`
+	// Dropped 因队列已满或发布者不存在而丢弃的日志条数
+	Dropped uint64 `json:"dropped"`
+	// Sampled 积压时被采样丢弃的日志条数
+	Sampled uint64 `json:"sampled"`
+}
+
+var writers sync.Map
+
+// GetStats
+// 	@Description 获取所有 mq 输出源的计数，key 为 source/topic
+// 	@Return map[string]Stats
+func GetStats() map[string]Stats {
+	stats := make(map[string]Stats)
+	writers.Range(func(key, value interface{}) bool {
+		w := value.(*publishWriter)
+		stats[key.(string)] = w.stats()
+		return true
+	})
+	return stats
+}
+
+// publishWriter 每次缓冲刷新作为一个批次，逐条发布到 topic
+type publishWriter struct {
+	*batch.Writer
+	source string
+	topic  string
+
+	published uint64
+	failed    uint64
+	dropped   uint64
+	sampled   uint64
+}
+
+func newPublishWriter(source, topic string, queueSize int) *publishWriter {
+	w := &publishWriter{
+		source: source,
+		topic:  topic,
+	}
+	w.Writer = batch.NewWriter(w, queueSize, 0)
+	writers.Store(source+"/"+topic, w)
+	return w
+}
+
+func (w *publishWriter) stats() Stats {
+	return Stats{
+		Published: atomic.LoadUint64(&w.published),
+		Failed:    atomic.LoadUint64(&w.failed),
+		Dropped:   atomic.LoadUint64(&w.dropped),
+		Sampled:   atomic.LoadUint64(&w.sampled),
+	}
+}
+
+// Send 逐条发布
+func (w *publishWriter) Send(lines [][]byte) {
+	p, ok := getPublisher(w.source)
+	if !ok {
+		atomic.AddUint64(&w.dropped, uint64(len(lines)))
+		return
+	}
+	for _, line := range lines {
+		if _, err := p.Publish(context.Background(), w.topic, kmq.NewMessage(line)); err != nil {
+			atomic.AddUint64(&w.failed, 1)
+			continue
+		}
+		atomic.AddUint64(&w.published, 1)
+	}
+}
+
+// Drop ...
+func (w *publishWriter) Drop(lines [][]byte) {
+	atomic.AddUint64(&w.dropped, uint64(len(lines)))
+}
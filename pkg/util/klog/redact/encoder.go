@@ -0,0 +1,112 @@
+// @Description 脱敏编码器
+
+package redact
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// encoder 在编码前按全局规则脱敏字段，可包装任意 zapcore.Encoder
+type encoder struct {
+	zapcore.Encoder
+}
+
+// NewEncoder
+// 	@Description 包装编码器，编码时使用 Default 返回的脱敏规则，规则可在运行时替换
+//	@Param enc 被包装的编码器
+// 	@Return zapcore.Encoder
+func NewEncoder(enc zapcore.Encoder) zapcore.Encoder {
+	return &encoder{Encoder: enc}
+}
+
+// Clone ...
+func (e *encoder) Clone() zapcore.Encoder {
+	return &encoder{Encoder: e.Encoder.Clone()}
+}
+
+// AddString ...
+func (e *encoder) AddString(key, val string) {
+	e.Encoder.AddString(key, Default().String(key, val))
+}
+
+// AddByteString ...
+func (e *encoder) AddByteString(key string, val []byte) {
+	r := Default()
+	if r == nil {
+		e.Encoder.AddByteString(key, val)
+		return
+	}
+	e.Encoder.AddString(key, r.String(key, string(val)))
+}
+
+// AddReflected ...
+func (e *encoder) AddReflected(key string, obj interface{}) error {
+	return e.Encoder.AddReflected(key, reflected(Default(), key, obj))
+}
+
+// EncodeEntry ...
+func (e *encoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	r := Default()
+	if r == nil {
+		return e.Encoder.EncodeEntry(ent, fields)
+	}
+	ent.Message = r.Text(ent.Message)
+	redacted := make([]zapcore.Field, len(fields))
+	for i, f := range fields {
+		redacted[i] = field(r, f)
+	}
+	return e.Encoder.EncodeEntry(ent, redacted)
+}
+
+// field 脱敏单个字段，数值等类型的敏感字段统一替换为掩码字符串
+func field(r *Redactor, f zapcore.Field) zapcore.Field {
+	switch f.Type {
+	case zapcore.SkipType, zapcore.NamespaceType:
+		return f
+	}
+	if r.IsSensitive(f.Key) {
+		return zapcore.Field{Key: f.Key, Type: zapcore.StringType, String: r.mask}
+	}
+	switch f.Type {
+	case zapcore.StringType:
+		f.String = r.String(f.Key, f.String)
+	case zapcore.ByteStringType:
+		return zapcore.Field{Key: f.Key, Type: zapcore.StringType, String: r.String(f.Key, string(f.Interface.([]byte)))}
+	case zapcore.ReflectType:
+		f.Interface = reflected(r, f.Key, f.Interface)
+	}
+	return f
+}
+
+// reflected 将 map、struct、slice 转为通用结构后递归脱敏
+func reflected(r *Redactor, key string, obj interface{}) interface{} {
+	if r == nil || obj == nil {
+		return obj
+	}
+	if r.IsSensitive(key) {
+		return r.mask
+	}
+	switch reflect.Indirect(reflect.ValueOf(obj)).Kind() {
+	case reflect.Map, reflect.Struct, reflect.Slice, reflect.Array:
+	case reflect.String:
+		if s, ok := obj.(string); ok {
+			return r.String(key, s)
+		}
+		return obj
+	default:
+		return obj
+	}
+	bs, err := json.Marshal(obj)
+	if err != nil {
+		return obj
+	}
+	var generic interface{}
+	if err := json.Unmarshal(bs, &generic); err != nil {
+		return obj
+	}
+	return r.Value(key, generic)
+}
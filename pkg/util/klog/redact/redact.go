@@ -0,0 +1,208 @@
+// @Description 日志脱敏
+
+package redact
+
+import (
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	// defaultMask 默认掩码
+	defaultMask = "******"
+	// sqlPlaceholder SQL 字面量替换后的占位符
+	sqlPlaceholder = "?"
+)
+
+// Config 脱敏配置
+type Config struct {
+	// Disable 关闭脱敏
+	Disable bool
+	// Fields 需要整体掩码的字段名，不区分大小写，忽略 "_" 和 "-"
+	Fields []string
+	// Patterns 对字符串值做正则替换，匹配部分替换为掩码
+	Patterns []string
+	// SQLKeys 值为 SQL 语句的字段名，语句中的字符串和数字字面量替换为 ?
+	SQLKeys []string
+	// Mask 掩码，默认为 ******
+	Mask string
+}
+
+// DefaultConfig
+// 	@Description 默认脱敏配置
+// 	@Return Config
+func DefaultConfig() Config {
+	return Config{
+		Fields:  []string{"password", "passwd", "pwd", "ak", "token", "accessToken", "refreshToken", "secret", "phone", "mobile", "idCard", "citizenNo"},
+		SQLKeys: []string{"sql"},
+		Mask:    defaultMask,
+	}
+}
+
+// Redactor 编译后的脱敏规则
+type Redactor struct {
+	fields   map[string]struct{}
+	sqlKeys  map[string]struct{}
+	patterns []*regexp.Regexp
+	mask     string
+}
+
+// Build
+// 	@Description 编译脱敏规则
+// 	@Receiver c
+// 	@Return *Redactor
+// 	@Return error 正则不合法时返回错误
+func (c Config) Build() (*Redactor, error) {
+	if c.Disable {
+		return nil, nil
+	}
+	r := &Redactor{
+		fields:  make(map[string]struct{}, len(c.Fields)),
+		sqlKeys: make(map[string]struct{}, len(c.SQLKeys)),
+		mask:    c.Mask,
+	}
+	if r.mask == "" {
+		r.mask = defaultMask
+	}
+	for _, f := range c.Fields {
+		r.fields[normalizeKey(f)] = struct{}{}
+	}
+	for _, k := range c.SQLKeys {
+		r.sqlKeys[normalizeKey(k)] = struct{}{}
+	}
+	for _, p := range c.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+func normalizeKey(key string) string {
+	key = strings.ToLower(key)
+	if strings.ContainsAny(key, "_-") {
+		key = strings.NewReplacer("_", "", "-", "").Replace(key)
+	}
+	return key
+}
+
+// IsSensitive
+// 	@Description 字段是否需要整体掩码
+// 	@Receiver r
+//	@Param key 字段名
+// 	@Return bool
+func (r *Redactor) IsSensitive(key string) bool {
+	if r == nil || len(r.fields) == 0 {
+		return false
+	}
+	_, ok := r.fields[normalizeKey(key)]
+	return ok
+}
+
+func (r *Redactor) isSQL(key string) bool {
+	if r == nil || len(r.sqlKeys) == 0 {
+		return false
+	}
+	_, ok := r.sqlKeys[normalizeKey(key)]
+	return ok
+}
+
+// String
+// 	@Description 按字段名脱敏字符串值
+// 	@Receiver r
+//	@Param key 字段名
+//	@Param val 值
+// 	@Return string
+func (r *Redactor) String(key, val string) string {
+	if r == nil {
+		return val
+	}
+	if r.IsSensitive(key) {
+		return r.mask
+	}
+	if r.isSQL(key) {
+		val = SQL(val)
+	}
+	return r.Text(val)
+}
+
+// Text
+// 	@Description 对文本应用正则规则
+// 	@Receiver r
+//	@Param val
+// 	@Return string
+func (r *Redactor) Text(val string) string {
+	if r == nil {
+		return val
+	}
+	for _, re := range r.patterns {
+		val = re.ReplaceAllString(val, r.mask)
+	}
+	return val
+}
+
+// Value
+// 	@Description 递归脱敏 map 和 slice 中的值
+// 	@Receiver r
+//	@Param key 字段名
+//	@Param val 值，需为 json 反序列化后的结构
+// 	@Return interface{}
+func (r *Redactor) Value(key string, val interface{}) interface{} {
+	if r == nil {
+		return val
+	}
+	if r.IsSensitive(key) {
+		return r.mask
+	}
+	switch v := val.(type) {
+	case string:
+		return r.String(key, v)
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = r.Value(k, item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = r.Value(key, item)
+		}
+		return v
+	default:
+		return val
+	}
+}
+
+// sqlLiteral 匹配单引号、双引号字符串以及独立的数字字面量
+var sqlLiteral = regexp.MustCompile(`'(?:[^'\\]|\\.|'')*'|"(?:[^"\\]|\\.)*"|\b\d+(?:\.\d+)?\b`)
+
+// SQL
+// 	@Description 将 SQL 语句中的字符串和数字字面量替换为 ?
+//	@Param sql SQL 语句
+// 	@Return string
+func SQL(sql string) string {
+	return sqlLiteral.ReplaceAllString(sql, sqlPlaceholder)
+}
+
+var current atomic.Value
+
+func init() {
+	r, _ := DefaultConfig().Build()
+	SetDefault(r)
+}
+
+// SetDefault
+// 	@Description 设置全局脱敏规则，为 nil 时不脱敏
+//	@Param r
+func SetDefault(r *Redactor) {
+	current.Store(&r)
+}
+
+// Default
+// 	@Description 当前全局脱敏规则
+// 	@Return *Redactor
+func Default() *Redactor {
+	return *current.Load().(**Redactor)
+}
@@ -0,0 +1,74 @@
+package redact
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func newTestEncoder() zapcore.Encoder {
+	return NewEncoder(zapcore.NewJSONEncoder(zapcore.EncoderConfig{MessageKey: "msg"}))
+}
+
+func encode(t *testing.T, enc zapcore.Encoder, msg string, fields ...zapcore.Field) string {
+	buf, err := enc.EncodeEntry(zapcore.Entry{Message: msg}, fields)
+	assert.Nil(t, err)
+	return strings.TrimSpace(buf.String())
+}
+
+func TestRedactor_String(t *testing.T) {
+	r, err := DefaultConfig().Build()
+	assert.Nil(t, err)
+
+	assert.Equal(t, defaultMask, r.String("password", "123456"))
+	assert.Equal(t, defaultMask, r.String("access_token", "abc"))
+	assert.Equal(t, defaultMask, r.String("Citizen-No", "110101199001011234"))
+	assert.Equal(t, "kuaigo", r.String("name", "kuaigo"))
+	assert.Equal(t, "select * from user where id = ? and name = ?", r.String("sql", "select * from user where id = 10 and name = 'tom'"))
+}
+
+func TestSQL(t *testing.T) {
+	assert.Equal(t, "update t1 set a = ?, b = ? where c = ?", SQL(`update t1 set a = 'it''s', b = 1.5 where c = "x"`))
+	assert.Equal(t, "select col1 from t2 where id in (?,?)", SQL("select col1 from t2 where id in (1,2)"))
+}
+
+func TestEncoder(t *testing.T) {
+	defer SetDefault(Default())
+	r, err := Config{
+		Fields:   []string{"ak"},
+		SQLKeys:  []string{"sql"},
+		Patterns: []string{`1[3-9]\d{9}`},
+	}.Build()
+	assert.Nil(t, err)
+	SetDefault(r)
+
+	enc := newTestEncoder()
+	out := encode(t, enc, "user 13800138000 login",
+		zap.String("ak", "secret-ak"),
+		zap.Int64("ak", 1),
+		zap.String("sql", "select * from user where phone = '13800138000'"),
+		zap.Any("P", map[string]interface{}{"ak": "x", "uid": 1, "list": []string{"13900139000"}}),
+	)
+	assert.NotContains(t, out, "secret-ak")
+	assert.NotContains(t, out, "13800138000")
+	assert.NotContains(t, out, "13900139000")
+	assert.Contains(t, out, `"msg":"user ****** login"`)
+	assert.Contains(t, out, `"sql":"select * from user where phone = ?"`)
+	assert.Contains(t, out, `"uid":1`)
+
+	SetDefault(nil)
+	out = encode(t, enc, "raw", zap.String("ak", "secret-ak"))
+	assert.Contains(t, out, "secret-ak")
+}
+
+func TestConfig_Build(t *testing.T) {
+	r, err := Config{Disable: true}.Build()
+	assert.Nil(t, err)
+	assert.Nil(t, r)
+
+	_, err = Config{Patterns: []string{"("}}.Build()
+	assert.NotNil(t, err)
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/LuoHongLiang0921/kuaigo/pkg/conf"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/defers"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/buffer"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/redact"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog/zap"
 	"github.com/LuoHongLiang0921/kuaigo/pkg/util/ktime"
 	"strings"
@@ -180,7 +181,7 @@ func (c *Config) BuildAlter(alterKey string, enablerFunc zap.LevelEnablerFunc) z
 		return nil
 	}
 	encoder := func() zap.Encoder {
-		return zap.NewJSONEncoder(*getRedisZapConfig())
+		return redact.NewEncoder(zap.NewJSONEncoder(*getRedisZapConfig()))
 	}
 
 	errRedisLog := redisLog.SetLogKey(alterKey)
@@ -208,7 +209,7 @@ func (c *Config) Build() zap.Core {
 	}
 	rws := zap.AddSync(redisLog)
 	encoder := func() zap.Encoder {
-		return zap.NewJSONEncoder(*getRedisZapConfig())
+		return redact.NewEncoder(zap.NewJSONEncoder(*getRedisZapConfig()))
 	}
 	if c.lv == nil {
 		panic(fmt.Errorf("%s atom level is empty", c.parentKey))
@@ -77,6 +77,7 @@ var (
 	OmitKey                  = zapcore.OmitKey
 	New                      = zap.New
 	NewTee                   = zapcore.NewTee
+	NewSamplerWithOptions    = zapcore.NewSamplerWithOptions
 	NewDevelopmentConfig     = zap.NewDevelopmentConfig
 )
 
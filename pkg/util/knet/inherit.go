@@ -0,0 +1,156 @@
+// @Description 监听继承，热重启时子进程通过文件描述符复用父进程的监听
+
+package knet
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+)
+
+const (
+	// EnvInheritListeners 父进程传递的监听列表，逗号分隔的 network://address，按顺序对应从 3 开始的文件描述符
+	EnvInheritListeners = "KUAIGO_INHERIT_LISTENERS"
+	// inheritFDStart 第一个继承的文件描述符，0~2 为标准输入输出
+	inheritFDStart = 3
+)
+
+var (
+	inheritOnce sync.Once
+	inheritMu   sync.Mutex
+	// inherited 父进程传递且尚未被使用的监听
+	inherited map[string][]net.Listener
+	// active 当前进程正在使用的监听，热重启时传递给子进程
+	active = make(map[*inheritableListener]struct{})
+)
+
+// inheritableListener 记录监听的 network://address，关闭时从 active 中移除
+type inheritableListener struct {
+	net.Listener
+	key string
+}
+
+// Close ...
+func (l *inheritableListener) Close() error {
+	inheritMu.Lock()
+	delete(active, l)
+	inheritMu.Unlock()
+	return l.Listener.Close()
+}
+
+func listenerKey(network, address string) string {
+	return network + "://" + address
+}
+
+// loadInherited 解析父进程传递的监听，只执行一次
+func loadInherited() {
+	inherited = make(map[string][]net.Listener)
+	value := os.Getenv(EnvInheritListeners)
+	if value == "" {
+		return
+	}
+	_ = os.Unsetenv(EnvInheritListeners)
+	for i, key := range strings.Split(value, ",") {
+		f := os.NewFile(uintptr(inheritFDStart+i), key)
+		if f == nil {
+			continue
+		}
+		ln, err := net.FileListener(f)
+		_ = f.Close()
+		if err != nil {
+			continue
+		}
+		inherited[key] = append(inherited[key], ln)
+	}
+}
+
+// Listen
+//  @Description  监听地址，优先复用父进程传递的相同 network 与 address 的监听，返回的监听可通过 ListenerFiles 传递给子进程
+//  @Param network 网络类型 tcp、tcp4、tcp6、unix
+//  @Param address 监听地址，与父进程配置的地址一致时才会复用，端口为 0 时同样复用
+//  @Return net.Listener
+//  @Return error
+func Listen(network, address string) (net.Listener, error) {
+	inheritOnce.Do(loadInherited)
+	key := listenerKey(network, address)
+
+	inheritMu.Lock()
+	defer inheritMu.Unlock()
+	var ln net.Listener
+	if lns := inherited[key]; len(lns) > 0 {
+		ln, inherited[key] = lns[0], lns[1:]
+	} else {
+		var err error
+		if ln, err = net.Listen(network, address); err != nil {
+			return nil, err
+		}
+	}
+	l := &inheritableListener{Listener: ln, key: key}
+	active[l] = struct{}{}
+	return l, nil
+}
+
+// IsInherited
+//  @Description  当前进程是否由热重启启动
+//  @Return bool
+func IsInherited() bool {
+	inheritOnce.Do(loadInherited)
+	inheritMu.Lock()
+	defer inheritMu.Unlock()
+	return len(inherited) > 0
+}
+
+// CloseInherited
+//  @Description  关闭父进程传递但未被使用的监听，如新版本移除了某个服务，子进程就绪后调用
+func CloseInherited() {
+	inheritOnce.Do(loadInherited)
+	inheritMu.Lock()
+	defer inheritMu.Unlock()
+	for key, lns := range inherited {
+		for _, ln := range lns {
+			_ = ln.Close()
+		}
+		delete(inherited, key)
+	}
+}
+
+// ListenerFiles
+//  @Description  复制当前进程所有监听的文件描述符，用于传递给子进程，调用方负责关闭返回的文件
+//  @Return []*os.File 按顺序作为子进程的 ExtraFiles
+//  @Return string 子进程环境变量 EnvInheritListeners 的值
+//  @Return error
+func ListenerFiles() ([]*os.File, string, error) {
+	inheritMu.Lock()
+	defer inheritMu.Unlock()
+	files := make([]*os.File, 0, len(active))
+	keys := make([]string, 0, len(active))
+	for l := range active {
+		fl, ok := l.Listener.(interface {
+			File() (*os.File, error)
+		})
+		if !ok {
+			closeFiles(files)
+			return nil, "", fmt.Errorf("knet: listener %s can not be inherited", l.key)
+		}
+		if ul, ok := l.Listener.(*net.UnixListener); ok {
+			// 子进程继续使用该 socket 文件，父进程关闭时不能删除
+			ul.SetUnlinkOnClose(false)
+		}
+		f, err := fl.File()
+		if err != nil {
+			closeFiles(files)
+			return nil, "", err
+		}
+		files = append(files, f)
+		keys = append(keys, l.key)
+	}
+	return files, strings.Join(keys, ","), nil
+}
+
+func closeFiles(files []*os.File) {
+	for _, f := range files {
+		_ = f.Close()
+	}
+}
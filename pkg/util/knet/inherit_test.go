@@ -0,0 +1,74 @@
+package knet
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"os/exec"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const envInheritHelper = "KNET_INHERIT_HELPER"
+
+// TestInheritHelper 作为子进程运行，复用父进程的监听并回复一次请求
+func TestInheritHelper(t *testing.T) {
+	if os.Getenv(envInheritHelper) == "" {
+		return
+	}
+	if !IsInherited() {
+		os.Exit(2)
+	}
+	ln, err := Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		os.Exit(3)
+	}
+	conn, err := ln.Accept()
+	if err != nil {
+		os.Exit(4)
+	}
+	_, _ = conn.Write([]byte("child\n"))
+	_ = conn.Close()
+	os.Exit(0)
+}
+
+func TestListen_Inherit(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("listener inheritance is not supported on windows")
+	}
+	ln, err := Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer ln.Close()
+
+	files, value, err := ListenerFiles()
+	assert.Nil(t, err)
+	assert.Equal(t, "tcp://127.0.0.1:0", value)
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestInheritHelper$")
+	cmd.Env = append(os.Environ(), envInheritHelper+"=1", EnvInheritListeners+"="+value)
+	cmd.ExtraFiles = files
+	assert.Nil(t, cmd.Start())
+	closeFiles(files)
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	assert.Nil(t, err)
+	defer conn.Close()
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	assert.Nil(t, err)
+	assert.Equal(t, "child\n", line)
+	assert.Nil(t, cmd.Wait())
+}
+
+func TestListen_Close(t *testing.T) {
+	ln, err := Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	assert.Nil(t, ln.Close())
+
+	inheritMu.Lock()
+	defer inheritMu.Unlock()
+	for l := range active {
+		assert.NotEqual(t, ln, l)
+	}
+}
@@ -0,0 +1,68 @@
+// @Description nonce 存储
+
+package ksign
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/cache/config"
+)
+
+// NonceStore nonce 存储
+type NonceStore interface {
+	// Add 记录 nonce，已存在时返回 false
+	Add(ctx context.Context, key string, ttl time.Duration) (bool, error)
+}
+
+type cacheNonceStore struct {
+	cache config.ICache
+}
+
+// NewCacheNonceStore
+//  @Description  使用缓存的 SETNX 记录 nonce，多实例共享
+//  @Param cache
+//  @Return NonceStore
+func NewCacheNonceStore(cache config.ICache) NonceStore {
+	return &cacheNonceStore{cache: cache}
+}
+
+// Add ...
+func (s *cacheNonceStore) Add(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return s.cache.WithContext(ctx).SetNxWithErr(key, 1, ttl)
+}
+
+type memoryNonceStore struct {
+	mu     sync.Mutex
+	nonces map[string]time.Time
+	swept  time.Time
+}
+
+// NewMemoryNonceStore
+//  @Description  进程内记录 nonce，只适用于单实例部署
+//  @Return NonceStore
+func NewMemoryNonceStore() NonceStore {
+	return &memoryNonceStore{nonces: make(map[string]time.Time), swept: time.Now()}
+}
+
+// Add ...
+func (s *memoryNonceStore) Add(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	// 每隔 ttl 清理一次过期的 nonce
+	if now.Sub(s.swept) > ttl {
+		s.swept = now
+		for k, expire := range s.nonces {
+			if now.After(expire) {
+				delete(s.nonces, k)
+			}
+		}
+	}
+	if expire, ok := s.nonces[key]; ok && now.Before(expire) {
+		return false, nil
+	}
+	s.nonces[key] = now.Add(ttl)
+	return true, nil
+}
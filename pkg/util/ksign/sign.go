@@ -0,0 +1,150 @@
+// @Description HMAC-SHA256 请求签名，对规范化请求（方法、路径、排序后的 query、body 摘要、时间戳、nonce）签名
+
+package ksign
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pborman/uuid"
+)
+
+// Version 签名版本，客户端通过 HeaderVersion 协商，未携带时服务端走旧版 MD5 签名
+const Version = "2"
+
+// Algorithm 规范化请求的首行
+const Algorithm = "KUAIGO-HMAC-SHA256"
+
+// 签名请求头
+const (
+	HeaderVersion   = "X-Sign-Version"
+	HeaderApp       = "X-Sign-App"
+	HeaderKey       = "X-Sign-Key"
+	HeaderTimestamp = "X-Sign-Timestamp"
+	HeaderNonce     = "X-Sign-Nonce"
+	HeaderSignature = "X-Sign-Signature"
+)
+
+var (
+	// ErrMissingHeader 缺少签名请求头
+	ErrMissingHeader = errors.New("ksign: missing signature header")
+	// ErrTimestamp 时间戳不合法或超出允许的时钟偏差
+	ErrTimestamp = errors.New("ksign: timestamp out of range")
+	// ErrNonce nonce 不合法
+	ErrNonce = errors.New("ksign: invalid nonce")
+	// ErrReplay nonce 已使用过
+	ErrReplay = errors.New("ksign: request replayed")
+	// ErrUnknownApp 应用或密钥未配置
+	ErrUnknownApp = errors.New("ksign: unknown app or key")
+	// ErrSignature 签名不匹配
+	ErrSignature = errors.New("ksign: signature mismatch")
+)
+
+// CanonicalRequest
+//  @Description  生成规范化请求，各部分以换行连接，query 按 key、value 排序后编码
+//  @Param method 请求方法
+//  @Param path 请求路径，不含 query
+//  @Param query
+//  @Param body 请求体
+//  @Param timestamp unix 秒
+//  @Param nonce
+//  @Return string
+func CanonicalRequest(method, path string, query url.Values, body []byte, timestamp, nonce string) string {
+	if path == "" {
+		path = "/"
+	}
+	bodyHash := sha256.Sum256(body)
+	return strings.Join([]string{
+		Algorithm,
+		strings.ToUpper(method),
+		path,
+		canonicalQuery(query),
+		hex.EncodeToString(bodyHash[:]),
+		timestamp,
+		nonce,
+	}, "\n")
+}
+
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	for _, key := range keys {
+		values := append([]string{}, query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			if sb.Len() > 0 {
+				sb.WriteByte('&')
+			}
+			sb.WriteString(url.QueryEscape(key))
+			sb.WriteByte('=')
+			sb.WriteString(url.QueryEscape(value))
+		}
+	}
+	return sb.String()
+}
+
+// Signature
+//  @Description  计算签名
+//  @Param secret 密钥
+//  @Param canonical 规范化请求
+//  @Return string 小写十六进制
+func Signature(secret, canonical string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(canonical))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Sign
+//  @Description  为请求签名并设置签名请求头，供服务间调用或测试使用
+//  @Param req 请求，body 读取后会被重置
+//  @Param app 应用 id
+//  @Param keyID 密钥 id，为空时服务端依次尝试该应用的所有密钥
+//  @Param secret 密钥
+//  @Return error
+func Sign(req *http.Request, app, keyID, secret string) error {
+	body, err := readBody(req)
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	nonce := strings.Replace(uuid.New(), "-", "", -1)
+	canonical := CanonicalRequest(req.Method, req.URL.Path, req.URL.Query(), body, timestamp, nonce)
+
+	req.Header.Set(HeaderVersion, Version)
+	req.Header.Set(HeaderApp, app)
+	if keyID != "" {
+		req.Header.Set(HeaderKey, keyID)
+	}
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderNonce, nonce)
+	req.Header.Set(HeaderSignature, Signature(secret, canonical))
+	return nil
+}
+
+// readBody 读取请求体并重置，使后续处理仍可读取
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
@@ -0,0 +1,91 @@
+package ksign
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newVerifier() *Verifier {
+	config := DefaultConfig()
+	config.Skew = time.Minute
+	config.Apps = map[string]map[string]string{"101": {"k1": "old", "k2": "new"}}
+	return config.BuildWithNonceStore(NewMemoryNonceStore())
+}
+
+func TestCanonicalRequest(t *testing.T) {
+	query := url.Values{"b": {"2", "1"}, "a": {"x y"}}
+	assert.Equal(t, "KUAIGO-HMAC-SHA256\nPOST\n/v1/user\na=x+y&b=1&b=2\n"+
+		"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855\n1600000000\nabc",
+		CanonicalRequest("post", "/v1/user", query, nil, "1600000000", "abc"))
+}
+
+func TestVerifier(t *testing.T) {
+	v := newVerifier()
+	ctx := context.Background()
+	newRequest := func(keyID, secret string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/v1/user?b=2&a=1", strings.NewReader(`{"name":"kuaigo"}`))
+		assert.Nil(t, Sign(req, "101", keyID, secret))
+		return req
+	}
+
+	req := newRequest("k1", "old")
+	app, err := v.Verify(ctx, req)
+	assert.Nil(t, err)
+	assert.Equal(t, "101", app)
+	// body 可再次读取
+	body, _ := ioutil.ReadAll(req.Body)
+	assert.Equal(t, `{"name":"kuaigo"}`, string(body))
+
+	// 重放
+	req.Body = ioutil.NopCloser(strings.NewReader(string(body)))
+	_, err = v.Verify(ctx, req)
+	assert.Equal(t, ErrReplay, err)
+
+	// 未指定密钥 id 时尝试所有密钥
+	_, err = v.Verify(ctx, newRequest("", "new"))
+	assert.Nil(t, err)
+
+	// 篡改 body
+	req = newRequest("k2", "new")
+	req.Body = ioutil.NopCloser(strings.NewReader(`{"name":"other"}`))
+	_, err = v.Verify(ctx, req)
+	assert.Equal(t, ErrSignature, err)
+
+	// 轮换后旧密钥失效
+	v.SetApps(map[string]map[string]string{"101": {"k2": "new"}})
+	_, err = v.Verify(ctx, newRequest("k1", "old"))
+	assert.Equal(t, ErrUnknownApp, err)
+	_, err = v.Verify(ctx, newRequest("", "old"))
+	assert.Equal(t, ErrSignature, err)
+
+	// 时间戳超出偏差
+	req = newRequest("k2", "new")
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(time.Now().Add(-2*time.Minute).Unix(), 10))
+	_, err = v.Verify(ctx, req)
+	assert.Equal(t, ErrTimestamp, err)
+
+	req = newRequest("k2", "new")
+	req.Header.Del(HeaderNonce)
+	_, err = v.Verify(ctx, req)
+	assert.Equal(t, ErrMissingHeader, err)
+}
+
+func TestMemoryNonceStore(t *testing.T) {
+	s := NewMemoryNonceStore()
+	ok, _ := s.Add(context.Background(), "n", 20*time.Millisecond)
+	assert.True(t, ok)
+	ok, _ = s.Add(context.Background(), "n", 20*time.Millisecond)
+	assert.False(t, ok)
+	time.Sleep(30 * time.Millisecond)
+	ok, _ = s.Add(context.Background(), "n", 20*time.Millisecond)
+	assert.True(t, ok)
+}
@@ -0,0 +1,196 @@
+// @Description 签名校验，校验时间戳偏差并使用 nonce 防重放，密钥随配置热更新
+
+package ksign
+
+import (
+	"context"
+	"crypto/hmac"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/conf"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/core/cache"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/ecode"
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
+
+	"github.com/pkg/errors"
+)
+
+//ModName ..
+const ModName = "util.ksign"
+
+// nonceKeyPrefix nonce 在缓存中的 key 前缀
+const nonceKeyPrefix = "kuaigo:sign:nonce:"
+
+// Config 签名校验配置
+type Config struct {
+	// Skew 允许的客户端时钟偏差，默认 5m
+	Skew time.Duration
+	// NonceTTL nonce 保存时间，不应小于 2 倍 Skew，默认 2 倍 Skew
+	NonceTTL time.Duration
+	// Cache 保存 nonce 的缓存配置 key，为空时保存在进程内，多实例部署时应配置
+	Cache string
+	// Apps 应用 id 到密钥 id、密钥的映射，轮换时先增加新密钥，客户端切换后再删除旧密钥
+	Apps map[string]map[string]string
+
+	key    string
+	logger *klog.Logger
+}
+
+// DefaultConfig ...
+func DefaultConfig() *Config {
+	return &Config{
+		Skew:   5 * time.Minute,
+		logger: klog.KuaigoLogger.With(klog.FieldMod(ModName)),
+	}
+}
+
+// RawConfig ...
+func RawConfig(key string) *Config {
+	var config = DefaultConfig()
+	if err := conf.UnmarshalKey(key, &config); err != nil &&
+		errors.Cause(err) != conf.ErrInvalidKey {
+		config.logger.Panic("ksign parse config panic", klog.FieldErrKind(ecode.ErrKindUnmarshalConfigErr), klog.FieldErr(err), klog.FieldKey(key), klog.FieldValueAny(config))
+	}
+	config.key = key
+	return config
+}
+
+// WithLogger ...
+func (config *Config) WithLogger(logger *klog.Logger) *Config {
+	config.logger = logger
+	return config
+}
+
+// Build
+//  @Description  创建校验器，由 RawConfig 创建时配置变更后重新加载 Apps
+//  @Receiver config
+//  @Return *Verifier
+func (config *Config) Build() *Verifier {
+	var nonces NonceStore
+	if config.Cache != "" {
+		nonces = NewCacheNonceStore(cache.GetCacheManagerInstance().GetCache(context.Background(), config.Cache))
+	} else {
+		nonces = NewMemoryNonceStore()
+	}
+	return config.BuildWithNonceStore(nonces)
+}
+
+// BuildWithNonceStore
+//  @Description  使用指定的 nonce 存储创建校验器
+//  @Receiver config
+//  @Param nonces
+//  @Return *Verifier
+func (config *Config) BuildWithNonceStore(nonces NonceStore) *Verifier {
+	if config.NonceTTL < 2*config.Skew {
+		config.NonceTTL = 2 * config.Skew
+	}
+	v := &Verifier{config: config, nonces: nonces, apps: config.Apps, now: time.Now}
+	if config.key != "" {
+		conf.OnChange(func(c *conf.Configuration) {
+			var apps map[string]map[string]string
+			if err := c.UnmarshalKey(config.key+".apps", &apps); err != nil {
+				config.logger.Warn("ksign reload apps failed", klog.FieldErr(err), klog.FieldKey(config.key))
+				return
+			}
+			v.SetApps(apps)
+		})
+	}
+	return v
+}
+
+// Verifier 签名校验器
+type Verifier struct {
+	config *Config
+	nonces NonceStore
+	now    func() time.Time
+
+	mu   sync.RWMutex
+	apps map[string]map[string]string
+}
+
+// SetApps
+//  @Description  替换应用密钥
+//  @Receiver v
+//  @Param apps 应用 id 到密钥 id、密钥的映射
+func (v *Verifier) SetApps(apps map[string]map[string]string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.apps = apps
+}
+
+// secrets 获取应用的密钥，keyID 为空时返回所有密钥
+func (v *Verifier) secrets(app, keyID string) []string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	keys := v.apps[app]
+	if keyID != "" {
+		if secret, ok := keys[keyID]; ok {
+			return []string{secret}
+		}
+		return nil
+	}
+	secrets := make([]string, 0, len(keys))
+	for _, secret := range keys {
+		secrets = append(secrets, secret)
+	}
+	return secrets
+}
+
+// Verify
+//  @Description  校验请求签名，签名通过后记录 nonce，同一 nonce 在 NonceTTL 内只能使用一次
+//  @Receiver v
+//  @Param ctx
+//  @Param req 请求，body 读取后会被重置
+//  @Return app 应用 id
+//  @Return err ErrMissingHeader、ErrTimestamp、ErrNonce、ErrUnknownApp、ErrSignature、ErrReplay 或缓存错误
+func (v *Verifier) Verify(ctx context.Context, req *http.Request) (app string, err error) {
+	app = req.Header.Get(HeaderApp)
+	timestamp := req.Header.Get(HeaderTimestamp)
+	nonce := req.Header.Get(HeaderNonce)
+	signature := req.Header.Get(HeaderSignature)
+	if app == "" || timestamp == "" || nonce == "" || signature == "" {
+		return app, ErrMissingHeader
+	}
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return app, ErrTimestamp
+	}
+	if skew := v.now().Sub(time.Unix(ts, 0)); skew > v.config.Skew || -skew > v.config.Skew {
+		return app, ErrTimestamp
+	}
+	if len(nonce) < 8 || len(nonce) > 64 {
+		return app, ErrNonce
+	}
+	secrets := v.secrets(app, req.Header.Get(HeaderKey))
+	if len(secrets) == 0 {
+		return app, ErrUnknownApp
+	}
+
+	body, err := readBody(req)
+	if err != nil {
+		return app, err
+	}
+	canonical := CanonicalRequest(req.Method, req.URL.Path, req.URL.Query(), body, timestamp, nonce)
+	matched := false
+	for _, secret := range secrets {
+		if hmac.Equal([]byte(Signature(secret, canonical)), []byte(signature)) {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return app, ErrSignature
+	}
+
+	ok, err := v.nonces.Add(ctx, nonceKeyPrefix+app+":"+nonce, v.config.NonceTTL)
+	if err != nil {
+		return app, err
+	}
+	if !ok {
+		return app, ErrReplay
+	}
+	return app, nil
+}
@@ -0,0 +1,167 @@
+// @Description 服务端 TLS 配置，证书变更后自动重新加载，支持客户端证书校验
+
+package ktls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 客户端证书校验方式
+const (
+	// ClientAuthNone 不请求客户端证书
+	ClientAuthNone = "none"
+	// ClientAuthRequest 请求但不校验客户端证书，不会暴露客户端身份
+	ClientAuthRequest = "request"
+	// ClientAuthVerifyIfGiven 客户端提供证书时校验
+	ClientAuthVerifyIfGiven = "verify_if_given"
+	// ClientAuthRequire 要求并校验客户端证书
+	ClientAuthRequire = "require"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// Config TLS 配置
+type Config struct {
+	// CertFile 服务端证书文件，为空时不开启 TLS
+	CertFile string
+	// KeyFile 服务端私钥文件
+	KeyFile string
+	// ClientCAFile 校验客户端证书的 CA 文件，配置后默认要求客户端证书
+	ClientCAFile string
+	// ClientAuth 客户端证书校验方式 none|request|verify_if_given|require
+	ClientAuth string
+	// AllowedSANs 允许的客户端身份，匹配证书的 DNS、URI、Email、IP 与 CommonName
+	// 支持 *.example.com 子域通配与 spiffe://cluster/ns/* 前缀通配，为空时不限制
+	AllowedSANs []string
+	// MinVersion 最低 TLS 版本，默认 1.2
+	MinVersion string
+	// ReloadInterval 检查证书文件变更的间隔，默认 10s
+	ReloadInterval time.Duration
+}
+
+// Enabled
+//  @Description  是否开启 TLS
+//  @Receiver c
+//  @Return bool
+func (c *Config) Enabled() bool {
+	return c != nil && c.CertFile != ""
+}
+
+// VerifiesClient
+//  @Description  是否校验客户端证书，校验通过的客户端身份才会放入请求上下文
+//  @Receiver c
+//  @Return bool
+func (c *Config) VerifiesClient() bool {
+	switch c.clientAuth() {
+	case ClientAuthVerifyIfGiven, ClientAuthRequire:
+		return true
+	}
+	return false
+}
+
+func (c *Config) clientAuth() string {
+	if c.ClientAuth == "" && c.ClientCAFile != "" {
+		return ClientAuthRequire
+	}
+	if c.ClientAuth == "" {
+		return ClientAuthNone
+	}
+	return c.ClientAuth
+}
+
+// Build
+//  @Description  构建 tls.Config，证书与客户端 CA 按 ReloadInterval 检查变更并重新加载
+//  @Receiver c
+//  @Return *tls.Config
+//  @Return error 证书加载失败或配置错误
+func (c *Config) Build() (*tls.Config, error) {
+	if !c.Enabled() {
+		return nil, errors.New("ktls: cert file is empty")
+	}
+	minVersion := uint16(tls.VersionTLS12)
+	if c.MinVersion != "" {
+		v, ok := tlsVersions[c.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("ktls: unknown min version %q", c.MinVersion)
+		}
+		minVersion = v
+	}
+	auth := c.clientAuth()
+	if c.VerifiesClient() && c.ClientCAFile == "" {
+		return nil, fmt.Errorf("ktls: client auth %q requires client CA file", auth)
+	}
+	if len(c.AllowedSANs) > 0 && auth != ClientAuthRequire {
+		return nil, errors.New("ktls: allowed SANs requires client auth require")
+	}
+	interval := c.ReloadInterval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	r := newReloader(c.CertFile, c.KeyFile, c.ClientCAFile, interval)
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{
+		MinVersion:     minVersion,
+		GetCertificate: r.getCertificate,
+	}
+	switch auth {
+	case ClientAuthNone:
+		config.ClientAuth = tls.NoClientCert
+	case ClientAuthRequest, ClientAuthVerifyIfGiven:
+		config.ClientAuth = tls.RequestClientCert
+	case ClientAuthRequire:
+		config.ClientAuth = tls.RequireAnyClientCert
+	default:
+		return nil, fmt.Errorf("ktls: unknown client auth %q", c.ClientAuth)
+	}
+	// 客户端 CA 会重新加载，不能使用 tls.Config.ClientCAs，由 VerifyPeerCertificate 校验
+	if c.VerifiesClient() {
+		allowed := c.AllowedSANs
+		config.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			return r.verifyClient(rawCerts, allowed)
+		}
+	}
+	return config, nil
+}
+
+// verifyClient 使用当前的客户端 CA 校验证书链，并校验客户端身份
+func (r *reloader) verifyClient(rawCerts [][]byte, allowed []string) error {
+	if len(rawCerts) == 0 {
+		return nil
+	}
+	certs := make([]*x509.Certificate, 0, len(rawCerts))
+	for _, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return fmt.Errorf("ktls: parse client certificate: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	opts := x509.VerifyOptions{
+		Roots:         r.clientCAs(),
+		Intermediates: x509.NewCertPool(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	for _, cert := range certs[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	if _, err := certs[0].Verify(opts); err != nil {
+		return fmt.Errorf("ktls: verify client certificate: %w", err)
+	}
+	if len(allowed) > 0 && !NewIdentity(certs[0]).Match(allowed...) {
+		return fmt.Errorf("ktls: client identity %s not allowed", strings.Join(NewIdentity(certs[0]).Names(), ","))
+	}
+	return nil
+}
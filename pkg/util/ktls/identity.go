@@ -0,0 +1,128 @@
+// @Description 客户端证书身份
+
+package ktls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"strings"
+)
+
+type identityKey struct{}
+
+// Identity 校验通过的客户端证书身份
+type Identity struct {
+	// CommonName 证书主题的 CN
+	CommonName string
+	// DNSNames SAN 中的域名
+	DNSNames []string
+	// URIs SAN 中的 URI，如 spiffe://cluster/ns/default/sa/api
+	URIs []string
+	// Emails SAN 中的邮箱
+	Emails []string
+	// IPs SAN 中的 ip
+	IPs []string
+	// SerialNumber 证书序列号
+	SerialNumber string
+}
+
+// NewIdentity
+//  @Description  从证书中提取身份
+//  @Param cert 客户端证书
+//  @Return *Identity
+func NewIdentity(cert *x509.Certificate) *Identity {
+	id := &Identity{
+		CommonName:   cert.Subject.CommonName,
+		DNSNames:     cert.DNSNames,
+		Emails:       cert.EmailAddresses,
+		SerialNumber: cert.SerialNumber.String(),
+	}
+	for _, u := range cert.URIs {
+		id.URIs = append(id.URIs, u.String())
+	}
+	for _, ip := range cert.IPAddresses {
+		id.IPs = append(id.IPs, ip.String())
+	}
+	return id
+}
+
+// FromConnectionState
+//  @Description  从 TLS 连接状态中提取客户端身份，调用方需确保证书已校验
+//  @Param state 连接状态
+//  @Return *Identity 客户端未提供证书时为 nil
+func FromConnectionState(state *tls.ConnectionState) *Identity {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return nil
+	}
+	return NewIdentity(state.PeerCertificates[0])
+}
+
+// Names
+//  @Description  身份的所有名称，SAN 在前，CommonName 在后
+//  @Receiver id
+//  @Return []string
+func (id *Identity) Names() []string {
+	names := make([]string, 0, len(id.DNSNames)+len(id.URIs)+len(id.Emails)+len(id.IPs)+1)
+	names = append(names, id.DNSNames...)
+	names = append(names, id.URIs...)
+	names = append(names, id.Emails...)
+	names = append(names, id.IPs...)
+	if id.CommonName != "" {
+		names = append(names, id.CommonName)
+	}
+	return names
+}
+
+// Match
+//  @Description  身份的任一名称匹配任一规则时返回 true
+//  @Receiver id
+//  @Param patterns 规则，支持 *.example.com 子域通配与以 * 结尾的前缀通配
+//  @Return bool
+func (id *Identity) Match(patterns ...string) bool {
+	if id == nil {
+		return false
+	}
+	for _, name := range id.Names() {
+		for _, pattern := range patterns {
+			if matchName(pattern, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchName(pattern, name string) bool {
+	switch {
+	case strings.HasPrefix(pattern, "*."):
+		// 只匹配一级子域
+		i := strings.Index(name, ".")
+		return i > 0 && strings.EqualFold(name[i:], pattern[1:])
+	case strings.HasSuffix(pattern, "*"):
+		return strings.HasPrefix(name, pattern[:len(pattern)-1])
+	}
+	return strings.EqualFold(pattern, name)
+}
+
+// NewContext
+//  @Description  将客户端身份放入上下文
+//  @Param ctx
+//  @Param id 客户端身份
+//  @Return context.Context
+func NewContext(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// FromContext
+//  @Description  从上下文中获取客户端身份
+//  @Param ctx
+//  @Return *Identity
+//  @Return bool 上下文中不存在时为 false
+func FromContext(ctx context.Context) (*Identity, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	id, ok := ctx.Value(identityKey{}).(*Identity)
+	return id, ok && id != nil
+}
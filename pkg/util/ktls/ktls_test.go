@@ -0,0 +1,211 @@
+package ktls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCert(t *testing.T, parent *testCert, serial int64, cn string, dns []string, uris []string, isCA bool) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	tpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		DNSNames:              dns,
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	for _, u := range uris {
+		parsed, _ := url.Parse(u)
+		tpl.URIs = append(tpl.URIs, parsed)
+	}
+	if isCA {
+		tpl.KeyUsage |= x509.KeyUsageCertSign
+	}
+	signer, signerKey := tpl, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, signer, &key.PublicKey, signerKey)
+	assert.Nil(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.Nil(t, err)
+	return &testCert{cert: cert, key: key}
+}
+
+func (c *testCert) write(t *testing.T, dir, name string) (string, string) {
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	assert.Nil(t, err)
+	assert.Nil(t, ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw}), 0600))
+	assert.Nil(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certFile, keyFile
+}
+
+func (c *testCert) tlsCert() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.cert.Raw}, PrivateKey: c.key}
+}
+
+// handshake 使用服务端配置与客户端证书握手，返回服务端看到的连接状态
+func handshake(t *testing.T, serverConfig *tls.Config, ca *testCert, client *testCert) (tls.ConnectionState, error) {
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", serverConfig)
+	assert.Nil(t, err)
+	defer ln.Close()
+
+	type result struct {
+		state tls.ConnectionState
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			done <- result{err: err}
+			return
+		}
+		defer conn.Close()
+		tc := conn.(*tls.Conn)
+		err = tc.Handshake()
+		done <- result{state: tc.ConnectionState(), err: err}
+	}()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	clientConfig := &tls.Config{RootCAs: pool, ServerName: "127.0.0.1"}
+	if client != nil {
+		clientConfig.Certificates = []tls.Certificate{client.tlsCert()}
+	}
+	conn, err := tls.Dial("tcp", ln.Addr().String(), clientConfig)
+	if err == nil {
+		// TLS 1.3 下客户端证书在服务端校验，读一次确保服务端完成握手
+		_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+		_, _ = conn.Read(make([]byte, 1))
+		conn.Close()
+	}
+	res := <-done
+	return res.state, res.err
+}
+
+func TestConfig_MutualTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ktls")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	ca := newTestCert(t, nil, 1, "ca", nil, nil, true)
+	caFile, _ := ca.write(t, dir, "ca")
+	certFile, keyFile := newTestCert(t, ca, 2, "server", []string{"localhost"}, nil, false).write(t, dir, "server")
+	allowedClient := newTestCert(t, ca, 3, "api", []string{"api.svc.local"}, []string{"spiffe://cluster/ns/default/sa/api"}, false)
+	otherClient := newTestCert(t, ca, 4, "job", []string{"job.other.local"}, nil, false)
+	untrusted := newTestCert(t, nil, 5, "evil", []string{"api.svc.local"}, nil, false)
+
+	config := &Config{
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		ClientCAFile: caFile,
+		AllowedSANs:  []string{"*.svc.local"},
+	}
+	assert.True(t, config.VerifiesClient())
+	serverConfig, err := config.Build()
+	assert.Nil(t, err)
+
+	state, err := handshake(t, serverConfig, ca, allowedClient)
+	assert.Nil(t, err)
+	id := FromConnectionState(&state)
+	assert.Equal(t, "api", id.CommonName)
+	assert.Equal(t, []string{"spiffe://cluster/ns/default/sa/api"}, id.URIs)
+	assert.True(t, id.Match("spiffe://cluster/ns/default/*"))
+
+	_, err = handshake(t, serverConfig, ca, otherClient)
+	assert.NotNil(t, err)
+	_, err = handshake(t, serverConfig, ca, untrusted)
+	assert.NotNil(t, err)
+	_, err = handshake(t, serverConfig, ca, nil)
+	assert.NotNil(t, err)
+}
+
+func TestConfig_Reload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ktls")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	ca := newTestCert(t, nil, 1, "ca", nil, nil, true)
+	certFile, keyFile := newTestCert(t, ca, 2, "server", nil, nil, false).write(t, dir, "server")
+	config := &Config{CertFile: certFile, KeyFile: keyFile, ReloadInterval: time.Millisecond}
+	serverConfig, err := config.Build()
+	assert.Nil(t, err)
+
+	cert, err := serverConfig.GetCertificate(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(2), leaf(t, cert).SerialNumber)
+
+	newTestCert(t, ca, 6, "server", nil, nil, false).write(t, dir, "server")
+	future := time.Now().Add(time.Minute)
+	assert.Nil(t, os.Chtimes(certFile, future, future))
+	time.Sleep(2 * time.Millisecond)
+	cert, err = serverConfig.GetCertificate(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(6), leaf(t, cert).SerialNumber)
+
+	// 文件损坏时继续使用旧证书
+	assert.Nil(t, ioutil.WriteFile(keyFile, []byte("broken"), 0600))
+	future = future.Add(time.Minute)
+	assert.Nil(t, os.Chtimes(keyFile, future, future))
+	time.Sleep(2 * time.Millisecond)
+	cert, err = serverConfig.GetCertificate(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(6), leaf(t, cert).SerialNumber)
+}
+
+func leaf(t *testing.T, cert *tls.Certificate) *x509.Certificate {
+	if cert.Leaf != nil {
+		return cert.Leaf
+	}
+	c, err := x509.ParseCertificate(cert.Certificate[0])
+	assert.Nil(t, err)
+	return c
+}
+
+func TestConfig_Build(t *testing.T) {
+	assert.False(t, (*Config)(nil).Enabled())
+	_, err := (&Config{CertFile: "a", KeyFile: "b", ClientAuth: ClientAuthRequire}).Build()
+	assert.NotNil(t, err)
+	_, err = (&Config{CertFile: "a", KeyFile: "b", MinVersion: "2.0"}).Build()
+	assert.NotNil(t, err)
+	_, err = (&Config{CertFile: "a", KeyFile: "b"}).Build()
+	assert.NotNil(t, err)
+}
+
+func TestIdentity_Match(t *testing.T) {
+	id := &Identity{CommonName: "api", DNSNames: []string{"api.svc.local"}}
+	assert.True(t, id.Match("*.svc.local"))
+	assert.False(t, id.Match("*.local"))
+	assert.True(t, id.Match("API"))
+	assert.False(t, id.Match("job"))
+	assert.False(t, (*Identity)(nil).Match("*"))
+}
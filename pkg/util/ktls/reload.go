@@ -0,0 +1,126 @@
+// @Description 证书文件变更后重新加载
+
+package ktls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/LuoHongLiang0921/kuaigo/pkg/util/klog"
+)
+
+// reloader 握手时按间隔检查证书文件的修改时间，变更后重新加载，加载失败时继续使用旧证书
+type reloader struct {
+	certFile string
+	keyFile  string
+	caFile   string
+	interval time.Duration
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	caPool  *x509.CertPool
+	modTime map[string]time.Time
+	// checkedAt 上次检查的时间，unix 纳秒
+	checkedAt int64
+}
+
+func newReloader(certFile, keyFile, caFile string, interval time.Duration) *reloader {
+	return &reloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		caFile:   caFile,
+		interval: interval,
+		modTime:  make(map[string]time.Time),
+	}
+}
+
+func (r *reloader) files() []string {
+	files := []string{r.certFile, r.keyFile}
+	if r.caFile != "" {
+		files = append(files, r.caFile)
+	}
+	return files
+}
+
+// load 加载证书与客户端 CA
+func (r *reloader) load() error {
+	modTime := make(map[string]time.Time)
+	for _, file := range r.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			return fmt.Errorf("ktls: %w", err)
+		}
+		modTime[file] = info.ModTime()
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("ktls: load key pair: %w", err)
+	}
+	var pool *x509.CertPool
+	if r.caFile != "" {
+		data, err := ioutil.ReadFile(r.caFile)
+		if err != nil {
+			return fmt.Errorf("ktls: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return fmt.Errorf("ktls: no certificate found in %s", r.caFile)
+		}
+	}
+	r.mu.Lock()
+	r.cert, r.caPool, r.modTime = &cert, pool, modTime
+	r.mu.Unlock()
+	atomic.StoreInt64(&r.checkedAt, time.Now().UnixNano())
+	return nil
+}
+
+// maybeReload 距上次检查超过间隔时检查文件修改时间
+func (r *reloader) maybeReload() {
+	now := time.Now().UnixNano()
+	last := atomic.LoadInt64(&r.checkedAt)
+	if now-last < int64(r.interval) || !atomic.CompareAndSwapInt64(&r.checkedAt, last, now) {
+		return
+	}
+	if !r.changed() {
+		return
+	}
+	if err := r.load(); err != nil {
+		klog.Error("ktls reload certificate failed, keep using the old one", klog.FieldErr(err), klog.String("cert", r.certFile))
+		return
+	}
+	klog.Info("ktls certificate reloaded", klog.String("cert", r.certFile))
+}
+
+func (r *reloader) changed() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, file := range r.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		if !info.ModTime().Equal(r.modTime[file]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *reloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.maybeReload()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+func (r *reloader) clientCAs() *x509.CertPool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.caPool
+}
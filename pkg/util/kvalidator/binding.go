@@ -0,0 +1,186 @@
+// @Description 将校验函数注册为 validator 标签，并按语言翻译校验错误
+
+package kvalidator
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/zh"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	zhTranslations "github.com/go-playground/validator/v10/translations/zh"
+)
+
+// 支持的语言
+const (
+	LangZh = "zh"
+	LangEn = "en"
+	// DefaultLang 无法识别请求语言时使用的语言
+	DefaultLang = LangZh
+)
+
+// 自定义校验标签
+const (
+	// TagMobile 手机号
+	TagMobile = "mobile"
+	// TagCitizenNo 18 位身份证号
+	TagCitizenNo = "citizen_no"
+	// TagUnixTime unix 时间戳
+	TagUnixTime = "unix_time"
+	// TagFullURL 带协议的完整 url
+	TagFullURL = "full_url"
+)
+
+// tag 自定义标签的校验函数与各语言的错误信息，{0} 为字段名
+type tag struct {
+	check    func(string) bool
+	messages map[string]string
+}
+
+var tags = map[string]tag{
+	TagMobile: {
+		check:    IsMobileNumber,
+		messages: map[string]string{LangZh: "{0}必须是有效的手机号", LangEn: "{0} must be a valid mobile number"},
+	},
+	TagCitizenNo: {
+		check: func(s string) bool {
+			b := []byte(s)
+			return IsCitizenNo(&b)
+		},
+		messages: map[string]string{LangZh: "{0}必须是有效的身份证号", LangEn: "{0} must be a valid citizen number"},
+	},
+	TagUnixTime: {
+		check:    IsUnixTime,
+		messages: map[string]string{LangZh: "{0}必须是有效的时间戳", LangEn: "{0} must be a valid unix timestamp"},
+	},
+	TagFullURL: {
+		check:    IsFullURL,
+		messages: map[string]string{LangZh: "{0}必须是完整的URL", LangEn: "{0} must be a full URL"},
+	},
+}
+
+var (
+	uni = ut.New(zh.New(), zh.New(), en.New())
+
+	defaultTranslations = map[string]func(v *validator.Validate, trans ut.Translator) error{
+		LangZh: zhTranslations.RegisterDefaultTranslations,
+		LangEn: enTranslations.RegisterDefaultTranslations,
+	}
+)
+
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	// Field 字段名，优先使用 json、form、header、uri 标签中的名称，嵌套字段以 . 连接
+	Field string `json:"field"`
+	// Tag 未通过的校验标签
+	Tag string `json:"tag"`
+	// Param 校验标签的参数，如 max=10 中的 10
+	Param string `json:"param,omitempty"`
+	// Message 按请求语言翻译后的错误信息
+	Message string `json:"message"`
+}
+
+// Setup
+//  @Description  注册自定义校验标签、字段名解析与各语言的错误翻译
+//  @Param v 校验器，gin 中为 binding.Validator.Engine()
+//  @Return error
+func Setup(v *validator.Validate) error {
+	v.RegisterTagNameFunc(fieldName)
+	for name, t := range tags {
+		check := t.check
+		err := v.RegisterValidation(name, func(fl validator.FieldLevel) bool {
+			field := fl.Field()
+			return field.Kind() == reflect.String && check(field.String())
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for lang, register := range defaultTranslations {
+		trans, _ := uni.GetTranslator(lang)
+		if err := register(v, trans); err != nil {
+			return err
+		}
+		for name, t := range tags {
+			if err := registerTranslation(v, trans, name, t.messages[lang]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func registerTranslation(v *validator.Validate, trans ut.Translator, name, message string) error {
+	return v.RegisterTranslation(name, trans, func(trans ut.Translator) error {
+		return trans.Add(name, message, true)
+	}, func(trans ut.Translator, fe validator.FieldError) string {
+		msg, _ := trans.T(fe.Tag(), fe.Field())
+		return msg
+	})
+}
+
+// fieldName 字段名取请求中的参数名，"-" 表示忽略
+func fieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "form", "header", "uri"} {
+		name := strings.SplitN(field.Tag.Get(key), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// MatchLang
+//  @Description  按顺序匹配第一个支持的语言，如 Accept-Language 与客户端系统语言
+//  @Param langs 语言，支持 zh-CN、en-US,en;q=0.9 等格式
+//  @Return string 均不支持时返回 DefaultLang
+func MatchLang(langs ...string) string {
+	for _, lang := range langs {
+		for _, part := range strings.Split(lang, ",") {
+			part = strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+			part = strings.ToLower(strings.SplitN(strings.Replace(part, "_", "-", -1), "-", 2)[0])
+			if _, ok := defaultTranslations[part]; ok {
+				return part
+			}
+		}
+	}
+	return DefaultLang
+}
+
+// Translate
+//  @Description  将校验错误翻译为字段错误列表
+//  @Param err ShouldBind 等返回的错误
+//  @Param lang 语言，见 MatchLang
+//  @Return []FieldError
+//  @Return bool err 不是校验错误时为 false
+func Translate(err error, lang string) ([]FieldError, bool) {
+	ves, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return nil, false
+	}
+	trans, _ := uni.GetTranslator(lang)
+	fields := make([]FieldError, 0, len(ves))
+	for _, fe := range ves {
+		fields = append(fields, FieldError{
+			Field:   namespace(fe.Namespace()),
+			Tag:     fe.Tag(),
+			Param:   fe.Param(),
+			Message: fe.Translate(trans),
+		})
+	}
+	return fields, true
+}
+
+// namespace 去掉最外层结构体名
+func namespace(ns string) string {
+	if i := strings.Index(ns, "."); i >= 0 {
+		return ns[i+1:]
+	}
+	return ns
+}
@@ -0,0 +1,56 @@
+package kvalidator
+
+import (
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+)
+
+type bindUser struct {
+	Mobile    string `json:"mobile" binding:"required,mobile"`
+	CitizenNo string `form:"citizenNo" binding:"omitempty,citizen_no"`
+	Name      string `json:"name" binding:"required,max=4"`
+	Ignored   string `json:"-" binding:"omitempty,full_url"`
+}
+
+type bindReq struct {
+	User bindUser `json:"user"`
+}
+
+func newTestValidator(t *testing.T) *validator.Validate {
+	v := validator.New()
+	v.SetTagName("binding")
+	assert.Nil(t, Setup(v))
+	return v
+}
+
+func TestSetup_Tags(t *testing.T) {
+	v := newTestValidator(t)
+	assert.Nil(t, v.Struct(&bindReq{User: bindUser{Mobile: "13312345678", Name: "kg"}}))
+
+	err := v.Struct(&bindReq{User: bindUser{Mobile: "12345", CitizenNo: "110101199003070000", Name: "kuaigo"}})
+	fields, ok := Translate(err, LangZh)
+	assert.True(t, ok)
+	assert.Equal(t, []FieldError{
+		{Field: "user.mobile", Tag: TagMobile, Message: "mobile必须是有效的手机号"},
+		{Field: "user.citizenNo", Tag: TagCitizenNo, Message: "citizenNo必须是有效的身份证号"},
+		{Field: "user.name", Tag: "max", Param: "4", Message: "name长度不能超过4个字符"},
+	}, fields)
+
+	fields, ok = Translate(err, LangEn)
+	assert.True(t, ok)
+	assert.Equal(t, "mobile must be a valid mobile number", fields[0].Message)
+	assert.Equal(t, "name must be a maximum of 4 characters in length", fields[2].Message)
+
+	_, ok = Translate(assert.AnError, LangZh)
+	assert.False(t, ok)
+}
+
+func TestMatchLang(t *testing.T) {
+	assert.Equal(t, LangEn, MatchLang("en-US,en;q=0.9,zh;q=0.8"))
+	assert.Equal(t, LangZh, MatchLang("zh_CN"))
+	assert.Equal(t, LangEn, MatchLang("fr-FR", "", "en"))
+	assert.Equal(t, DefaultLang, MatchLang("fr-FR"))
+	assert.Equal(t, DefaultLang, MatchLang())
+}